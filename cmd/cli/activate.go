@@ -1,14 +1,18 @@
 package cli
 
 import (
+	"context"
+
 	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
+	"github.com/philslol/kritis3m_scalev2/control/types"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
 )
 
 var activateCmd = &cobra.Command{
 	Use:   "activate",
 	Short: "activate nodes",
-	Long:  "activate a node in the network",
+	Long:  "activate a version set on nodes of the network, it has to be submitted with version-set submit and approved by another user first",
 }
 
 func init() {
@@ -18,6 +22,10 @@ func init() {
 
 	activateNodeCmd.Flags().StringP("node-serial", "n", "", "Node serial number")
 	activateNodeCmd.Flags().StringP("version-number", "v", "", "version number")
+	activateNodeCmd.Flags().BoolP("watch", "w", false, "follow the progress of the activation")
+	activateNodeCmd.Flags().Bool("force", false, "activate even if the version set fails validation")
+	activateNodeCmd.Flags().Bool("ignore-window", false, "activate outside the maintenance windows of the nodes")
+	activateNodeCmd.Flags().String("min-agent-version", "", "only activate on nodes reporting at least this agent version, like 2.3")
 
 	activateNodeCmd.MarkFlagRequired("version-number")
 	activateNodeCmd.MarkFlagRequired("node-serial")
@@ -25,12 +33,23 @@ func init() {
 
 	activateGroupCmd.Flags().StringP("version-number", "v", "", "version number")
 	activateGroupCmd.Flags().StringP("group", "g", "", "group name")
+	activateGroupCmd.Flags().BoolP("watch", "w", false, "follow the progress of the activation")
+	activateGroupCmd.Flags().Bool("force", false, "activate even if the version set fails validation")
+	activateGroupCmd.Flags().Bool("ignore-window", false, "activate outside the maintenance windows of the nodes")
+	activateGroupCmd.Flags().String("min-agent-version", "", "only activate on nodes reporting at least this agent version, like 2.3")
+
+	addRolloutFlags(activateGroupCmd)
 
 	activateGroupCmd.MarkFlagRequired("version-number")
 	activateGroupCmd.MarkFlagRequired("group")
 	activateCmd.AddCommand(activateGroupCmd)
 
 	activateFleetCmd.Flags().StringP("version-number", "v", "", "version number")
+	activateFleetCmd.Flags().BoolP("watch", "w", false, "follow the progress of the activation")
+	activateFleetCmd.Flags().Bool("force", false, "activate even if the version set fails validation")
+	activateFleetCmd.Flags().Bool("ignore-window", false, "activate outside the maintenance windows of the nodes")
+	activateFleetCmd.Flags().String("min-agent-version", "", "only activate on nodes reporting at least this agent version, like 2.3")
+	addRolloutFlags(activateFleetCmd)
 	activateFleetCmd.MarkFlagRequired("version-number")
 	activateCmd.AddCommand(activateFleetCmd)
 
@@ -49,6 +68,7 @@ var activateNodeCmd = &cobra.Command{
 		if err != nil {
 			cli_logger.Fatal().Err(err).Msg("Failed to get client")
 		}
+		ctx = withActivationFlags(ctx, cmd)
 
 		defer cancel()
 		defer conn.Close()
@@ -64,6 +84,9 @@ var activateNodeCmd = &cobra.Command{
 		}
 		cli_logger.Info().Msgf("Returncode is %d, with metadata %v", resp.Retcode, resp.Metadata)
 
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			return watchTransaction(activationTxID(resp))
+		}
 		return nil
 	},
 }
@@ -80,6 +103,10 @@ var activateGroupCmd = &cobra.Command{
 			cli_logger.Fatal().Msg("Version number or group name is missing")
 		}
 
+		if policy := rolloutPolicyFromFlags(cmd); policy != nil {
+			return startRollout(cmd, versionSetId, &group, policy)
+		}
+
 		req := &grpc_southbound.ActivateFleetRequest{
 			VersionSetId: versionSetId,
 			GroupName:    &group,
@@ -90,6 +117,7 @@ var activateGroupCmd = &cobra.Command{
 		if err != nil {
 			cli_logger.Fatal().Err(err).Msg("Failed to get client")
 		}
+		ctx = withActivationFlags(ctx, cmd)
 		defer cancel()
 		defer conn.Close()
 
@@ -99,6 +127,9 @@ var activateGroupCmd = &cobra.Command{
 		}
 		cli_logger.Info().Msgf("Returncode is %d, with metadata %v", resp.Retcode, resp.Metadata)
 
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			return watchTransaction(activationTxID(resp))
+		}
 		return nil
 	},
 }
@@ -114,6 +145,10 @@ var activateFleetCmd = &cobra.Command{
 			cli_logger.Fatal().Msg("Version number is missing")
 		}
 
+		if policy := rolloutPolicyFromFlags(cmd); policy != nil {
+			return startRollout(cmd, versionSetId, nil, policy)
+		}
+
 		req := &grpc_southbound.ActivateFleetRequest{
 			VersionSetId: versionSetId,
 		}
@@ -123,6 +158,7 @@ var activateFleetCmd = &cobra.Command{
 		if err != nil {
 			cli_logger.Fatal().Err(err).Msg("Failed to get client")
 		}
+		ctx = withActivationFlags(ctx, cmd)
 		defer cancel()
 		defer conn.Close()
 
@@ -132,6 +168,36 @@ var activateFleetCmd = &cobra.Command{
 		}
 		cli_logger.Info().Msgf("Returncode is %d, with metadata %v", resp.Retcode, resp.Metadata)
 
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			return watchTransaction(activationTxID(resp))
+		}
 		return nil
 	},
 }
+
+// withActivationFlags asks the server to skip the validation of the version
+// set if --force is given, to ignore the maintenance windows with
+// --ignore-window and to skip nodes with an older agent than
+// --min-agent-version. The activation requests are shared with the gateways,
+// so the flags travel as metadata.
+func withActivationFlags(ctx context.Context, cmd *cobra.Command) context.Context {
+	if force, _ := cmd.Flags().GetBool("force"); force {
+		ctx = metadata.AppendToOutgoingContext(ctx, types.ForceActivationKey, "true")
+	}
+	if ignore, _ := cmd.Flags().GetBool("ignore-window"); ignore {
+		ctx = metadata.AppendToOutgoingContext(ctx, types.IgnoreWindowKey, "true")
+	}
+	if minVersion, _ := cmd.Flags().GetString("min-agent-version"); minVersion != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, types.MinAgentVersionKey, minVersion)
+	}
+	return ctx
+}
+
+// activationTxID returns the transaction id from the metadata of an activation
+func activationTxID(resp *grpc_southbound.ActivateResponse) int32 {
+	txID, ok := resp.GetMetadata().GetFields()["tx_id"]
+	if !ok {
+		cli_logger.Fatal().Msg("Activation response carries no transaction id")
+	}
+	return int32(txID.GetNumberValue())
+}
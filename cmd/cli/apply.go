@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	cli_logger.Debug().Msg("Registering apply command")
+
+	applyCmd.Flags().StringP("file", "f", "", "manifest file in YAML or JSON, - reads from stdin")
+	applyCmd.MarkFlagRequired("file")
+	applyCmd.Flags().Bool("dry-run", false, "only show the plan")
+	applyCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	rootCmd.AddCommand(applyCmd)
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "apply a version set manifest",
+	Long: `create or update a version set from a manifest describing its endpoint configs,
+groups, nodes, hardware configs and proxies. Entities missing in the manifest
+are deleted. All changes are applied in one database transaction, applying the
+same manifest again changes nothing. Only draft version sets can be changed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		manifest, err := readManifest(file)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msgf("Failed to read manifest %s", file)
+		}
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to encode manifest")
+		}
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.ApplyManifest(ctx, &grpc_scale.ApplyManifestRequest{Manifest: data, DryRun: dryRun})
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to apply manifest")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(resp, "", outputFormat)
+			return nil
+		}
+
+		if len(resp.Steps) > 0 {
+			PrintPlanAsTable(resp.Steps)
+		}
+		switch {
+		case len(resp.Steps) == 0:
+			cli_logger.Info().Msgf("Version set %s is up to date", resp.VersionSetId)
+		case dryRun:
+			cli_logger.Info().Msgf("Dry run, %d changes not applied", len(resp.Steps))
+		default:
+			cli_logger.Info().Msgf("Applied %d changes to version set %s", len(resp.Steps), resp.VersionSetId)
+		}
+		return nil
+	},
+}
+
+// readManifest parses a manifest file, JSON files are detected by their
+// extension, everything else is read as YAML. Unknown fields are rejected.
+func readManifest(file string) (*types.Manifest, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &types.Manifest{}
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(manifest)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(manifest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func PrintPlanAsTable(steps []*grpc_scale.PlanStep) {
+	type stepRow struct {
+		Action string
+		Kind   string
+		Name   string
+		Fields string
+	}
+	rows := make([]stepRow, 0, len(steps))
+	for _, step := range steps {
+		rows = append(rows, stepRow{
+			Action: step.Action,
+			Kind:   step.Kind,
+			Name:   step.Name,
+			Fields: strings.Join(step.Fields, ", "),
+		})
+	}
+	PrintAsTable(rows, []TableColumn{
+		{Header: "ACTION", FieldPath: "Action"},
+		{Header: "KIND", FieldPath: "Kind"},
+		{Header: "NAME", FieldPath: "Name"},
+		{Header: "FIELDS", FieldPath: "Fields"},
+	})
+}
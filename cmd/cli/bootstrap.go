@@ -61,6 +61,16 @@ var bootstrapCmd = &cobra.Command{
 			cli_logger.Fatal().Err(err).Msg("Failed to initialize database schema")
 		}
 
+		// bootstrap may be re-run against a live database to apply new migrations
+		existing, err := sm.ListVersionSets(ctx)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to list version sets")
+		}
+		if len(existing) > 0 {
+			cli_logger.Info().Msg("Database already bootstrapped, skipping initial version set")
+			return nil
+		}
+
 		cli_logger.Info().Msg("Creating initial version set")
 
 		defaultVersionSet := types.VersionSet{
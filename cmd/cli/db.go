@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	cli_logger.Debug().Msg("Registering db commands")
+	rootCmd.AddCommand(dbCli)
+	dbCli.AddCommand(migrateCli)
+
+	migrateUpCmd.Flags().IntP("to", "t", 0, "Target schema version (default is latest)")
+	migrateCli.AddCommand(migrateUpCmd)
+
+	migrateDownCmd.Flags().IntP("steps", "n", 1, "Number of migrations to revert")
+	migrateCli.AddCommand(migrateDownCmd)
+
+	migrateStatusCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	migrateCli.AddCommand(migrateStatusCmd)
+}
+
+var dbCli = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the database",
+	Long:  "Maintenance commands operating directly on the kritis3m_scale database",
+}
+
+var migrateCli = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage schema migrations",
+	Long:  "Upgrade or downgrade the database schema without losing existing data",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply pending migrations",
+	Long:  "Apply all pending schema migrations, or up to the version given with --to",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, _ := cmd.Flags().GetInt("to")
+
+		app, err := getKritis3mScaleApp()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to create kritis3m-scale instance")
+		}
+
+		sm, ctx, cancel, err := app.GetRawDB(60 * time.Second)
+		defer cancel()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get raw database")
+		}
+
+		applied, err := sm.MigrateUp(ctx, target)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msgf("Migration failed after applying %v", applied)
+		}
+
+		if len(applied) == 0 {
+			cli_logger.Info().Msg("Database schema is up to date")
+			return nil
+		}
+		cli_logger.Info().Msgf("Applied migrations: %v", applied)
+		return nil
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Revert applied migrations",
+	Long:  "Revert the most recently applied schema migrations. Reverting the baseline drops all data",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		steps, _ := cmd.Flags().GetInt("steps")
+
+		app, err := getKritis3mScaleApp()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to create kritis3m-scale instance")
+		}
+
+		sm, ctx, cancel, err := app.GetRawDB(60 * time.Second)
+		defer cancel()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get raw database")
+		}
+
+		reverted, err := sm.MigrateDown(ctx, steps)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msgf("Migration failed after reverting %v", reverted)
+		}
+
+		cli_logger.Info().Msgf("Reverted migrations: %v", reverted)
+		return nil
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show migration status",
+	Long:  "List all known schema migrations and whether they are applied",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := getKritis3mScaleApp()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to create kritis3m-scale instance")
+		}
+
+		sm, ctx, cancel, err := app.GetRawDB(10 * time.Second)
+		defer cancel()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get raw database")
+		}
+
+		status, err := sm.MigrationStatus(ctx)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get migration status")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(status, "", outputFormat)
+			return nil
+		}
+
+		columns := []TableColumn{
+			{Header: "VERSION", FieldPath: "Version"},
+			{Header: "NAME", FieldPath: "Name"},
+			{Header: "APPLIED AT", FieldPath: "AppliedAt"},
+		}
+		PrintAsTable(status, columns)
+		cli_logger.Debug().Msgf("latest known schema version is %d", db.LatestSchemaVersion(sm.Dialect()))
+		return nil
+	},
+}
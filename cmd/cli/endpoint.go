@@ -21,7 +21,6 @@ func init() {
 	createEndpointCmd.Flags().BoolP("no-encryption", "e", false, "Disable encryption")
 	createEndpointCmd.Flags().StringP("kex-method", "k", "ASL_KEX_DEFAULT", "ASL key exchange method")
 	createEndpointCmd.Flags().StringP("cipher", "c", "", "Cipher configuration")
-	createEndpointCmd.Flags().StringP("created-by", "u", "", "User creating the endpoint")
 	createEndpointCmd.Flags().StringP("version-number", "v", "", "Reference to the version")
 	endpointCli.AddCommand(createEndpointCmd)
 
@@ -71,7 +70,6 @@ var createEndpointCmd = &cobra.Command{
 		noEncryption, _ := cmd.Flags().GetBool("no-encryption")
 		kexMethod, _ := cmd.Flags().GetString("kex-method")
 		cipher, _ := cmd.Flags().GetString("cipher")
-		createdBy, _ := cmd.Flags().GetString("created-by")
 		versionSetID, _ := cmd.Flags().GetString("version-number")
 
 		ctx, client, conn, cancel, err := getClient()
@@ -88,7 +86,6 @@ var createEndpointCmd = &cobra.Command{
 			NoEncryption:         noEncryption,
 			AslKeyExchangeMethod: types.ASLKeyExchangeMethodToProto(kexMethod),
 			Cipher:               &cipher,
-			CreatedBy:            createdBy,
 			VersionSetId:         versionSetID,
 		}
 
@@ -27,8 +27,6 @@ func init() {
 	createGroupCmd.MarkFlagRequired("endpoint-config")
 
 	createGroupCmd.Flags().StringP("legacy-config", "c", "", "Legacy config name")
-	createGroupCmd.Flags().StringP("created-by", "u", "", "User creating the group")
-	createGroupCmd.MarkFlagRequired("created-by")
 	groupCli.AddCommand(createGroupCmd)
 
 	// Read command flags
@@ -77,7 +75,6 @@ var createGroupCmd = &cobra.Command{
 		versionSetID, _ := cmd.Flags().GetString("version-number")
 		endpointConfig, _ := cmd.Flags().GetString("endpoint-config")
 		legacyConfig, _ := cmd.Flags().GetString("legacy-config")
-		createdBy, _ := cmd.Flags().GetString("created-by")
 
 		ctx, client, conn, cancel, err := getClient()
 		if err != nil {
@@ -92,7 +89,6 @@ var createGroupCmd = &cobra.Command{
 			VersionSetId:       versionSetID,
 			EndpointConfigName: endpointConfig,
 			LegacyConfigName:   &legacyConfig,
-			CreatedBy:          createdBy,
 		}
 
 		rsp, err := client.CreateGroup(ctx, request)
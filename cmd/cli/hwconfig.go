@@ -26,9 +26,6 @@ func init() {
 	createHwConfigCmd.Flags().StringP("version-number", "v", "", "Version set ID")
 	createHwConfigCmd.MarkFlagRequired("version-number")
 
-	createHwConfigCmd.Flags().StringP("created-by", "u", "", "User creating the hardware config")
-	createHwConfigCmd.MarkFlagRequired("created-by")
-
 	// Add all commands to hardware config CLI
 	hwConfigCli.AddCommand(createHwConfigCmd)
 
@@ -76,7 +73,6 @@ var createHwConfigCmd = &cobra.Command{
 		cli_logger.Info().Msgf("ipCidr: %s", ipCidr)
 		nodeSerial, _ := cmd.Flags().GetString("serial-number")
 		versionSetID, _ := cmd.Flags().GetString("version-number")
-		createdBy, _ := cmd.Flags().GetString("created-by")
 
 		ctx, client, conn, cancel, err := getClient()
 		if err != nil {
@@ -91,7 +87,6 @@ var createHwConfigCmd = &cobra.Command{
 			IpCidr:           ipCidr,
 			NodeSerialNumber: nodeSerial,
 			VersionSetId:     versionSetID,
-			CreatedBy:        createdBy,
 		}
 
 		rsp, err := client.CreateHardwareConfig(ctx, request)
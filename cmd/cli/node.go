@@ -3,11 +3,15 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func init() {
@@ -25,9 +29,6 @@ func init() {
 	createNodeCmd.Flags().StringP("version-number", "v", "", "Reference to the version")
 	createNodeCmd.MarkFlagRequired("version-number")
 
-	createNodeCmd.Flags().StringP("created-by", "u", "", "User creating the node")
-	createNodeCmd.MarkFlagRequired("created-by")
-
 	// Add all commands to node CLI
 	nodeCli.AddCommand(createNodeCmd)
 
@@ -64,6 +65,15 @@ func init() {
 	listNodesCmd.Flags().Bool("include", false, "Include related configs")
 	listNodesCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
 	nodeCli.AddCommand(listNodesCmd)
+
+	nodeStatusCmd.Flags().Bool("online", false, "only show online nodes")
+	nodeStatusCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	nodeCli.AddCommand(nodeStatusCmd)
+
+	nodeDriftCmd.Flags().Bool("all", false, "include resolved drift events")
+	nodeDriftCmd.Flags().Int32("limit", 50, "maximum number of entries, 0 for all")
+	nodeDriftCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	nodeCli.AddCommand(nodeDriftCmd)
 }
 
 var nodeCli = &cobra.Command{
@@ -81,7 +91,6 @@ var createNodeCmd = &cobra.Command{
 		networkIndex, _ := cmd.Flags().GetInt32("network-index")
 		locality, _ := cmd.Flags().GetString("locality")
 		versionSetID, _ := cmd.Flags().GetString("version-number")
-		createdBy, _ := cmd.Flags().GetString("created-by")
 		ctx, client, conn, cancel, err := getClient()
 		if err != nil {
 			cli_logger.Fatal().Err(err).Msg("Failed to get client")
@@ -95,7 +104,6 @@ var createNodeCmd = &cobra.Command{
 			NetworkIndex: networkIndex,
 			Locality:     &locality,
 			VersionSetId: versionSetID,
-			User:         createdBy,
 		}
 
 		rsp, err := client.CreateNode(ctx, request)
@@ -113,7 +121,7 @@ var createNodeCmd = &cobra.Command{
 var readNodeCmd = &cobra.Command{
 	Use:   "read",
 	Short: "Read node details",
-	Long:  "Read and display details of a specific node",
+	Long:  "Read and display details of a specific node and the inventory it reported in its last hello",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		id, _ := cmd.Flags().GetInt32("id")
 
@@ -159,12 +167,28 @@ var readNodeCmd = &cobra.Command{
 			cli_logger.Fatal().Err(err).Msg("Failed to get node")
 		}
 
+		// the inventory is reported by the node in its hello, it is missing
+		// until the node sent a hello with payload
+		inventory, err := grpc_scale.NewScaleClient(conn).GetNodeInventory(ctx, &grpc_scale.GetNodeInventoryRequest{
+			SerialNumber: rsp.GetNode().GetSerialNumber(),
+		})
+		if err != nil && status.Code(err) != codes.NotFound {
+			cli_logger.Warn().Err(err).Msg("Failed to get node inventory")
+		}
+
 		if HasMachineOutputFlag() {
-			SuccessOutput(rsp, "", outputFormat)
+			SuccessOutput(struct {
+				*grpc_southbound.NodeResponse
+				Inventory *grpc_scale.NodeInventory `json:"inventory,omitempty"`
+			}{rsp, inventory}, "", outputFormat)
 			return nil
 		}
 
 		PrintNodeResponseAsTable([]*grpc_southbound.NodeResponse{rsp})
+		if inventory != nil {
+			fmt.Println()
+			PrintNodeInventory(inventory)
+		}
 		return nil
 	},
 }
@@ -287,6 +311,161 @@ var listNodesCmd = &cobra.Command{
 	},
 }
 
+var nodeStatusCmd = &cobra.Command{
+	Use:   "status [serial-number]",
+	Short: "Show whether nodes are online",
+	Long: `Show the broker connection and the last hello of all nodes or of a single one.
+A node is online while it is connected to the broker or within the online
+window after its last hello, only online nodes receive fleet updates.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req := &grpc_scale.ListNodeStatusRequest{}
+		if len(args) == 1 {
+			req.SerialNumber = args[0]
+		}
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		rsp, err := client.ListNodeStatus(ctx, req)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get node status")
+		}
+
+		nodes := rsp.GetNodes()
+		if onlineOnly, _ := cmd.Flags().GetBool("online"); onlineOnly {
+			online := nodes[:0]
+			for _, node := range nodes {
+				if node.Online {
+					online = append(online, node)
+				}
+			}
+			nodes = online
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(nodes, "", outputFormat)
+			return nil
+		}
+
+		PrintNodeStatusAsTable(nodes)
+		return nil
+	},
+}
+
+var nodeDriftCmd = &cobra.Command{
+	Use:   "drift [serial-number]",
+	Short: "Report nodes whose config drifted",
+	Long: `Report nodes whose config hash, sent with their hello, differs from the hash
+of the config the controller pushed to them. Only open drift events are shown
+unless --all is given, an event is resolved once the node reports the
+expected hash again.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req := &grpc_scale.ListDriftEventsRequest{}
+		req.IncludeResolved, _ = cmd.Flags().GetBool("all")
+		req.Limit, _ = cmd.Flags().GetInt32("limit")
+		if len(args) == 1 {
+			req.SerialNumber = args[0]
+		}
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		rsp, err := client.ListDriftEvents(ctx, req)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to list drift events")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(rsp.GetEvents(), "", outputFormat)
+			return nil
+		}
+		if len(rsp.GetEvents()) == 0 {
+			cli_logger.Info().Msg("No drift detected")
+			return nil
+		}
+
+		PrintDriftEventsAsTable(rsp.GetEvents())
+		return nil
+	},
+}
+
+func PrintDriftEventsAsTable(events []*grpc_scale.DriftEvent) {
+	// hashes are shortened like git object ids
+	short := func(hash string) string {
+		if len(hash) > 12 {
+			return hash[:12]
+		}
+		return hash
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ID\tSERIAL NUMBER\tVERSION SET\tEXPECTED\tREPORTED\tREPORTED VERSION SET\tDETECTED AT\tRESOLVED AT")
+	for _, e := range events {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Id,
+			e.SerialNumber,
+			e.VersionSetId,
+			short(e.ExpectedHash),
+			short(e.ReportedHash),
+			e.ReportedVersionSetId,
+			formatTimestamp(e.DetectedAt),
+			formatTimestamp(e.ResolvedAt),
+		)
+	}
+	w.Flush()
+}
+
+func PrintNodeStatusAsTable(nodes []*grpc_scale.NodeStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SERIAL NUMBER\tSTATUS\tREMOTE ADDRESS\tCONNECTED AT\tDISCONNECTED AT\tREASON\tLAST SEEN")
+
+	for _, node := range nodes {
+		state := "offline"
+		if node.Online {
+			state = "online"
+		}
+		reason := ""
+		if !node.Connected {
+			reason = node.DisconnectReason
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			node.SerialNumber,
+			state,
+			node.RemoteAddr,
+			formatTimestamp(node.ConnectedAt),
+			formatTimestamp(node.DisconnectedAt),
+			reason,
+			formatTimestamp(node.LastSeen),
+		)
+	}
+	w.Flush()
+}
+
+func PrintNodeInventory(inv *grpc_scale.NodeInventory) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "AGENT VERSION\t%s\n", inv.AgentVersion)
+	fmt.Fprintf(w, "FIRMWARE VERSION\t%s\n", inv.FirmwareVersion)
+	fmt.Fprintf(w, "HARDWARE MODEL\t%s\n", inv.HardwareModel)
+	fmt.Fprintf(w, "UPTIME\t%s\n", time.Duration(inv.Uptime)*time.Second)
+	fmt.Fprintf(w, "APPLIED VERSION SET\t%s\n", inv.VersionSetId)
+	fmt.Fprintf(w, "APPLIED TRANSACTION\t%d\n", inv.TxId)
+	fmt.Fprintf(w, "KEX METHODS\t%s\n", strings.Join(inv.KexMethods, ", "))
+	fmt.Fprintf(w, "CIPHERS\t%s\n", strings.Join(inv.Ciphers, ", "))
+	fmt.Fprintf(w, "CONFIG HASH\t%s\n", inv.ConfigHash)
+	fmt.Fprintf(w, "REPORTED AT\t%s\n", formatTimestamp(inv.ReportedAt))
+	w.Flush()
+}
+
 func PrintNodeResponseAsTable(nodes []*grpc_southbound.NodeResponse) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
 	fmt.Fprintln(w, "ID\tSERIAL NUMBER\tNETWORK INDEX\tLOCALITY\tVERSION SET ID\tLAST SEEN")
@@ -41,9 +41,6 @@ func init() {
 	createProxyCmd.Flags().StringP("version-number", "v", "", "Version set ID")
 	createProxyCmd.MarkFlagRequired("version-number")
 
-	createProxyCmd.Flags().StringP("created-by", "u", "", "User creating the proxy")
-	createProxyCmd.MarkFlagRequired("created-by")
-
 	createProxyCmd.Flags().StringP("name", "m", "", "Name of the proxy")
 	createProxyCmd.MarkFlagRequired("name")
 	proxyCli.AddCommand(createProxyCmd)
@@ -91,7 +88,6 @@ var createProxyCmd = &cobra.Command{
 		serverEndpoint, _ := cmd.Flags().GetString("server-endpoint")
 		clientEndpoint, _ := cmd.Flags().GetString("client-endpoint")
 		versionSetID, _ := cmd.Flags().GetString("version-number")
-		createdBy, _ := cmd.Flags().GetString("created-by")
 		name, _ := cmd.Flags().GetString("name")
 
 		ctx, client, conn, cancel, err := getClient()
@@ -123,7 +119,6 @@ var createProxyCmd = &cobra.Command{
 			ServerEndpointAddr: serverEndpoint,
 			ClientEndpointAddr: clientEndpoint,
 			VersionSetId:       versionSetID,
-			CreatedBy:          createdBy,
 			Name:               name,
 		}
 
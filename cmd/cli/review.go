@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"strconv"
+
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func init() {
+	cli_logger.Debug().Msg("Registering review commands")
+
+	for _, cmd := range []*cobra.Command{submitVersionSetCmd, approveVersionSetCmd, rejectVersionSetCmd, commentVersionSetCmd} {
+		cmd.Flags().StringP("comment", "c", "", "Comment recorded with the review")
+		cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+		versionSetCli.AddCommand(cmd)
+	}
+
+	listReviewsCmd.Flags().String("user", "", "only reviews of this user")
+	listReviewsCmd.Flags().String("since", "", "start of the time range: RFC3339, date, date and time or a duration ago like 24h")
+	listReviewsCmd.Flags().String("until", "", "end of the time range, same formats as --since")
+	listReviewsCmd.Flags().Int32("limit", 50, "maximum number of entries, 0 for all")
+	listReviewsCmd.Flags().Bool("diff", false, "show the diffs recorded with the submissions")
+	listReviewsCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	versionSetCli.AddCommand(listReviewsCmd)
+}
+
+type reviewFunc func(ctx context.Context, client grpc_scale.ScaleClient, req *grpc_scale.ReviewRequest) (*grpc_scale.Review, error)
+
+// runReview sends a review of the version set in args[0] and prints the result
+func runReview(cmd *cobra.Command, args []string, action string, send reviewFunc) error {
+	comment, _ := cmd.Flags().GetString("comment")
+
+	ctx, client, conn, cancel, err := getScaleClient()
+	if err != nil {
+		cli_logger.Fatal().Err(err).Msg("Failed to get client")
+	}
+	defer cancel()
+	defer conn.Close()
+
+	review, err := send(ctx, client, &grpc_scale.ReviewRequest{
+		VersionSetId: args[0],
+		Comment:      comment,
+	})
+	if err != nil {
+		cli_logger.Fatal().Err(err).Msgf("Failed to %s version set", action)
+	}
+
+	if HasMachineOutputFlag() {
+		SuccessOutput(review, "", outputFormat)
+		return nil
+	}
+	if len(review.GetDiff()) > 0 {
+		PrintVersionSetDiffAsTable(review.GetDiff())
+	}
+	cli_logger.Info().Msgf("Version set %s %s by %s", review.VersionSetId, review.Action, review.User)
+	return nil
+}
+
+var submitVersionSetCmd = &cobra.Command{
+	Use:   "submit <id>",
+	Short: "Submit a draft version set for review",
+	Long: `Freeze a draft version set and record it for review together with its diff
+to the active version set. It can only be activated after another user
+approved it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReview(cmd, args, "submit", func(ctx context.Context, client grpc_scale.ScaleClient, req *grpc_scale.ReviewRequest) (*grpc_scale.Review, error) {
+			return client.SubmitVersionSet(ctx, req)
+		})
+	},
+}
+
+var approveVersionSetCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "Approve a submitted version set",
+	Long:  "Approve the latest submission of a version set, the approver must not be the submitter",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReview(cmd, args, "approve", func(ctx context.Context, client grpc_scale.ScaleClient, req *grpc_scale.ReviewRequest) (*grpc_scale.Review, error) {
+			return client.ApproveVersionSet(ctx, req)
+		})
+	},
+}
+
+var rejectVersionSetCmd = &cobra.Command{
+	Use:   "reject <id>",
+	Short: "Reject a submitted version set",
+	Long:  "Reject a submitted version set with a comment, it returns to draft and has to be submitted again",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReview(cmd, args, "reject", func(ctx context.Context, client grpc_scale.ScaleClient, req *grpc_scale.ReviewRequest) (*grpc_scale.Review, error) {
+			return client.RejectVersionSet(ctx, req)
+		})
+	},
+}
+
+var commentVersionSetCmd = &cobra.Command{
+	Use:   "comment <id>",
+	Short: "Comment on a version set",
+	Long:  "Add a comment to the review history of a version set",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReview(cmd, args, "comment on", func(ctx context.Context, client grpc_scale.ScaleClient, req *grpc_scale.ReviewRequest) (*grpc_scale.Review, error) {
+			return client.CommentVersionSet(ctx, req)
+		})
+	},
+}
+
+var listReviewsCmd = &cobra.Command{
+	Use:   "reviews [id]",
+	Short: "List the review history",
+	Long:  "List submissions, approvals, rejections and comments of one or all version sets, newest first",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req := &grpc_scale.ListReviewsRequest{}
+		req.Limit, _ = cmd.Flags().GetInt32("limit")
+		if len(args) == 1 {
+			req.VersionSetId = &args[0]
+		}
+		if user, _ := cmd.Flags().GetString("user"); user != "" {
+			req.User = &user
+		}
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			req.Since = timestamppb.New(parseHistoryTime(since))
+		}
+		if until, _ := cmd.Flags().GetString("until"); until != "" {
+			req.Until = timestamppb.New(parseHistoryTime(until))
+		}
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.ListReviews(ctx, req)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to list reviews")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(resp.Reviews, "", outputFormat)
+			return nil
+		}
+
+		PrintReviewsAsTable(resp.Reviews)
+		if showDiff, _ := cmd.Flags().GetBool("diff"); showDiff {
+			for _, review := range resp.Reviews {
+				if len(review.GetDiff()) > 0 {
+					cli_logger.Info().Msgf("Diff of submission %d", review.Id)
+					PrintVersionSetDiffAsTable(review.GetDiff())
+				}
+			}
+		}
+		return nil
+	},
+}
+
+func PrintReviewsAsTable(reviews []*grpc_scale.Review) {
+	type reviewRow struct {
+		ID         int32
+		VersionSet string
+		Action     string
+		User       string
+		CreatedAt  string
+		Changes    string
+		Comment    string
+	}
+	rows := make([]reviewRow, 0, len(reviews))
+	for _, review := range reviews {
+		changes := ""
+		if review.Action == "submitted" {
+			changes = strconv.Itoa(len(review.GetDiff())) + " nodes"
+		}
+		rows = append(rows, reviewRow{
+			ID:         review.Id,
+			VersionSet: review.VersionSetId,
+			Action:     review.Action,
+			User:       review.User,
+			CreatedAt:  formatTimestamp(review.CreatedAt),
+			Changes:    changes,
+			Comment:    review.Comment,
+		})
+	}
+	PrintAsTable(rows, []TableColumn{
+		{Header: "ID", FieldPath: "ID"},
+		{Header: "VERSION SET", FieldPath: "VersionSet"},
+		{Header: "ACTION", FieldPath: "Action"},
+		{Header: "USER", FieldPath: "User"},
+		{Header: "CREATED AT", FieldPath: "CreatedAt"},
+		{Header: "CHANGES", FieldPath: "Changes"},
+		{Header: "COMMENT", FieldPath: "Comment"},
+	})
+}
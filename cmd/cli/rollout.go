@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"strconv"
+	"strings"
+
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var rolloutCmd = &cobra.Command{
+	Use:   "rollout",
+	Short: "manage staged rollouts",
+	Long:  "inspect, resume and abort staged rollouts started with activate fleet or activate group",
+}
+
+func init() {
+	cli_logger.Debug().Msg("Registering rollout commands")
+
+	rootCmd.AddCommand(rolloutCmd)
+
+	rolloutStatusCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	rolloutCmd.AddCommand(rolloutStatusCmd)
+
+	rolloutResumeCmd.Flags().Int32("failure-threshold", 0, "number of failed nodes tolerated from now on")
+	rolloutCmd.AddCommand(rolloutResumeCmd)
+
+	rolloutCmd.AddCommand(rolloutAbortCmd)
+}
+
+// addRolloutFlags adds the flags which turn an activation into a staged rollout
+func addRolloutFlags(cmd *cobra.Command) {
+	cmd.Flags().Int32("canary", 0, "number of nodes updated in the first wave")
+	cmd.Flags().Int32("wave-percent", 0, "percentage of the nodes updated per wave after the canary wave")
+	cmd.Flags().Duration("wave-pause", 0, "pause between two waves, e.g. 5m")
+	cmd.Flags().Int32("failure-threshold", 0, "number of failed nodes tolerated before the rollout halts")
+}
+
+// rolloutPolicyFromFlags returns the rollout policy given on the command line,
+// or nil if none of the rollout flags is set
+func rolloutPolicyFromFlags(cmd *cobra.Command) *grpc_scale.RolloutPolicy {
+	if !cmd.Flags().Changed("canary") && !cmd.Flags().Changed("wave-percent") &&
+		!cmd.Flags().Changed("wave-pause") && !cmd.Flags().Changed("failure-threshold") {
+		return nil
+	}
+
+	canary, _ := cmd.Flags().GetInt32("canary")
+	wavePercent, _ := cmd.Flags().GetInt32("wave-percent")
+	wavePause, _ := cmd.Flags().GetDuration("wave-pause")
+	failureThreshold, _ := cmd.Flags().GetInt32("failure-threshold")
+
+	return &grpc_scale.RolloutPolicy{
+		CanaryNodes:      canary,
+		WavePercent:      wavePercent,
+		WavePause:        durationpb.New(wavePause),
+		FailureThreshold: failureThreshold,
+	}
+}
+
+func startRollout(cmd *cobra.Command, versionSetId string, group *string, policy *grpc_scale.RolloutPolicy) error {
+	force, _ := cmd.Flags().GetBool("force")
+	ignoreWindow, _ := cmd.Flags().GetBool("ignore-window")
+	minAgentVersion, _ := cmd.Flags().GetString("min-agent-version")
+
+	ctx, client, conn, cancel, err := getScaleClient()
+	if err != nil {
+		cli_logger.Fatal().Err(err).Msg("Failed to get client")
+	}
+	defer cancel()
+	defer conn.Close()
+
+	resp, err := client.StartRollout(ctx, &grpc_scale.StartRolloutRequest{
+		VersionSetId:    versionSetId,
+		GroupName:       group,
+		Policy:          policy,
+		Force:           force,
+		IgnoreWindow:    ignoreWindow,
+		MinAgentVersion: minAgentVersion,
+	})
+	if err != nil {
+		cli_logger.Fatal().Err(err).Msg("Failed to start rollout")
+	}
+	cli_logger.Info().Msgf("Started rollout %d in %d waves", resp.TxId, len(resp.Waves))
+	PrintRolloutAsTable(resp)
+
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		return watchTransaction(resp.TxId)
+	}
+	return nil
+}
+
+func parseTxID(arg string) int32 {
+	id, err := strconv.ParseInt(arg, 10, 32)
+	if err != nil {
+		cli_logger.Fatal().Err(err).Msg("Invalid transaction id")
+	}
+	return int32(id)
+}
+
+var rolloutStatusCmd = &cobra.Command{
+	Use:   "status <tx-id>",
+	Short: "show a rollout",
+	Long:  "show the waves and the progress of a rollout",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		txID := parseTxID(args[0])
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.GetRollout(ctx, &grpc_scale.GetRolloutRequest{TxId: txID})
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get rollout")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(resp, "", outputFormat)
+			return nil
+		}
+
+		PrintRolloutAsTable(resp)
+		return nil
+	},
+}
+
+var rolloutResumeCmd = &cobra.Command{
+	Use:   "resume <tx-id>",
+	Short: "resume a halted rollout",
+	Long:  "resume a halted rollout with the first wave that has not been applied completely",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		req := &grpc_scale.ResumeRolloutRequest{TxId: parseTxID(args[0])}
+		if cmd.Flags().Changed("failure-threshold") {
+			threshold, _ := cmd.Flags().GetInt32("failure-threshold")
+			req.FailureThreshold = &threshold
+		}
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.ResumeRollout(ctx, req)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to resume rollout")
+		}
+		cli_logger.Info().Msgf("Resumed rollout %d", resp.TxId)
+
+		return nil
+	},
+}
+
+var rolloutAbortCmd = &cobra.Command{
+	Use:   "abort <tx-id>",
+	Short: "abort a rollout",
+	Long:  "abort a running or halted rollout, a version update is rolled back to the previous version set",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		txID := parseTxID(args[0])
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.AbortRollout(ctx, &grpc_scale.AbortRolloutRequest{TxId: txID})
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to abort rollout")
+		}
+		cli_logger.Info().Msgf("Rollout %d is %s", resp.TxId, resp.Status)
+
+		return nil
+	},
+}
+
+func PrintRolloutAsTable(rollout *grpc_scale.RolloutResponse) {
+	cli_logger.Info().Msgf("Rollout %d of version set %s is %s: %s",
+		rollout.TxId, rollout.VersionSetId, rollout.Status, rollout.GetDescription())
+	if len(rollout.FailedNodes) > 0 {
+		cli_logger.Info().Msgf("Failed nodes: %s", strings.Join(rollout.FailedNodes, ", "))
+	}
+	if len(rollout.QueuedNodes) > 0 {
+		cli_logger.Info().Msgf("Queued for offline nodes: %s", strings.Join(rollout.QueuedNodes, ", "))
+	}
+
+	type waveRow struct {
+		Index int32
+		State string
+		Nodes string
+	}
+	rows := make([]waveRow, 0, len(rollout.Waves))
+	for _, wave := range rollout.Waves {
+		rows = append(rows, waveRow{Index: wave.Index, State: wave.State, Nodes: strings.Join(wave.SerialNumbers, ", ")})
+	}
+	columns := []TableColumn{
+		{Header: "WAVE", FieldPath: "Index"},
+		{Header: "STATE", FieldPath: "State"},
+		{Header: "NODES", FieldPath: "Nodes"},
+	}
+	PrintAsTable(rows, columns)
+}
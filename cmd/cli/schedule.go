@@ -0,0 +1,371 @@
+package cli
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var maintenanceWindowCmd = &cobra.Command{
+	Use:     "maintenance-window",
+	Aliases: []string{"mw"},
+	Short:   "manage maintenance windows",
+	Long:    "manage the recurring maintenance windows of the localities, nodes of a locality with windows are only activated while one of them is open",
+}
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "manage scheduled activations",
+	Long:  "schedule the activation of a version set for the fleet or a group, list and cancel scheduled activations",
+}
+
+func init() {
+	cli_logger.Debug().Msg("Registering schedule commands")
+
+	rootCmd.AddCommand(maintenanceWindowCmd)
+	rootCmd.AddCommand(scheduleCmd)
+
+	createMaintenanceWindowCmd.Flags().StringP("name", "n", "", "name of the window")
+	createMaintenanceWindowCmd.Flags().StringP("locality", "l", "", "locality of the nodes the window applies to")
+	createMaintenanceWindowCmd.Flags().String("days", "", "comma separated weekdays, e.g. mon,wed,fri, every day if empty")
+	createMaintenanceWindowCmd.Flags().String("start", "", "start of the window as HH:MM")
+	createMaintenanceWindowCmd.Flags().Duration("duration", time.Hour, "length of the window, e.g. 2h")
+	createMaintenanceWindowCmd.Flags().String("timezone", "UTC", "IANA time zone of the start time, e.g. Europe/Berlin")
+	createMaintenanceWindowCmd.MarkFlagRequired("name")
+	createMaintenanceWindowCmd.MarkFlagRequired("locality")
+	createMaintenanceWindowCmd.MarkFlagRequired("start")
+	maintenanceWindowCmd.AddCommand(createMaintenanceWindowCmd)
+
+	listMaintenanceWindowsCmd.Flags().StringP("locality", "l", "", "only show the windows of this locality")
+	listMaintenanceWindowsCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	maintenanceWindowCmd.AddCommand(listMaintenanceWindowsCmd)
+
+	maintenanceWindowCmd.AddCommand(deleteMaintenanceWindowCmd)
+
+	createScheduleCmd.Flags().StringP("version-number", "v", "", "version number")
+	createScheduleCmd.Flags().StringP("group", "g", "", "group name, the whole fleet if empty")
+	createScheduleCmd.Flags().String("at", "", "time of the activation: RFC3339, date and time, or HH:MM for the next occurrence")
+	createScheduleCmd.Flags().String("timezone", "", "IANA time zone of --at, the local time zone if empty")
+	createScheduleCmd.Flags().Bool("force", false, "activate even if the version set fails validation")
+	createScheduleCmd.Flags().Bool("ignore-window", false, "activate outside the maintenance windows of the nodes")
+	createScheduleCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	createScheduleCmd.MarkFlagRequired("version-number")
+	createScheduleCmd.MarkFlagRequired("at")
+	scheduleCmd.AddCommand(createScheduleCmd)
+
+	listSchedulesCmd.Flags().BoolP("all", "a", false, "include finished and cancelled activations")
+	listSchedulesCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	scheduleCmd.AddCommand(listSchedulesCmd)
+
+	scheduleCmd.AddCommand(cancelScheduleCmd)
+}
+
+var createMaintenanceWindowCmd = &cobra.Command{
+	Use:   "create",
+	Short: "create a maintenance window",
+	Long:  "create a recurring maintenance window for the nodes of a locality",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("name")
+		locality, _ := cmd.Flags().GetString("locality")
+		days, _ := cmd.Flags().GetString("days")
+		start, _ := cmd.Flags().GetString("start")
+		duration, _ := cmd.Flags().GetDuration("duration")
+		timezone, _ := cmd.Flags().GetString("timezone")
+
+		req := &grpc_scale.MaintenanceWindow{
+			Name:      name,
+			Locality:  locality,
+			StartTime: start,
+			Duration:  durationpb.New(duration),
+			Timezone:  timezone,
+		}
+		if days != "" {
+			req.Days = strings.Split(days, ",")
+		}
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.CreateMaintenanceWindow(ctx, req)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to create maintenance window")
+		}
+		cli_logger.Info().Msgf("Created maintenance window %s with ID %d", resp.Name, resp.Id)
+		return nil
+	},
+}
+
+var listMaintenanceWindowsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list maintenance windows",
+	Long:  "list the maintenance windows of all or one locality",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		locality, _ := cmd.Flags().GetString("locality")
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.ListMaintenanceWindows(ctx, &grpc_scale.ListMaintenanceWindowsRequest{Locality: locality})
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to list maintenance windows")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(resp.Windows, "", outputFormat)
+			return nil
+		}
+
+		PrintMaintenanceWindowsAsTable(resp.Windows)
+		return nil
+	},
+}
+
+var deleteMaintenanceWindowCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "delete a maintenance window",
+	Long:  "delete a maintenance window by name",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		if _, err := client.DeleteMaintenanceWindow(ctx, &grpc_scale.DeleteMaintenanceWindowRequest{Name: args[0]}); err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to delete maintenance window")
+		}
+		cli_logger.Info().Msgf("Deleted maintenance window %s", args[0])
+		return nil
+	},
+}
+
+var createScheduleCmd = &cobra.Command{
+	Use:   "create",
+	Short: "schedule an activation",
+	Long: `schedule the activation of a version set for the fleet or a group.
+The activation is started by the controller at the given time, it is refused
+if that time is outside the maintenance windows of the nodes.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		versionSetId, _ := cmd.Flags().GetString("version-number")
+		group, _ := cmd.Flags().GetString("group")
+		at, _ := cmd.Flags().GetString("at")
+		timezone, _ := cmd.Flags().GetString("timezone")
+		force, _ := cmd.Flags().GetBool("force")
+		ignoreWindow, _ := cmd.Flags().GetBool("ignore-window")
+
+		req := &grpc_scale.ScheduleActivationRequest{
+			VersionSetId: versionSetId,
+			RunAt:        timestamppb.New(parseScheduleTime(at, timezone)),
+			Force:        force,
+			IgnoreWindow: ignoreWindow,
+		}
+		if group != "" {
+			req.GroupName = &group
+		}
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.ScheduleActivation(ctx, req)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to schedule activation")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(resp, "", outputFormat)
+			return nil
+		}
+		cli_logger.Info().Msgf("Scheduled activation %d at %s", resp.Id, formatTimestamp(resp.RunAt))
+		return nil
+	},
+}
+
+// parseScheduleTime accepts RFC3339, a date and time, or HH:MM which is the
+// next time the clock shows it. The latter two are read in timezone, or in
+// local time if it is empty.
+func parseScheduleTime(value string, timezone string) time.Time {
+	location := time.Local
+	if timezone != "" {
+		var err error
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msgf("Invalid time zone %q", timezone)
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	for _, layout := range []string{HeadscaleDateTimeFormat, "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t
+		}
+	}
+	if clock, err := time.Parse("15:04", value); err == nil {
+		now := time.Now().In(location)
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t
+	}
+	cli_logger.Fatal().Msgf("Invalid time %q", value)
+	return time.Time{}
+}
+
+var listSchedulesCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list scheduled activations",
+	Long:  "list the pending scheduled activations, with --all also the finished ones and their outcome",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.ListScheduledActivations(ctx, &grpc_scale.ListScheduledActivationsRequest{All: all})
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to list scheduled activations")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(resp.Activations, "", outputFormat)
+			return nil
+		}
+
+		PrintScheduledActivationsAsTable(resp.Activations)
+		return nil
+	},
+}
+
+var cancelScheduleCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "cancel a scheduled activation",
+	Long:  "cancel a scheduled activation which has not been started yet",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 32)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Invalid scheduled activation id")
+		}
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		if _, err := client.CancelScheduledActivation(ctx, &grpc_scale.CancelScheduledActivationRequest{Id: int32(id)}); err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to cancel scheduled activation")
+		}
+		cli_logger.Info().Msgf("Cancelled scheduled activation %d", id)
+		return nil
+	},
+}
+
+func PrintMaintenanceWindowsAsTable(windows []*grpc_scale.MaintenanceWindow) {
+	type windowRow struct {
+		ID        int32
+		Name      string
+		Locality  string
+		Days      string
+		Start     string
+		Duration  string
+		Timezone  string
+		CreatedBy string
+	}
+	rows := make([]windowRow, 0, len(windows))
+	for _, w := range windows {
+		days := strings.Join(w.Days, ",")
+		if days == "" {
+			days = "daily"
+		}
+		rows = append(rows, windowRow{
+			ID:        w.Id,
+			Name:      w.Name,
+			Locality:  w.Locality,
+			Days:      days,
+			Start:     w.StartTime,
+			Duration:  w.GetDuration().AsDuration().String(),
+			Timezone:  w.Timezone,
+			CreatedBy: w.CreatedBy,
+		})
+	}
+	PrintAsTable(rows, []TableColumn{
+		{Header: "ID", FieldPath: "ID"},
+		{Header: "NAME", FieldPath: "Name"},
+		{Header: "LOCALITY", FieldPath: "Locality"},
+		{Header: "DAYS", FieldPath: "Days"},
+		{Header: "START", FieldPath: "Start"},
+		{Header: "DURATION", FieldPath: "Duration"},
+		{Header: "TIMEZONE", FieldPath: "Timezone"},
+		{Header: "CREATED BY", FieldPath: "CreatedBy"},
+	})
+}
+
+func PrintScheduledActivationsAsTable(activations []*grpc_scale.ScheduledActivation) {
+	type activationRow struct {
+		ID          int32
+		VersionSet  string
+		Group       string
+		RunAt       string
+		Status      string
+		Transaction string
+		Message     string
+		CreatedBy   string
+	}
+	rows := make([]activationRow, 0, len(activations))
+	for _, a := range activations {
+		transaction := ""
+		if a.TxId != nil {
+			transaction = strconv.Itoa(int(a.GetTxId()))
+			if a.TxState != "" {
+				transaction += " (" + a.TxState + ")"
+			}
+		}
+		rows = append(rows, activationRow{
+			ID:          a.Id,
+			VersionSet:  a.VersionSetId,
+			Group:       a.GetGroupName(),
+			RunAt:       formatTimestamp(a.RunAt),
+			Status:      a.Status,
+			Transaction: transaction,
+			Message:     a.Message,
+			CreatedBy:   a.CreatedBy,
+		})
+	}
+	PrintAsTable(rows, []TableColumn{
+		{Header: "ID", FieldPath: "ID"},
+		{Header: "VERSION SET", FieldPath: "VersionSet"},
+		{Header: "GROUP", FieldPath: "Group"},
+		{Header: "RUN AT", FieldPath: "RunAt"},
+		{Header: "STATUS", FieldPath: "Status"},
+		{Header: "TRANSACTION", FieldPath: "Transaction"},
+		{Header: "MESSAGE", FieldPath: "Message"},
+		{Header: "CREATED BY", FieldPath: "CreatedBy"},
+	})
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	cli_logger.Debug().Msg("Registering token command")
+	rootCmd.AddCommand(tokenCmd)
+}
+
+var tokenCmd = &cobra.Command{
+	Use:   "token <name>",
+	Short: "Create a token for a cli user",
+	Long: `Create a random token for the cli user <name>. The printed user goes into
+cli_users of the server, the token into cli_token_file (or cli_token_env) of
+the cli. Only the sha256 of the token is kept by the server.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token := rand.Text()
+		sum := sha256.Sum256([]byte(token))
+
+		fmt.Printf("token: %s\n\n", token)
+		fmt.Printf("cli_users:\n  - name: %s\n    token_sha256: %q\n", args[0], hex.EncodeToString(sum[:]))
+		return nil
+	},
+}
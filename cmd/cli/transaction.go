@@ -0,0 +1,326 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var transactionCmd = &cobra.Command{
+	Use:   "transaction",
+	Short: "inspect transactions",
+	Long:  "list past and running transactions and show the per node log of a transaction",
+}
+
+var transitionCmd = &cobra.Command{
+	Use:   "transition",
+	Short: "inspect version transitions",
+	Long:  "list the version transitions of the fleet and show a single transition",
+}
+
+func init() {
+	cli_logger.Debug().Msg("Registering transaction commands")
+
+	rootCmd.AddCommand(transactionCmd)
+	rootCmd.AddCommand(transitionCmd)
+
+	addHistoryFlags(listTransactionsCmd)
+	listTransactionsCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	transactionCmd.AddCommand(listTransactionsCmd)
+
+	showTransactionCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	transactionCmd.AddCommand(showTransactionCmd)
+
+	addHistoryFlags(listTransitionsCmd)
+	listTransitionsCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	transitionCmd.AddCommand(listTransitionsCmd)
+
+	showTransitionCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	transitionCmd.AddCommand(showTransitionCmd)
+}
+
+// addHistoryFlags adds the flags which filter the transaction and transition history
+func addHistoryFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("node", "n", "", "serial number of a node involved")
+	cmd.Flags().StringP("version-set", "v", "", "ID of the version set")
+	cmd.Flags().StringP("state", "s", "", "state, e.g. applied, error or pending")
+	cmd.Flags().String("since", "", "start of the time range: RFC3339, date, date and time or a duration ago like 24h")
+	cmd.Flags().String("until", "", "end of the time range, same formats as --since")
+	cmd.Flags().Int32("limit", 50, "maximum number of entries, 0 for all")
+}
+
+func historyFilterFromFlags(cmd *cobra.Command) *grpc_scale.HistoryFilter {
+	filter := &grpc_scale.HistoryFilter{}
+	filter.Limit, _ = cmd.Flags().GetInt32("limit")
+
+	if node, _ := cmd.Flags().GetString("node"); node != "" {
+		filter.NodeSerial = &node
+	}
+	if versionSet, _ := cmd.Flags().GetString("version-set"); versionSet != "" {
+		filter.VersionSetId = &versionSet
+	}
+	if state, _ := cmd.Flags().GetString("state"); state != "" {
+		filter.State = &state
+	}
+	if since, _ := cmd.Flags().GetString("since"); since != "" {
+		filter.Since = timestamppb.New(parseHistoryTime(since))
+	}
+	if until, _ := cmd.Flags().GetString("until"); until != "" {
+		filter.Until = timestamppb.New(parseHistoryTime(until))
+	}
+	return filter
+}
+
+// parseHistoryTime accepts RFC3339, a date or a date and time in local time,
+// or a duration which is taken as that long ago
+func parseHistoryTime(value string) time.Time {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d)
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	for _, layout := range []string{HeadscaleDateTimeFormat, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+	cli_logger.Fatal().Msgf("Invalid time %q", value)
+	return time.Time{}
+}
+
+func formatTimestamp(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return ""
+	}
+	return ts.AsTime().Local().Format(HeadscaleDateTimeFormat)
+}
+
+var listTransactionsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list transactions",
+	Long:  "list transactions, newest first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter := historyFilterFromFlags(cmd)
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.ListTransactions(ctx, &grpc_scale.ListTransactionsRequest{Filter: filter})
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to list transactions")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(resp.Transactions, "", outputFormat)
+			return nil
+		}
+
+		PrintTransactionsAsTable(resp.Transactions)
+		return nil
+	},
+}
+
+var showTransactionCmd = &cobra.Command{
+	Use:   "show <tx-id>",
+	Short: "show a transaction",
+	Long:  "show a transaction with its per node log and its version transition",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		txID := parseTxID(args[0])
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.GetTransaction(ctx, &grpc_scale.GetTransactionRequest{TxId: txID})
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get transaction")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(resp, "", outputFormat)
+			return nil
+		}
+
+		PrintTransactionsAsTable([]*grpc_scale.Transaction{resp.Transaction})
+		if resp.VersionTransition != nil {
+			fmt.Println()
+			PrintTransitionsAsTable([]*grpc_scale.VersionTransition{resp.VersionTransition})
+		}
+
+		type logRow struct {
+			SerialNumber string
+			VersionSet   string
+			State        string
+			Timestamp    string
+			Reason       string
+		}
+		rows := make([]logRow, 0, len(resp.Log))
+		for _, entry := range resp.Log {
+			rows = append(rows, logRow{
+				SerialNumber: entry.NodeSerial,
+				VersionSet:   entry.VersionSetId,
+				State:        entry.State,
+				Timestamp:    formatTimestamp(entry.Timestamp),
+				Reason:       types.LogReason([]byte(entry.GetMetadata())),
+			})
+		}
+		fmt.Println()
+		PrintAsTable(rows, []TableColumn{
+			{Header: "SERIAL NUMBER", FieldPath: "SerialNumber"},
+			{Header: "VERSION SET", FieldPath: "VersionSet"},
+			{Header: "STATE", FieldPath: "State"},
+			{Header: "TIMESTAMP", FieldPath: "Timestamp"},
+			{Header: "REASON", FieldPath: "Reason"},
+		})
+		return nil
+	},
+}
+
+var listTransitionsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list version transitions",
+	Long:  "list version transitions, newest first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter := historyFilterFromFlags(cmd)
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.ListVersionTransitions(ctx, &grpc_scale.ListVersionTransitionsRequest{Filter: filter})
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to list version transitions")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(resp.VersionTransitions, "", outputFormat)
+			return nil
+		}
+
+		PrintTransitionsAsTable(resp.VersionTransitions)
+		return nil
+	},
+}
+
+var showTransitionCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "show a version transition",
+	Long:  "show a version transition with its metadata",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 32)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Invalid version transition id")
+		}
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+		defer cancel()
+		defer conn.Close()
+
+		resp, err := client.GetVersionTransition(ctx, &grpc_scale.GetVersionTransitionRequest{Id: int32(id)})
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get version transition")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(resp, "", outputFormat)
+			return nil
+		}
+
+		PrintTransitionsAsTable([]*grpc_scale.VersionTransition{resp})
+		if resp.Metadata != nil {
+			fmt.Printf("\nMetadata: %s\n", resp.GetMetadata())
+		}
+		return nil
+	},
+}
+
+func PrintTransactionsAsTable(transactions []*grpc_scale.Transaction) {
+	type transactionRow struct {
+		ID          int32
+		Type        string
+		State       string
+		CreatedAt   string
+		CompletedAt string
+		Description string
+	}
+	rows := make([]transactionRow, 0, len(transactions))
+	for _, tx := range transactions {
+		rows = append(rows, transactionRow{
+			ID:          tx.Id,
+			Type:        tx.Type,
+			State:       tx.GetState(),
+			CreatedAt:   formatTimestamp(tx.CreatedAt),
+			CompletedAt: formatTimestamp(tx.CompletedAt),
+			Description: tx.GetDescription(),
+		})
+	}
+	PrintAsTable(rows, []TableColumn{
+		{Header: "ID", FieldPath: "ID"},
+		{Header: "TYPE", FieldPath: "Type"},
+		{Header: "STATE", FieldPath: "State"},
+		{Header: "CREATED AT", FieldPath: "CreatedAt"},
+		{Header: "COMPLETED AT", FieldPath: "CompletedAt"},
+		{Header: "DESCRIPTION", FieldPath: "Description"},
+	})
+}
+
+func PrintTransitionsAsTable(transitions []*grpc_scale.VersionTransition) {
+	type transitionRow struct {
+		ID          int32
+		From        string
+		VersionSet  string
+		Transaction int32
+		Status      string
+		StartedAt   string
+		CompletedAt string
+		CreatedBy   string
+	}
+	rows := make([]transitionRow, 0, len(transitions))
+	for _, transition := range transitions {
+		from := ""
+		if transition.FromVersionTransition != nil {
+			from = strconv.Itoa(int(transition.GetFromVersionTransition()))
+		}
+		rows = append(rows, transitionRow{
+			ID:          transition.Id,
+			From:        from,
+			VersionSet:  transition.ToVersionSetId,
+			Transaction: transition.TransactionId,
+			Status:      transition.Status,
+			StartedAt:   formatTimestamp(transition.StartedAt),
+			CompletedAt: formatTimestamp(transition.CompletedAt),
+			CreatedBy:   transition.CreatedBy,
+		})
+	}
+	PrintAsTable(rows, []TableColumn{
+		{Header: "ID", FieldPath: "ID"},
+		{Header: "FROM", FieldPath: "From"},
+		{Header: "VERSION SET", FieldPath: "VersionSet"},
+		{Header: "TRANSACTION", FieldPath: "Transaction"},
+		{Header: "STATUS", FieldPath: "Status"},
+		{Header: "STARTED AT", FieldPath: "StartedAt"},
+		{Header: "COMPLETED AT", FieldPath: "CompletedAt"},
+		{Header: "CREATED BY", FieldPath: "CreatedBy"},
+	})
+}
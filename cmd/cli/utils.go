@@ -13,8 +13,10 @@ import (
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/philslol/kritis3m_scalev2/control"
 	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"gopkg.in/yaml.v3"
 )
 
@@ -33,11 +35,22 @@ func getClient() (context.Context, grpc_southbound.SouthboundClient, *grpc.Clien
 		Dur("timeout", cfg.CliConfig.Timeout).
 		Msgf("Setting timeout")
 
+	token, err := cfg.CliConfig.CliToken()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	address := cfg.CliConfig.ServerAddr
+	// the connection has no TLS, the token must not leave the host
+	if token != "" && !types.IsLoopbackAddr(address) {
+		return nil, nil, nil, nil, fmt.Errorf("refusing to send the cli token in plaintext to %s, reach the server through an ssh tunnel on 127.0.0.1", address)
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.CliConfig.Timeout)
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, types.AuthorizationKey, "Bearer "+token)
+	}
 
 	grpcOptions := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
 
-	address := cfg.CliConfig.ServerAddr
 	cli_logger.Trace().Caller().Str("address", address).Msg("Connecting via gRPC")
 
 	conn, err := grpc.NewClient(address, grpcOptions...)
@@ -51,6 +64,16 @@ func getClient() (context.Context, grpc_southbound.SouthboundClient, *grpc.Clien
 	return ctx, client, conn, cancel, nil
 }
 
+// getScaleClient connects to the scale service, which hosts the rpcs not
+// covered by the southbound api
+func getScaleClient() (context.Context, grpc_scale.ScaleClient, *grpc.ClientConn, context.CancelFunc, error) {
+	ctx, _, conn, cancel, err := getClient()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return ctx, grpc_scale.NewScaleClient(conn), conn, cancel, nil
+}
+
 func getKritis3mScaleApp() (*control.Kritis3m_Scale, error) {
 	cfg, err := types.GetKritis3mScaleConfig()
 	if err != nil {
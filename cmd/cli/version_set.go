@@ -1,11 +1,14 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"text/tabwriter"
 
 	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
 	"github.com/spf13/cobra"
 )
 
@@ -17,8 +20,6 @@ func init() {
 	createVersionSetCmd.Flags().StringP("name", "n", "", "Name of the version set")
 	createVersionSetCmd.MarkFlagRequired("name")
 	createVersionSetCmd.Flags().StringP("description", "d", "", "Description of the version set")
-	createVersionSetCmd.Flags().StringP("created-by", "u", "", "User creating the version set")
-	createVersionSetCmd.MarkFlagRequired("created-by")
 	versionSetCli.AddCommand(createVersionSetCmd)
 
 	readVersionSetCmd.Flags().StringP("id", "i", "", "ID of the version set")
@@ -35,6 +36,25 @@ func init() {
 	deleteVersionSetCmd.MarkFlagRequired("id")
 	versionSetCli.AddCommand(deleteVersionSetCmd)
 
+	cloneVersionSetCmd.Flags().StringP("id", "i", "", "ID of the version set to clone")
+	cloneVersionSetCmd.MarkFlagRequired("id")
+	cloneVersionSetCmd.Flags().StringP("name", "n", "", "Name of the new version set")
+	cloneVersionSetCmd.MarkFlagRequired("name")
+	versionSetCli.AddCommand(cloneVersionSetCmd)
+
+	diffVersionSetsCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	versionSetCli.AddCommand(diffVersionSetsCmd)
+
+	validateVersionSetCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format: json, json-line, yaml")
+	versionSetCli.AddCommand(validateVersionSetCmd)
+
+	// the bundle is always JSON, -o names the file it is written to
+	exportVersionSetCmd.Flags().StringP("output", "o", "", "File the bundle is written to, stdout if empty")
+	versionSetCli.AddCommand(exportVersionSetCmd)
+
+	importVersionSetCmd.Flags().StringP("name", "n", "", "Name of the new version set, defaults to the exported name")
+	versionSetCli.AddCommand(importVersionSetCmd)
+
 	listVersionSetsCmd.Flags().StringP("state", "s", "", "State of the version set: DRAFT, PENDING_DEPLOYMENT, ACTIVE, DISABLED")
 	versionSetCli.AddCommand(listVersionSetsCmd)
 
@@ -56,7 +76,6 @@ var createVersionSetCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name, _ := cmd.Flags().GetString("name")
 		description, _ := cmd.Flags().GetString("description")
-		createdBy, _ := cmd.Flags().GetString("created-by")
 
 		ctx, client, conn, cancel, err := getClient()
 		if err != nil {
@@ -69,7 +88,6 @@ var createVersionSetCmd = &cobra.Command{
 		request := &grpc_southbound.CreateVersionSetRequest{
 			Name:        name,
 			Description: description,
-			CreatedBy:   createdBy,
 		}
 
 		rsp, err := client.CreateVersionSet(ctx, request)
@@ -178,6 +196,185 @@ var deleteVersionSetCmd = &cobra.Command{
 	},
 }
 
+var cloneVersionSetCmd = &cobra.Command{
+	Use:   "clone",
+	Short: "Clone a version set",
+	Long:  "Create a new draft version set with a copy of all nodes, groups, proxies, endpoint and hardware configs of an existing version set",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, _ := cmd.Flags().GetString("id")
+		name, _ := cmd.Flags().GetString("name")
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+
+		defer cancel()
+		defer conn.Close()
+
+		request := &grpc_scale.CloneVersionSetRequest{
+			SourceId: id,
+			Name:     name,
+		}
+
+		rsp, err := client.CloneVersionSet(ctx, request)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to clone version set")
+		}
+
+		cli_logger.Info().Msgf("Version set %s cloned into draft %s", id, rsp.GetId())
+		return nil
+	},
+}
+
+var diffVersionSetsCmd = &cobra.Command{
+	Use:   "diff <from-id> <to-id>",
+	Short: "Show the changes between two version sets",
+	Long:  "Show per node which proxies, groups, endpoint and hardware configs change when moving from one version set to another",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+
+		defer cancel()
+		defer conn.Close()
+
+		request := &grpc_scale.DiffVersionSetsRequest{
+			FromId: args[0],
+			ToId:   args[1],
+		}
+
+		rsp, err := client.DiffVersionSets(ctx, request)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to diff version sets")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(rsp.GetNodes(), "", outputFormat)
+			return nil
+		}
+
+		if len(rsp.GetNodes()) == 0 {
+			cli_logger.Info().Msg("Version sets are identical")
+			return nil
+		}
+		PrintVersionSetDiffAsTable(rsp.GetNodes())
+		return nil
+	},
+}
+
+var validateVersionSetCmd = &cobra.Command{
+	Use:   "validate <id>",
+	Short: "Check a version set for configuration errors",
+	Long: `Apply the validation rules to a version set. The same rules run before every
+activation, errors block the activation unless it is forced.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+
+		defer cancel()
+		defer conn.Close()
+
+		rsp, err := client.ValidateVersionSet(ctx, &grpc_scale.ValidateVersionSetRequest{Id: args[0]})
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to validate version set")
+		}
+
+		if HasMachineOutputFlag() {
+			SuccessOutput(rsp, "", outputFormat)
+			return nil
+		}
+
+		if len(rsp.GetFindings()) > 0 {
+			PrintFindingsAsTable(rsp.GetFindings())
+		}
+		if !rsp.GetValid() {
+			cli_logger.Fatal().Msgf("Version set %s fails validation", args[0])
+		}
+		cli_logger.Info().Msgf("Version set %s is valid", args[0])
+		return nil
+	},
+}
+
+var exportVersionSetCmd = &cobra.Command{
+	Use:   "export <id>",
+	Short: "Export a version set as bundle",
+	Long:  "Write a version set with all of its entities and a content hash into a portable JSON bundle",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("output")
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+
+		defer cancel()
+		defer conn.Close()
+
+		rsp, err := client.ExportVersionSet(ctx, &grpc_scale.ExportVersionSetRequest{Id: args[0]})
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to export version set")
+		}
+
+		var bundle bytes.Buffer
+		if err := json.Indent(&bundle, rsp.GetBundle(), "", "  "); err != nil {
+			cli_logger.Fatal().Err(err).Msg("Invalid bundle")
+		}
+		bundle.WriteString("\n")
+
+		if file == "" {
+			os.Stdout.Write(bundle.Bytes())
+			return nil
+		}
+		if err := os.WriteFile(file, bundle.Bytes(), 0o644); err != nil {
+			cli_logger.Fatal().Err(err).Msgf("Failed to write %s", file)
+		}
+		cli_logger.Info().Msgf("Version set %s exported to %s", args[0], file)
+		return nil
+	},
+}
+
+var importVersionSetCmd = &cobra.Command{
+	Use:   "import <bundle.json>",
+	Short: "Import a version set bundle",
+	Long:  "Recreate an exported version set as a new draft version set",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundle, err := os.ReadFile(args[0])
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msgf("Failed to read %s", args[0])
+		}
+
+		request := &grpc_scale.ImportVersionSetRequest{Bundle: bundle}
+		if cmd.Flags().Changed("name") {
+			name, _ := cmd.Flags().GetString("name")
+			request.Name = &name
+		}
+
+		ctx, client, conn, cancel, err := getScaleClient()
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to get client")
+		}
+
+		defer cancel()
+		defer conn.Close()
+
+		rsp, err := client.ImportVersionSet(ctx, request)
+		if err != nil {
+			cli_logger.Fatal().Err(err).Msg("Failed to import version set")
+		}
+
+		cli_logger.Info().Msgf("Bundle %s imported as draft version set %s", rsp.GetContentHash(), rsp.GetId())
+		return nil
+	},
+}
+
 var listVersionSetsCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all version sets",
@@ -240,3 +437,33 @@ func PrintVersionSetsAsTable(versionSets []*grpc_southbound.VersionSet) {
 	}
 	w.Flush()
 }
+
+func PrintVersionSetDiffAsTable(nodes []*grpc_scale.NodeDiff) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SERIAL NUMBER\tKIND\tNAME\tCHANGE\tFIELD\tOLD\tNEW")
+
+	for _, node := range nodes {
+		for _, change := range node.GetChanges() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				node.SerialNumber,
+				change.Kind,
+				change.Name,
+				change.Change,
+				change.Field,
+				change.OldValue,
+				change.NewValue,
+			)
+		}
+	}
+	w.Flush()
+}
+
+func PrintFindingsAsTable(findings []*grpc_scale.Finding) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SEVERITY\tRULE\tKIND\tNAME\tMESSAGE")
+
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Severity, f.Rule, f.Kind, f.Name, f.Message)
+	}
+	w.Flush()
+}
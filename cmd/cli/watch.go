@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+)
+
+type nodeProgress struct {
+	SerialNumber string
+	State        string
+	Updated      string
+	Reason       string
+}
+
+// watchTransaction follows a transaction until it is completed. On a terminal
+// the state of every node is shown as a table which is redrawn on every
+// change, otherwise each event is printed on its own line.
+func watchTransaction(txID int32) error {
+	_, client, conn, cancel, err := getScaleClient()
+	if err != nil {
+		cli_logger.Fatal().Err(err).Msg("Failed to get client")
+	}
+	// the watch outlives the cli timeout, it ends with the transaction or on interrupt
+	cancel()
+	defer conn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stream, err := client.WatchTransaction(ctx, &grpc_scale.WatchTransactionRequest{TxId: txID})
+	if err != nil {
+		cli_logger.Fatal().Err(err).Msg("Failed to watch transaction")
+	}
+
+	stat, _ := os.Stdout.Stat()
+	redraw := stat != nil && stat.Mode()&os.ModeCharDevice != 0
+
+	var nodes []*nodeProgress
+	index := make(map[string]*nodeProgress)
+	var summary string
+	drawn := 0
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			cli_logger.Fatal().Err(err).Msg("Failed to watch transaction")
+		}
+
+		updated := event.Timestamp.AsTime().Local().Format(HeadscaleDateTimeFormat)
+		if event.SerialNumber != "" {
+			node, ok := index[event.SerialNumber]
+			if !ok {
+				node = &nodeProgress{SerialNumber: event.SerialNumber}
+				index[event.SerialNumber] = node
+				nodes = append(nodes, node)
+			}
+			node.State = event.State
+			node.Updated = updated
+			node.Reason = event.GetDescription()
+		} else {
+			summary = fmt.Sprintf("Transaction %d is %s", event.TxId, event.State)
+			if event.Description != nil {
+				summary += ": " + event.GetDescription()
+			}
+		}
+
+		if redraw {
+			drawn = drawProgress(nodes, summary, drawn)
+		} else if event.SerialNumber != "" {
+			line := fmt.Sprintf("%s %s %s", updated, event.SerialNumber, event.State)
+			if event.Description != nil {
+				line += ": " + event.GetDescription()
+			}
+			fmt.Println(line)
+		} else {
+			fmt.Printf("%s %s\n", updated, summary)
+		}
+
+		if event.Completed {
+			return nil
+		}
+	}
+}
+
+// drawProgress replaces the previous drawing of drawn lines with the current
+// state of the nodes and returns the number of lines drawn
+func drawProgress(nodes []*nodeProgress, summary string, drawn int) int {
+	if drawn > 0 {
+		fmt.Printf("\033[%dA\033[J", drawn)
+	}
+
+	columns := []TableColumn{
+		{Header: "SERIAL NUMBER", FieldPath: "SerialNumber"},
+		{Header: "STATE", FieldPath: "State"},
+		{Header: "UPDATED", FieldPath: "Updated"},
+		{Header: "REASON", FieldPath: "Reason"},
+	}
+	PrintAsTable(nodes, columns)
+	lines := len(nodes) + 1
+
+	if summary != "" {
+		fmt.Println(summary)
+		lines++
+	}
+	return lines
+}
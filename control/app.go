@@ -15,6 +15,7 @@ import (
 	"github.com/philslol/kritis3m_scalev2/control/db"
 	"github.com/philslol/kritis3m_scalev2/control/service/southbound"
 	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -43,6 +44,12 @@ func NewKritis3m_scale(cfg *types.Config) (*Kritis3m_Scale, error) {
 
 func (scale *Kritis3m_Scale) Serve() {
 	log.Info().Msgf("Entrypoint function serve")
+	if err := scale.cfg.CliConfig.CheckUsers(); err != nil {
+		log.Fatal().Err(err).Msg("The cli can not authenticate")
+	}
+	if err := scale.cfg.CliConfig.CheckListener(); err != nil {
+		log.Fatal().Err(err).Msg("The grpc server has no TLS")
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -69,15 +76,19 @@ func (scale *Kritis3m_Scale) Serve() {
 		defer estServer.Shutdown()
 	}
 
-	database, err := db.NewStateManager(ctx, scale.cfg.Log)
+	database, err := db.NewStateManager(ctx, scale.cfg.Database)
 	if err != nil {
-		log.Err(err).Msg("")
+		log.Fatal().Err(err).Msg("Failed to open the database")
 	}
 
 	broker := controlplane.NewBroker(scale.cfg.Broker)
 	if broker == nil {
 		log.Err(err).Msg("Broker is nil")
 	}
+	presence := southbound.NewPresenceQueue()
+	if err := broker.ReportPresence(presence); err != nil {
+		log.Err(err).Msg("Node presence is not tracked")
+	}
 	broker_ctx, broker_cancel := context.WithCancel(context.Background())
 	defer broker_cancel()
 
@@ -91,14 +102,22 @@ func (scale *Kritis3m_Scale) Serve() {
 	if control_plane == nil {
 		log.Err(err).Msg("Control Plane is nil")
 	}
+	control_plane.AttachBroker(broker)
 
+	database.SetOnlineWindow(scale.cfg.Presence.OnlineWindow)
 	sb := southbound.NewSouthbound(database, scale.cfg.CliConfig.ServerAddr)
+	sb.SetOfflineQueue(scale.cfg.OfflineQueue)
+	sb.SetUsers(scale.cfg.CliConfig.Users)
+	go sb.RunPresence(ctx, presence)
 	lis, err := net.Listen("tcp", scale.cfg.CliConfig.ServerAddr)
 	if err != nil {
 		log.Err(err).Msg("")
 	}
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(sb.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(sb.StreamInterceptor()),
+	)
 	if err != nil {
 		log.Err(err).Msg("")
 	}
@@ -106,6 +125,9 @@ func (scale *Kritis3m_Scale) Serve() {
 	grpc_southbound.RegisterSouthboundServer(s, sb)
 	grpc_est.RegisterEstServiceServer(s, sb)
 	grpc_control_plane.RegisterControlPlaneServer(s, control_plane)
+	grpc_scale.RegisterScaleServer(s, sb)
+	grpc_scale.RegisterControlPlaneRecoveryServer(s, control_plane)
+	grpc_scale.RegisterControlPlaneInventoryServer(s, control_plane)
 
 	go func() {
 		log.Info().Msgf("Server listening at %v", lis.Addr())
@@ -115,7 +137,20 @@ func (scale *Kritis3m_Scale) Serve() {
 		}
 	}()
 
+	// finish what a previous run left open, this needs the control plane served above.
+	// Scheduled activations, the reconciler and the expiry of queued updates
+	// only start once the recovered transactions are settled.
+	go func() {
+		if err := sb.RecoverTransactions(ctx); err != nil {
+			log.Err(err).Msg("Transaction recovery failed")
+		}
+		go sb.RunReconciler(ctx, scale.cfg.Reconciler)
+		go sb.RunOfflineQueue(ctx)
+		sb.RunScheduler(ctx)
+	}()
+
 	hello_service := southbound.NewHelloService(database, scale.cfg.CliConfig.ServerAddr, scale.cfg.Log)
+	hello_service.OnHello(sb.DeliverQueuedUpdate)
 	go func() {
 		err := hello_service.Hello(ctx)
 		if err != nil {
@@ -156,7 +191,7 @@ func (scale *Kritis3m_Scale) Serve() {
 func (scale *Kritis3m_Scale) GetRawDB(timeout time.Duration) (*db.StateManager, context.Context, context.CancelFunc, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 
-	database, err := db.NewStateManager(ctx, scale.cfg.Log)
+	database, err := db.NewStateManager(ctx, scale.cfg.Database)
 	if err != nil {
 		log.Err(err).Msg("Failed to get raw database")
 		return nil, ctx, cancel, err
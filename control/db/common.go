@@ -6,26 +6,42 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/gofrs/uuid/v5"
 	"github.com/rs/zerolog"
 )
 
 var log zerolog.Logger
 
-func (s *StateManager) UpdateWhere(ctx context.Context, table string, updates map[string]any, where string) error {
-	fields := []string{}
-	values := []any{}
-	paramCount := 1
+// UpdateWhere sets updates on the rows of table matching where. The $n
+// placeholders of where are bound to args.
+func (s *StateManager) UpdateWhere(ctx context.Context, table string, updates map[string]any, where string, args ...any) error {
+	query, values := updateWhereQuery(table, updates, where, args)
 
-	for field, value := range updates {
-		fields = append(fields, field+" = $"+strconv.Itoa(paramCount))
-		values = append(values, value)
-		paramCount++
-	}
+	return s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		_, err := tx.Exec(ctx, query, values...)
+		if err != nil {
+			return fmt.Errorf("failed to execute update: %w", err)
+		}
+		return nil
+	})
+}
 
-	query := "UPDATE " + table + " SET " + strings.Join(fields, ", ") + " WHERE " + where
+// UpdateDraftWhere is UpdateWhere for the entities of drafts. The version sets
+// holding the rows matching where and the version sets moveTo, which rows are
+// moved to, are checked and locked by the transaction of the update.
+// ErrNotDraft is returned unless all of them are drafts.
+func (s *StateManager) UpdateDraftWhere(ctx context.Context, table string, updates map[string]any, moveTo []uuid.UUID, where string, args ...any) error {
+	query, values := updateWhereQuery(table, updates, where, args)
 
-	return s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	return s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if err := lockDraftsWhere(ctx, tx, table, where, args...); err != nil {
+			return err
+		}
+		for _, id := range moveTo {
+			if err := lockDraft(ctx, tx, id); err != nil {
+				return err
+			}
+		}
 		_, err := tx.Exec(ctx, query, values...)
 		if err != nil {
 			return fmt.Errorf("failed to execute update: %w", err)
@@ -34,6 +50,22 @@ func (s *StateManager) UpdateWhere(ctx context.Context, table string, updates ma
 	})
 }
 
+// updateWhereQuery numbers the placeholders of updates after args, which
+// where binds as $1 to $len(args).
+func updateWhereQuery(table string, updates map[string]any, where string, args []any) (string, []any) {
+	fields := []string{}
+	values := append([]any{}, args...)
+	paramCount := len(args) + 1
+
+	for field, value := range updates {
+		fields = append(fields, field+" = $"+strconv.Itoa(paramCount))
+		values = append(values, value)
+		paramCount++
+	}
+
+	return "UPDATE " + table + " SET " + strings.Join(fields, ", ") + " WHERE " + where, values
+}
+
 func (s *StateManager) Update(ctx context.Context, table string, updates map[string]any, where_key string, where_value any) error {
 	fields := []string{}
 	values := []any{}
@@ -48,7 +80,7 @@ func (s *StateManager) Update(ctx context.Context, table string, updates map[str
 
 	query := "UPDATE " + table + " SET " + strings.Join(fields, ", ") + " WHERE " + where_key + " = $" + strconv.Itoa(paramCount)
 
-	return s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	return s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		_, err := tx.Exec(ctx, query, values...)
 		if err != nil {
 			return fmt.Errorf("failed to execute update: %w", err)
@@ -61,7 +93,7 @@ func (s *StateManager) Delete(ctx context.Context, table string, where_key strin
 	query := "DELETE FROM " + table + " WHERE " + where_key + " = $1"
 	where_values := []any{where_value}
 
-	return s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	return s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		_, err := tx.Exec(ctx, query, where_values...)
 		if err != nil {
 			return fmt.Errorf("failed to execute delete: %w", err)
@@ -69,3 +101,21 @@ func (s *StateManager) Delete(ctx context.Context, table string, where_key strin
 		return nil
 	})
 }
+
+// DeleteDraftWhere deletes the rows of table matching where, see
+// UpdateDraftWhere. ErrNotDraft is returned if one of them belongs to a
+// version set which is not a draft.
+func (s *StateManager) DeleteDraftWhere(ctx context.Context, table string, where string, args ...any) error {
+	query := "DELETE FROM " + table + " WHERE " + where
+
+	return s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if err := lockDraftsWhere(ctx, tx, table, where, args...); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to execute delete: %w", err)
+		}
+		return nil
+	})
+}
@@ -3,22 +3,18 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
 
 	grpc_control_plane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
 	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
 
 	// v1 "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/gen/go/v1"
-	"github.com/jackc/pgx/v5"
 	"github.com/philslol/kritis3m_scalev2/control/types"
 )
 
-func (s *StateManager) NodeUpdate(SerialNumber string, VersionSet string, ctx context.Context) (*grpc_control_plane.NodeUpdateItem, error) {
-	node := &grpc_control_plane.NodeUpdateItem{
-		SerialNumber: SerialNumber,
-		VersionSetId: VersionSet,
-	}
-	groupMap := make(map[string]*grpc_control_plane.GroupProxyUpdate)
-	query := `
+// nodeUpdateStatement selects the groups, endpoint configs and proxies of node $1 in version set $2
+var nodeUpdateStatement = map[string]string{
+	types.DatabasePostgres: `
 	WITH node_info AS (
 	    SELECT
 	        serial_number,
@@ -84,9 +80,103 @@ func (s *StateManager) NodeUpdate(SerialNumber string, VersionSet string, ctx co
 	    endpoint_configs ec2 ON g.legacy_config_name = ec2.name AND g.version_set_id = ec2.version_set_id
 	LEFT JOIN
 	    proxies p ON g.name = p.group_name AND g.version_set_id = p.version_set_id AND p.node_serial = $1;
-	`
+	`,
+	types.DatabaseSqlite: `
+	WITH node_info AS (
+	    SELECT
+	        serial_number,
+	        network_index,
+	        locality,
+	        CAST(version_set_id AS TEXT) AS version_set_id
+	    FROM
+	        nodes
+	    WHERE
+	        serial_number = $1
+	        AND version_set_id = $2
+	),
+	node_groups AS (
+	    SELECT DISTINCT
+	        p.group_name
+	    FROM
+	        proxies p
+	    WHERE
+	        p.node_serial = $1
+	        AND p.version_set_id = $2
+	)
+	SELECT
+	    -- Node information
+	    n.serial_number,
+	    n.network_index,
+	    n.locality,
+	    n.version_set_id,
+
+	    -- Group information
+	    g.name AS group_name,
+	    g.log_level AS group_log_level,
+
+	    -- Endpoint Config
+	    ec1.name AS endpoint_config_name,
+	    ec1.mutual_auth AS endpoint_mutual_auth,
+	    ec1.no_encryption AS endpoint_no_encryption,
+	    ec1.asl_key_exchange_method AS endpoint_kex_method,
+	    ec1.cipher AS endpoint_cipher,
+
+	    -- Legacy Config
+	    ec2.name AS legacy_config_name,
+	    ec2.mutual_auth AS legacy_mutual_auth,
+	    ec2.no_encryption AS legacy_no_encryption,
+	    ec2.asl_key_exchange_method AS legacy_kex_method,
+	    ec2.cipher AS legacy_cipher,
+
+	    -- Proxy information
+	    p.id AS proxy_id,
+	    p.name AS proxy_name,
+	    p.state AS proxy_state,
+	    p.proxy_type,
+	    p.server_endpoint_addr,
+	    p.client_endpoint_addr
+	FROM
+	    node_info n
+	JOIN
+	    node_groups ng ON true
+	JOIN
+	    groups g ON ng.group_name = g.name AND g.version_set_id = $2
+	LEFT JOIN
+	    endpoint_configs ec1 ON g.endpoint_config_name = ec1.name AND g.version_set_id = ec1.version_set_id
+	LEFT JOIN
+	    endpoint_configs ec2 ON g.legacy_config_name = ec2.name AND g.version_set_id = ec2.version_set_id
+	LEFT JOIN
+	    proxies p ON g.name = p.group_name AND g.version_set_id = p.version_set_id AND p.node_serial = $1;
+	`,
+}
+
+// nodeHwConfigStatement selects the hardware configs of node $1 in version set $2
+var nodeHwConfigStatement = map[string]string{
+	types.DatabasePostgres: `
+		SELECT
+			id,
+			device,
+			ip_cidr::text
+		FROM hardware_configs
+		WHERE node_serial = $1 AND version_set_id = $2::uuid`,
+	types.DatabaseSqlite: `
+		SELECT
+			id,
+			device,
+			CAST(ip_cidr AS TEXT)
+		FROM hardware_configs
+		WHERE node_serial = $1 AND version_set_id = $2`,
+}
 
-	s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+func (s *StateManager) NodeUpdate(SerialNumber string, VersionSet string, ctx context.Context) (*grpc_control_plane.NodeUpdateItem, error) {
+	node := &grpc_control_plane.NodeUpdateItem{
+		SerialNumber: SerialNumber,
+		VersionSetId: VersionSet,
+	}
+	groupMap := make(map[string]*grpc_control_plane.GroupProxyUpdate)
+	query := nodeUpdateStatement[s.Dialect()]
+
+	s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		rows, err := tx.Query(ctx, query, SerialNumber, VersionSet)
 		if err != nil {
 			return err
@@ -154,13 +244,7 @@ func (s *StateManager) NodeUpdate(SerialNumber string, VersionSet string, ctx co
 			})
 		}
 
-		hw_config_query := `
-		SELECT
-			id,
-			device,
-			ip_cidr::text
-		FROM hardware_configs
-		WHERE node_serial = $1 AND version_set_id = $2::uuid`
+		hw_config_query := nodeHwConfigStatement[s.Dialect()]
 
 		rows, err = tx.Query(ctx, hw_config_query, SerialNumber, VersionSet)
 		if err != nil {
@@ -197,19 +281,29 @@ func (s *StateManager) NodeUpdate(SerialNumber string, VersionSet string, ctx co
 	return node, nil
 }
 
+// versionFleetNodesStatement selects the nodes of version set $1
+var versionFleetNodesStatement = map[string]string{
+	types.DatabasePostgres: `
+		SELECT DISTINCT serial_number 
+		FROM nodes 
+		WHERE version_set_id = $1::uuid
+		`,
+	types.DatabaseSqlite: `
+		SELECT DISTINCT serial_number 
+		FROM nodes 
+		WHERE version_set_id = $1
+		`,
+}
+
 // GetVersionFleetUpdate retrieves all nodes for a specific version set
 /* MUST BE TESTED */
 func (s *StateManager) GetVersionFleetUpdate(ctx context.Context, versionSetId string) (*grpc_control_plane.FleetUpdate, error) {
 	var nodes []*grpc_control_plane.NodeUpdateItem
 
 	// Get all nodes for this version set
-	query := `
-		SELECT DISTINCT serial_number 
-		FROM nodes 
-		WHERE version_set_id = $1::uuid
-		`
+	query := versionFleetNodesStatement[s.Dialect()]
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		rows, err := tx.Query(ctx, query, versionSetId)
 		if err != nil {
 			return fmt.Errorf("failed to query nodes: %w", err)
@@ -247,21 +341,31 @@ func (s *StateManager) GetVersionFleetUpdate(ctx context.Context, versionSetId s
 	}, nil
 }
 
+// groupFleetNodesStatement selects the nodes with a proxy in group $1 of version set $2
+var groupFleetNodesStatement = map[string]string{
+	types.DatabasePostgres: `
+		SELECT DISTINCT p.node_serial
+		FROM proxies p
+		JOIN groups g ON p.group_name = g.name AND p.version_set_id = g.version_set_id
+		WHERE p.group_name = $1 
+		AND p.version_set_id = $2::uuid`,
+	types.DatabaseSqlite: `
+		SELECT DISTINCT p.node_serial
+		FROM proxies p
+		JOIN groups g ON p.group_name = g.name AND p.version_set_id = g.version_set_id
+		WHERE p.group_name = $1 
+		AND p.version_set_id = $2`,
+}
+
 // GetGroupFleetUpdate retrieves all nodes for a specific group in a version set
 /* MUST BE TESTED */
 func (s *StateManager) GetGroupFleetUpdate(ctx context.Context, groupName string, versionSetId string) (*grpc_control_plane.FleetUpdate, error) {
 	var nodes []*grpc_control_plane.NodeUpdateItem
 
 	// Get all nodes in this group
-	query := `
-		SELECT DISTINCT p.node_serial
-		FROM proxies p
-		JOIN groups g ON p.group_name = g.name AND p.version_set_id = g.version_set_id
-		WHERE p.group_name = $1 
-		AND p.version_set_id = $2::uuid
-		AND p.disabled_at IS NULL`
+	query := groupFleetNodesStatement[s.Dialect()]
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		rows, err := tx.Query(ctx, query, groupName, versionSetId)
 		if err != nil {
 			return fmt.Errorf("failed to query nodes: %w", err)
@@ -299,20 +403,10 @@ func (s *StateManager) GetGroupFleetUpdate(ctx context.Context, groupName string
 	}, nil
 }
 
-// GetFleetUpdateOptimized retrieves all nodes and their configurations in a single query
-// If groupName is empty, it performs a version update, otherwise a group update
-/* MUST BE TESTED */
-func (s *StateManager) GetFleetUpdateOptimized(ctx context.Context, versionSetId string, groupName string) (*grpc_control_plane.FleetUpdate, error) {
-	var query string
-	var args []any
-
-	query = `
-		WITH target_nodes AS (
-			SELECT DISTINCT serial_number
-			FROM nodes
-			WHERE version_set_id = $1::uuid
-			AND now()- last_seen < interval '2 minute'
-		)
+// nodeConfigColumns selects a node with its groups, endpoint configs, proxies
+// and hardware configs. It expects the nodes as n and nodeConfigJoins.
+var nodeConfigColumns = map[string]string{
+	types.DatabasePostgres: `
 		SELECT
 			-- Node information
 			n.serial_number,
@@ -338,7 +432,42 @@ func (s *StateManager) GetFleetUpdateOptimized(ctx context.Context, versionSetId
 			ec2.asl_key_exchange_method AS legacy_kex_method,
 			ec2.cipher AS legacy_cipher,
 
+			-- Proxy information
+			p.name AS proxy_name,
+			p.state AS proxy_state,
+			p.proxy_type,
+			p.server_endpoint_addr,
+			p.client_endpoint_addr,
+
+			-- Hardware Configurations
+			hc.id::int AS hwconfig_id,
+			hc.device AS hwconfig_device,
+			hc.ip_cidr::text AS hwconfig_ip_cidr`,
+	types.DatabaseSqlite: `
+		SELECT
+			-- Node information
+			n.serial_number,
+			n.network_index,
+			n.locality,
+			CAST(n.version_set_id AS TEXT),
+
+			-- Group information
+			g.name AS group_name,
+			g.log_level AS group_log_level,
+
+			-- Endpoint Config
+			ec1.name AS endpoint_config_name,
+			ec1.mutual_auth AS endpoint_mutual_auth,
+			ec1.no_encryption AS endpoint_no_encryption,
+			ec1.asl_key_exchange_method AS endpoint_kex_method,
+			ec1.cipher AS endpoint_cipher,
 
+			-- Legacy Config
+			ec2.name AS legacy_config_name,
+			ec2.mutual_auth AS legacy_mutual_auth,
+			ec2.no_encryption AS legacy_no_encryption,
+			ec2.asl_key_exchange_method AS legacy_kex_method,
+			ec2.cipher AS legacy_cipher,
 
 			-- Proxy information
 			p.name AS proxy_name,
@@ -348,24 +477,72 @@ func (s *StateManager) GetFleetUpdateOptimized(ctx context.Context, versionSetId
 			p.client_endpoint_addr,
 
 			-- Hardware Configurations
-			hc.id::int AS hwconfig_id,
+			hc.id AS hwconfig_id,
 			hc.device AS hwconfig_device,
-			hc.ip_cidr::text AS hwconfig_ip_cidr
-		FROM target_nodes tn
-		JOIN nodes n ON n.serial_number = tn.serial_number
+			CAST(hc.ip_cidr AS TEXT) AS hwconfig_ip_cidr`,
+}
+
+// nodeConfigJoins joins the configuration of the nodes n within their version set
+var nodeConfigJoins = map[string]string{
+	types.DatabasePostgres: `
 		LEFT JOIN hardware_configs hc ON n.serial_number = hc.node_serial AND n.version_set_id::uuid = hc.version_set_id
 		LEFT JOIN proxies p ON p.node_serial = n.serial_number AND p.version_set_id = n.version_set_id
 		LEFT JOIN groups g ON p.group_name = g.name AND g.version_set_id = n.version_set_id
 		LEFT JOIN endpoint_configs ec1 ON g.endpoint_config_name = ec1.name AND g.version_set_id = ec1.version_set_id
-		LEFT JOIN endpoint_configs ec2 ON g.legacy_config_name = ec2.name AND g.version_set_id = ec2.version_set_id
+		LEFT JOIN endpoint_configs ec2 ON g.legacy_config_name = ec2.name AND g.version_set_id = ec2.version_set_id`,
+	types.DatabaseSqlite: `
+		LEFT JOIN hardware_configs hc ON n.serial_number = hc.node_serial AND n.version_set_id = hc.version_set_id
+		LEFT JOIN proxies p ON p.node_serial = n.serial_number AND p.version_set_id = n.version_set_id
+		LEFT JOIN groups g ON p.group_name = g.name AND g.version_set_id = n.version_set_id
+		LEFT JOIN endpoint_configs ec1 ON g.endpoint_config_name = ec1.name AND g.version_set_id = ec1.version_set_id
+		LEFT JOIN endpoint_configs ec2 ON g.legacy_config_name = ec2.name AND g.version_set_id = ec2.version_set_id`,
+}
+
+// fleetUpdateStatement selects the configuration of the nodes of version set $1
+// which were online since $2
+var fleetUpdateStatement = map[string]string{
+	types.DatabasePostgres: `
+		WITH target_nodes AS (
+			SELECT DISTINCT serial_number
+			FROM nodes
+			WHERE version_set_id = $1::uuid
+			AND ` + onlineCondition("serial_number", "$2") + `
+		)
+		` + nodeConfigColumns[types.DatabasePostgres] + `
+		FROM target_nodes tn
+		JOIN nodes n ON n.serial_number = tn.serial_number
+		` + nodeConfigJoins[types.DatabasePostgres] + `
 		WHERE n.version_set_id = $1::uuid
-		ORDER BY n.serial_number, g.name, p.name`
+		ORDER BY n.serial_number, g.name, p.name`,
+	types.DatabaseSqlite: `
+		WITH target_nodes AS (
+			SELECT DISTINCT serial_number
+			FROM nodes
+			WHERE version_set_id = $1
+			AND ` + onlineCondition("serial_number", "$2") + `
+		)
+		` + nodeConfigColumns[types.DatabaseSqlite] + `
+		FROM target_nodes tn
+		JOIN nodes n ON n.serial_number = tn.serial_number
+		` + nodeConfigJoins[types.DatabaseSqlite] + `
+		WHERE n.version_set_id = $1
+		ORDER BY n.serial_number, g.name, p.name`,
+}
 
-	args = []any{versionSetId}
+// GetFleetUpdateOptimized retrieves all nodes and their configurations in a single query
+// If groupName is empty, it performs a version update, otherwise a group update
+/* MUST BE TESTED */
+func (s *StateManager) GetFleetUpdateOptimized(ctx context.Context, versionSetId string, groupName string) (*grpc_control_plane.FleetUpdate, error) {
+	var query string
+	var args []any
+
+	query = fleetUpdateStatement[s.Dialect()]
+
+	args = []any{versionSetId, time.Now().Add(-s.onlineWindow)}
 	nodeMap := make(map[string]*grpc_control_plane.NodeUpdateItem)
 	var nodes []*grpc_control_plane.NodeUpdateItem
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		rows, err := tx.Query(ctx, query, args...)
 		if err != nil {
 			return fmt.Errorf("failed to query nodes: %w", err)
@@ -504,17 +681,17 @@ func (s *StateManager) GetFleetUpdateOptimized(ctx context.Context, versionSetId
 	}, nil
 }
 
-func (s *StateManager) GetGroupUpdateOptimized(ctx context.Context, versionSetId string, groupName string) (*grpc_control_plane.FleetUpdate, error) {
-	var query string
-	var args []any
-
-	query = `
+// groupUpdateStatement selects the configuration of the nodes in group $1 of
+// version set $2 which were online since $3
+var groupUpdateStatement = map[string]string{
+	types.DatabasePostgres: `
 		WITH target_nodes AS (
 			SELECT DISTINCT p.node_serial
 			FROM proxies p
 			WHERE p.group_name = $1 
 			AND p.version_set_id = $2::uuid
-			AND now()- last_seen < interval '2 minute'
+			AND EXISTS (SELECT 1 FROM nodes n WHERE n.serial_number = p.node_serial AND n.version_set_id = p.version_set_id
+				AND ` + onlineCondition("n.serial_number", "$3") + `)
 		)
 		SELECT
 			-- Node information
@@ -550,13 +727,64 @@ func (s *StateManager) GetGroupUpdateOptimized(ctx context.Context, versionSetId
 		LEFT JOIN endpoint_configs ec1 ON g.endpoint_config_name = ec1.name AND g.version_set_id = ec1.version_set_id
 		LEFT JOIN endpoint_configs ec2 ON g.legacy_config_name = ec2.name AND g.version_set_id = ec2.version_set_id
 		WHERE n.version_set_id = $2::uuid
-		ORDER BY n.serial_number, g.name, p.name`
-	args = []any{groupName, versionSetId}
+		ORDER BY n.serial_number, g.name, p.name`,
+	types.DatabaseSqlite: `
+		WITH target_nodes AS (
+			SELECT DISTINCT p.node_serial
+			FROM proxies p
+			WHERE p.group_name = $1 
+			AND p.version_set_id = $2
+			AND EXISTS (SELECT 1 FROM nodes n WHERE n.serial_number = p.node_serial AND n.version_set_id = p.version_set_id
+				AND ` + onlineCondition("n.serial_number", "$3") + `)
+		)
+		SELECT
+			-- Node information
+			n.serial_number,
+			n.network_index,
+			n.locality,
+			CAST(n.version_set_id AS TEXT),
+			-- Group information
+			g.name AS group_name,
+			g.log_level AS group_log_level,
+			-- Endpoint Config
+			ec1.name AS endpoint_config_name,
+			ec1.mutual_auth AS endpoint_mutual_auth,
+			ec1.no_encryption AS endpoint_no_encryption,
+			ec1.asl_key_exchange_method AS endpoint_kex_method,
+			ec1.cipher AS endpoint_cipher,
+			-- Legacy Config
+			ec2.name AS legacy_config_name,
+			ec2.mutual_auth AS legacy_mutual_auth,
+			ec2.no_encryption AS legacy_no_encryption,
+			ec2.asl_key_exchange_method AS legacy_kex_method,
+			ec2.cipher AS legacy_cipher,
+			-- Proxy information
+			p.name AS proxy_name,
+			p.state AS proxy_state,
+			p.proxy_type,
+			p.server_endpoint_addr,
+			p.client_endpoint_addr
+		FROM target_nodes tn
+		JOIN nodes n ON n.serial_number = tn.node_serial
+		LEFT JOIN proxies p ON p.node_serial = n.serial_number AND p.version_set_id = n.version_set_id
+		LEFT JOIN groups g ON p.group_name = g.name AND g.version_set_id = n.version_set_id
+		LEFT JOIN endpoint_configs ec1 ON g.endpoint_config_name = ec1.name AND g.version_set_id = ec1.version_set_id
+		LEFT JOIN endpoint_configs ec2 ON g.legacy_config_name = ec2.name AND g.version_set_id = ec2.version_set_id
+		WHERE n.version_set_id = $2
+		ORDER BY n.serial_number, g.name, p.name`,
+}
+
+func (s *StateManager) GetGroupUpdateOptimized(ctx context.Context, versionSetId string, groupName string) (*grpc_control_plane.FleetUpdate, error) {
+	var query string
+	var args []any
+
+	query = groupUpdateStatement[s.Dialect()]
+	args = []any{groupName, versionSetId, time.Now().Add(-s.onlineWindow)}
 
 	nodeMap := make(map[string]*grpc_control_plane.NodeUpdateItem)
 	var nodes []*grpc_control_plane.NodeUpdateItem
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		rows, err := tx.Query(ctx, query, args...)
 		if err != nil {
 			return fmt.Errorf("failed to query nodes: %w", err)
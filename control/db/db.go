@@ -1,31 +1,48 @@
 package db
 
+// schemaSQL is the baseline schema. It is applied as migration 1 and is written
+// to be idempotent so that databases bootstrapped before the migration
+// subsystem existed can be adopted without a reset.
 const schemaSQL = `
- CREATE TYPE proxy_type AS ENUM ('not_specifed','forward', 'reverse', 'tlstls');
- CREATE TYPE version_state AS ENUM ('draft', 'pending_deployment', 'active', 'disabled');
- CREATE TYPE version_transition_status AS ENUM ('pending', 'active', 'failed', 'rollback', 'disabled');
- CREATE TYPE transaction_type AS ENUM ('node_update', 'group_update', 'version_update');
- CREATE TYPE transaction_state AS ENUM ('error', 'unknown', 'published', 'received', 'applicable', 'applied');
- CREATE TYPE operation_type AS ENUM ('INSERT', 'UPDATE', 'DELETE', 'ADD');
- CREATE TYPE asl_key_exchange_method AS ENUM (
-     'ASL_KEX_DEFAULT',
-     'ASL_KEX_CLASSIC_SECP256',
-     'ASL_KEX_CLASSIC_SECP384',
-     'ASL_KEX_CLASSIC_SECP521',
-     'ASL_KEX_CLASSIC_X25519',
-     'ASL_KEX_CLASSIC_X448',
-    'ASL_KEX_PQC_MLKEM512',
-     'ASL_KEX_PQC_MLKEM768',
-     'ASL_KEX_PQC_MLKEM1024',
-     'ASL_KEX_HYBRID_SECP256_MLKEM512',
-     'ASL_KEX_HYBRID_SECP384_MLKEM768',
-     'ASL_KEX_HYBRID_SECP256_MLKEM768',
-     'ASL_KEX_HYBRID_SECP521_MLKEM1024',
-     'ASL_KEX_HYBRID_SECP384_MLKEM1024',
-     'ASL_KEX_HYBRID_X25519_MLKEM512',
-     'ASL_KEX_HYBRID_X448_MLKEM768',
-     'ASL_KEX_HYBRID_X25519_MLKEM768'
- );
+DO $$ BEGIN
+    CREATE TYPE proxy_type AS ENUM ('not_specifed','forward', 'reverse', 'tlstls');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+DO $$ BEGIN
+    CREATE TYPE version_state AS ENUM ('draft', 'pending_deployment', 'active', 'disabled');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+DO $$ BEGIN
+    CREATE TYPE version_transition_status AS ENUM ('pending', 'active', 'failed', 'rollback', 'disabled');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+DO $$ BEGIN
+    CREATE TYPE transaction_type AS ENUM ('node_update', 'group_update', 'version_update');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+DO $$ BEGIN
+    CREATE TYPE transaction_state AS ENUM ('error', 'unknown', 'published', 'received', 'applicable', 'applied');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+DO $$ BEGIN
+    CREATE TYPE operation_type AS ENUM ('INSERT', 'UPDATE', 'DELETE', 'ADD');
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
+DO $$ BEGIN
+    CREATE TYPE asl_key_exchange_method AS ENUM (
+        'ASL_KEX_DEFAULT',
+        'ASL_KEX_CLASSIC_SECP256',
+        'ASL_KEX_CLASSIC_SECP384',
+        'ASL_KEX_CLASSIC_SECP521',
+        'ASL_KEX_CLASSIC_X25519',
+        'ASL_KEX_CLASSIC_X448',
+        'ASL_KEX_PQC_MLKEM512',
+        'ASL_KEX_PQC_MLKEM768',
+        'ASL_KEX_PQC_MLKEM1024',
+        'ASL_KEX_HYBRID_SECP256_MLKEM512',
+        'ASL_KEX_HYBRID_SECP384_MLKEM768',
+        'ASL_KEX_HYBRID_SECP256_MLKEM768',
+        'ASL_KEX_HYBRID_SECP521_MLKEM1024',
+        'ASL_KEX_HYBRID_SECP384_MLKEM1024',
+        'ASL_KEX_HYBRID_X25519_MLKEM512',
+        'ASL_KEX_HYBRID_X448_MLKEM768',
+        'ASL_KEX_HYBRID_X25519_MLKEM768'
+    );
+EXCEPTION WHEN duplicate_object THEN NULL; END $$;
 CREATE TABLE IF NOT EXISTS transactions (
                                             id SERIAL PRIMARY KEY,
                                             type transaction_type NOT NULL,
@@ -183,3 +200,36 @@ CREATE INDEX IF NOT EXISTS idx_hwconfig_version ON hardware_configs(version_set_
 CREATE INDEX IF NOT EXISTS idx_groups_version ON groups(version_set_id);
 CREATE INDEX IF NOT EXISTS idx_endpoint_version ON endpoint_configs(version_set_id);
 `
+
+// dropSQL removes every object created by the schema. It is used by
+// ResetDatabase and as the down step of the baseline migration.
+const dropSQL = `
+    drop trigger if exists trigger_update_node_disabled_status on nodes;
+	drop function if exists trg_update_node_disabled_status();
+	drop table if exists change_log cascade;
+	drop table if exists version_sets cascade;
+	drop table if exists version_transitions cascade;
+	drop type if exists version_state cascade;
+	drop type if exists version_transition_status cascade;
+	drop type if exists transaction_type cascade;
+	drop type if exists transaction_state cascade;
+	drop table if exists hardware_configs cascade;
+	drop table if exists proxies cascade;
+	drop table if exists endpoint_configs cascade;
+	drop table if exists groups cascade;
+	drop table if exists nodes cascade;
+	drop table if exists enroll cascade;
+	drop table if exists transactions cascade;
+	drop function if exists handle_transaction_rollback() cascade;
+	drop table if exists transaction_log cascade;
+	drop function if exists ensure_single_pending_transaction() cascade;
+	drop function if exists create_new_pending_transaction() cascade;
+	drop function if exists log_changes() cascade;
+	drop function if exists process_rollback() cascade;
+	drop function if exists complete_transaction() cascade;
+	drop function if exists rollback_transaction() cascade;
+	drop type if exists transaction_status cascade;
+	drop type if exists proxy_type cascade;
+	drop type if exists asl_key_exchange_method cascade;
+	drop type if exists operation_type cascade;
+	`
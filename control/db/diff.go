@@ -0,0 +1,328 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// nodeSnapshot is the configuration a node receives from a version set
+type nodeSnapshot struct {
+	networkIndex int32
+	locality     string
+	groups       map[string]*groupSnapshot
+	proxies      map[string]*proxySnapshot
+	// device -> set of ip_cidr
+	hwConfigs map[string]map[string]bool
+}
+
+type groupSnapshot struct {
+	logLevel int32
+	endpoint *types.EndpointConfig
+	legacy   *types.EndpointConfig
+}
+
+type proxySnapshot struct {
+	group     string
+	state     bool
+	proxyType string
+	server    string
+	client    string
+}
+
+// DiffVersionSets compares the configuration every node receives from version
+// set a with the one it receives from version set b. Only nodes with changes
+// are returned, ordered by serial number.
+func (s *StateManager) DiffVersionSets(ctx context.Context, a, b uuid.UUID) ([]*types.NodeDiff, error) {
+	from, err := s.loadNodeSnapshots(ctx, a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load version set %s: %w", a, err)
+	}
+	to, err := s.loadNodeSnapshots(ctx, b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load version set %s: %w", b, err)
+	}
+
+	serials := make(map[string]bool)
+	for serial := range from {
+		serials[serial] = true
+	}
+	for serial := range to {
+		serials[serial] = true
+	}
+
+	var diffs []*types.NodeDiff
+	for _, serial := range sortedKeys(serials) {
+		if changes := diffNode(serial, from[serial], to[serial]); len(changes) > 0 {
+			diffs = append(diffs, &types.NodeDiff{SerialNumber: serial, Changes: changes})
+		}
+	}
+	return diffs, nil
+}
+
+// nodeSnapshotsStatement selects the configuration of the nodes of version set $1
+var nodeSnapshotsStatement = map[string]string{
+	types.DatabasePostgres: nodeConfigColumns[types.DatabasePostgres] + `
+		FROM nodes n
+		` + nodeConfigJoins[types.DatabasePostgres] + `
+		WHERE n.version_set_id = $1::uuid
+		ORDER BY n.serial_number, g.name, p.name`,
+	types.DatabaseSqlite: nodeConfigColumns[types.DatabaseSqlite] + `
+		FROM nodes n
+		` + nodeConfigJoins[types.DatabaseSqlite] + `
+		WHERE n.version_set_id = $1
+		ORDER BY n.serial_number, g.name, p.name`,
+}
+
+// loadNodeSnapshots reads the nodes of a version set with the joins used for fleet updates
+func (s *StateManager) loadNodeSnapshots(ctx context.Context, versionSetID uuid.UUID) (map[string]*nodeSnapshot, error) {
+	query := nodeSnapshotsStatement[s.Dialect()]
+
+	nodes := make(map[string]*nodeSnapshot)
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		rows, err := tx.Query(ctx, query, versionSetID.String())
+		if err != nil {
+			return fmt.Errorf("failed to query nodes: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				serialNumber   string
+				networkIndex   int32
+				locality       *string
+				versionSetId   string
+				groupName      *string
+				groupLogLevel  *int32
+				endpointConfig nullableEndpointConfig
+				legacyConfig   nullableEndpointConfig
+				proxyName      *string
+				proxyState     *bool
+				proxyType      *string
+				serverEndpoint *string
+				clientEndpoint *string
+				hwconfigID     *int32
+				hwconfigDevice *string
+				hwconfigIPCIDR *string
+			)
+
+			err := rows.Scan(
+				&serialNumber, &networkIndex, &locality, &versionSetId,
+				&groupName, &groupLogLevel,
+				&endpointConfig.name, &endpointConfig.mutualAuth, &endpointConfig.noEncryption,
+				&endpointConfig.kexMethod, &endpointConfig.cipher,
+				&legacyConfig.name, &legacyConfig.mutualAuth, &legacyConfig.noEncryption,
+				&legacyConfig.kexMethod, &legacyConfig.cipher,
+				&proxyName, &proxyState, &proxyType, &serverEndpoint, &clientEndpoint,
+				&hwconfigID, &hwconfigDevice, &hwconfigIPCIDR,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+
+			node, exists := nodes[serialNumber]
+			if !exists {
+				node = &nodeSnapshot{
+					networkIndex: networkIndex,
+					locality:     deref(locality),
+					groups:       make(map[string]*groupSnapshot),
+					proxies:      make(map[string]*proxySnapshot),
+					hwConfigs:    make(map[string]map[string]bool),
+				}
+				nodes[serialNumber] = node
+			}
+
+			if hwconfigID != nil {
+				device := deref(hwconfigDevice)
+				if node.hwConfigs[device] == nil {
+					node.hwConfigs[device] = make(map[string]bool)
+				}
+				node.hwConfigs[device][deref(hwconfigIPCIDR)] = true
+			}
+
+			if groupName != nil {
+				if _, ok := node.groups[*groupName]; !ok {
+					group := &groupSnapshot{
+						endpoint: endpointConfig.toEndpointConfig(),
+						legacy:   legacyConfig.toEndpointConfig(),
+					}
+					if groupLogLevel != nil {
+						group.logLevel = *groupLogLevel
+					}
+					node.groups[*groupName] = group
+				}
+			}
+
+			if proxyName != nil {
+				node.proxies[*proxyName] = &proxySnapshot{
+					group:     deref(groupName),
+					state:     proxyState != nil && *proxyState,
+					proxyType: deref(proxyType),
+					server:    deref(serverEndpoint),
+					client:    deref(clientEndpoint),
+				}
+			}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// nullableEndpointConfig scans an endpoint config of a LEFT JOIN
+type nullableEndpointConfig struct {
+	name         *string
+	mutualAuth   *bool
+	noEncryption *bool
+	kexMethod    *string
+	cipher       *string
+}
+
+func (c nullableEndpointConfig) toEndpointConfig() *types.EndpointConfig {
+	if c.name == nil {
+		return nil
+	}
+	return &types.EndpointConfig{
+		Name:                 *c.name,
+		MutualAuth:           c.mutualAuth != nil && *c.mutualAuth,
+		NoEncryption:         c.noEncryption != nil && *c.noEncryption,
+		ASLKeyExchangeMethod: deref(c.kexMethod),
+		Cipher:               c.cipher,
+	}
+}
+
+// nodeDiffer collects the changes of a node, an entity shared by several
+// groups of the node is reported once
+type nodeDiffer struct {
+	changes []types.ConfigChange
+	seen    map[string]bool
+}
+
+func (d *nodeDiffer) add(change types.ConfigChange) {
+	key := change.Kind + "\x00" + change.Name + "\x00" + change.Field + "\x00" + string(change.Change)
+	if d.seen[key] {
+		return
+	}
+	d.seen[key] = true
+	d.changes = append(d.changes, change)
+}
+
+func (d *nodeDiffer) compare(kind, name, field string, old, new any) {
+	o, n := fmt.Sprint(old), fmt.Sprint(new)
+	if o != n {
+		d.add(types.ConfigChange{Kind: kind, Name: name, Change: types.ChangeChanged, Field: field, Old: o, New: n})
+	}
+}
+
+// presence reports an entity which exists in one of the version sets only,
+// it returns true if the entity exists in both
+func (d *nodeDiffer) presence(kind, name string, inA, inB bool) bool {
+	switch {
+	case inA && !inB:
+		d.add(types.ConfigChange{Kind: kind, Name: name, Change: types.ChangeRemoved})
+	case !inA && inB:
+		d.add(types.ConfigChange{Kind: kind, Name: name, Change: types.ChangeAdded})
+	}
+	return inA && inB
+}
+
+func diffNode(serial string, a, b *nodeSnapshot) []types.ConfigChange {
+	d := &nodeDiffer{seen: make(map[string]bool)}
+	d.presence("node", serial, a != nil, b != nil)
+	if a == nil {
+		a = &nodeSnapshot{}
+	}
+	if b == nil {
+		b = &nodeSnapshot{}
+	}
+	if len(d.changes) == 0 {
+		d.compare("node", serial, "network_index", a.networkIndex, b.networkIndex)
+		d.compare("node", serial, "locality", a.locality, b.locality)
+	}
+
+	for _, name := range unionKeys(a.proxies, b.proxies) {
+		pa, pb := a.proxies[name], b.proxies[name]
+		if !d.presence("proxy", name, pa != nil, pb != nil) {
+			continue
+		}
+		d.compare("proxy", name, "group", pa.group, pb.group)
+		d.compare("proxy", name, "state", pa.state, pb.state)
+		d.compare("proxy", name, "proxy_type", pa.proxyType, pb.proxyType)
+		d.compare("proxy", name, "server_endpoint_addr", pa.server, pb.server)
+		d.compare("proxy", name, "client_endpoint_addr", pa.client, pb.client)
+	}
+
+	for _, name := range unionKeys(a.groups, b.groups) {
+		ga, gb := a.groups[name], b.groups[name]
+		if !d.presence("group", name, ga != nil, gb != nil) {
+			continue
+		}
+		d.compare("group", name, "log_level", ga.logLevel, gb.logLevel)
+		d.compare("group", name, "endpoint_config", endpointName(ga.endpoint), endpointName(gb.endpoint))
+		d.compare("group", name, "legacy_config", endpointName(ga.legacy), endpointName(gb.legacy))
+		d.diffEndpoint(ga.endpoint, gb.endpoint)
+		d.diffEndpoint(ga.legacy, gb.legacy)
+	}
+
+	for _, device := range unionKeys(a.hwConfigs, b.hwConfigs) {
+		ha, hb := a.hwConfigs[device], b.hwConfigs[device]
+		if !d.presence("hardware_config", device, ha != nil, hb != nil) {
+			continue
+		}
+		d.compare("hardware_config", device, "ip_cidr",
+			strings.Join(sortedKeys(ha), ", "), strings.Join(sortedKeys(hb), ", "))
+	}
+	return d.changes
+}
+
+// diffEndpoint compares the endpoint config a group uses in both version sets.
+// A group switching to another config is reported by the group already.
+func (d *nodeDiffer) diffEndpoint(a, b *types.EndpointConfig) {
+	if a == nil || b == nil || a.Name != b.Name {
+		return
+	}
+	d.compare("endpoint_config", b.Name, "mutual_auth", a.MutualAuth, b.MutualAuth)
+	d.compare("endpoint_config", b.Name, "no_encryption", a.NoEncryption, b.NoEncryption)
+	d.compare("endpoint_config", b.Name, "asl_key_exchange_method", a.ASLKeyExchangeMethod, b.ASLKeyExchangeMethod)
+	d.compare("endpoint_config", b.Name, "cipher", deref(a.Cipher), deref(b.Cipher))
+}
+
+func endpointName(config *types.EndpointConfig) string {
+	if config == nil {
+		return ""
+	}
+	return config.Name
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make(map[string]bool, len(a)+len(b))
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return sortedKeys(keys)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+const driftColumns = `id, serial_number, version_set_id, expected_hash, reported_hash, reported_version_set_id,
+	detected_at, resolved_at`
+
+// AppliedVersionSet returns the version set whose config a node should run:
+// the version set of the last update it applied, or the active version set if
+// it never applied one. inFlight is true while an update of the node has not
+// finished, its config is in transition then. uuid.Nil is returned if there
+// is neither an applied nor an active version set.
+func (s *StateManager) AppliedVersionSet(ctx context.Context, serialNumber string) (id uuid.UUID, inFlight bool, err error) {
+	err = s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		var state types.TransactionState
+		err := tx.QueryRow(ctx, `
+			SELECT state FROM transaction_log
+			WHERE node_serial = $1
+			ORDER BY id DESC LIMIT 1`, serialNumber).Scan(&state)
+		if err != nil && !IsNoRows(err) {
+			return err
+		}
+		if updateInFlight(state) {
+			inFlight = true
+			return nil
+		}
+
+		err = tx.QueryRow(ctx, `
+			SELECT version_set_id FROM transaction_log
+			WHERE node_serial = $1 AND state = 'applied'
+			ORDER BY id DESC LIMIT 1`, serialNumber).Scan(&id)
+		if !IsNoRows(err) {
+			return err
+		}
+		err = tx.QueryRow(ctx, `SELECT id FROM version_sets WHERE state = 'active'`).Scan(&id)
+		if IsNoRows(err) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to get applied version set of %s", serialNumber)
+		return uuid.Nil, false, fmt.Errorf("failed to get applied version set of %s: %w", serialNumber, err)
+	}
+	return id, inFlight, nil
+}
+
+// updateInFlight reports whether the last logged state of a node belongs to an
+// update which has not finished
+func updateInFlight(state types.TransactionState) bool {
+	switch state {
+	case types.TransactionStatePublished, types.TransactionStateReceived, types.TransactionStateApplicable:
+		return true
+	}
+	return false
+}
+
+// RecordDrift compares the config hash reported by a node with the expected
+// one. A mismatch opens a drift event unless the same mismatch is already
+// open, a match resolves the open event. It returns the opened event, nil if
+// none was opened.
+func (s *StateManager) RecordDrift(ctx context.Context, check *types.DriftEvent) (*types.DriftEvent, error) {
+	var opened *types.DriftEvent
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		var openID int
+		var expected, reported string
+		err := tx.QueryRow(ctx, `
+			SELECT id, expected_hash, reported_hash FROM node_drift_events
+			WHERE serial_number = $1 AND resolved_at IS NULL
+			ORDER BY id DESC LIMIT 1`, check.SerialNumber).Scan(&openID, &expected, &reported)
+		if err != nil && !IsNoRows(err) {
+			return err
+		}
+		drifted := check.ExpectedHash != check.ReportedHash
+		if openID != 0 {
+			if drifted && expected == check.ExpectedHash && reported == check.ReportedHash {
+				return nil
+			}
+			if _, err := tx.Exec(ctx, `UPDATE node_drift_events SET resolved_at = $2 WHERE id = $1`, openID, check.DetectedAt); err != nil {
+				return err
+			}
+		}
+		if !drifted {
+			return nil
+		}
+
+		event := *check
+		err = tx.QueryRow(ctx, `
+			INSERT INTO node_drift_events (serial_number, version_set_id, expected_hash, reported_hash, reported_version_set_id, detected_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+			event.SerialNumber, event.VersionSetID, event.ExpectedHash, event.ReportedHash, event.ReportedVersionSetID, event.DetectedAt,
+		).Scan(&event.ID)
+		if err != nil {
+			return err
+		}
+		opened = &event
+		return nil
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to record drift of %s", check.SerialNumber)
+		return nil, fmt.Errorf("failed to record drift of %s: %w", check.SerialNumber, err)
+	}
+	return opened, nil
+}
+
+// ListDriftEvents returns drift events, newest first
+func (s *StateManager) ListDriftEvents(ctx context.Context, filter types.DriftFilter) ([]*types.DriftEvent, error) {
+	var conditions []string
+	var args []any
+	if filter.SerialNumber != "" {
+		conditions = append(conditions, `serial_number = `+addArg(&args, filter.SerialNumber))
+	}
+	if !filter.IncludeResolved {
+		conditions = append(conditions, `resolved_at IS NULL`)
+	}
+	query := `SELECT ` + driftColumns + ` FROM node_drift_events` + whereClause(conditions) + ` ORDER BY id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ` + addArg(&args, filter.Limit)
+	}
+
+	var events []*types.DriftEvent
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		return queryEach(ctx, tx, query, args, func(rows Rows) error {
+			var e types.DriftEvent
+			if err := rows.Scan(&e.ID, &e.SerialNumber, &e.VersionSetID, &e.ExpectedHash, &e.ReportedHash,
+				&e.ReportedVersionSetID, &e.DetectedAt, &e.ResolvedAt); err != nil {
+				return err
+			}
+			events = append(events, &e)
+			return nil
+		})
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to list drift events")
+		return nil, fmt.Errorf("failed to list drift events: %w", err)
+	}
+	return events, nil
+}
@@ -4,13 +4,15 @@ import (
 	"context"
 
 	"github.com/gofrs/uuid/v5"
-	"github.com/jackc/pgx/v5"
 	"github.com/philslol/kritis3m_scalev2/control/types"
 )
 
 func (s *StateManager) CreateEndpointConfig(ctx context.Context, config *types.EndpointConfig) error {
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if err := lockDraft(ctx, tx, config.VersionSetID); err != nil {
+			return err
+		}
 		query := `
 		INSERT INTO endpoint_configs (name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id, created_by)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
@@ -33,14 +35,22 @@ func (s *StateManager) CreateEndpointConfig(ctx context.Context, config *types.E
 	return nil
 }
 
+// endpointConfigByIDStatement selects endpoint config $1
+var endpointConfigByIDStatement = map[string]string{
+	types.DatabasePostgres: `
+		SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id::text, created_at, updated_at, created_by
+		FROM endpoint_configs WHERE id = $1`,
+	types.DatabaseSqlite: `
+		SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, CAST(version_set_id AS TEXT), created_at, updated_at, created_by
+		FROM endpoint_configs WHERE id = $1`,
+}
+
 func (s *StateManager) GetEndpointConfigByID(ctx context.Context, id int) (*types.EndpointConfig, error) {
 
 	var config types.EndpointConfig
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 
-		query := `
-		SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id::text, created_at, updated_at, created_by
-		FROM endpoint_configs WHERE id = $1`
+		query := endpointConfigByIDStatement[s.Dialect()]
 
 		return tx.QueryRow(ctx, query, id).Scan(
 			&config.ID, &config.Name, &config.MutualAuth, &config.NoEncryption,
@@ -55,20 +65,32 @@ func (s *StateManager) GetEndpointConfigByID(ctx context.Context, id int) (*type
 	return &config, nil
 }
 
+// endpointConfigsOfVersionSetStatement selects the endpoint configs of version set $1
+var endpointConfigsOfVersionSetStatement = map[string]string{
+	types.DatabasePostgres: `SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id::text, created_at, updated_at, created_by FROM endpoint_configs WHERE version_set_id = $1`,
+	types.DatabaseSqlite:   `SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, CAST(version_set_id AS TEXT), created_at, updated_at, created_by FROM endpoint_configs WHERE version_set_id = $1`,
+}
+
+// endpointConfigsStatement selects the endpoint configs of all version sets
+var endpointConfigsStatement = map[string]string{
+	types.DatabasePostgres: `SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id::text, created_at, updated_at, created_by FROM endpoint_configs`,
+	types.DatabaseSqlite:   `SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, CAST(version_set_id AS TEXT), created_at, updated_at, created_by FROM endpoint_configs`,
+}
+
 func (s *StateManager) ListEndpointConfigs(ctx context.Context, versionSetID *uuid.UUID) ([]*types.EndpointConfig, error) {
 	var configs []*types.EndpointConfig
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 
 		var err error
 		var query string
-		var rows pgx.Rows
+		var rows Rows
 		if versionSetID != nil {
-			query = `SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id::text, created_at, updated_at, created_by FROM endpoint_configs WHERE version_set_id = $1`
+			query = endpointConfigsOfVersionSetStatement[s.Dialect()]
 			rows, err = tx.Query(ctx, query, versionSetID)
 		} else {
 			log.Info().Msg("listing all endpoint configs")
-			query = `SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id::text, created_at, updated_at, created_by FROM endpoint_configs`
+			query = endpointConfigsStatement[s.Dialect()]
 			rows, err = tx.Query(ctx, query)
 		}
 		if err != nil {
@@ -105,13 +127,21 @@ func (s *StateManager) ListEndpointConfigs(ctx context.Context, versionSetID *uu
 	return configs, nil
 }
 
+// endpointConfigByNameStatement selects endpoint config $1 of version set $2
+var endpointConfigByNameStatement = map[string]string{
+	types.DatabasePostgres: `
+		SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id::text, created_at, updated_at, created_by
+		FROM endpoint_configs WHERE name = $1 AND version_set_id = $2`,
+	types.DatabaseSqlite: `
+		SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, CAST(version_set_id AS TEXT), created_at, updated_at, created_by
+		FROM endpoint_configs WHERE name = $1 AND version_set_id = $2`,
+}
+
 func (s *StateManager) GetEndpointConfigByName(ctx context.Context, name string, versionSetID *uuid.UUID) (*types.EndpointConfig, error) {
 	var config types.EndpointConfig
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 
-		query := `
-		SELECT id, name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id::text, created_at, updated_at, created_by
-		FROM endpoint_configs WHERE name = $1 AND version_set_id = $2`
+		query := endpointConfigByNameStatement[s.Dialect()]
 
 		return tx.QueryRow(ctx, query, name, versionSetID).Scan(
 			&config.ID, &config.Name, &config.MutualAuth, &config.NoEncryption,
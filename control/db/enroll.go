@@ -4,14 +4,13 @@ import (
 	"context"
 	"strconv"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/philslol/kritis3m_scalev2/control/types"
 )
 
 func (s *StateManager) ListEnroll(ctx context.Context) ([]*types.EnrollCallRequest, error) {
 	var enrolls []*types.EnrollCallRequest
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 		SELECT id, est_serial_number, serial_number, organization, issued_at, expires_at, 
 		       signature_algorithm, plane, created_at, updated_at
@@ -57,7 +56,7 @@ func (s *StateManager) ListEnroll(ctx context.Context) ([]*types.EnrollCallReque
 func (s *StateManager) GetEnroll(ctx context.Context, serialNumber string) ([]*types.EnrollCallRequest, error) {
 	var enrolls []*types.EnrollCallRequest
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 		SELECT id, est_serial_number, serial_number, organization, issued_at, expires_at, 
 		       signature_algorithm, plane, created_at, updated_at
@@ -104,7 +103,7 @@ func (s *StateManager) GetEnroll(ctx context.Context, serialNumber string) ([]*t
 func (s *StateManager) GetEnrollEstSerial(ctx context.Context, estSerialNumber string) (*types.EnrollCallRequest, error) {
 	var enroll types.EnrollCallRequest
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 		SELECT id, est_serial_number, serial_number, organization, issued_at, expires_at, 
 		       signature_algorithm, plane, created_at, updated_at
@@ -134,7 +133,7 @@ func (s *StateManager) GetEnrollEstSerial(ctx context.Context, estSerialNumber s
 }
 
 func (s *StateManager) CreateEnroll(ctx context.Context, enroll *types.EnrollCallRequest) error {
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 		INSERT INTO enroll 
 		(est_serial_number, serial_number, organization, issued_at, expires_at, 
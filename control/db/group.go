@@ -4,12 +4,14 @@ import (
 	"context"
 
 	"github.com/gofrs/uuid/v5"
-	"github.com/jackc/pgx/v5"
 	"github.com/philslol/kritis3m_scalev2/control/types"
 )
 
 func (s *StateManager) CreateGroup(ctx context.Context, group *types.Group) error {
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if err := lockDraft(ctx, tx, group.VersionSetID); err != nil {
+			return err
+		}
 		query := `
         INSERT INTO groups (
             name, 
@@ -39,7 +41,7 @@ func (s *StateManager) CreateGroup(ctx context.Context, group *types.Group) erro
 
 func (s *StateManager) GetByID(ctx context.Context, id int) (*types.Group, error) {
 	group := &types.Group{}
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
         SELECT 
             id, 
@@ -83,10 +85,10 @@ func (s *StateManager) GetListGroup(ctx context.Context, versionSetID *uuid.UUID
 	groups := []*types.Group{}
 	var err error
 
-	err = s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err = s.ExecuteInTransaction(ctx, func(tx Tx) error {
 
 		var query string
-		var rows pgx.Rows
+		var rows Rows
 		if versionSetID != nil {
 			query = `
         SELECT 
@@ -154,7 +156,7 @@ func (s *StateManager) GetListGroup(ctx context.Context, versionSetID *uuid.UUID
 func (s *StateManager) GetGroupByName(ctx context.Context, name string, versionSetID *uuid.UUID) (*types.Group, error) {
 	group := &types.Group{}
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 		SELECT 
 			id, 
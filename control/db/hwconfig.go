@@ -4,13 +4,15 @@ import (
 	"context"
 
 	"github.com/gofrs/uuid/v5"
-	"github.com/jackc/pgx/v5"
 	"github.com/philslol/kritis3m_scalev2/control/types"
 )
 
 func (s *StateManager) CreateHwConfig(ctx context.Context, config *types.HardwareConfig) error {
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if err := lockDraft(ctx, tx, config.VersionSetID); err != nil {
+			return err
+		}
 		query := `
         INSERT INTO hardware_configs (
             node_serial, device, ip_cidr, version_set_id, created_by
@@ -29,13 +31,22 @@ func (s *StateManager) CreateHwConfig(ctx context.Context, config *types.Hardwar
 	return nil
 }
 
-func (s *StateManager) GetHwConfigPByID(ctx context.Context, id int) (*types.HardwareConfig, error) {
-	config := &types.HardwareConfig{}
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
-		query := `
+// hwConfigByIDStatement selects hardware config $1
+var hwConfigByIDStatement = map[string]string{
+	types.DatabasePostgres: `
         SELECT id, node_serial, device, ip_cidr::text, version_set_id,
                created_at, updated_at, created_by
-        FROM hardware_configs WHERE id = $1`
+        FROM hardware_configs WHERE id = $1`,
+	types.DatabaseSqlite: `
+        SELECT id, node_serial, device, CAST(ip_cidr AS TEXT), version_set_id,
+               created_at, updated_at, created_by
+        FROM hardware_configs WHERE id = $1`,
+}
+
+func (s *StateManager) GetHwConfigPByID(ctx context.Context, id int) (*types.HardwareConfig, error) {
+	config := &types.HardwareConfig{}
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := hwConfigByIDStatement[s.Dialect()]
 
 		var ipCIDR string
 		err := tx.QueryRow(ctx, query, id).Scan(
@@ -56,13 +67,26 @@ func (s *StateManager) GetHwConfigPByID(ctx context.Context, id int) (*types.Har
 	return config, nil
 }
 
+// hwConfigsOfNodeStatement selects the hardware configs of node $1
+var hwConfigsOfNodeStatement = map[string]string{
+	types.DatabasePostgres: `
+        SELECT hc.id, hc.node_serial, hc.device, hc.ip_cidr::text, hc.version_set_id,
+               hc.created_at, hc.updated_at, hc.created_by
+        FROM hardware_configs hc
+        JOIN nodes n ON n.serial_number = hc.node_serial AND n.version_set_id = hc.version_set_id
+        WHERE n.id = $1`,
+	types.DatabaseSqlite: `
+        SELECT hc.id, hc.node_serial, hc.device, CAST(hc.ip_cidr AS TEXT), hc.version_set_id,
+               hc.created_at, hc.updated_at, hc.created_by
+        FROM hardware_configs hc
+        JOIN nodes n ON n.serial_number = hc.node_serial AND n.version_set_id = hc.version_set_id
+        WHERE n.id = $1`,
+}
+
 func (s *StateManager) GetHwConfigbyNodeID(ctx context.Context, nodeID int) ([]*types.HardwareConfig, error) {
 	configs := []*types.HardwareConfig{}
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
-		query := `
-        SELECT id, node_serial, device, ip_cidr::text, version_set_id,
-               created_at, updated_at, created_by
-        FROM hardware_configs WHERE node_id = $1`
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := hwConfigsOfNodeStatement[s.Dialect()]
 
 		rows, err := tx.Query(ctx, query, nodeID)
 		if err != nil {
@@ -93,13 +117,22 @@ func (s *StateManager) GetHwConfigbyNodeID(ctx context.Context, nodeID int) ([]*
 	return configs, nil
 }
 
-func (s *StateManager) GetHwConfigBySerial(ctx context.Context, serialNumber string, versionSetID uuid.UUID) ([]*types.HardwareConfig, error) {
-	configs := []*types.HardwareConfig{}
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
-		query := `
+// hwConfigsBySerialStatement selects the hardware configs of node $2 in version set $1
+var hwConfigsBySerialStatement = map[string]string{
+	types.DatabasePostgres: `
         SELECT id, node_serial, device, ip_cidr::text, version_set_id,
                created_at, updated_at, created_by
-        FROM hardware_configs WHERE version_set_id = $1 AND node_serial = $2`
+        FROM hardware_configs WHERE version_set_id = $1 AND node_serial = $2`,
+	types.DatabaseSqlite: `
+        SELECT id, node_serial, device, CAST(ip_cidr AS TEXT), version_set_id,
+               created_at, updated_at, created_by
+        FROM hardware_configs WHERE version_set_id = $1 AND node_serial = $2`,
+}
+
+func (s *StateManager) GetHwConfigBySerial(ctx context.Context, serialNumber string, versionSetID uuid.UUID) ([]*types.HardwareConfig, error) {
+	configs := []*types.HardwareConfig{}
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := hwConfigsBySerialStatement[s.Dialect()]
 
 		rows, err := tx.Query(ctx, query, versionSetID, serialNumber)
 		if err != nil {
@@ -130,14 +163,23 @@ func (s *StateManager) GetHwConfigBySerial(ctx context.Context, serialNumber str
 	return configs, nil
 }
 
+// hwConfigsOfVersionSetStatement selects the hardware configs of version set $1
+var hwConfigsOfVersionSetStatement = map[string]string{
+	types.DatabasePostgres: `
+        SELECT id, node_serial, device, ip_cidr::text, version_set_id,
+               created_at, updated_at, created_by
+        FROM hardware_configs WHERE version_set_id = $1`,
+	types.DatabaseSqlite: `
+        SELECT id, node_serial, device, CAST(ip_cidr AS TEXT), version_set_id,
+               created_at, updated_at, created_by
+        FROM hardware_configs WHERE version_set_id = $1`,
+}
+
 func (s *StateManager) GetHwConfigByVersionSetID(ctx context.Context, versionSetID uuid.UUID) ([]*types.HardwareConfig, error) {
 	configs := []*types.HardwareConfig{}
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
-		query := `
-        SELECT id, node_serial, device, ip_cidr::text, version_set_id,
-               created_at, updated_at, created_by
-        FROM hardware_configs WHERE version_set_id = $1`
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := hwConfigsOfVersionSetStatement[s.Dialect()]
 
 		rows, err := tx.Query(ctx, query, versionSetID)
 		if err != nil {
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+const inventoryColumns = `serial_number, agent_version, firmware_version, hardware_model, uptime,
+	version_set_id, tx_id, kex_methods, ciphers, config_hash, reported_at`
+
+func scanInventory(row Row) (*types.NodeInventory, error) {
+	var inv types.NodeInventory
+	var kexMethods, ciphers string
+	if err := row.Scan(&inv.SerialNumber, &inv.AgentVersion, &inv.FirmwareVersion, &inv.HardwareModel, &inv.Uptime,
+		&inv.VersionSetID, &inv.TxID, &kexMethods, &ciphers, &inv.ConfigHash, &inv.ReportedAt); err != nil {
+		return nil, err
+	}
+	inv.KexMethods = splitList(kexMethods)
+	inv.Ciphers = splitList(ciphers)
+	return &inv, nil
+}
+
+// splitList reverses the strings.Join of a stored list
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+// RecordHello marks a node as seen at the given time and stores the inventory
+// it reported, an inventory of nil keeps the one stored before
+func (s *StateManager) RecordHello(ctx context.Context, serialNumber string, seen time.Time, inv *types.NodeInventory) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if _, err := tx.Exec(ctx, `UPDATE nodes SET last_seen = $2 WHERE serial_number = $1`, serialNumber, seen); err != nil {
+			return err
+		}
+		if inv == nil {
+			return nil
+		}
+		query := `
+			INSERT INTO node_inventory (` + inventoryColumns + `)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (serial_number) DO UPDATE
+			SET agent_version = $2, firmware_version = $3, hardware_model = $4, uptime = $5,
+				version_set_id = $6, tx_id = $7, kex_methods = $8, ciphers = $9, config_hash = $10, reported_at = $11`
+		_, err := tx.Exec(ctx, query, serialNumber, inv.AgentVersion, inv.FirmwareVersion, inv.HardwareModel, inv.Uptime,
+			inv.VersionSetID, inv.TxID, strings.Join(inv.KexMethods, ","), strings.Join(inv.Ciphers, ","), inv.ConfigHash, seen)
+		return err
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to record hello of %s", serialNumber)
+		return fmt.Errorf("failed to record hello of %s: %w", serialNumber, err)
+	}
+	return nil
+}
+
+// GetNodeInventory returns the last inventory reported by a node, ErrNoRows if
+// it never reported one
+func (s *StateManager) GetNodeInventory(ctx context.Context, serialNumber string) (*types.NodeInventory, error) {
+	var inv *types.NodeInventory
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		var err error
+		inv, err = scanInventory(tx.QueryRow(ctx, `SELECT `+inventoryColumns+` FROM node_inventory WHERE serial_number = $1`, serialNumber))
+		return err
+	})
+	if err != nil {
+		if IsNoRows(err) {
+			return nil, err
+		}
+		log.Err(err).Msgf("failed to get inventory of %s", serialNumber)
+		return nil, fmt.Errorf("failed to get inventory of %s: %w", serialNumber, err)
+	}
+	return inv, nil
+}
+
+// AgentVersions returns the agent version last reported by every node which
+// sent an inventory
+func (s *StateManager) AgentVersions(ctx context.Context) (map[string]string, error) {
+	versions := make(map[string]string)
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		return queryEach(ctx, tx, `SELECT serial_number, agent_version FROM node_inventory`, nil, func(rows Rows) error {
+			var serial, version string
+			if err := rows.Scan(&serial, &version); err != nil {
+				return err
+			}
+			versions[serial] = version
+			return nil
+		})
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to get agent versions")
+		return nil, fmt.Errorf("failed to get agent versions: %w", err)
+	}
+	return versions, nil
+}
@@ -0,0 +1,657 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// ErrInvalidManifest is returned for manifests which can not be applied as
+// written, like dangling references or a missing author
+var ErrInvalidManifest = errors.New("invalid manifest")
+
+// ApplyManifest brings the version set described by m in line with it. The
+// plan is computed and executed in a single database transaction, so either
+// all or none of its steps take effect. With dryRun the plan is only returned.
+// Applying the same manifest twice yields an empty plan.
+func (s *StateManager) ApplyManifest(ctx context.Context, m *types.Manifest, dryRun bool) (*types.ManifestPlan, error) {
+	m.Normalize()
+	if err := m.CheckReferences(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+	}
+
+	plan := &types.ManifestPlan{Steps: []types.PlanStep{}}
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		id, err := findManifestVersionSet(ctx, tx, m.VersionSet)
+		if err != nil {
+			return err
+		}
+
+		current := &types.Manifest{}
+		var hwIDs map[string][]int
+		if id != uuid.Nil {
+			current, hwIDs, err = loadManifest(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			current.Normalize()
+		}
+
+		createdBy := m.VersionSet.CreatedBy
+		if createdBy == "" {
+			createdBy = current.VersionSet.CreatedBy
+		}
+		if createdBy == "" {
+			return fmt.Errorf("%w: version_set.created_by is required to create version set %q", ErrInvalidManifest, m.VersionSet.Name)
+		}
+
+		a := &manifestApply{tx: tx, versionSetID: id, createdBy: createdBy}
+		a.plan(current, m)
+		plan.Steps = a.steps
+		plan.VersionSetID = id
+		if dryRun {
+			return nil
+		}
+		if id != uuid.Nil && len(a.steps) > 0 {
+			if err := lockDraft(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+
+		if err := a.execute(ctx, m, hwIDs); err != nil {
+			return err
+		}
+		plan.VersionSetID = a.versionSetID
+		plan.Applied = true
+		return nil
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to apply manifest")
+		return nil, fmt.Errorf("failed to apply manifest: %w", err)
+	}
+	return plan, nil
+}
+
+// ImportManifest creates a new draft version set with the content of m. Unlike
+// ApplyManifest it never matches an existing version set. Entities found in
+// origins keep their author and creation time.
+func (s *StateManager) ImportManifest(ctx context.Context, m *types.Manifest, origins map[string]types.Origin, metadata []byte) (uuid.UUID, error) {
+	m.Normalize()
+	if err := m.CheckReferences(); err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+	}
+	if m.VersionSet.CreatedBy == "" {
+		return uuid.Nil, fmt.Errorf("%w: version_set.created_by is required to create version set %q", ErrInvalidManifest, m.VersionSet.Name)
+	}
+
+	a := &manifestApply{createdBy: m.VersionSet.CreatedBy, origins: origins, metadata: metadata}
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		a.tx = tx
+		a.plan(&types.Manifest{}, m)
+		return a.execute(ctx, m, nil)
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to import manifest")
+		return uuid.Nil, fmt.Errorf("failed to import manifest: %w", err)
+	}
+	return a.versionSetID, nil
+}
+
+// ExportManifest returns the content of a version set as a manifest and the
+// origins of its entities by types.OriginKey
+func (s *StateManager) ExportManifest(ctx context.Context, id uuid.UUID) (*types.Manifest, map[string]types.Origin, error) {
+	var m *types.Manifest
+	var origins map[string]types.Origin
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		var hwIDs map[string][]int
+		var err error
+		m, hwIDs, err = loadManifest(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		origins, err = loadOrigins(ctx, tx, id, hwIDs)
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to export version set %s: %w", id, err)
+	}
+	m.Normalize()
+	return m, origins, nil
+}
+
+// findManifestVersionSet returns the version set selected by the manifest, or
+// uuid.Nil if a version set with the name does not exist yet
+func findManifestVersionSet(ctx context.Context, tx Tx, vs types.ManifestVersionSet) (uuid.UUID, error) {
+	if vs.ID != "" {
+		id, err := uuid.FromString(vs.ID)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("%w: invalid version set id %q: %w", ErrInvalidManifest, vs.ID, err)
+		}
+		var found uuid.UUID
+		if err := tx.QueryRow(ctx, `SELECT id FROM version_sets WHERE id = $1`, id).Scan(&found); err != nil {
+			return uuid.Nil, fmt.Errorf("version set %s: %w", id, err)
+		}
+		return found, nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id FROM version_sets
+		WHERE name = $1 AND disabled_at IS NULL AND state <> 'disabled'`, vs.Name)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return uuid.Nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return uuid.Nil, err
+	}
+
+	switch len(ids) {
+	case 0:
+		return uuid.Nil, nil
+	case 1:
+		return ids[0], nil
+	default:
+		return uuid.Nil, fmt.Errorf("%d version sets are named %q, select one by id", len(ids), vs.Name)
+	}
+}
+
+// manifestEndpointConfigsStatement selects the endpoint configs of version set $1 for a manifest
+var manifestEndpointConfigsStatement = map[string]string{
+	types.DatabasePostgres: `
+		SELECT name, mutual_auth, no_encryption, asl_key_exchange_method::text, COALESCE(cipher, '')
+		FROM endpoint_configs WHERE version_set_id = $1 ORDER BY name`,
+	types.DatabaseSqlite: `
+		SELECT name, mutual_auth, no_encryption, CAST(asl_key_exchange_method AS TEXT), COALESCE(cipher, '')
+		FROM endpoint_configs WHERE version_set_id = $1 ORDER BY name`,
+}
+
+// manifestHwConfigsStatement selects the hardware configs of version set $1 for a manifest
+var manifestHwConfigsStatement = map[string]string{
+	types.DatabasePostgres: `
+		SELECT id, node_serial, device, ip_cidr::text
+		FROM hardware_configs WHERE version_set_id = $1 ORDER BY node_serial, device, id`,
+	types.DatabaseSqlite: `
+		SELECT id, node_serial, device, CAST(ip_cidr AS TEXT)
+		FROM hardware_configs WHERE version_set_id = $1 ORDER BY node_serial, device, id`,
+}
+
+// manifestProxiesStatement selects the proxies of version set $1 for a manifest
+var manifestProxiesStatement = map[string]string{
+	types.DatabasePostgres: `
+		SELECT name, node_serial, group_name, state, proxy_type::text, server_endpoint_addr, client_endpoint_addr
+		FROM proxies WHERE version_set_id = $1 ORDER BY name`,
+	types.DatabaseSqlite: `
+		SELECT name, node_serial, group_name, state, CAST(proxy_type AS TEXT), server_endpoint_addr, client_endpoint_addr
+		FROM proxies WHERE version_set_id = $1 ORDER BY name`,
+}
+
+// loadManifest reads a version set as manifest. Hardware configs have no key
+// in the schema, their ids are returned by ManifestHardwareConfig.Key.
+func loadManifest(ctx context.Context, tx Tx, id uuid.UUID) (*types.Manifest, map[string][]int, error) {
+	m := &types.Manifest{}
+	err := tx.QueryRow(ctx, `
+		SELECT name, COALESCE(description, ''), created_by
+		FROM version_sets WHERE id = $1`, id,
+	).Scan(&m.VersionSet.Name, &m.VersionSet.Description, &m.VersionSet.CreatedBy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = queryEach(ctx, tx, manifestEndpointConfigsStatement[tx.Dialect()], []any{id},
+		func(rows Rows) error {
+			var e types.ManifestEndpointConfig
+			err := rows.Scan(&e.Name, &e.MutualAuth, &e.NoEncryption, &e.ASLKeyExchangeMethod, &e.Cipher)
+			m.EndpointConfigs = append(m.EndpointConfigs, e)
+			return err
+		})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load endpoint configs: %w", err)
+	}
+
+	err = queryEach(ctx, tx, `
+		SELECT name, log_level, COALESCE(endpoint_config_name, ''), COALESCE(legacy_config_name, '')
+		FROM groups WHERE version_set_id = $1 ORDER BY name`, []any{id},
+		func(rows Rows) error {
+			var g types.ManifestGroup
+			err := rows.Scan(&g.Name, &g.LogLevel, &g.EndpointConfig, &g.LegacyConfig)
+			m.Groups = append(m.Groups, g)
+			return err
+		})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load groups: %w", err)
+	}
+
+	err = queryEach(ctx, tx, `
+		SELECT serial_number, network_index, COALESCE(locality, '')
+		FROM nodes WHERE version_set_id = $1 ORDER BY serial_number`, []any{id},
+		func(rows Rows) error {
+			var n types.ManifestNode
+			err := rows.Scan(&n.SerialNumber, &n.NetworkIndex, &n.Locality)
+			m.Nodes = append(m.Nodes, n)
+			return err
+		})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load nodes: %w", err)
+	}
+
+	hwIDs := make(map[string][]int)
+	err = queryEach(ctx, tx, manifestHwConfigsStatement[tx.Dialect()], []any{id},
+		func(rows Rows) error {
+			var hwID int
+			var h types.ManifestHardwareConfig
+			if err := rows.Scan(&hwID, &h.Node, &h.Device, &h.IPCIDR); err != nil {
+				return err
+			}
+			h.IPCIDR = types.NormalizeCIDR(h.IPCIDR)
+			if _, ok := hwIDs[h.Key()]; !ok {
+				m.HardwareConfigs = append(m.HardwareConfigs, h)
+			}
+			hwIDs[h.Key()] = append(hwIDs[h.Key()], hwID)
+			return nil
+		})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load hardware configs: %w", err)
+	}
+
+	err = queryEach(ctx, tx, manifestProxiesStatement[tx.Dialect()], []any{id},
+		func(rows Rows) error {
+			var p types.ManifestProxy
+			var state bool
+			err := rows.Scan(&p.Name, &p.Node, &p.Group, &state, &p.ProxyType, &p.ServerEndpointAddr, &p.ClientEndpointAddr)
+			p.State = &state
+			m.Proxies = append(m.Proxies, p)
+			return err
+		})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load proxies: %w", err)
+	}
+	return m, hwIDs, nil
+}
+
+// loadOrigins reads the origins of the entities of a version set. Hardware
+// configs are matched to their key by the ids returned by loadManifest.
+func loadOrigins(ctx context.Context, tx Tx, id uuid.UUID, hwIDs map[string][]int) (map[string]types.Origin, error) {
+	origins := make(map[string]types.Origin)
+	for _, entity := range []struct{ kind, table, key string }{
+		{"endpoint_config", "endpoint_configs", "name"},
+		{"group", "groups", "name"},
+		{"node", "nodes", "serial_number"},
+		{"proxy", "proxies", "name"},
+	} {
+		query := fmt.Sprintf(`SELECT %s, created_by, created_at FROM %s WHERE version_set_id = $1`, entity.key, entity.table)
+		err := queryEach(ctx, tx, query, []any{id}, func(rows Rows) error {
+			var key string
+			var origin types.Origin
+			if err := rows.Scan(&key, &origin.CreatedBy, &origin.CreatedAt); err != nil {
+				return err
+			}
+			origins[types.OriginKey(entity.kind, key)] = origin
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load origins of %s: %w", entity.table, err)
+		}
+	}
+
+	hwKeys := make(map[int]string)
+	for key, ids := range hwIDs {
+		// duplicates of a hardware config are merged into the first one
+		hwKeys[ids[0]] = key
+	}
+	err := queryEach(ctx, tx, `
+		SELECT id, created_by, created_at FROM hardware_configs WHERE version_set_id = $1`, []any{id},
+		func(rows Rows) error {
+			var hwID int
+			var origin types.Origin
+			if err := rows.Scan(&hwID, &origin.CreatedBy, &origin.CreatedAt); err != nil {
+				return err
+			}
+			if key, ok := hwKeys[hwID]; ok {
+				origins[types.OriginKey("hardware_config", key)] = origin
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load origins of hardware_configs: %w", err)
+	}
+	return origins, nil
+}
+
+// queryEach calls scan for every row of query
+func queryEach(ctx context.Context, tx Tx, query string, args []any, scan func(Rows) error) error {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// entityChanges are the differences of one kind of entity between the
+// database and a manifest
+type entityChanges[T any] struct {
+	create []T
+	update []T
+	delete []T
+}
+
+// diffEntities matches current and desired entities by key. Create and update
+// steps are added to the plan right away, delete steps are returned.
+func diffEntities[T any](kind string, current, desired []T, key func(T) string, steps *[]types.PlanStep) (entityChanges[T], []types.PlanStep) {
+	var changes entityChanges[T]
+	existing := make(map[string]T, len(current))
+	for _, entity := range current {
+		existing[key(entity)] = entity
+	}
+
+	wanted := make(map[string]bool, len(desired))
+	for _, entity := range desired {
+		k := key(entity)
+		wanted[k] = true
+		old, ok := existing[k]
+		if !ok {
+			changes.create = append(changes.create, entity)
+			*steps = append(*steps, types.PlanStep{Action: types.PlanCreate, Kind: kind, Name: k})
+			continue
+		}
+		if fields := changedFields(old, entity); len(fields) > 0 {
+			changes.update = append(changes.update, entity)
+			*steps = append(*steps, types.PlanStep{Action: types.PlanUpdate, Kind: kind, Name: k, Fields: fields})
+		}
+	}
+
+	var deletes []types.PlanStep
+	for _, entity := range current {
+		if k := key(entity); !wanted[k] {
+			changes.delete = append(changes.delete, entity)
+			deletes = append(deletes, types.PlanStep{Action: types.PlanDelete, Kind: kind, Name: k})
+		}
+	}
+	return changes, deletes
+}
+
+// changedFields returns the json names of the fields which differ between a and b
+func changedFields(a, b any) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	var fields []string
+	for i := 0; i < va.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			name, _, _ := strings.Cut(va.Type().Field(i).Tag.Get("json"), ",")
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+type manifestApply struct {
+	tx           Tx
+	versionSetID uuid.UUID
+	createdBy    string
+	// origins of the created entities by types.OriginKey
+	origins map[string]types.Origin
+	// metadata of a created version set
+	metadata []byte
+	steps    []types.PlanStep
+
+	versionSetFields []string
+	endpoints        entityChanges[types.ManifestEndpointConfig]
+	groups           entityChanges[types.ManifestGroup]
+	nodes            entityChanges[types.ManifestNode]
+	hwConfigs        entityChanges[types.ManifestHardwareConfig]
+	proxies          entityChanges[types.ManifestProxy]
+}
+
+// plan computes the steps from current to desired. Creates and updates run in
+// dependency order, deletes follow in reverse order.
+func (a *manifestApply) plan(current, desired *types.Manifest) {
+	if a.versionSetID == uuid.Nil {
+		a.steps = append(a.steps, types.PlanStep{Action: types.PlanCreate, Kind: "version_set", Name: desired.VersionSet.Name})
+	} else {
+		if desired.VersionSet.Name != "" && desired.VersionSet.Name != current.VersionSet.Name {
+			a.versionSetFields = append(a.versionSetFields, "name")
+		}
+		if desired.VersionSet.Description != current.VersionSet.Description {
+			a.versionSetFields = append(a.versionSetFields, "description")
+		}
+		if len(a.versionSetFields) > 0 {
+			a.steps = append(a.steps, types.PlanStep{
+				Action: types.PlanUpdate, Kind: "version_set", Name: a.versionSetID.String(), Fields: a.versionSetFields,
+			})
+		}
+	}
+
+	var endpointDeletes, groupDeletes, nodeDeletes, hwDeletes, proxyDeletes []types.PlanStep
+	a.endpoints, endpointDeletes = diffEntities("endpoint_config", current.EndpointConfigs, desired.EndpointConfigs,
+		func(e types.ManifestEndpointConfig) string { return e.Name }, &a.steps)
+	a.nodes, nodeDeletes = diffEntities("node", current.Nodes, desired.Nodes,
+		func(n types.ManifestNode) string { return n.SerialNumber }, &a.steps)
+	a.groups, groupDeletes = diffEntities("group", current.Groups, desired.Groups,
+		func(g types.ManifestGroup) string { return g.Name }, &a.steps)
+	a.hwConfigs, hwDeletes = diffEntities("hardware_config", current.HardwareConfigs, desired.HardwareConfigs,
+		types.ManifestHardwareConfig.Key, &a.steps)
+	a.proxies, proxyDeletes = diffEntities("proxy", current.Proxies, desired.Proxies,
+		func(p types.ManifestProxy) string { return p.Name }, &a.steps)
+
+	for _, deletes := range [][]types.PlanStep{proxyDeletes, hwDeletes, groupDeletes, nodeDeletes, endpointDeletes} {
+		a.steps = append(a.steps, deletes...)
+	}
+}
+
+// updateEndpointConfigStatement updates endpoint config $1 of version set $2 from a manifest
+var updateEndpointConfigStatement = map[string]string{
+	types.DatabasePostgres: `
+			UPDATE endpoint_configs
+			SET mutual_auth = $3, no_encryption = $4, asl_key_exchange_method = $5, cipher = $6, updated_at = NOW()
+			WHERE name = $1 AND version_set_id = $2`,
+	types.DatabaseSqlite: `
+			UPDATE endpoint_configs
+			SET mutual_auth = $3, no_encryption = $4, asl_key_exchange_method = $5, cipher = $6, updated_at = CURRENT_TIMESTAMP
+			WHERE name = $1 AND version_set_id = $2`,
+}
+
+// updateNodeStatement updates node $1 of version set $2 from a manifest
+var updateNodeStatement = map[string]string{
+	types.DatabasePostgres: `
+			UPDATE nodes SET network_index = $3, locality = $4, updated_at = NOW()
+			WHERE serial_number = $1 AND version_set_id = $2`,
+	types.DatabaseSqlite: `
+			UPDATE nodes SET network_index = $3, locality = $4, updated_at = CURRENT_TIMESTAMP
+			WHERE serial_number = $1 AND version_set_id = $2`,
+}
+
+// updateGroupStatement updates group $1 of version set $2 from a manifest
+var updateGroupStatement = map[string]string{
+	types.DatabasePostgres: `
+			UPDATE groups SET log_level = $3, endpoint_config_name = $4, legacy_config_name = $5, updated_at = NOW()
+			WHERE name = $1 AND version_set_id = $2`,
+	types.DatabaseSqlite: `
+			UPDATE groups SET log_level = $3, endpoint_config_name = $4, legacy_config_name = $5, updated_at = CURRENT_TIMESTAMP
+			WHERE name = $1 AND version_set_id = $2`,
+}
+
+// updateProxyStatement updates proxy $1 of version set $2 from a manifest
+var updateProxyStatement = map[string]string{
+	types.DatabasePostgres: `
+			UPDATE proxies
+			SET node_serial = $3, group_name = $4, state = $5, proxy_type = $6,
+				server_endpoint_addr = $7, client_endpoint_addr = $8, updated_at = NOW()
+			WHERE name = $1 AND version_set_id = $2`,
+	types.DatabaseSqlite: `
+			UPDATE proxies
+			SET node_serial = $3, group_name = $4, state = $5, proxy_type = $6,
+				server_endpoint_addr = $7, client_endpoint_addr = $8, updated_at = CURRENT_TIMESTAMP
+			WHERE name = $1 AND version_set_id = $2`,
+}
+
+// origin returns the author and creation time of a created entity. Entities
+// without origin are created now by the author of the manifest.
+func (a *manifestApply) origin(kind, key string) (string, time.Time) {
+	if origin, ok := a.origins[types.OriginKey(kind, key)]; ok && origin.CreatedBy != "" && !origin.CreatedAt.IsZero() {
+		return origin.CreatedBy, origin.CreatedAt
+	}
+	return a.createdBy, time.Now().UTC()
+}
+
+func (a *manifestApply) execute(ctx context.Context, m *types.Manifest, hwIDs map[string][]int) error {
+	tx := a.tx
+	if a.versionSetID == uuid.Nil {
+		err := tx.QueryRow(ctx, `
+			INSERT INTO version_sets (name, description, created_by, metadata)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id`,
+			m.VersionSet.Name, m.VersionSet.Description, a.createdBy, a.metadata,
+		).Scan(&a.versionSetID)
+		if err != nil {
+			return fmt.Errorf("failed to create version set: %w", err)
+		}
+	} else if len(a.versionSetFields) > 0 {
+		name := m.VersionSet.Name
+		_, err := tx.Exec(ctx, `
+			UPDATE version_sets SET name = COALESCE(NULLIF($2, ''), name), description = $3
+			WHERE id = $1`,
+			a.versionSetID, name, m.VersionSet.Description)
+		if err != nil {
+			return fmt.Errorf("failed to update version set: %w", err)
+		}
+	}
+	id := a.versionSetID
+
+	for _, e := range a.endpoints.create {
+		createdBy, createdAt := a.origin("endpoint_config", e.Name)
+		_, err := tx.Exec(ctx, `
+			INSERT INTO endpoint_configs (name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id, created_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			e.Name, e.MutualAuth, e.NoEncryption, e.ASLKeyExchangeMethod, nullString(e.Cipher), id, createdBy, createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to create endpoint config %s: %w", e.Name, err)
+		}
+	}
+	for _, e := range a.endpoints.update {
+		_, err := tx.Exec(ctx, updateEndpointConfigStatement[tx.Dialect()],
+			e.Name, id, e.MutualAuth, e.NoEncryption, e.ASLKeyExchangeMethod, nullString(e.Cipher))
+		if err != nil {
+			return fmt.Errorf("failed to update endpoint config %s: %w", e.Name, err)
+		}
+	}
+
+	for _, n := range a.nodes.create {
+		createdBy, createdAt := a.origin("node", n.SerialNumber)
+		_, err := tx.Exec(ctx, `
+			INSERT INTO nodes (serial_number, network_index, locality, version_set_id, created_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			n.SerialNumber, n.NetworkIndex, n.Locality, id, createdBy, createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to create node %s: %w", n.SerialNumber, err)
+		}
+	}
+	for _, n := range a.nodes.update {
+		_, err := tx.Exec(ctx, updateNodeStatement[tx.Dialect()],
+			n.SerialNumber, id, n.NetworkIndex, n.Locality)
+		if err != nil {
+			return fmt.Errorf("failed to update node %s: %w", n.SerialNumber, err)
+		}
+	}
+
+	for _, g := range a.groups.create {
+		createdBy, createdAt := a.origin("group", g.Name)
+		_, err := tx.Exec(ctx, `
+			INSERT INTO groups (name, log_level, endpoint_config_name, legacy_config_name, version_set_id, created_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			g.Name, g.LogLevel, nullString(g.EndpointConfig), nullString(g.LegacyConfig), id, createdBy, createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to create group %s: %w", g.Name, err)
+		}
+	}
+	for _, g := range a.groups.update {
+		_, err := tx.Exec(ctx, updateGroupStatement[tx.Dialect()],
+			g.Name, id, g.LogLevel, nullString(g.EndpointConfig), nullString(g.LegacyConfig))
+		if err != nil {
+			return fmt.Errorf("failed to update group %s: %w", g.Name, err)
+		}
+	}
+
+	for _, h := range a.hwConfigs.create {
+		createdBy, createdAt := a.origin("hardware_config", h.Key())
+		_, err := tx.Exec(ctx, `
+			INSERT INTO hardware_configs (node_serial, device, ip_cidr, version_set_id, created_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			h.Node, h.Device, h.IPCIDR, id, createdBy, createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to create hardware config %s: %w", h.Key(), err)
+		}
+	}
+
+	for _, p := range a.proxies.create {
+		createdBy, createdAt := a.origin("proxy", p.Name)
+		_, err := tx.Exec(ctx, `
+			INSERT INTO proxies (name, node_serial, group_name, state, proxy_type, server_endpoint_addr, client_endpoint_addr, version_set_id, created_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			p.Name, p.Node, p.Group, *p.State, p.ProxyType, p.ServerEndpointAddr, p.ClientEndpointAddr, id, createdBy, createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to create proxy %s: %w", p.Name, err)
+		}
+	}
+	for _, p := range a.proxies.update {
+		_, err := tx.Exec(ctx, updateProxyStatement[tx.Dialect()],
+			p.Name, id, p.Node, p.Group, *p.State, p.ProxyType, p.ServerEndpointAddr, p.ClientEndpointAddr)
+		if err != nil {
+			return fmt.Errorf("failed to update proxy %s: %w", p.Name, err)
+		}
+	}
+
+	for _, p := range a.proxies.delete {
+		if _, err := tx.Exec(ctx, `DELETE FROM proxies WHERE name = $1 AND version_set_id = $2`, p.Name, id); err != nil {
+			return fmt.Errorf("failed to delete proxy %s: %w", p.Name, err)
+		}
+	}
+	for _, h := range a.hwConfigs.delete {
+		for _, hwID := range hwIDs[h.Key()] {
+			if _, err := tx.Exec(ctx, `DELETE FROM hardware_configs WHERE id = $1`, hwID); err != nil {
+				return fmt.Errorf("failed to delete hardware config %s: %w", h.Key(), err)
+			}
+		}
+	}
+	for _, g := range a.groups.delete {
+		if _, err := tx.Exec(ctx, `DELETE FROM groups WHERE name = $1 AND version_set_id = $2`, g.Name, id); err != nil {
+			return fmt.Errorf("failed to delete group %s: %w", g.Name, err)
+		}
+	}
+	for _, n := range a.nodes.delete {
+		if _, err := tx.Exec(ctx, `DELETE FROM nodes WHERE serial_number = $1 AND version_set_id = $2`, n.SerialNumber, id); err != nil {
+			return fmt.Errorf("failed to delete node %s: %w", n.SerialNumber, err)
+		}
+	}
+	for _, e := range a.endpoints.delete {
+		if _, err := tx.Exec(ctx, `DELETE FROM endpoint_configs WHERE name = $1 AND version_set_id = $2`, e.Name, id); err != nil {
+			return fmt.Errorf("failed to delete endpoint config %s: %w", e.Name, err)
+		}
+	}
+	return nil
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
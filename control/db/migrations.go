@@ -0,0 +1,398 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// migration is a single numbered schema step. Versions must be strictly
+// increasing and never reused once released, otherwise live controllers can
+// not be upgraded in place.
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// migrations holds every schema step of the Postgres backend in order. New
+// steps are appended at the end; existing steps must not be modified after
+// they have been released. The SQLite backend has its own list in
+// migrations_sqlite.go with the same versions.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "baseline schema",
+		up:      schemaSQL,
+		down:    dropSQL,
+	},
+	{
+		version: 2,
+		name:    "transaction metadata",
+		up:      `ALTER TABLE transactions ADD COLUMN IF NOT EXISTS metadata JSONB;`,
+		down:    `ALTER TABLE transactions DROP COLUMN IF EXISTS metadata;`,
+	},
+	{
+		version: 3,
+		name:    "transaction state",
+		up: `
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS state transaction_state;
+UPDATE transactions t SET state = CASE
+    WHEN EXISTS (SELECT 1 FROM transaction_log l WHERE l.transaction_id = t.id AND l.state = 'error') THEN 'error'::transaction_state
+    ELSE 'applied'::transaction_state END
+WHERE t.completed_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transaction_log_node ON transaction_log (node_serial, timestamp);`,
+		down: `
+DROP INDEX IF EXISTS idx_transaction_log_node;
+ALTER TABLE transactions DROP COLUMN IF EXISTS state;`,
+	},
+	{
+		version: 4,
+		name:    "maintenance windows and scheduled activations",
+		up: `
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    locality TEXT NOT NULL,
+    days TEXT NOT NULL DEFAULT '',
+    start_time TEXT NOT NULL,
+    duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0),
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE TABLE IF NOT EXISTS scheduled_activations (
+    id SERIAL PRIMARY KEY,
+    version_set_id UUID NOT NULL REFERENCES version_sets(id) ON DELETE CASCADE,
+    group_name TEXT,
+    run_at TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'running', 'started', 'failed', 'cancelled')),
+    force BOOLEAN NOT NULL DEFAULT false,
+    ignore_window BOOLEAN NOT NULL DEFAULT false,
+    transaction_id INTEGER REFERENCES transactions(id),
+    message TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_scheduled_activations_due ON scheduled_activations (status, run_at);`,
+		down: `
+DROP TABLE IF EXISTS scheduled_activations;
+DROP TABLE IF EXISTS maintenance_windows;`,
+	},
+	{
+		version: 5,
+		name:    "version set reviews",
+		up: `
+CREATE TABLE IF NOT EXISTS version_set_reviews (
+    id SERIAL PRIMARY KEY,
+    version_set_id UUID NOT NULL REFERENCES version_sets(id) ON DELETE CASCADE,
+    action TEXT NOT NULL CHECK (action IN ('submitted', 'approved', 'rejected', 'commented')),
+    user_name TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    diff TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_version_set_reviews_version_set ON version_set_reviews (version_set_id, id);`,
+		down: `
+DROP TABLE IF EXISTS version_set_reviews;`,
+	},
+	{
+		version: 6,
+		name:    "node presence",
+		up: `
+CREATE TABLE IF NOT EXISTS node_presence (
+    serial_number TEXT PRIMARY KEY,
+    connected BOOLEAN NOT NULL DEFAULT false,
+    connected_at TIMESTAMPTZ,
+    disconnected_at TIMESTAMPTZ,
+    remote_addr TEXT NOT NULL DEFAULT '',
+    disconnect_reason TEXT NOT NULL DEFAULT ''
+);`,
+		down: `
+DROP TABLE IF EXISTS node_presence;`,
+	},
+	{
+		version: 7,
+		name:    "node inventory",
+		up: `
+CREATE TABLE IF NOT EXISTS node_inventory (
+    serial_number TEXT PRIMARY KEY,
+    agent_version TEXT NOT NULL DEFAULT '',
+    firmware_version TEXT NOT NULL DEFAULT '',
+    hardware_model TEXT NOT NULL DEFAULT '',
+    uptime BIGINT NOT NULL DEFAULT 0,
+    version_set_id TEXT NOT NULL DEFAULT '',
+    tx_id INTEGER NOT NULL DEFAULT 0,
+    kex_methods TEXT NOT NULL DEFAULT '',
+    ciphers TEXT NOT NULL DEFAULT '',
+    reported_at TIMESTAMPTZ NOT NULL
+);`,
+		down: `
+DROP TABLE IF EXISTS node_inventory;`,
+	},
+	{
+		version: 8,
+		name:    "config drift",
+		up: `
+ALTER TABLE node_inventory ADD COLUMN IF NOT EXISTS config_hash TEXT NOT NULL DEFAULT '';
+CREATE TABLE IF NOT EXISTS node_drift_events (
+    id SERIAL PRIMARY KEY,
+    serial_number TEXT NOT NULL,
+    version_set_id UUID NOT NULL,
+    expected_hash TEXT NOT NULL,
+    reported_hash TEXT NOT NULL,
+    reported_version_set_id TEXT NOT NULL DEFAULT '',
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_node_drift_events_serial ON node_drift_events (serial_number, id);`,
+		down: `
+DROP TABLE IF EXISTS node_drift_events;
+ALTER TABLE node_inventory DROP COLUMN IF EXISTS config_hash;`,
+	},
+	{
+		version: 9,
+		name:    "pending node updates",
+		up: `
+CREATE TABLE IF NOT EXISTS pending_node_updates (
+    id SERIAL PRIMARY KEY,
+    serial_number TEXT NOT NULL,
+    version_set_id UUID NOT NULL REFERENCES version_sets(id) ON DELETE CASCADE,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id),
+    source_transaction_id INTEGER REFERENCES transactions(id),
+    update_item TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'delivering', 'delivered', 'failed', 'expired', 'superseded')),
+    message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    claimed_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_pending_node_updates_status ON pending_node_updates (status, serial_number);
+CREATE INDEX IF NOT EXISTS idx_pending_node_updates_source ON pending_node_updates (source_transaction_id, status);`,
+		down: `
+DROP TABLE IF EXISTS pending_node_updates;`,
+	},
+}
+
+// migrationLockID is the advisory lock key taken while migrating, so that two
+// controllers starting against the same database do not migrate concurrently.
+const migrationLockID = 4_730_301
+
+const schemaMigrationsSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);`
+
+func (s *StateManager) schemaMigrationsSQL() string {
+	if s.store.Dialect() == types.DatabaseSqlite {
+		return sqliteSchemaMigrationsSQL
+	}
+	return schemaMigrationsSQL
+}
+
+// dialectMigrations returns the migrations of the given backend
+func dialectMigrations(dialect string) []migration {
+	if dialect == types.DatabaseSqlite {
+		return sqliteMigrations
+	}
+	return migrations
+}
+
+func sortedMigrations(list []migration) []migration {
+	sorted := make([]migration, len(list))
+	copy(sorted, list)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].version < sorted[j].version })
+	return sorted
+}
+
+// LatestSchemaVersion returns the version of the newest known migration of
+// the given backend.
+func LatestSchemaVersion(dialect string) int {
+	sorted := sortedMigrations(dialectMigrations(dialect))
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[len(sorted)-1].version
+}
+
+// lockMigrations serialises migrations between controllers. SQLite only has a
+// single writer, so there is nothing to lock there.
+func (s *StateManager) lockMigrations(ctx context.Context, tx Tx) error {
+	if s.store.Dialect() == types.DatabasePostgres {
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockID); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+	}
+	if _, err := tx.Exec(ctx, s.schemaMigrationsSQL()); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func appliedMigrations(ctx context.Context, tx Tx) (map[int]time.Time, error) {
+	applied := make(map[int]time.Time)
+	rows, err := tx.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// SchemaVersion returns the highest applied migration, or 0 for an empty database.
+func (s *StateManager) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if _, err := tx.Exec(ctx, s.schemaMigrationsSQL()); err != nil {
+			return err
+		}
+		return tx.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to get schema version")
+		return 0, err
+	}
+	return version, nil
+}
+
+// MigrateUp applies all pending migrations up to and including target.
+// A target of 0 migrates to the latest version. Every step runs in its own
+// transaction, so a failing step leaves the previous steps applied.
+func (s *StateManager) MigrateUp(ctx context.Context, target int) ([]int, error) {
+	if target == 0 {
+		target = LatestSchemaVersion(s.store.Dialect())
+	}
+
+	var done []int
+	for _, m := range sortedMigrations(dialectMigrations(s.store.Dialect())) {
+		if m.version > target {
+			break
+		}
+
+		applied := false
+		err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+			if err := s.lockMigrations(ctx, tx); err != nil {
+				return err
+			}
+			current, err := appliedMigrations(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if _, ok := current[m.version]; ok {
+				return nil
+			}
+
+			log.Info().Msgf("applying migration %d: %s", m.version, m.name)
+			if _, err := tx.Exec(ctx, m.up); err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+			}
+			if _, err := tx.Exec(ctx,
+				"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+				m.version, m.name); err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+			}
+			applied = true
+			return nil
+		})
+		if err != nil {
+			log.Err(err).Msg("failed to migrate up")
+			return done, err
+		}
+		if applied {
+			done = append(done, m.version)
+		}
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the given number of applied migrations, newest first.
+func (s *StateManager) MigrateDown(ctx context.Context, steps int) ([]int, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be positive")
+	}
+
+	sorted := sortedMigrations(dialectMigrations(s.store.Dialect()))
+	var done []int
+	for i := len(sorted) - 1; i >= 0 && len(done) < steps; i-- {
+		m := sorted[i]
+
+		reverted := false
+		err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+			if err := s.lockMigrations(ctx, tx); err != nil {
+				return err
+			}
+			current, err := appliedMigrations(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if _, ok := current[m.version]; !ok {
+				return nil
+			}
+
+			log.Info().Msgf("reverting migration %d: %s", m.version, m.name)
+			if _, err := tx.Exec(ctx, m.down); err != nil {
+				return fmt.Errorf("revert of migration %d (%s) failed: %w", m.version, m.name, err)
+			}
+			if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", m.version); err != nil {
+				return fmt.Errorf("failed to remove migration record %d: %w", m.version, err)
+			}
+			reverted = true
+			return nil
+		})
+		if err != nil {
+			log.Err(err).Msg("failed to migrate down")
+			return done, err
+		}
+		if reverted {
+			done = append(done, m.version)
+		}
+	}
+	return done, nil
+}
+
+// MigrationStatus lists every known migration together with its applied state.
+func (s *StateManager) MigrationStatus(ctx context.Context) ([]*types.SchemaMigration, error) {
+	var status []*types.SchemaMigration
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if _, err := tx.Exec(ctx, s.schemaMigrationsSQL()); err != nil {
+			return err
+		}
+		current, err := appliedMigrations(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for _, m := range sortedMigrations(dialectMigrations(s.store.Dialect())) {
+			entry := &types.SchemaMigration{
+				Version: m.version,
+				Name:    m.name,
+			}
+			if appliedAt, ok := current[m.version]; ok {
+				entry.AppliedAt = &appliedAt
+			}
+			status = append(status, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to get migration status")
+		return nil, err
+	}
+	return status, nil
+}
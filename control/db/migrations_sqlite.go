@@ -0,0 +1,337 @@
+package db
+
+// sqliteMigrations holds the schema steps of the SQLite backend. Every step
+// has the version and name of its Postgres counterpart in migrations, only the
+// statements differ. Released steps must not be modified either.
+var sqliteMigrations = []migration{
+	{
+		version: 1,
+		name:    "baseline schema",
+		up:      sqliteSchemaSQL,
+		down:    sqliteDropSQL,
+	},
+	{
+		version: 2,
+		name:    "transaction metadata",
+		up:      `ALTER TABLE transactions ADD COLUMN metadata BLOB;`,
+		down:    `ALTER TABLE transactions DROP COLUMN metadata;`,
+	},
+	{
+		version: 3,
+		name:    "transaction state",
+		up: `
+ALTER TABLE transactions ADD COLUMN state TEXT CHECK (state IN ('error', 'unknown', 'published', 'received', 'applicable', 'applied'));
+UPDATE transactions SET state = CASE
+    WHEN EXISTS (SELECT 1 FROM transaction_log l WHERE l.transaction_id = transactions.id AND l.state = 'error') THEN 'error'
+    ELSE 'applied' END
+WHERE completed_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transaction_log_node ON transaction_log (node_serial, timestamp);`,
+		down: `
+DROP INDEX IF EXISTS idx_transaction_log_node;
+ALTER TABLE transactions DROP COLUMN state;`,
+	},
+	{
+		version: 4,
+		name:    "maintenance windows and scheduled activations",
+		up: `
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    locality TEXT NOT NULL,
+    days TEXT NOT NULL DEFAULT '',
+    start_time TEXT NOT NULL,
+    duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0),
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS scheduled_activations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_set_id TEXT NOT NULL REFERENCES version_sets(id) ON DELETE CASCADE,
+    group_name TEXT,
+    run_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'running', 'started', 'failed', 'cancelled')),
+    force BOOLEAN NOT NULL DEFAULT false,
+    ignore_window BOOLEAN NOT NULL DEFAULT false,
+    transaction_id INTEGER REFERENCES transactions(id),
+    message TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_scheduled_activations_due ON scheduled_activations (status, run_at);`,
+		down: `
+DROP TABLE IF EXISTS scheduled_activations;
+DROP TABLE IF EXISTS maintenance_windows;`,
+	},
+	{
+		version: 5,
+		name:    "version set reviews",
+		up: `
+CREATE TABLE IF NOT EXISTS version_set_reviews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_set_id TEXT NOT NULL REFERENCES version_sets(id) ON DELETE CASCADE,
+    action TEXT NOT NULL CHECK (action IN ('submitted', 'approved', 'rejected', 'commented')),
+    user_name TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    diff TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_version_set_reviews_version_set ON version_set_reviews (version_set_id, id);`,
+		down: `
+DROP TABLE IF EXISTS version_set_reviews;`,
+	},
+	{
+		version: 6,
+		name:    "node presence",
+		up: `
+CREATE TABLE IF NOT EXISTS node_presence (
+    serial_number TEXT PRIMARY KEY,
+    connected BOOLEAN NOT NULL DEFAULT false,
+    connected_at TIMESTAMP,
+    disconnected_at TIMESTAMP,
+    remote_addr TEXT NOT NULL DEFAULT '',
+    disconnect_reason TEXT NOT NULL DEFAULT ''
+);`,
+		down: `
+DROP TABLE IF EXISTS node_presence;`,
+	},
+	{
+		version: 7,
+		name:    "node inventory",
+		up: `
+CREATE TABLE IF NOT EXISTS node_inventory (
+    serial_number TEXT PRIMARY KEY,
+    agent_version TEXT NOT NULL DEFAULT '',
+    firmware_version TEXT NOT NULL DEFAULT '',
+    hardware_model TEXT NOT NULL DEFAULT '',
+    uptime INTEGER NOT NULL DEFAULT 0,
+    version_set_id TEXT NOT NULL DEFAULT '',
+    tx_id INTEGER NOT NULL DEFAULT 0,
+    kex_methods TEXT NOT NULL DEFAULT '',
+    ciphers TEXT NOT NULL DEFAULT '',
+    reported_at TIMESTAMP NOT NULL
+);`,
+		down: `
+DROP TABLE IF EXISTS node_inventory;`,
+	},
+	{
+		version: 8,
+		name:    "config drift",
+		up: `
+ALTER TABLE node_inventory ADD COLUMN config_hash TEXT NOT NULL DEFAULT '';
+CREATE TABLE IF NOT EXISTS node_drift_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    serial_number TEXT NOT NULL,
+    version_set_id TEXT NOT NULL,
+    expected_hash TEXT NOT NULL,
+    reported_hash TEXT NOT NULL,
+    reported_version_set_id TEXT NOT NULL DEFAULT '',
+    detected_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_node_drift_events_serial ON node_drift_events (serial_number, id);`,
+		down: `
+DROP TABLE IF EXISTS node_drift_events;
+ALTER TABLE node_inventory DROP COLUMN config_hash;`,
+	},
+	{
+		version: 9,
+		name:    "pending node updates",
+		up: `
+CREATE TABLE IF NOT EXISTS pending_node_updates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    serial_number TEXT NOT NULL,
+    version_set_id TEXT NOT NULL REFERENCES version_sets(id) ON DELETE CASCADE,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id),
+    source_transaction_id INTEGER REFERENCES transactions(id),
+    update_item TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'delivering', 'delivered', 'failed', 'expired', 'superseded')),
+    message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    claimed_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pending_node_updates_status ON pending_node_updates (status, serial_number);
+CREATE INDEX IF NOT EXISTS idx_pending_node_updates_source ON pending_node_updates (source_transaction_id, status);`,
+		down: `
+DROP TABLE IF EXISTS pending_node_updates;`,
+	},
+}
+
+// sqliteUUID generates a random version 4 UUID in canonical text form, the
+// SQLite counterpart of gen_random_uuid().
+const sqliteUUID = `(lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' ||
+    substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) ||
+    substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6))))`
+
+// sqliteSchemaSQL is the SQLite variant of schemaSQL. Enums are stored as
+// checked TEXT, UUIDs as canonical text and JSONB as BLOB. Tables with a
+// composite primary key in Postgres use the serial id as rowid instead and keep
+// the composite key as UNIQUE constraint, which foreign keys can reference.
+const sqliteSchemaSQL = `
+CREATE TABLE IF NOT EXISTS transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type TEXT NOT NULL CHECK (type IN ('node_update', 'group_update', 'version_update')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    description TEXT
+);
+
+CREATE TABLE IF NOT EXISTS version_sets (
+    id TEXT PRIMARY KEY DEFAULT ` + sqliteUUID + `,
+    name TEXT NOT NULL,
+    description TEXT,
+    state TEXT NOT NULL DEFAULT 'draft' CHECK (state IN ('draft', 'pending_deployment', 'active', 'disabled')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    activated_at TIMESTAMP,
+    disabled_at TIMESTAMP,
+    created_by TEXT NOT NULL,
+    metadata BLOB
+);
+
+CREATE TABLE IF NOT EXISTS nodes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    serial_number TEXT NOT NULL CHECK (length(serial_number) <= 50),
+    network_index INTEGER NOT NULL,
+    locality TEXT,
+    last_seen TIMESTAMP,
+    version_set_id TEXT REFERENCES version_sets(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by TEXT NOT NULL,
+    UNIQUE (serial_number, version_set_id)
+);
+
+CREATE TABLE IF NOT EXISTS version_transitions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_version_transition INTEGER REFERENCES version_transitions(id),
+    to_version_id TEXT NOT NULL REFERENCES version_sets(id),
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'active', 'failed', 'rollback', 'disabled')),
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    transaction_id INTEGER REFERENCES transactions(id),
+    completed_at TIMESTAMP,
+    disabled_at TIMESTAMP DEFAULT NULL,
+    created_by TEXT NOT NULL,
+    metadata BLOB
+);
+
+CREATE TABLE IF NOT EXISTS transaction_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INTEGER REFERENCES transactions(id) ON DELETE CASCADE,
+    node_serial TEXT NOT NULL,
+    version_set_id TEXT NOT NULL,
+    state TEXT NOT NULL CHECK (state IN ('error', 'unknown', 'published', 'received', 'applicable', 'applied')),
+    timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    metadata BLOB,
+    FOREIGN KEY (node_serial, version_set_id)
+        REFERENCES nodes(serial_number, version_set_id)
+);
+
+CREATE TABLE IF NOT EXISTS endpoint_configs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    mutual_auth BOOLEAN NOT NULL DEFAULT false,
+    no_encryption BOOLEAN NOT NULL DEFAULT false,
+    asl_key_exchange_method TEXT NOT NULL DEFAULT 'ASL_KEX_DEFAULT',
+    cipher TEXT,
+    version_set_id TEXT REFERENCES version_sets(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by TEXT NOT NULL,
+    UNIQUE (name, version_set_id)
+);
+
+CREATE TABLE IF NOT EXISTS groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    log_level INTEGER NOT NULL DEFAULT 0,
+    endpoint_config_name TEXT,
+    legacy_config_name TEXT,
+    version_set_id TEXT REFERENCES version_sets(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by TEXT NOT NULL,
+    UNIQUE (name, version_set_id),
+    FOREIGN KEY (endpoint_config_name, version_set_id)
+        REFERENCES endpoint_configs(name, version_set_id),
+    FOREIGN KEY (legacy_config_name, version_set_id)
+        REFERENCES endpoint_configs(name, version_set_id)
+);
+
+CREATE TABLE IF NOT EXISTS hardware_configs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    node_serial TEXT NOT NULL,
+    device TEXT NOT NULL,
+    ip_cidr TEXT NOT NULL,
+    version_set_id TEXT REFERENCES version_sets(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by TEXT NOT NULL,
+    FOREIGN KEY (node_serial, version_set_id)
+        REFERENCES nodes(serial_number, version_set_id)
+);
+
+CREATE TABLE IF NOT EXISTS proxies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    node_serial TEXT NOT NULL,
+    group_name TEXT NOT NULL,
+    state BOOLEAN NOT NULL DEFAULT true,
+    proxy_type TEXT NOT NULL CHECK (proxy_type IN ('not_specifed', 'not_specified', 'forward', 'reverse', 'tlstls')),
+    server_endpoint_addr TEXT NOT NULL,
+    client_endpoint_addr TEXT NOT NULL,
+    version_set_id TEXT REFERENCES version_sets(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by TEXT NOT NULL,
+    UNIQUE (name, version_set_id),
+    FOREIGN KEY (node_serial, version_set_id)
+        REFERENCES nodes(serial_number, version_set_id),
+    FOREIGN KEY (group_name, version_set_id)
+        REFERENCES groups(name, version_set_id)
+);
+
+CREATE TABLE IF NOT EXISTS enroll (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    est_serial_number VARCHAR(255),
+    serial_number TEXT NOT NULL CHECK (length(serial_number) <= 50),
+    organization VARCHAR(255),
+    issued_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    signature_algorithm VARCHAR(120),
+    plane VARCHAR(80),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_version_transitions_status ON version_transitions(status);
+CREATE INDEX IF NOT EXISTS idx_nodes_version ON nodes(version_set_id);
+CREATE INDEX IF NOT EXISTS idx_proxies_version ON proxies(version_set_id);
+CREATE INDEX IF NOT EXISTS idx_hwconfig_version ON hardware_configs(version_set_id);
+CREATE INDEX IF NOT EXISTS idx_groups_version ON groups(version_set_id);
+CREATE INDEX IF NOT EXISTS idx_endpoint_version ON endpoint_configs(version_set_id);
+`
+
+// sqliteDropSQL is the SQLite variant of dropSQL. SQLite has no CASCADE, so
+// tables are dropped children first.
+const sqliteDropSQL = `
+	drop table if exists transaction_log;
+	drop table if exists proxies;
+	drop table if exists hardware_configs;
+	drop table if exists groups;
+	drop table if exists endpoint_configs;
+	drop table if exists version_transitions;
+	drop table if exists nodes;
+	drop table if exists version_sets;
+	drop table if exists transactions;
+	drop table if exists enroll;
+	`
+
+const sqliteSchemaMigrationsSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`
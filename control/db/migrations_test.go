@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// releasedMigrations pins the statements of every released migration. A
+// database which applied a version never runs it again, so a changed step
+// has to become a new migration instead. Only the baseline schema has shipped
+// so far; the steps added since are pinned once they are released.
+var releasedMigrations = map[string]map[int]string{
+	types.DatabasePostgres: {
+		1: "5d7fdfb44b005a5e4e0e1c27908359dc956696908f10a699ee4a142cd89f2f3c",
+	},
+	types.DatabaseSqlite: {
+		1: "bc933be81198de4bfc64bd5a39d22ebb223227abc9410dc0789113007f55425d",
+	},
+}
+
+func migrationChecksum(m migration) string {
+	sum := sha256.Sum256([]byte(m.up + "\x00" + m.down))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestReleasedMigrationsUnchanged(t *testing.T) {
+	lists := map[string][]migration{
+		types.DatabasePostgres: migrations,
+		types.DatabaseSqlite:   sqliteMigrations,
+	}
+	for dialect, list := range lists {
+		for _, m := range list {
+			want, ok := releasedMigrations[dialect][m.version]
+			if !ok {
+				continue
+			}
+			if got := migrationChecksum(m); got != want {
+				t.Errorf("%s migration %d (%s) was modified after its release, add a new migration instead", dialect, m.version, m.name)
+			}
+		}
+	}
+}
+
+func TestMigrationsMatchBetweenDialects(t *testing.T) {
+	postgres := sortedMigrations(migrations)
+	sqlite := sortedMigrations(sqliteMigrations)
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("postgres has %d migrations, sqlite has %d", len(postgres), len(sqlite))
+	}
+	if pg, lite := LatestSchemaVersion(types.DatabasePostgres), LatestSchemaVersion(types.DatabaseSqlite); pg != lite {
+		t.Errorf("latest postgres version is %d, latest sqlite version is %d", pg, lite)
+	}
+	for i := range postgres {
+		if postgres[i].version != sqlite[i].version || postgres[i].name != sqlite[i].name {
+			t.Errorf("migration %d (%s) has no sqlite counterpart, found %d (%s)",
+				postgres[i].version, postgres[i].name, sqlite[i].version, sqlite[i].name)
+		}
+	}
+}
+
+func TestSqliteMigrateUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	sm := newSqliteStateManager(t)
+	latest := LatestSchemaVersion(types.DatabaseSqlite)
+
+	applied, err := sm.MigrateUp(ctx, 0)
+	if err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if len(applied) != latest {
+		t.Fatalf("applied %v, want all %d migrations", applied, latest)
+	}
+	version, err := sm.SchemaVersion(ctx)
+	if err != nil || version != latest {
+		t.Fatalf("schema version %d (%v), want %d", version, err, latest)
+	}
+
+	reverted, err := sm.MigrateDown(ctx, latest)
+	if err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	if len(reverted) != latest {
+		t.Fatalf("reverted %v, want all %d migrations", reverted, latest)
+	}
+	if _, err := sm.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("migrate up after down: %v", err)
+	}
+}
+
+// newSqliteStateManager opens a StateManager on an empty SQLite database
+// which is removed with the test
+func newSqliteStateManager(t *testing.T) *StateManager {
+	t.Helper()
+	sm, err := NewStateManager(context.Background(), types.DatabaseConfig{
+		Type:       types.DatabaseSqlite,
+		SqlitePath: filepath.Join(t.TempDir(), "scale.db"),
+	})
+	if err != nil {
+		t.Fatalf("failed to open sqlite database: %v", err)
+	}
+	t.Cleanup(sm.Close)
+	return sm
+}
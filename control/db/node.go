@@ -4,12 +4,14 @@ import (
 	"context"
 
 	"github.com/gofrs/uuid/v5"
-	"github.com/jackc/pgx/v5"
 	"github.com/philslol/kritis3m_scalev2/control/types"
 )
 
 func (s *StateManager) CreateNode(ctx context.Context, node *types.Node) (*types.Node, error) {
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if err := lockDraft(ctx, tx, node.VersionSetID); err != nil {
+			return err
+		}
 		query := `
 		INSERT INTO nodes (serial_number, network_index, locality, version_set_id, created_by)
 		VALUES ($1, $2, $3, $4, $5)
@@ -33,7 +35,7 @@ func (s *StateManager) CreateNode(ctx context.Context, node *types.Node) (*types
 
 func (s *StateManager) GetNodebyID(ctx context.Context, Id int) (*types.Node, error) {
 	node := &types.Node{}
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 		SELECT id, serial_number, network_index, locality, last_seen, version_set_id, 
 		       created_at, updated_at, created_by
@@ -68,7 +70,7 @@ func (s *StateManager) GetNodebyID(ctx context.Context, Id int) (*types.Node, er
 func (s *StateManager) ListNodes(ctx context.Context, versionSetID *uuid.UUID) ([]*types.Node, error) {
 	var nodes []*types.Node
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 
 		var query string
 		var args []interface{}
@@ -121,12 +123,12 @@ func (s *StateManager) ListNodes(ctx context.Context, versionSetID *uuid.UUID) (
 }
 
 func (s *StateManager) DeleteNode(ctx context.Context, serialNumber string, versionSetID uuid.UUID) error {
-	return s.Delete(ctx, "nodes", "serial_number", serialNumber)
+	return s.DeleteDraftWhere(ctx, "nodes", "serial_number = $1 AND version_set_id = $2", serialNumber, versionSetID)
 }
 
 func (s *StateManager) GetNodebySerial(ctx context.Context, serialNumber string, versionSetID uuid.UUID) (*types.Node, error) {
 	node := &types.Node{}
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 
 		query := `
 		SELECT id, serial_number, network_index, locality, last_seen, version_set_id, 
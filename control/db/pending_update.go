@@ -0,0 +1,288 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const pendingUpdateColumns = `id, serial_number, version_set_id, transaction_id, source_transaction_id, update_item, status, message,
+	created_at, expires_at, claimed_at, finished_at`
+
+func scanPendingUpdate(row Row) (*types.PendingUpdate, error) {
+	var u types.PendingUpdate
+	var item string
+	if err := row.Scan(&u.ID, &u.SerialNumber, &u.VersionSetID, &u.TransactionID, &u.SourceTransactionID, &item, &u.Status, &u.Message,
+		&u.CreatedAt, &u.ExpiresAt, &u.ClaimedAt, &u.FinishedAt); err != nil {
+		return nil, err
+	}
+	u.Item = &grpc_controlplane.NodeUpdateItem{}
+	if err := protojson.Unmarshal([]byte(item), u.Item); err != nil {
+		return nil, fmt.Errorf("invalid update item of pending update %d: %w", u.ID, err)
+	}
+	return &u, nil
+}
+
+// supersedeQueuedUpdatesStatement supersedes the queued updates of node $1
+var supersedeQueuedUpdatesStatement = map[string]string{
+	types.DatabasePostgres: `
+			UPDATE pending_node_updates
+			SET status = 'superseded', message = $2, finished_at = NOW()
+			WHERE serial_number = $1 AND status = 'queued'
+			RETURNING transaction_id`,
+	types.DatabaseSqlite: `
+			UPDATE pending_node_updates
+			SET status = 'superseded', message = $2, finished_at = CURRENT_TIMESTAMP
+			WHERE serial_number = $1 AND status = 'queued'
+			RETURNING transaction_id`,
+}
+
+// EnqueueNodeUpdate queues an update for an offline node. A queued update of
+// the same node is superseded, the transactions of the superseded updates are
+// returned so they can be completed.
+func (s *StateManager) EnqueueNodeUpdate(ctx context.Context, u *types.PendingUpdate) ([]int, error) {
+	item, err := protojson.Marshal(u.Item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode update of %s: %w", u.SerialNumber, err)
+	}
+
+	var superseded []int
+	err = s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		err := queryEach(ctx, tx, supersedeQueuedUpdatesStatement[s.Dialect()],
+			[]any{u.SerialNumber, fmt.Sprintf("superseded by transaction %d", u.TransactionID)},
+			func(rows Rows) error {
+				var id int
+				if err := rows.Scan(&id); err != nil {
+					return err
+				}
+				superseded = append(superseded, id)
+				return nil
+			})
+		if err != nil {
+			return err
+		}
+
+		return tx.QueryRow(ctx, `
+			INSERT INTO pending_node_updates (serial_number, version_set_id, transaction_id, source_transaction_id, update_item, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, status, created_at`,
+			u.SerialNumber, u.VersionSetID, u.TransactionID, u.SourceTransactionID, string(item), u.ExpiresAt,
+		).Scan(&u.ID, &u.Status, &u.CreatedAt)
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to queue update of %s", u.SerialNumber)
+		return nil, fmt.Errorf("failed to queue update of %s: %w", u.SerialNumber, err)
+	}
+	return superseded, nil
+}
+
+// SupersedeQueuedUpdates supersedes the updates still queued by the fleet
+// update or rollout sourceTx and returns their transactions, so that they can
+// be completed
+func (s *StateManager) SupersedeQueuedUpdates(ctx context.Context, sourceTx int, message string) ([]int, error) {
+	var superseded []int
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		return queryEach(ctx, tx, `
+			UPDATE pending_node_updates
+			SET status = 'superseded', message = $2, finished_at = CURRENT_TIMESTAMP
+			WHERE source_transaction_id = $1 AND status = 'queued'
+			RETURNING transaction_id`,
+			[]any{sourceTx, message},
+			func(rows Rows) error {
+				var id int
+				if err := rows.Scan(&id); err != nil {
+					return err
+				}
+				superseded = append(superseded, id)
+				return nil
+			})
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to supersede updates queued by transaction %d", sourceTx)
+		return nil, fmt.Errorf("failed to supersede updates queued by transaction %d: %w", sourceTx, err)
+	}
+	return superseded, nil
+}
+
+// SourceUpdateCounts counts the updates which the fleet update or rollout
+// sourceTx queued, by their status
+func (s *StateManager) SourceUpdateCounts(ctx context.Context, sourceTx int) (map[types.PendingUpdateStatus]int, error) {
+	counts := make(map[types.PendingUpdateStatus]int)
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		return queryEach(ctx, tx, `
+			SELECT status, COUNT(*) FROM pending_node_updates
+			WHERE source_transaction_id = $1
+			GROUP BY status`, []any{sourceTx}, func(rows Rows) error {
+			var status string
+			var count int
+			if err := rows.Scan(&status, &count); err != nil {
+				return err
+			}
+			counts[types.PendingUpdateStatus(status)] = count
+			return nil
+		})
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to count updates queued by transaction %d", sourceTx)
+		return nil, fmt.Errorf("failed to count updates queued by transaction %d: %w", sourceTx, err)
+	}
+	return counts, nil
+}
+
+// QueuedUpdate returns the queued update of a node, ErrNoRows if there is none
+func (s *StateManager) QueuedUpdate(ctx context.Context, serialNumber string) (*types.PendingUpdate, error) {
+	var u *types.PendingUpdate
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		var err error
+		u, err = scanPendingUpdate(tx.QueryRow(ctx, `
+			SELECT `+pendingUpdateColumns+` FROM pending_node_updates
+			WHERE serial_number = $1 AND status = 'queued'`, serialNumber))
+		return err
+	})
+	if err != nil {
+		if IsNoRows(err) {
+			return nil, err
+		}
+		log.Err(err).Msgf("failed to get queued update of %s", serialNumber)
+		return nil, fmt.Errorf("failed to get queued update of %s: %w", serialNumber, err)
+	}
+	return u, nil
+}
+
+// ClaimQueuedUpdate marks a queued update as being delivered. It returns false
+// if the update is no longer queued, another hello claimed it first or it
+// expired or was superseded in the meantime.
+func (s *StateManager) ClaimQueuedUpdate(ctx context.Context, id int, now time.Time) (bool, error) {
+	var claimed bool
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE pending_node_updates SET status = 'delivering', claimed_at = $2
+			WHERE id = $1 AND status = 'queued'`, id, now)
+		if err != nil {
+			return err
+		}
+		claimed = tag.RowsAffected() == 1
+		return nil
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to claim pending update %d", id)
+		return false, fmt.Errorf("failed to claim pending update %d: %w", id, err)
+	}
+	return claimed, nil
+}
+
+// finishPendingUpdateStatement sets the final status of pending update $1
+var finishPendingUpdateStatement = map[string]string{
+	types.DatabasePostgres: `
+			UPDATE pending_node_updates SET status = $2, message = $3, finished_at = NOW()
+			WHERE id = $1`,
+	types.DatabaseSqlite: `
+			UPDATE pending_node_updates SET status = $2, message = $3, finished_at = CURRENT_TIMESTAMP
+			WHERE id = $1`,
+}
+
+// FinishPendingUpdate sets the final status of a pending update
+func (s *StateManager) FinishPendingUpdate(ctx context.Context, id int, status types.PendingUpdateStatus, message string) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		_, err := tx.Exec(ctx, finishPendingUpdateStatement[s.Dialect()], id, string(status), message)
+		return err
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to finish pending update %d", id)
+		return fmt.Errorf("failed to finish pending update %d: %w", id, err)
+	}
+	return nil
+}
+
+// ExpireQueuedUpdates marks the queued updates which expired before now and
+// returns them
+func (s *StateManager) ExpireQueuedUpdates(ctx context.Context, now time.Time) ([]*types.PendingUpdate, error) {
+	var expired []*types.PendingUpdate
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		err := queryEach(ctx, tx, `
+			SELECT `+pendingUpdateColumns+` FROM pending_node_updates
+			WHERE status = 'queued' AND expires_at <= $1
+			ORDER BY id`, []any{now}, func(rows Rows) error {
+			u, err := scanPendingUpdate(rows)
+			if err != nil {
+				return err
+			}
+			expired = append(expired, u)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, u := range expired {
+			_, err := tx.Exec(ctx, `
+				UPDATE pending_node_updates SET status = 'expired', message = 'node did not return in time', finished_at = $2
+				WHERE id = $1`, u.ID, now)
+			if err != nil {
+				return err
+			}
+			u.Status = types.PendingUpdateExpired
+		}
+		return nil
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to expire queued updates")
+		return nil, fmt.Errorf("failed to expire queued updates: %w", err)
+	}
+	return expired, nil
+}
+
+// failInterruptedDeliveriesStatement fails the deliveries claimed before $1
+var failInterruptedDeliveriesStatement = map[string]string{
+	types.DatabasePostgres: `
+			UPDATE pending_node_updates
+			SET status = 'failed', message = 'interrupted by a controller restart', finished_at = NOW()
+			WHERE status = 'delivering' AND claimed_at < $1`,
+	types.DatabaseSqlite: `
+			UPDATE pending_node_updates
+			SET status = 'failed', message = 'interrupted by a controller restart', finished_at = CURRENT_TIMESTAMP
+			WHERE status = 'delivering' AND claimed_at < $1`,
+}
+
+// FailInterruptedDeliveries fails the deliveries claimed before a restart of
+// the controller, their transactions are recovered like any other
+func (s *StateManager) FailInterruptedDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	var count int64
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		tag, err := tx.Exec(ctx, failInterruptedDeliveriesStatement[s.Dialect()], before)
+		if err != nil {
+			return err
+		}
+		count = tag.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail interrupted deliveries: %w", err)
+	}
+	return count, nil
+}
+
+// PendingTransactionIDs returns the transactions of updates which are still
+// queued or were claimed after since. They are not interrupted by a restart.
+func (s *StateManager) PendingTransactionIDs(ctx context.Context, since time.Time) (map[int]bool, error) {
+	ids := make(map[int]bool)
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		return queryEach(ctx, tx, `
+			SELECT transaction_id FROM pending_node_updates
+			WHERE status = 'queued' OR (status = 'delivering' AND claimed_at >= $1)`, []any{since}, func(rows Rows) error {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids[id] = true
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending transactions: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// postgresStorage is the pgx backed Storage.
+type postgresStorage struct {
+	pool *pgxpool.Pool
+}
+
+type postgresTx struct {
+	tx pgx.Tx
+}
+
+func (p *postgresStorage) Dialect() string {
+	return types.DatabasePostgres
+}
+
+func (p *postgresStorage) Begin(ctx context.Context) (storageTx, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresTx{tx: tx}, nil
+}
+
+func (p *postgresStorage) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
+func (p *postgresStorage) Close() {
+	p.pool.Close()
+}
+
+func (t *postgresTx) Exec(ctx context.Context, sql string, args ...any) (CommandTag, error) {
+	return t.tx.Exec(ctx, sql, args...)
+}
+
+func (t *postgresTx) Query(ctx context.Context, sql string, args ...any) (Rows, error) {
+	return t.tx.Query(ctx, sql, args...)
+}
+
+func (t *postgresTx) QueryRow(ctx context.Context, sql string, args ...any) Row {
+	return t.tx.QueryRow(ctx, sql, args...)
+}
+
+func (t *postgresTx) Dialect() string {
+	return types.DatabasePostgres
+}
+
+func (t *postgresTx) Commit(ctx context.Context) error {
+	return t.tx.Commit(ctx)
+}
+
+func (t *postgresTx) Rollback(ctx context.Context) error {
+	return t.tx.Rollback(ctx)
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// onlineCondition is the SQL condition for a node which is connected to the
+// broker or sent a hello after the time in the since parameter
+func onlineCondition(serialColumn string, since string) string {
+	return `(last_seen > ` + since + ` OR EXISTS (SELECT 1 FROM node_presence np WHERE np.serial_number = ` +
+		serialColumn + ` AND np.connected))`
+}
+
+// RecordPresence stores a connect or disconnect reported by the broker. A
+// disconnect is ignored if the node connected again in the meantime.
+func (s *StateManager) RecordPresence(ctx context.Context, event types.PresenceEvent) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if event.Connected {
+			query := `
+				INSERT INTO node_presence (serial_number, connected, connected_at, remote_addr, disconnect_reason)
+				VALUES ($1, true, $2, $3, '')
+				ON CONFLICT (serial_number) DO UPDATE
+				SET connected = true, connected_at = $2, remote_addr = $3, disconnect_reason = ''`
+			_, err := tx.Exec(ctx, query, event.ClientID, event.ConnectedAt, event.RemoteAddr)
+			return err
+		}
+		query := `
+			UPDATE node_presence
+			SET connected = false, disconnected_at = $3, disconnect_reason = $4
+			WHERE serial_number = $1 AND connected_at = $2`
+		_, err := tx.Exec(ctx, query, event.ClientID, event.ConnectedAt, event.Time, event.Reason)
+		return err
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to record presence of %s", event.ClientID)
+		return fmt.Errorf("failed to record presence of %s: %w", event.ClientID, err)
+	}
+	return nil
+}
+
+// resetPresenceStatement marks every connected node as disconnected
+var resetPresenceStatement = map[string]string{
+	types.DatabasePostgres: `
+			UPDATE node_presence
+			SET connected = false, disconnected_at = NOW(), disconnect_reason = $1
+			WHERE connected`,
+	types.DatabaseSqlite: `
+			UPDATE node_presence
+			SET connected = false, disconnected_at = CURRENT_TIMESTAMP, disconnect_reason = $1
+			WHERE connected`,
+}
+
+// ResetPresence marks all nodes as disconnected. The broker runs inside the
+// controller, so no connection survives a restart.
+func (s *StateManager) ResetPresence(ctx context.Context, reason string) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		_, err := tx.Exec(ctx, resetPresenceStatement[s.Dialect()], reason)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset node presence: %w", err)
+	}
+	return nil
+}
+
+// ListNodeStatus returns the status of every node known in any version set,
+// or of a single node if serial is not empty, ordered by serial number
+func (s *StateManager) ListNodeStatus(ctx context.Context, serial string) ([]*types.NodeStatus, error) {
+	// a node has a row per version set, the newest last_seen of them counts
+	query := `
+		SELECT n.serial_number, n.last_seen, COALESCE(np.connected, false),
+			np.connected_at, np.disconnected_at, COALESCE(np.remote_addr, ''), COALESCE(np.disconnect_reason, '')
+		FROM nodes n
+		LEFT JOIN node_presence np ON np.serial_number = n.serial_number
+		WHERE $1 = '' OR n.serial_number = $1
+		ORDER BY n.serial_number`
+
+	var statuses []*types.NodeStatus
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		return queryEach(ctx, tx, query, []any{serial}, func(rows Rows) error {
+			var st types.NodeStatus
+			if err := rows.Scan(&st.SerialNumber, &st.LastSeen, &st.Connected,
+				&st.ConnectedAt, &st.DisconnectedAt, &st.RemoteAddr, &st.DisconnectReason); err != nil {
+				return err
+			}
+			if n := len(statuses); n > 0 && statuses[n-1].SerialNumber == st.SerialNumber {
+				if prev := statuses[n-1]; st.LastSeen != nil && (prev.LastSeen == nil || st.LastSeen.After(*prev.LastSeen)) {
+					prev.LastSeen = st.LastSeen
+				}
+				return nil
+			}
+			statuses = append(statuses, &st)
+			return nil
+		})
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to list node status")
+		return nil, fmt.Errorf("failed to list node status: %w", err)
+	}
+
+	since := time.Now().Add(-s.onlineWindow)
+	for _, st := range statuses {
+		st.Online = st.Connected || (st.LastSeen != nil && st.LastSeen.After(since))
+	}
+	return statuses, nil
+}
+
+// OnlineNodes returns the serial numbers of the online nodes of a version set
+func (s *StateManager) OnlineNodes(ctx context.Context, versionSetID uuid.UUID) (map[string]bool, error) {
+	query := `SELECT serial_number FROM nodes WHERE version_set_id = $1 AND ` + onlineCondition("serial_number", "$2")
+
+	online := make(map[string]bool)
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		return queryEach(ctx, tx, query, []any{versionSetID, time.Now().Add(-s.onlineWindow)}, func(rows Rows) error {
+			var serial string
+			if err := rows.Scan(&serial); err != nil {
+				return err
+			}
+			online[serial] = true
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get online nodes: %w", err)
+	}
+	return online, nil
+}
@@ -4,14 +4,16 @@ import (
 	"context"
 
 	"github.com/gofrs/uuid/v5"
-	"github.com/jackc/pgx/v5"
 	"github.com/philslol/kritis3m_scalev2/control/types"
 )
 
 // CreateProxy inserts a new proxy record into the database.
 func (s *StateManager) CreateProxy(ctx context.Context, proxy *types.Proxy) (*types.Proxy, error) {
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if err := lockDraft(ctx, tx, proxy.VersionSetID); err != nil {
+			return err
+		}
 		query := `
 		INSERT INTO proxies 
 			(name, node_serial, group_name, state, proxy_type, server_endpoint_addr, client_endpoint_addr, version_set_id, created_by) 
@@ -37,7 +39,7 @@ func (s *StateManager) ListProxies(ctx context.Context) ([]types.Proxy, error) {
 
 	var proxies []types.Proxy
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `SELECT id, name, node_serial, group_name, state, proxy_type, server_endpoint_addr, 
 	client_endpoint_addr, version_set_id,  created_at, updated_at, created_by FROM proxies`
 
@@ -84,7 +86,7 @@ func (s *StateManager) GetProxiesByProxyID(ctx context.Context, proxyID int) ([]
 
 	var proxies []*types.Proxy
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 
 		query := `SELECT id, name, node_serial, group_name, state, proxy_type, server_endpoint_addr, 
 	client_endpoint_addr, version_set_id, created_at, updated_at, created_by FROM proxies WHERE id = $1`
@@ -121,7 +123,7 @@ func (s *StateManager) GetProxiesByProxyID(ctx context.Context, proxyID int) ([]
 func (s *StateManager) GetProxyByName(ctx context.Context, name string, versionSetID uuid.UUID) (*types.Proxy, error) {
 
 	proxy := &types.Proxy{}
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 		SELECT id, name, node_serial, group_name, state, proxy_type, server_endpoint_addr, client_endpoint_addr, version_set_id, created_by
 		FROM proxies
@@ -154,11 +156,11 @@ func (s *StateManager) GetProxyByName(ctx context.Context, name string, versionS
 func (s *StateManager) GetProxyBySerialNumber(ctx context.Context, serialNumber string, versionSetID uuid.UUID) ([]*types.Proxy, error) {
 
 	var proxies []*types.Proxy
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 		SELECT id, name, node_serial, group_name, state, proxy_type, server_endpoint_addr, client_endpoint_addr, version_set_id, created_by
-		FROM proxies	
-		WHERE serial_number = $1 AND version_set_id = $2`
+		FROM proxies
+		WHERE node_serial = $1 AND version_set_id = $2`
 
 		rows, err := tx.Query(ctx, query, serialNumber, versionSetID)
 		if err != nil {
@@ -203,7 +205,7 @@ func (s *StateManager) GetProxyByID(ctx context.Context, id int) (*types.Proxy,
 
 	proxy := &types.Proxy{}
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 		SELECT id, name, node_serial, group_name, state, proxy_type, server_endpoint_addr, client_endpoint_addr, version_set_id, created_by
 		FROM proxies
@@ -237,7 +239,7 @@ func (s *StateManager) GetProxyByID(ctx context.Context, id int) (*types.Proxy,
 func (s *StateManager) GetAllProxies(ctx context.Context) ([]*types.Proxy, error) {
 	var proxies []*types.Proxy
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `SELECT id, name, node_serial, group_name, state, proxy_type, server_endpoint_addr, client_endpoint_addr, version_set_id, created_by FROM proxies`
 
 		rows, err := tx.Query(ctx, query)
@@ -280,7 +282,7 @@ func (s *StateManager) GetAllProxies(ctx context.Context) ([]*types.Proxy, error
 func (s *StateManager) GetProxyByVersionSetID(ctx context.Context, versionSetID uuid.UUID) ([]*types.Proxy, error) {
 	var proxies []*types.Proxy
 
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 		SELECT id, name, node_serial, group_name, state, proxy_type, server_endpoint_addr, client_endpoint_addr, version_set_id, created_by
 		FROM proxies
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// reconcileCandidatesStatement selects the reconcile state of the nodes of version set $1
+var reconcileCandidatesStatement = map[string]string{
+	types.DatabasePostgres: `
+		SELECT n.serial_number,
+			(SELECT l.state FROM transaction_log l
+				WHERE l.node_serial = n.serial_number ORDER BY l.id DESC LIMIT 1),
+			(SELECT l.version_set_id::text FROM transaction_log l
+				WHERE l.node_serial = n.serial_number AND l.state = 'applied' ORDER BY l.id DESC LIMIT 1),
+			EXISTS (SELECT 1 FROM node_drift_events d
+				WHERE d.serial_number = n.serial_number AND d.resolved_at IS NULL)
+		FROM nodes n
+		WHERE n.version_set_id = $1 AND `,
+	types.DatabaseSqlite: `
+		SELECT n.serial_number,
+			(SELECT l.state FROM transaction_log l
+				WHERE l.node_serial = n.serial_number ORDER BY l.id DESC LIMIT 1),
+			(SELECT CAST(l.version_set_id AS TEXT) FROM transaction_log l
+				WHERE l.node_serial = n.serial_number AND l.state = 'applied' ORDER BY l.id DESC LIMIT 1),
+			EXISTS (SELECT 1 FROM node_drift_events d
+				WHERE d.serial_number = n.serial_number AND d.resolved_at IS NULL)
+		FROM nodes n
+		WHERE n.version_set_id = $1 AND `,
+}
+
+// ReconcileCandidates returns the online nodes of a version set which did not
+// apply it or drifted from it, ordered by serial number. Nodes with an update
+// in progress or queued are left out.
+func (s *StateManager) ReconcileCandidates(ctx context.Context, versionSetID uuid.UUID) ([]*types.ReconcileCandidate, error) {
+	query := reconcileCandidatesStatement[s.Dialect()] + onlineCondition("n.serial_number", "$2") + `
+		AND NOT EXISTS (SELECT 1 FROM pending_node_updates q
+			WHERE q.serial_number = n.serial_number AND q.status IN ('queued', 'delivering'))
+		ORDER BY n.serial_number`
+
+	var candidates []*types.ReconcileCandidate
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		return queryEach(ctx, tx, query, []any{versionSetID, time.Now().Add(-s.onlineWindow)}, func(rows Rows) error {
+			var serial string
+			var lastState, applied *string
+			var drifted bool
+			if err := rows.Scan(&serial, &lastState, &applied, &drifted); err != nil {
+				return err
+			}
+			if lastState != nil && updateInFlight(types.TransactionState(*lastState)) {
+				return nil
+			}
+			switch {
+			case applied == nil || *applied != versionSetID.String():
+				candidates = append(candidates, &types.ReconcileCandidate{SerialNumber: serial, Reason: types.ReconcileOutdated})
+			case drifted:
+				candidates = append(candidates, &types.ReconcileCandidate{SerialNumber: serial, Reason: types.ReconcileDrifted})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to get reconcile candidates")
+		return nil, fmt.Errorf("failed to get reconcile candidates: %w", err)
+	}
+	return candidates, nil
+}
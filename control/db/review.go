@@ -0,0 +1,242 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// ErrNotPending is returned for approvals and rejections of a version set
+// which is not awaiting review
+var ErrNotPending = errors.New("version set is not pending review")
+
+// ErrSelfApproval is returned if the submitter of a version set approves it
+var ErrSelfApproval = errors.New("version set cannot be approved by its submitter")
+
+// ErrAlreadyApproved is returned for a second approval of the same submission
+var ErrAlreadyApproved = errors.New("version set is already approved")
+
+const reviewColumns = `id, version_set_id, action, user_name, comment, diff, created_at`
+
+func scanReview(rows Rows) (*types.Review, error) {
+	var r types.Review
+	var diff *string
+	if err := rows.Scan(&r.ID, &r.VersionSetID, &r.Action, &r.User, &r.Comment, &diff, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	if diff != nil {
+		if err := json.Unmarshal([]byte(*diff), &r.Diff); err != nil {
+			return nil, fmt.Errorf("invalid diff of review %d: %w", r.ID, err)
+		}
+	}
+	return &r, nil
+}
+
+// reviewsOf returns the reviews of a version set in the order they were made
+func reviewsOf(ctx context.Context, tx Tx, versionSetID uuid.UUID) ([]*types.Review, error) {
+	var reviews []*types.Review
+	query := `SELECT ` + reviewColumns + ` FROM version_set_reviews WHERE version_set_id = $1 ORDER BY id`
+	err := queryEach(ctx, tx, query, []any{versionSetID}, func(rows Rows) error {
+		r, err := scanReview(rows)
+		if err != nil {
+			return err
+		}
+		reviews = append(reviews, r)
+		return nil
+	})
+	return reviews, err
+}
+
+func insertReview(ctx context.Context, tx Tx, r *types.Review) error {
+	var diff *string
+	if r.Diff != nil {
+		data, err := json.Marshal(r.Diff)
+		if err != nil {
+			return fmt.Errorf("failed to encode diff: %w", err)
+		}
+		value := string(data)
+		diff = &value
+	}
+	query := `
+		INSERT INTO version_set_reviews (version_set_id, action, user_name, comment, diff)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+	return tx.QueryRow(ctx, query, r.VersionSetID, string(r.Action), r.User, r.Comment, diff).Scan(&r.ID, &r.CreatedAt)
+}
+
+// versionSetStateStatement locks version set $1 against concurrent reviews
+// and draft changes, see lockDraftStatement, until the transaction ends
+var versionSetStateStatement = map[string]string{
+	types.DatabasePostgres: `SELECT state FROM version_sets WHERE id = $1 FOR UPDATE`,
+	types.DatabaseSqlite:   `SELECT state FROM version_sets WHERE id = $1`,
+}
+
+// versionSetState locks a version set for the rest of tx and returns its
+// state, ErrNoRows if it does not exist. Two approvals of the same
+// submission are serialized, the second one sees the first.
+func versionSetState(ctx context.Context, tx Tx, id uuid.UUID) (types.VersionState, error) {
+	var state types.VersionState
+	err := tx.QueryRow(ctx, versionSetStateStatement[tx.Dialect()], id).Scan(&state)
+	return state, err
+}
+
+// SubmitVersionSet freezes a draft for review and records the submission
+// with its diff
+func (s *StateManager) SubmitVersionSet(ctx context.Context, r *types.Review) error {
+	r.Action = types.ReviewSubmitted
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		state, err := versionSetState(ctx, tx, r.VersionSetID)
+		if err != nil {
+			return err
+		}
+		if state != types.VERSION_STATE_DRAFT {
+			return fmt.Errorf("%w: version set %s is %s", ErrNotDraft, r.VersionSetID, state)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE version_sets SET state = 'pending_deployment' WHERE id = $1`, r.VersionSetID); err != nil {
+			return err
+		}
+		return insertReview(ctx, tx, r)
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to submit version set %s", r.VersionSetID)
+		return fmt.Errorf("failed to submit version set %s: %w", r.VersionSetID, err)
+	}
+	return nil
+}
+
+// ApproveVersionSet records the approval of the latest submission of a
+// version set. The approver must differ from the submitter.
+func (s *StateManager) ApproveVersionSet(ctx context.Context, r *types.Review) error {
+	r.Action = types.ReviewApproved
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		state, err := versionSetState(ctx, tx, r.VersionSetID)
+		if err != nil {
+			return err
+		}
+		if state != types.VERSION_STATE_PENDING_DEPLOYMENT {
+			return fmt.Errorf("%w: version set %s is %s", ErrNotPending, r.VersionSetID, state)
+		}
+		reviews, err := reviewsOf(ctx, tx, r.VersionSetID)
+		if err != nil {
+			return err
+		}
+		submission, approval := types.CurrentApproval(reviews)
+		switch {
+		case submission == nil:
+			// frozen by an activation before reviews existed
+			return fmt.Errorf("%w: version set %s was never submitted", ErrNotPending, r.VersionSetID)
+		case submission.User == r.User:
+			return fmt.Errorf("%w %s", ErrSelfApproval, submission.User)
+		case approval != nil:
+			return fmt.Errorf("%w by %s", ErrAlreadyApproved, approval.User)
+		}
+		return insertReview(ctx, tx, r)
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to approve version set %s", r.VersionSetID)
+		return fmt.Errorf("failed to approve version set %s: %w", r.VersionSetID, err)
+	}
+	return nil
+}
+
+// RejectVersionSet records a rejection and returns the version set to draft,
+// so that it can be changed and submitted again
+func (s *StateManager) RejectVersionSet(ctx context.Context, r *types.Review) error {
+	r.Action = types.ReviewRejected
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		state, err := versionSetState(ctx, tx, r.VersionSetID)
+		if err != nil {
+			return err
+		}
+		if state != types.VERSION_STATE_PENDING_DEPLOYMENT {
+			return fmt.Errorf("%w: version set %s is %s", ErrNotPending, r.VersionSetID, state)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE version_sets SET state = 'draft' WHERE id = $1`, r.VersionSetID); err != nil {
+			return err
+		}
+		return insertReview(ctx, tx, r)
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to reject version set %s", r.VersionSetID)
+		return fmt.Errorf("failed to reject version set %s: %w", r.VersionSetID, err)
+	}
+	return nil
+}
+
+// CommentVersionSet adds a comment to the review history of a version set in
+// any state
+func (s *StateManager) CommentVersionSet(ctx context.Context, r *types.Review) error {
+	r.Action = types.ReviewCommented
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if _, err := versionSetState(ctx, tx, r.VersionSetID); err != nil {
+			return err
+		}
+		return insertReview(ctx, tx, r)
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to comment on version set %s", r.VersionSetID)
+		return fmt.Errorf("failed to comment on version set %s: %w", r.VersionSetID, err)
+	}
+	return nil
+}
+
+// VersionSetApproval returns the latest submission of a version set and its
+// approval, both are nil if there is none
+func (s *StateManager) VersionSetApproval(ctx context.Context, versionSetID uuid.UUID) (*types.Review, *types.Review, error) {
+	var submission, approval *types.Review
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		reviews, err := reviewsOf(ctx, tx, versionSetID)
+		if err != nil {
+			return err
+		}
+		submission, approval = types.CurrentApproval(reviews)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get approval of version set %s: %w", versionSetID, err)
+	}
+	return submission, approval, nil
+}
+
+// ListReviews returns the review history matching filter, newest first
+func (s *StateManager) ListReviews(ctx context.Context, filter types.ReviewFilter) ([]*types.Review, error) {
+	var conditions []string
+	var args []any
+	if filter.VersionSetID != nil {
+		conditions = append(conditions, `version_set_id = `+addArg(&args, *filter.VersionSetID))
+	}
+	if filter.User != "" {
+		conditions = append(conditions, `user_name = `+addArg(&args, filter.User))
+	}
+	if filter.Since != nil {
+		conditions = append(conditions, `created_at >= `+addArg(&args, *filter.Since))
+	}
+	if filter.Until != nil {
+		conditions = append(conditions, `created_at < `+addArg(&args, *filter.Until))
+	}
+	query := `SELECT ` + reviewColumns + ` FROM version_set_reviews` + whereClause(conditions) + ` ORDER BY id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ` + addArg(&args, filter.Limit)
+	}
+
+	var reviews []*types.Review
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		return queryEach(ctx, tx, query, args, func(rows Rows) error {
+			r, err := scanReview(rows)
+			if err != nil {
+				return err
+			}
+			reviews = append(reviews, r)
+			return nil
+		})
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to list reviews")
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	return reviews, nil
+}
@@ -0,0 +1,311 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// CreateMaintenanceWindow stores a maintenance window and sets its id
+func (s *StateManager) CreateMaintenanceWindow(ctx context.Context, w *types.MaintenanceWindow) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := `
+			INSERT INTO maintenance_windows (name, locality, days, start_time, duration_minutes, timezone, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at`
+		return tx.QueryRow(ctx, query,
+			w.Name, w.Locality, types.FormatWeekdays(w.Days), w.StartTime,
+			int(w.Duration/time.Minute), w.Timezone, w.CreatedBy,
+		).Scan(&w.ID, &w.CreatedAt)
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to create maintenance window")
+		return fmt.Errorf("failed to create maintenance window %s: %w", w.Name, err)
+	}
+	return nil
+}
+
+// ListMaintenanceWindows returns the windows of a locality, or all windows if
+// locality is empty
+func (s *StateManager) ListMaintenanceWindows(ctx context.Context, locality string) ([]*types.MaintenanceWindow, error) {
+	query := `
+		SELECT id, name, locality, days, start_time, duration_minutes, timezone, created_by, created_at
+		FROM maintenance_windows
+		WHERE $1 = '' OR locality = $1
+		ORDER BY locality, name`
+
+	var windows []*types.MaintenanceWindow
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		return queryEach(ctx, tx, query, []any{locality}, func(rows Rows) error {
+			var w types.MaintenanceWindow
+			var days string
+			var minutes int
+			if err := rows.Scan(&w.ID, &w.Name, &w.Locality, &days, &w.StartTime,
+				&minutes, &w.Timezone, &w.CreatedBy, &w.CreatedAt); err != nil {
+				return err
+			}
+			parsed, err := types.ParseWeekdays(days)
+			if err != nil {
+				return fmt.Errorf("maintenance window %s: %w", w.Name, err)
+			}
+			w.Days = parsed
+			w.Duration = time.Duration(minutes) * time.Minute
+			windows = append(windows, &w)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+	return windows, nil
+}
+
+// DeleteMaintenanceWindow removes a window by name, ErrNoRows is returned if
+// it does not exist
+func (s *StateManager) DeleteMaintenanceWindow(ctx context.Context, name string) error {
+	return s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		result, err := tx.Exec(ctx, `DELETE FROM maintenance_windows WHERE name = $1`, name)
+		if err != nil {
+			return fmt.Errorf("failed to delete maintenance window %s: %w", name, err)
+		}
+		if result.RowsAffected() == 0 {
+			return ErrNoRows
+		}
+		return nil
+	})
+}
+
+// NodeLocalities maps the serial numbers of the nodes of a version set to their locality
+func (s *StateManager) NodeLocalities(ctx context.Context, versionSetID uuid.UUID) (map[string]string, error) {
+	localities := make(map[string]string)
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := `SELECT serial_number, COALESCE(locality, '') FROM nodes WHERE version_set_id = $1`
+		return queryEach(ctx, tx, query, []any{versionSetID}, func(rows Rows) error {
+			var serial, locality string
+			if err := rows.Scan(&serial, &locality); err != nil {
+				return err
+			}
+			localities[serial] = locality
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node localities: %w", err)
+	}
+	return localities, nil
+}
+
+const scheduledActivationColumns = `
+	a.id, a.version_set_id, a.group_name, a.run_at, a.status, a.force, a.ignore_window,
+	a.transaction_id, t.state, a.message, a.created_by, a.created_at, a.finished_at`
+
+func scanScheduledActivation(rows Rows) (*types.ScheduledActivation, error) {
+	var a types.ScheduledActivation
+	err := rows.Scan(&a.ID, &a.VersionSetID, &a.GroupName, &a.RunAt, &a.Status, &a.Force, &a.IgnoreWindow,
+		&a.TransactionID, &a.TransactionState, &a.Message, &a.CreatedBy, &a.CreatedAt, &a.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// CreateScheduledActivation stores a scheduled activation and sets its id
+func (s *StateManager) CreateScheduledActivation(ctx context.Context, a *types.ScheduledActivation) error {
+	a.Status = types.ScheduleScheduled
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := `
+			INSERT INTO scheduled_activations (version_set_id, group_name, run_at, status, force, ignore_window, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at`
+		return tx.QueryRow(ctx, query,
+			a.VersionSetID, a.GroupName, a.RunAt, string(a.Status), a.Force, a.IgnoreWindow, a.CreatedBy,
+		).Scan(&a.ID, &a.CreatedAt)
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to create scheduled activation")
+		return fmt.Errorf("failed to schedule activation: %w", err)
+	}
+	return nil
+}
+
+// ListScheduledActivations returns the scheduled activations ordered by their
+// time, finished ones only if all is set
+func (s *StateManager) ListScheduledActivations(ctx context.Context, all bool) ([]*types.ScheduledActivation, error) {
+	query := `SELECT ` + scheduledActivationColumns + `
+		FROM scheduled_activations a
+		LEFT JOIN transactions t ON t.id = a.transaction_id
+		WHERE $1 OR a.status IN ('scheduled', 'running')
+		ORDER BY a.run_at, a.id`
+
+	var activations []*types.ScheduledActivation
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		return queryEach(ctx, tx, query, []any{all}, func(rows Rows) error {
+			a, err := scanScheduledActivation(rows)
+			if err != nil {
+				return err
+			}
+			activations = append(activations, a)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled activations: %w", err)
+	}
+	return activations, nil
+}
+
+// GetScheduledActivation returns a scheduled activation by id
+func (s *StateManager) GetScheduledActivation(ctx context.Context, id int) (*types.ScheduledActivation, error) {
+	query := `SELECT ` + scheduledActivationColumns + `
+		FROM scheduled_activations a
+		LEFT JOIN transactions t ON t.id = a.transaction_id
+		WHERE a.id = $1`
+
+	var activation *types.ScheduledActivation
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		err := queryEach(ctx, tx, query, []any{id}, func(rows Rows) error {
+			var err error
+			activation, err = scanScheduledActivation(rows)
+			return err
+		})
+		if err == nil && activation == nil {
+			return ErrNoRows
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled activation %d: %w", id, err)
+	}
+	return activation, nil
+}
+
+// cancelScheduledActivationStatement cancels scheduled activation $1
+var cancelScheduledActivationStatement = map[string]string{
+	types.DatabasePostgres: `
+			UPDATE scheduled_activations SET status = 'cancelled', finished_at = NOW()
+			WHERE id = $1 AND status = 'scheduled'`,
+	types.DatabaseSqlite: `
+			UPDATE scheduled_activations SET status = 'cancelled', finished_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND status = 'scheduled'`,
+}
+
+// CancelScheduledActivation cancels an activation which has not fired yet. It
+// returns false if the activation is not scheduled anymore.
+func (s *StateManager) CancelScheduledActivation(ctx context.Context, id int) (bool, error) {
+	var cancelled bool
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		result, err := tx.Exec(ctx, cancelScheduledActivationStatement[s.Dialect()], id)
+		if err != nil {
+			return err
+		}
+		cancelled = result.RowsAffected() > 0
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel scheduled activation %d: %w", id, err)
+	}
+	return cancelled, nil
+}
+
+// ClaimDueActivations moves the activations due at now from scheduled to
+// running and returns them. An activation is only claimed once, even with
+// several controllers polling the same database.
+func (s *StateManager) ClaimDueActivations(ctx context.Context, now time.Time) ([]*types.ScheduledActivation, error) {
+	query := `SELECT ` + scheduledActivationColumns + `
+		FROM scheduled_activations a
+		LEFT JOIN transactions t ON t.id = a.transaction_id
+		WHERE a.status = 'scheduled' AND a.run_at <= $1
+		ORDER BY a.run_at, a.id`
+
+	var claimed []*types.ScheduledActivation
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		var due []*types.ScheduledActivation
+		err := queryEach(ctx, tx, query, []any{now}, func(rows Rows) error {
+			a, err := scanScheduledActivation(rows)
+			if err != nil {
+				return err
+			}
+			due = append(due, a)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, a := range due {
+			result, err := tx.Exec(ctx, `
+				UPDATE scheduled_activations SET status = 'running'
+				WHERE id = $1 AND status = 'scheduled'`, a.ID)
+			if err != nil {
+				return err
+			}
+			if result.RowsAffected() > 0 {
+				a.Status = types.ScheduleRunning
+				claimed = append(claimed, a)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim scheduled activations: %w", err)
+	}
+	return claimed, nil
+}
+
+// finishScheduledActivationStatement records the outcome of scheduled activation $1
+var finishScheduledActivationStatement = map[string]string{
+	types.DatabasePostgres: `
+			UPDATE scheduled_activations
+			SET status = $2, transaction_id = $3, message = $4, finished_at = NOW()
+			WHERE id = $1`,
+	types.DatabaseSqlite: `
+			UPDATE scheduled_activations
+			SET status = $2, transaction_id = $3, message = $4, finished_at = CURRENT_TIMESTAMP
+			WHERE id = $1`,
+}
+
+// FinishScheduledActivation records the outcome of firing an activation
+func (s *StateManager) FinishScheduledActivation(ctx context.Context, id int, status types.ScheduleStatus, transactionID *int, message string) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		_, err := tx.Exec(ctx, finishScheduledActivationStatement[s.Dialect()], id, string(status), transactionID, message)
+		return err
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to finish scheduled activation %d", id)
+		return fmt.Errorf("failed to finish scheduled activation %d: %w", id, err)
+	}
+	return nil
+}
+
+// failInterruptedActivationsStatement fails the activations left running by a stopped controller
+var failInterruptedActivationsStatement = map[string]string{
+	types.DatabasePostgres: `
+			UPDATE scheduled_activations
+			SET status = 'failed', message = 'controller stopped before the activation started', finished_at = NOW()
+			WHERE status = 'running'`,
+	types.DatabaseSqlite: `
+			UPDATE scheduled_activations
+			SET status = 'failed', message = 'controller stopped before the activation started', finished_at = CURRENT_TIMESTAMP
+			WHERE status = 'running'`,
+}
+
+// FailInterruptedActivations marks activations which were claimed by a
+// controller that stopped before it started them
+func (s *StateManager) FailInterruptedActivations(ctx context.Context) (int, error) {
+	var count int
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		result, err := tx.Exec(ctx, failInterruptedActivationsStatement[s.Dialect()])
+		if err != nil {
+			return err
+		}
+		count = int(result.RowsAffected())
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update interrupted activations: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,204 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// sqliteStorage is the database/sql backed Storage used for single-box and
+// test deployments. It runs the queries of the Postgres backend, except for the
+// statements kept per dialect. SQLite binds their $n placeholders natively.
+type sqliteStorage struct {
+	db *sql.DB
+}
+
+type sqliteTx struct {
+	tx *sql.Tx
+}
+
+type sqliteRows struct {
+	*sql.Rows
+}
+
+type sqliteRow struct {
+	row *sql.Row
+}
+
+type sqliteCommandTag struct {
+	rowsAffected int64
+}
+
+// sqliteParams returns the numbers of the $n parameters of query in the order
+// SQLite numbers them: by their first appearance, ignoring string literals,
+// quoted identifiers and comments.
+func sqliteParams(query string) []int {
+	var params []int
+	seen := make(map[int]bool)
+	for i := 0; i < len(query); i++ {
+		switch {
+		case query[i] == '\'' || query[i] == '"':
+			end := strings.IndexByte(query[i+1:], query[i])
+			if end < 0 {
+				return params
+			}
+			i += end + 1
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return params
+			}
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i:], "*/")
+			if end < 0 {
+				return params
+			}
+			i += end + 1
+		case query[i] == '$':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			if n, err := strconv.Atoi(query[i+1 : j]); err == nil && !seen[n] {
+				seen[n] = true
+				params = append(params, n)
+			}
+			i = j - 1
+		}
+	}
+	return params
+}
+
+// sqliteArgs orders args like SQLite numbers the parameters of query, the
+// driver binds them by position. All timestamps are stored in UTC, so that
+// they compare correctly as text.
+func sqliteArgs(query string, args []any) []any {
+	ordered := args
+	if params := sqliteParams(query); len(params) == len(args) {
+		ordered = make([]any, len(args))
+		for i, n := range params {
+			if n < 1 || n > len(args) {
+				return args
+			}
+			ordered[i] = args[n-1]
+		}
+	}
+
+	converted := make([]any, len(ordered))
+	for i, arg := range ordered {
+		switch v := arg.(type) {
+		case time.Time:
+			converted[i] = v.UTC()
+		case *time.Time:
+			if v != nil {
+				converted[i] = v.UTC()
+			} else {
+				converted[i] = nil
+			}
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
+
+// openSqlite opens the SQLite database at path, creating it if necessary.
+func openSqlite(ctx context.Context, path string, maxConns int32) (*sqliteStorage, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite database path is empty")
+	}
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL", path)
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	if maxConns > 0 {
+		db.SetMaxOpenConns(int(maxConns))
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open sqlite database %s: %w", path, err)
+	}
+	return &sqliteStorage{db: db}, nil
+}
+
+func (s *sqliteStorage) Dialect() string {
+	return types.DatabaseSqlite
+}
+
+func (s *sqliteStorage) Begin(ctx context.Context) (storageTx, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteTx{tx: tx}, nil
+}
+
+func (s *sqliteStorage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *sqliteStorage) Close() {
+	s.db.Close()
+}
+
+func (t *sqliteTx) Exec(ctx context.Context, query string, args ...any) (CommandTag, error) {
+	result, err := t.tx.ExecContext(ctx, query, sqliteArgs(query, args)...)
+	if err != nil {
+		return sqliteCommandTag{}, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return sqliteCommandTag{}, err
+	}
+	return sqliteCommandTag{rowsAffected: affected}, nil
+}
+
+func (t *sqliteTx) Query(ctx context.Context, query string, args ...any) (Rows, error) {
+	rows, err := t.tx.QueryContext(ctx, query, sqliteArgs(query, args)...)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteRows{rows}, nil
+}
+
+func (t *sqliteTx) QueryRow(ctx context.Context, query string, args ...any) Row {
+	return &sqliteRow{row: t.tx.QueryRowContext(ctx, query, sqliteArgs(query, args)...)}
+}
+
+func (t *sqliteTx) Dialect() string {
+	return types.DatabaseSqlite
+}
+
+func (t *sqliteTx) Commit(ctx context.Context) error {
+	return t.tx.Commit()
+}
+
+func (t *sqliteTx) Rollback(ctx context.Context) error {
+	return t.tx.Rollback()
+}
+
+// Close matches pgx.Rows, which does not return an error on close
+func (r *sqliteRows) Close() {
+	r.Rows.Close()
+}
+
+func (r *sqliteRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoRows
+	}
+	return err
+}
+
+func (c sqliteCommandTag) RowsAffected() int64 {
+	return c.rowsAffected
+}
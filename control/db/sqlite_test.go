@@ -0,0 +1,636 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// sqliteFleet is a draft version set with one connected node, its group,
+// endpoint config, hardware config and proxy
+type sqliteFleet struct {
+	sm           *StateManager
+	versionSetID uuid.UUID
+	node         *types.Node
+	proxy        *types.Proxy
+}
+
+func newSqliteFleet(t *testing.T) *sqliteFleet {
+	t.Helper()
+	ctx := context.Background()
+	sm := newSqliteStateManager(t)
+	if _, err := sm.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	description := "test fleet"
+	id, err := sm.CreateVersionSet(ctx, types.VersionSet{Name: "fleet", Description: &description, CreatedBy: "tester", State: types.VERSION_STATE_DRAFT})
+	if err != nil {
+		t.Fatalf("create version set: %v", err)
+	}
+	cipher := "AES-256-GCM"
+	if err := sm.CreateEndpointConfig(ctx, &types.EndpointConfig{Name: "ep", MutualAuth: true, ASLKeyExchangeMethod: "ASL_KEX_DEFAULT", Cipher: &cipher, VersionSetID: id, CreatedBy: "tester"}); err != nil {
+		t.Fatalf("create endpoint config: %v", err)
+	}
+	if err := sm.CreateGroup(ctx, &types.Group{Name: "plant", LogLevel: 2, EndpointConfigName: "ep", LegacyConfigName: "ep", VersionSetID: id, CreatedBy: "tester"}); err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	node, err := sm.CreateNode(ctx, &types.Node{SerialNumber: "gw-1", NetworkIndex: 1, Locality: "hall", VersionSetID: id, CreatedBy: "tester"})
+	if err != nil {
+		t.Fatalf("create node: %v", err)
+	}
+	if err := sm.CreateHwConfig(ctx, &types.HardwareConfig{NodeSerial: "gw-1", Device: "eth0", IPCIDR: "10.0.0.1/24", VersionSetID: id, CreatedBy: "tester"}); err != nil {
+		t.Fatalf("create hardware config: %v", err)
+	}
+	proxy, err := sm.CreateProxy(ctx, &types.Proxy{Name: "web", NodeSerial: "gw-1", GroupName: "plant", State: true, ProxyType: types.PROXY_TYPE_REVERSE,
+		ServerEndpointAddr: "0.0.0.0:443", ClientEndpointAddr: "127.0.0.1:80", VersionSetID: id, CreatedBy: "tester"})
+	if err != nil {
+		t.Fatalf("create proxy: %v", err)
+	}
+	if err := sm.RecordPresence(ctx, types.PresenceEvent{ClientID: "gw-1", Connected: true, ConnectedAt: time.Now(), RemoteAddr: "10.0.0.1:50000"}); err != nil {
+		t.Fatalf("record presence: %v", err)
+	}
+	return &sqliteFleet{sm: sm, versionSetID: id, node: node, proxy: proxy}
+}
+
+func TestSqliteParams(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{`SELECT * FROM nodes WHERE id = $1 AND name = $2`, []int{1, 2}},
+		{`UPDATE nodes SET last_seen = $2 WHERE serial_number = $1 OR serial_number = $2`, []int{2, 1}},
+		{`SELECT '$3', "$4" -- $5
+			/* $6 */ FROM nodes WHERE id = $1`, []int{1}},
+		{`SELECT 1`, nil},
+	}
+	for _, test := range tests {
+		if got := sqliteParams(test.query); !slices.Equal(got, test.want) {
+			t.Errorf("sqliteParams(%q) = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestSqliteNodeQueries(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	node, err := f.sm.GetNodebyID(ctx, f.node.ID)
+	if err != nil || node.SerialNumber != "gw-1" || node.VersionSetID != f.versionSetID {
+		t.Fatalf("GetNodebyID = %+v, %v", node, err)
+	}
+	node, err = f.sm.GetNodebySerial(ctx, "gw-1", f.versionSetID)
+	if err != nil || node.ID != f.node.ID || node.Locality != "hall" {
+		t.Fatalf("GetNodebySerial = %+v, %v", node, err)
+	}
+	if _, err := f.sm.GetNodebyID(ctx, f.node.ID+100); !IsNoRows(err) {
+		t.Fatalf("GetNodebyID of a missing node = %v, want no rows", err)
+	}
+
+	seen := time.Now().Add(-time.Minute)
+	if err := f.sm.UpdateWhere(ctx, "nodes", map[string]any{"last_seen": seen}, "serial_number = $1", "gw-1"); err != nil {
+		t.Fatalf("update node: %v", err)
+	}
+	nodes, err := f.sm.ListNodes(ctx, &f.versionSetID)
+	if err != nil || len(nodes) != 1 {
+		t.Fatalf("ListNodes = %v, %v", nodes, err)
+	}
+	if nodes[0].LastSeen == nil || !nodes[0].LastSeen.Equal(seen) {
+		t.Errorf("last seen %v, want %v", nodes[0].LastSeen, seen)
+	}
+
+	item, err := f.sm.NodeUpdate("gw-1", f.versionSetID.String(), ctx)
+	if err != nil {
+		t.Fatalf("NodeUpdate: %v", err)
+	}
+	if item.SerialNumber != "gw-1" || item.VersionSetId != f.versionSetID.String() || len(item.HardwareConfig) != 1 || len(item.GroupProxyUpdate) != 1 {
+		t.Fatalf("NodeUpdate = %+v", item)
+	}
+
+	if err := f.sm.DeleteNode(ctx, "gw-1", f.versionSetID); err == nil {
+		t.Errorf("deleted a node which is still referenced by its proxy")
+	}
+}
+
+func TestSqliteGroupQueries(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	group, err := f.sm.GetGroupByName(ctx, "plant", &f.versionSetID)
+	if err != nil || group.LogLevel != 2 || group.EndpointConfigName != "ep" {
+		t.Fatalf("GetGroupByName = %+v, %v", group, err)
+	}
+	byID, err := f.sm.GetByID(ctx, group.ID)
+	if err != nil || byID.Name != "plant" {
+		t.Fatalf("GetByID = %+v, %v", byID, err)
+	}
+	groups, err := f.sm.GetListGroup(ctx, &f.versionSetID)
+	if err != nil || len(groups) != 1 {
+		t.Fatalf("GetListGroup = %v, %v", groups, err)
+	}
+
+	fleet, err := f.sm.GetGroupFleetUpdate(ctx, "plant", f.versionSetID.String())
+	if err != nil || len(fleet.NodeUpdateItems) != 1 {
+		t.Fatalf("GetGroupFleetUpdate = %v, %v", fleet, err)
+	}
+	fleet, err = f.sm.GetGroupUpdateOptimized(ctx, f.versionSetID.String(), "plant")
+	if err != nil || len(fleet.NodeUpdateItems) != 1 {
+		t.Fatalf("GetGroupUpdateOptimized = %v, %v", fleet, err)
+	}
+}
+
+func TestSqliteProxyQueries(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	proxy, err := f.sm.GetProxyByName(ctx, "web", f.versionSetID)
+	if err != nil || proxy.ProxyType != types.PROXY_TYPE_REVERSE || proxy.ServerEndpointAddr != "0.0.0.0:443" {
+		t.Fatalf("GetProxyByName = %+v, %v", proxy, err)
+	}
+	byID, err := f.sm.GetProxyByID(ctx, f.proxy.ID)
+	if err != nil || byID.Name != "web" || !byID.State {
+		t.Fatalf("GetProxyByID = %+v, %v", byID, err)
+	}
+	bySerial, err := f.sm.GetProxyBySerialNumber(ctx, "gw-1", f.versionSetID)
+	if err != nil || len(bySerial) != 1 {
+		t.Fatalf("GetProxyBySerialNumber = %v, %v", bySerial, err)
+	}
+	byVersionSet, err := f.sm.GetProxyByVersionSetID(ctx, f.versionSetID)
+	if err != nil || len(byVersionSet) != 1 {
+		t.Fatalf("GetProxyByVersionSetID = %v, %v", byVersionSet, err)
+	}
+	all, err := f.sm.GetAllProxies(ctx)
+	if err != nil || len(all) != 1 {
+		t.Fatalf("GetAllProxies = %v, %v", all, err)
+	}
+}
+
+func TestSqliteEndpointQueries(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	config, err := f.sm.GetEndpointConfigByName(ctx, "ep", &f.versionSetID)
+	if err != nil || !config.MutualAuth || config.Cipher == nil || *config.Cipher != "AES-256-GCM" || config.VersionSetID != f.versionSetID {
+		t.Fatalf("GetEndpointConfigByName = %+v, %v", config, err)
+	}
+	byID, err := f.sm.GetEndpointConfigByID(ctx, config.ID)
+	if err != nil || byID.ASLKeyExchangeMethod != "ASL_KEX_DEFAULT" {
+		t.Fatalf("GetEndpointConfigByID = %+v, %v", byID, err)
+	}
+	for _, versionSetID := range []*uuid.UUID{&f.versionSetID, nil} {
+		configs, err := f.sm.ListEndpointConfigs(ctx, versionSetID)
+		if err != nil || len(configs) != 1 {
+			t.Fatalf("ListEndpointConfigs(%v) = %v, %v", versionSetID, configs, err)
+		}
+	}
+}
+
+func TestSqliteHwConfigQueries(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	configs, err := f.sm.GetHwConfigBySerial(ctx, "gw-1", f.versionSetID)
+	if err != nil || len(configs) != 1 || configs[0].IPCIDR != "10.0.0.1/24" || configs[0].Device != "eth0" {
+		t.Fatalf("GetHwConfigBySerial = %v, %v", configs, err)
+	}
+	config, err := f.sm.GetHwConfigPByID(ctx, configs[0].ID)
+	if err != nil || config.NodeSerial != "gw-1" {
+		t.Fatalf("GetHwConfigPByID = %+v, %v", config, err)
+	}
+	byNode, err := f.sm.GetHwConfigbyNodeID(ctx, f.node.ID)
+	if err != nil || len(byNode) != 1 {
+		t.Fatalf("GetHwConfigbyNodeID = %v, %v", byNode, err)
+	}
+	byVersionSet, err := f.sm.GetHwConfigByVersionSetID(ctx, f.versionSetID)
+	if err != nil || len(byVersionSet) != 1 {
+		t.Fatalf("GetHwConfigByVersionSetID = %v, %v", byVersionSet, err)
+	}
+}
+
+func TestSqliteVersionSetQueries(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	vs, err := f.sm.GetVersionSetByID(ctx, f.versionSetID)
+	if err != nil || vs.Name != "fleet" || vs.State != types.VERSION_STATE_DRAFT {
+		t.Fatalf("GetVersionSetByID = %+v, %v", vs, err)
+	}
+
+	clone, err := f.sm.CloneVersionSet(ctx, f.versionSetID, "copy", "")
+	if err != nil {
+		t.Fatalf("CloneVersionSet: %v", err)
+	}
+	nodes, _ := f.sm.ListNodes(ctx, &clone)
+	groups, _ := f.sm.GetListGroup(ctx, &clone)
+	configs, _ := f.sm.ListEndpointConfigs(ctx, &clone)
+	hwConfigs, _ := f.sm.GetHwConfigByVersionSetID(ctx, clone)
+	proxies, _ := f.sm.GetProxyByVersionSetID(ctx, clone)
+	if len(nodes) != 1 || len(groups) != 1 || len(configs) != 1 || len(hwConfigs) != 1 || len(proxies) != 1 {
+		t.Fatalf("clone has %d nodes, %d groups, %d endpoint configs, %d hardware configs and %d proxies, want one of each",
+			len(nodes), len(groups), len(configs), len(hwConfigs), len(proxies))
+	}
+	if nodes[0].VersionSetID != clone {
+		t.Errorf("cloned node belongs to %s, want %s", nodes[0].VersionSetID, clone)
+	}
+	if _, err := f.sm.CloneVersionSet(ctx, uuid.Must(uuid.NewV4()), "missing", ""); !IsNoRows(err) {
+		t.Errorf("clone of a missing version set = %v, want no rows", err)
+	}
+
+	if _, err := f.sm.TransitionVersionSet(ctx, f.versionSetID, types.VERSION_STATE_PENDING_DEPLOYMENT); err != nil {
+		t.Fatalf("draft to pending: %v", err)
+	}
+	if err := f.sm.ActivateVersionSet(ctx, f.versionSetID); err != nil {
+		t.Fatalf("ActivateVersionSet: %v", err)
+	}
+	vs, err = f.sm.GetVersionSetByID(ctx, f.versionSetID)
+	if err != nil || vs.State != types.VERSION_STATE_ACTIVE || vs.ActivatedAt == nil {
+		t.Fatalf("activated version set = %+v, %v", vs, err)
+	}
+	sets, err := f.sm.ListVersionSets(ctx)
+	if err != nil || len(sets) != 2 {
+		t.Fatalf("ListVersionSets = %v, %v", sets, err)
+	}
+
+	fleet, err := f.sm.GetFleetUpdateOptimized(ctx, f.versionSetID.String(), "")
+	if err != nil || len(fleet.NodeUpdateItems) != 1 {
+		t.Fatalf("GetFleetUpdateOptimized = %v, %v", fleet, err)
+	}
+	fleet, err = f.sm.GetVersionFleetUpdate(ctx, f.versionSetID.String())
+	if err != nil || len(fleet.NodeUpdateItems) != 1 {
+		t.Fatalf("GetVersionFleetUpdate = %v, %v", fleet, err)
+	}
+}
+
+func TestSqliteDraftOnlyWrites(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	if err := f.sm.UpdateDraftWhere(ctx, "nodes", map[string]any{"locality": "yard"}, nil, "serial_number = $1", "gw-1"); err != nil {
+		t.Fatalf("update of a draft: %v", err)
+	}
+	if _, err := f.sm.TransitionVersionSet(ctx, f.versionSetID, types.VERSION_STATE_PENDING_DEPLOYMENT); err != nil {
+		t.Fatalf("draft to pending: %v", err)
+	}
+
+	if err := f.sm.UpdateDraftWhere(ctx, "nodes", map[string]any{"locality": "hall"}, nil, "serial_number = $1", "gw-1"); !errors.Is(err, ErrNotDraft) {
+		t.Errorf("update of a pending version set = %v, want ErrNotDraft", err)
+	}
+	if err := f.sm.DeleteDraftWhere(ctx, "proxies", "id = $1", f.proxy.ID); !errors.Is(err, ErrNotDraft) {
+		t.Errorf("delete from a pending version set = %v, want ErrNotDraft", err)
+	}
+	if _, err := f.sm.CreateNode(ctx, &types.Node{SerialNumber: "gw-2", VersionSetID: f.versionSetID, CreatedBy: "tester"}); !errors.Is(err, ErrNotDraft) {
+		t.Errorf("create in a pending version set = %v, want ErrNotDraft", err)
+	}
+	if _, err := f.sm.CreateNode(ctx, &types.Node{SerialNumber: "gw-2", VersionSetID: uuid.Must(uuid.NewV4()), CreatedBy: "tester"}); !IsNoRows(err) {
+		t.Errorf("create in a missing version set = %v, want no rows", err)
+	}
+
+	node, err := f.sm.GetNodebySerial(ctx, "gw-1", f.versionSetID)
+	if err != nil || node.Locality != "yard" {
+		t.Errorf("node after refused update = %+v, %v", node, err)
+	}
+	if _, err := f.sm.GetProxyByID(ctx, f.proxy.ID); err != nil {
+		t.Errorf("proxy after refused delete: %v", err)
+	}
+}
+
+func TestSqliteDraftWhereQuotedName(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	if _, err := f.sm.TransitionVersionSet(ctx, f.versionSetID, types.VERSION_STATE_PENDING_DEPLOYMENT); err != nil {
+		t.Fatalf("draft to pending: %v", err)
+	}
+	draftID, err := f.sm.CreateVersionSet(ctx, types.VersionSet{Name: "draft", CreatedBy: "tester", State: types.VERSION_STATE_DRAFT})
+	if err != nil {
+		t.Fatalf("create version set: %v", err)
+	}
+	if _, err := f.sm.CreateNode(ctx, &types.Node{SerialNumber: "o'neil", Locality: "hall", VersionSetID: draftID, CreatedBy: "tester"}); err != nil {
+		t.Fatalf("create node: %v", err)
+	}
+
+	where := "serial_number = $1 AND version_set_id = $2"
+	if err := f.sm.UpdateDraftWhere(ctx, "nodes", map[string]any{"locality": "yard"}, nil, where, "x' OR '1'='1", draftID); err != nil {
+		t.Fatalf("update with a quoted serial number: %v", err)
+	}
+	if err := f.sm.DeleteDraftWhere(ctx, "nodes", where, "x' OR '1'='1", draftID); err != nil {
+		t.Fatalf("delete with a quoted serial number: %v", err)
+	}
+	if node, err := f.sm.GetNodebySerial(ctx, "gw-1", f.versionSetID); err != nil || node.Locality != "hall" {
+		t.Errorf("node of the pending version set = %+v, %v, want it untouched", node, err)
+	}
+
+	if err := f.sm.UpdateDraftWhere(ctx, "nodes", map[string]any{"locality": "yard"}, nil, where, "o'neil", draftID); err != nil {
+		t.Fatalf("update of o'neil: %v", err)
+	}
+	node, err := f.sm.GetNodebySerial(ctx, "o'neil", draftID)
+	if err != nil || node.Locality != "yard" {
+		t.Errorf("o'neil after update = %+v, %v, want locality yard", node, err)
+	}
+}
+
+func TestSqliteManifestExportImport(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	manifest, origins, err := f.sm.ExportManifest(ctx, f.versionSetID)
+	if err != nil {
+		t.Fatalf("ExportManifest: %v", err)
+	}
+	for _, key := range []string{"endpoint_config/ep", "group/plant", "node/gw-1", "hardware_config/gw-1/eth0/10.0.0.1/24", "proxy/web"} {
+		if origin := origins[key]; origin.CreatedBy != "tester" || origin.CreatedAt.IsZero() {
+			t.Errorf("origin of %s = %+v, want created by tester", key, origin)
+		}
+	}
+
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	origins["proxy/web"] = types.Origin{CreatedBy: "engineer", CreatedAt: created}
+	manifest.VersionSet.Name = "imported"
+	manifest.VersionSet.CreatedBy = "importer"
+	id, err := f.sm.ImportManifest(ctx, manifest, origins, nil)
+	if err != nil {
+		t.Fatalf("ImportManifest: %v", err)
+	}
+	_, imported, err := f.sm.ExportManifest(ctx, id)
+	if err != nil {
+		t.Fatalf("ExportManifest of the import: %v", err)
+	}
+	if origin := imported["proxy/web"]; origin.CreatedBy != "engineer" || !origin.CreatedAt.Equal(created) {
+		t.Errorf("imported proxy origin = %+v, want engineer at %s", origin, created)
+	}
+	if origin := imported["node/gw-1"]; origin.CreatedBy != "tester" {
+		t.Errorf("imported node origin = %+v, want tester", origin)
+	}
+
+	manifest.VersionSet.CreatedBy = ""
+	if _, err := f.sm.ImportManifest(ctx, manifest, nil, nil); !errors.Is(err, ErrInvalidManifest) {
+		t.Errorf("import without author = %v, want ErrInvalidManifest", err)
+	}
+	manifest.VersionSet.CreatedBy = "importer"
+	manifest.Proxies[0].Group = "missing"
+	if _, err := f.sm.ImportManifest(ctx, manifest, nil, nil); !errors.Is(err, ErrInvalidManifest) {
+		t.Errorf("import with a dangling reference = %v, want ErrInvalidManifest", err)
+	}
+}
+
+func TestSqliteTransactionQueries(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	txID, err := f.sm.CreateTransaction(ctx, "roll out", types.TransactionTypeVersionUpdate)
+	if err != nil {
+		t.Fatalf("CreateTransaction: %v", err)
+	}
+	transitionID, err := f.sm.CreateVersionTransition(ctx, &types.VersionTransition{ToVersionSetID: f.versionSetID, Status: types.VersionTransitionActive, CreatedBy: "tester", TransactionID: txID})
+	if err != nil {
+		t.Fatalf("CreateVersionTransition: %v", err)
+	}
+	if err := f.sm.SetTransactionMetadata(ctx, txID, []byte(`{"wave":1}`)); err != nil {
+		t.Fatalf("SetTransactionMetadata: %v", err)
+	}
+	for _, state := range []types.TransactionState{types.TransactionStateError, types.TransactionStateApplied} {
+		if _, err := f.sm.LogNodeTransaction(ctx, &types.NodeTransactionLog{TransactionID: txID, NodeSerial: "gw-1", VersionSetID: f.versionSetID, State: state, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("LogNodeTransaction: %v", err)
+		}
+	}
+
+	transaction, err := f.sm.GetTransaction(ctx, txID)
+	if err != nil || string(transaction.Metadata) != `{"wave":1}` {
+		t.Fatalf("GetTransaction = %+v, %v", transaction, err)
+	}
+	latest, err := f.sm.GetLatestNodeStates(ctx, txID)
+	if err != nil || latest["gw-1"] == nil || latest["gw-1"].State != types.TransactionStateApplied {
+		t.Fatalf("GetLatestNodeStates = %v, %v", latest, err)
+	}
+	entries, err := f.sm.ListTransactionLog(ctx, txID)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("ListTransactionLog = %v, %v", entries, err)
+	}
+	open, err := f.sm.ListOpenTransactions(ctx)
+	if err != nil || len(open) != 1 {
+		t.Fatalf("ListOpenTransactions = %v, %v", open, err)
+	}
+
+	applied := types.TransactionStateApplied
+	now := time.Now()
+	if err := f.sm.UpdateTransaction(ctx, txID, &now, &applied, nil); err != nil {
+		t.Fatalf("UpdateTransaction: %v", err)
+	}
+	if err := f.sm.UpdateVersionTransitionStatus(ctx, transitionID, "failed", nil); err != nil {
+		t.Fatalf("UpdateVersionTransitionStatus: %v", err)
+	}
+
+	serial := "gw-1"
+	state := string(applied)
+	since := now.Add(-time.Hour)
+	transactions, err := f.sm.ListTransactions(ctx, types.HistoryFilter{NodeSerial: &serial, VersionSetID: &f.versionSetID, State: &state, Since: &since, Limit: 5})
+	if err != nil || len(transactions) != 1 || transactions[0].ID != txID {
+		t.Fatalf("ListTransactions = %v, %v", transactions, err)
+	}
+	failed := "failed"
+	transitions, err := f.sm.ListVersionTransitions(ctx, types.HistoryFilter{State: &failed, VersionSetID: &f.versionSetID})
+	if err != nil || len(transitions) != 1 {
+		t.Fatalf("ListVersionTransitions = %v, %v", transitions, err)
+	}
+	transition, err := f.sm.GetVersionTransitionByTransaction(ctx, txID)
+	if err != nil || transition.Status != "failed" {
+		t.Fatalf("GetVersionTransitionByTransaction = %+v, %v", transition, err)
+	}
+}
+
+func TestSqlitePendingUpdateQueries(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	source, err := f.sm.CreateTransaction(ctx, "roll out", types.TransactionTypeVersionUpdate)
+	if err != nil {
+		t.Fatalf("CreateTransaction: %v", err)
+	}
+	queuedTx, err := f.sm.CreateTransaction(ctx, "queued update of gw-1", types.TransactionTypeNodeUpdate)
+	if err != nil {
+		t.Fatalf("CreateTransaction: %v", err)
+	}
+	item := &grpc_controlplane.NodeUpdateItem{SerialNumber: "gw-1"}
+	if _, err := f.sm.EnqueueNodeUpdate(ctx, &types.PendingUpdate{SerialNumber: "gw-1", VersionSetID: f.versionSetID, TransactionID: queuedTx, SourceTransactionID: &source, Item: item, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("EnqueueNodeUpdate: %v", err)
+	}
+
+	update, err := f.sm.QueuedUpdate(ctx, "gw-1")
+	if err != nil || update.SourceTransactionID == nil || *update.SourceTransactionID != source || update.Item.SerialNumber != "gw-1" {
+		t.Fatalf("QueuedUpdate = %+v, %v", update, err)
+	}
+	if superseded, err := f.sm.SupersedeQueuedUpdates(ctx, queuedTx, "wrong source"); err != nil || len(superseded) != 0 {
+		t.Fatalf("SupersedeQueuedUpdates of another source = %v, %v", superseded, err)
+	}
+	superseded, err := f.sm.SupersedeQueuedUpdates(ctx, source, "rolled back")
+	if err != nil || len(superseded) != 1 || superseded[0] != queuedTx {
+		t.Fatalf("SupersedeQueuedUpdates = %v, %v", superseded, err)
+	}
+	if _, err := f.sm.QueuedUpdate(ctx, "gw-1"); !IsNoRows(err) {
+		t.Fatalf("QueuedUpdate after supersede = %v, want no rows", err)
+	}
+}
+
+func TestSqliteReviewQueries(t *testing.T) {
+	ctx := context.Background()
+	f := newSqliteFleet(t)
+
+	if err := f.sm.ApproveVersionSet(ctx, &types.Review{VersionSetID: f.versionSetID, User: "bob"}); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("approval of a draft = %v, want ErrNotPending", err)
+	}
+	if err := f.sm.SubmitVersionSet(ctx, &types.Review{VersionSetID: f.versionSetID, User: "alice"}); err != nil {
+		t.Fatalf("SubmitVersionSet: %v", err)
+	}
+	if err := f.sm.ApproveVersionSet(ctx, &types.Review{VersionSetID: f.versionSetID, User: "alice"}); !errors.Is(err, ErrSelfApproval) {
+		t.Errorf("approval by the submitter = %v, want ErrSelfApproval", err)
+	}
+	if err := f.sm.ApproveVersionSet(ctx, &types.Review{VersionSetID: f.versionSetID, User: "bob"}); err != nil {
+		t.Fatalf("ApproveVersionSet: %v", err)
+	}
+	if err := f.sm.ApproveVersionSet(ctx, &types.Review{VersionSetID: f.versionSetID, User: "carol"}); !errors.Is(err, ErrAlreadyApproved) {
+		t.Errorf("second approval = %v, want ErrAlreadyApproved", err)
+	}
+	submission, approval, err := f.sm.VersionSetApproval(ctx, f.versionSetID)
+	if err != nil || submission == nil || submission.User != "alice" || approval == nil || approval.User != "bob" {
+		t.Fatalf("VersionSetApproval = %+v, %+v, %v", submission, approval, err)
+	}
+
+	// a rejection and a new submission need a new approval, by anyone but the
+	// new submitter
+	if err := f.sm.RejectVersionSet(ctx, &types.Review{VersionSetID: f.versionSetID, User: "carol"}); err != nil {
+		t.Fatalf("RejectVersionSet: %v", err)
+	}
+	if err := f.sm.SubmitVersionSet(ctx, &types.Review{VersionSetID: f.versionSetID, User: "bob"}); err != nil {
+		t.Fatalf("second SubmitVersionSet: %v", err)
+	}
+	if _, approval, err := f.sm.VersionSetApproval(ctx, f.versionSetID); err != nil || approval != nil {
+		t.Fatalf("approval after resubmission = %+v, %v, want none", approval, err)
+	}
+	if err := f.sm.ApproveVersionSet(ctx, &types.Review{VersionSetID: f.versionSetID, User: "bob"}); !errors.Is(err, ErrSelfApproval) {
+		t.Errorf("approval by the new submitter = %v, want ErrSelfApproval", err)
+	}
+	if err := f.sm.ApproveVersionSet(ctx, &types.Review{VersionSetID: f.versionSetID, User: "alice"}); err != nil {
+		t.Errorf("approval by the first submitter: %v", err)
+	}
+
+	reviews, err := f.sm.ListReviews(ctx, types.ReviewFilter{VersionSetID: &f.versionSetID})
+	if err != nil || len(reviews) != 5 {
+		t.Fatalf("ListReviews = %v, %v, want 5 reviews", reviews, err)
+	}
+	if reviews[0].Action != types.ReviewApproved || reviews[0].User != "alice" {
+		t.Errorf("latest review = %s by %s, want approved by alice", reviews[0].Action, reviews[0].User)
+	}
+}
+
+func TestSqliteManifestReapply(t *testing.T) {
+	ctx := context.Background()
+	sm := newSqliteStateManager(t)
+	if _, err := sm.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	// the manifest leaves out what Normalize and the database fill in: the key
+	// exchange method, the state of a proxy, the prefix length of an address
+	disabled := false
+	manifest := func() *types.Manifest {
+		return &types.Manifest{
+			VersionSet: types.ManifestVersionSet{Name: "plant", CreatedBy: "tester"},
+			EndpointConfigs: []types.ManifestEndpointConfig{
+				{Name: "legacy", NoEncryption: true},
+				{Name: "ep", MutualAuth: true, ASLKeyExchangeMethod: "ASL_KEX_DEFAULT", Cipher: "AES-256-GCM"},
+			},
+			Groups: []types.ManifestGroup{
+				{Name: "plant", LogLevel: 2, EndpointConfig: "ep", LegacyConfig: "legacy"},
+				{Name: "office", LogLevel: 3, EndpointConfig: "ep"},
+			},
+			Nodes: []types.ManifestNode{
+				{SerialNumber: "gw-2", NetworkIndex: 2},
+				{SerialNumber: "gw-1", NetworkIndex: 1, Locality: "hall"},
+			},
+			HardwareConfigs: []types.ManifestHardwareConfig{
+				{Node: "gw-1", Device: "eth0", IPCIDR: "10.0.0.1/24"},
+				{Node: "gw-1", Device: "eth0", IPCIDR: "10.0.1.7"},
+				{Node: "gw-2", Device: "eth1", IPCIDR: "fd00::2/64"},
+			},
+			Proxies: []types.ManifestProxy{
+				{Name: "web", Node: "gw-1", Group: "plant", ProxyType: types.PROXY_TYPE_REVERSE, ServerEndpointAddr: "0.0.0.0:443", ClientEndpointAddr: "127.0.0.1:80"},
+				{Name: "api", Node: "gw-2", Group: "plant", State: &disabled, ProxyType: types.PROXY_TYPE_TLSTLS, ServerEndpointAddr: "0.0.0.0:8443", ClientEndpointAddr: "10.0.0.3:443"},
+				{Name: "out", Node: "gw-2", Group: "office", ProxyType: types.PROXY_TYPE_FORWARD, ServerEndpointAddr: "10.0.0.4:443", ClientEndpointAddr: ":8080"},
+			},
+		}
+	}
+
+	plan, err := sm.ApplyManifest(ctx, manifest(), false)
+	if err != nil || !plan.Applied || len(plan.Steps) == 0 {
+		t.Fatalf("first ApplyManifest = %+v, %v", plan, err)
+	}
+	id := plan.VersionSetID
+
+	// every write sets updated_at or creates a row with a new id
+	tables := []string{"endpoint_configs", "groups", "nodes", "hardware_configs", "proxies"}
+	for _, table := range tables {
+		err := sm.ExecuteInTransaction(ctx, func(tx Tx) error {
+			_, err := tx.Exec(ctx, `UPDATE `+table+` SET updated_at = '2000-01-01 00:00:00' WHERE version_set_id = $1`, id)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("backdate %s: %v", table, err)
+		}
+	}
+	rows := func() []string {
+		var rows []string
+		for _, table := range tables {
+			err := sm.ExecuteInTransaction(ctx, func(tx Tx) error {
+				query := `SELECT id, CAST(updated_at AS TEXT) FROM ` + table + ` WHERE version_set_id = $1 ORDER BY id`
+				return queryEach(ctx, tx, query, []any{id}, func(r Rows) error {
+					var rowID int
+					var updatedAt string
+					if err := r.Scan(&rowID, &updatedAt); err != nil {
+						return err
+					}
+					rows = append(rows, fmt.Sprintf("%s/%d/%s", table, rowID, updatedAt))
+					return nil
+				})
+			})
+			if err != nil {
+				t.Fatalf("rows of %s: %v", table, err)
+			}
+		}
+		return rows
+	}
+	before := rows()
+
+	for _, dryRun := range []bool{true, false} {
+		plan, err = sm.ApplyManifest(ctx, manifest(), dryRun)
+		if err != nil {
+			t.Fatalf("ApplyManifest again with dry run %v: %v", dryRun, err)
+		}
+		if len(plan.Steps) != 0 || plan.VersionSetID != id {
+			t.Errorf("plan of the same manifest with dry run %v = %+v, want no steps for %s", dryRun, plan.Steps, id)
+		}
+	}
+	if after := rows(); !slices.Equal(before, after) {
+		t.Errorf("rows after applying the same manifest = %v, want %v", after, before)
+	}
+
+	// an exported manifest matches the version set it came from, and an empty
+	// plan does not need a draft
+	if _, err := sm.TransitionVersionSet(ctx, id, types.VERSION_STATE_PENDING_DEPLOYMENT); err != nil {
+		t.Fatalf("draft to pending: %v", err)
+	}
+	exported, _, err := sm.ExportManifest(ctx, id)
+	if err != nil {
+		t.Fatalf("ExportManifest: %v", err)
+	}
+	plan, err = sm.ApplyManifest(ctx, exported, false)
+	if err != nil || len(plan.Steps) != 0 {
+		t.Errorf("ApplyManifest of the export = %+v, %v, want no steps", plan, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	pgxuuid "github.com/jackc/pgx-gofrs-uuid"
@@ -13,77 +14,153 @@ import (
 )
 
 type StateManager struct {
-	pool *pgxpool.Pool
+	store Storage
+	// onlineWindow is how recently a node must have sent a hello to count as
+	// online if it is not connected to the broker
+	onlineWindow time.Duration
 }
 
-// Config holds database configuration
-type Config struct {
-	Host         string
-	Port         int
-	User         string
-	Password     string
-	DatabaseName string
-	SSLMode      string
+// defaultConfig fills unset fields of cfg with the built-in defaults. The
+// password has no default, see NewStateManager.
+func defaultConfig(cfg types.DatabaseConfig) types.DatabaseConfig {
+	if cfg.Type == "" {
+		cfg.Type = types.DatabasePostgres
+	}
+	if cfg.Host == "" {
+		cfg.Host = "localhost"
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 5432
+	}
+	if cfg.User == "" {
+		cfg.User = "postgres"
+	}
+	if cfg.DatabaseName == "" {
+		cfg.DatabaseName = "postgres"
+	}
+	if cfg.SSLMode == "" {
+		cfg.SSLMode = "prefer"
+	}
+	if cfg.MaxConns == 0 {
+		cfg.MaxConns = 10
+	}
+	if cfg.MinConns == 0 {
+		cfg.MinConns = 2
+	}
+	if cfg.MaxConnLifetime == 0 {
+		cfg.MaxConnLifetime = time.Hour
+	}
+	if cfg.MaxConnIdleTime == 0 {
+		cfg.MaxConnIdleTime = 30 * time.Minute
+	}
+	return cfg
 }
 
-// DefaultConfig returns a default configuration
-func DefaultConfig() Config {
-	return Config{
-		Host:         "localhost",
-		Port:         5432,
-		User:         "postgres",
-		Password:     "postgres",
-		DatabaseName: "postgres",
-	}
+// quoteConnValue quotes a keyword/value connection string value, so that
+// passwords and paths containing spaces or quotes survive parsing
+func quoteConnValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
 }
 
-// BuildConnectionString creates a PostgreSQL connection string
-func (c Config) BuildConnectionString() string {
-	return fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		c.Host, c.Port, c.User, c.Password, c.DatabaseName, c.SSLMode,
-	)
+// buildConnectionString creates a PostgreSQL connection string. The database
+// name is omitted when dbname is empty, which is used for the admin connection.
+func buildConnectionString(c types.DatabaseConfig, dbname string) string {
+	params := []string{
+		"host=" + quoteConnValue(c.Host),
+		fmt.Sprintf("port=%d", c.Port),
+		"user=" + quoteConnValue(c.User),
+		"password=" + quoteConnValue(c.Password),
+		"sslmode=" + quoteConnValue(c.SSLMode),
+	}
+	if dbname != "" {
+		params = append(params, "dbname="+quoteConnValue(dbname))
+	}
+	if c.SSLRootCert != "" {
+		params = append(params, "sslrootcert="+quoteConnValue(c.SSLRootCert))
+	}
+	if c.SSLCert != "" {
+		params = append(params, "sslcert="+quoteConnValue(c.SSLCert))
+	}
+	if c.SSLKey != "" {
+		params = append(params, "sslkey="+quoteConnValue(c.SSLKey))
+	}
+	return strings.Join(params, " ")
 }
 
-func NewStateManager(ctx context.Context, log_cfg types.LogConfig) (*StateManager, error) {
+func NewStateManager(ctx context.Context, cfg types.DatabaseConfig) (*StateManager, error) {
 	log.Trace().Msg("in function new Statemanager")
-	dbConfig := DefaultConfig()
+	dbConfig := defaultConfig(cfg)
 
-	log = types.CreateLogger("db", log_cfg.Level, log_cfg.File)
+	log = types.CreateLogger("db", cfg.LogConfig.Level, cfg.LogConfig.File)
 
 	// Override with environment variables if needed
 	if envHost := os.Getenv("DB_HOST"); envHost != "" {
 		dbConfig.Host = envHost
 	}
 
-	pool, err := SetupDatabase(ctx, dbConfig)
-	if err != nil {
-		log.Err(err).Msgf("Failed to setup database: %v", err)
-		return nil, err
+	if dbConfig.MinConns > dbConfig.MaxConns {
+		return nil, fmt.Errorf("database min_conns (%d) exceeds max_conns (%d)", dbConfig.MinConns, dbConfig.MaxConns)
+	}
+
+	switch dbConfig.Type {
+	case types.DatabaseSqlite:
+		store, err := openSqlite(ctx, dbConfig.SqlitePath, dbConfig.MaxConns)
+		if err != nil {
+			log.Err(err).Msg("Failed to open sqlite database")
+			return nil, err
+		}
+		return &StateManager{store: store, onlineWindow: types.DefaultOnlineWindow}, nil
+	case types.DatabasePostgres:
+		// a well-known default password would hide a missing secret
+		if dbConfig.Password == "" && dbConfig.SSLCert == "" {
+			return nil, fmt.Errorf("database password is not set, configure password, password_file or password_env")
+		}
+		pool, err := SetupDatabase(ctx, dbConfig)
+		if err != nil {
+			log.Err(err).Msgf("Failed to setup database: %v", err)
+			return nil, err
+		}
+		return &StateManager{store: &postgresStorage{pool: pool}, onlineWindow: types.DefaultOnlineWindow}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database type %q", dbConfig.Type)
 	}
+}
 
-	return &StateManager{pool: pool}, nil
+// Dialect returns the database backend in use, see types.DatabasePostgres and
+// types.DatabaseSqlite
+func (sm *StateManager) Dialect() string {
+	return sm.store.Dialect()
+}
+
+// SetOnlineWindow sets how long a node counts as online after its last hello
+func (sm *StateManager) SetOnlineWindow(window time.Duration) {
+	if window > 0 {
+		sm.onlineWindow = window
+	}
+}
+
+// Close releases all connections of the state manager
+func (sm *StateManager) Close() {
+	sm.store.Close()
 }
 
 // SetupDatabase initializes the database and runs migrations
-func SetupDatabase(ctx context.Context, config Config) (*pgxpool.Pool, error) {
+func SetupDatabase(ctx context.Context, config types.DatabaseConfig) (*pgxpool.Pool, error) {
 	log.Trace().Msg("in function SetupDatabase")
 	// First, try to connect to create the database if it doesn't exist
-	adminConnStr := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s",
-		config.Host, config.Port, config.User, config.Password,
-	)
-	log.Debug().Msgf("admin connection is: %s", adminConnStr)
+	adminConnStr := buildConnectionString(config, "")
+	log.Debug().Msgf("admin connection to %s:%d as %s (sslmode=%s)", config.Host, config.Port, config.User, config.SSLMode)
 	adminPool, err := pgxpool.Connect(ctx, adminConnStr)
 	if err != nil {
-		log.Err(err).Msg("")
 		log.Err(err).Msg("failed to connect to PostgreSQL")
 		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
 	}
 	defer adminPool.Close()
 
 	// Connect to the specific database
-	poolConfig, err := pgxpool.ParseConfig(config.BuildConnectionString())
+	poolConfig, err := pgxpool.ParseConfig(buildConnectionString(config, config.DatabaseName))
 	if err != nil {
 		log.Err(err).Msgf("failed to parse connection string")
 		return nil, err
@@ -94,10 +171,10 @@ func SetupDatabase(ctx context.Context, config Config) (*pgxpool.Pool, error) {
 	}
 
 	// Set connection pool settings
-	poolConfig.MaxConns = 10
-	poolConfig.MinConns = 2
-	poolConfig.MaxConnLifetime = time.Hour
-	poolConfig.MaxConnIdleTime = 30 * time.Minute
+	poolConfig.MaxConns = config.MaxConns
+	poolConfig.MinConns = config.MinConns
+	poolConfig.MaxConnLifetime = config.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = config.MaxConnIdleTime
 	poolConfig.HealthCheckPeriod = 1 * time.Minute
 
 	// Create connection pool
@@ -117,56 +194,28 @@ func SetupDatabase(ctx context.Context, config Config) (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
-// InitializeSchema creates all necessary database tables and types
+// InitializeSchema brings the database schema up to the latest migration
 func (sm *StateManager) InitializeSchema() error {
 	log.Debug().Msg("Initializing database schema")
 
-	return sm.ExecuteInTransaction(context.Background(), func(tx pgx.Tx) error {
-		_, err := tx.Exec(context.Background(), schemaSQL)
-		if err != nil {
-			return fmt.Errorf("failed to initialize schema: %w", err)
-		}
-		return nil
-	})
+	applied, err := sm.MigrateUp(context.Background(), 0)
+	if err != nil {
+		return fmt.Errorf("failed to initialize schema: %w", err)
+	}
+	log.Debug().Msgf("applied migrations: %v", applied)
+	return nil
 }
 
 // ResetDatabase drops all tables and recreates them
 func (sm *StateManager) ResetDatabase() error {
 	log.Debug().Msg("Resetting database")
 
-	// Drop all tables in reverse order of dependencies
-	dropSQL := `
-    drop trigger if exists trigger_update_node_disabled_status on nodes;
-	drop function if exists trg_update_node_disabled_status();
-	drop table if exists change_log cascade;
-	drop table if exists version_sets cascade;
-	drop table if exists version_transitions cascade;
-	drop type if exists version_state cascade;
-	drop type if exists version_transition_status cascade;
-	drop type if exists transaction_type cascade;
-	drop type if exists transaction_state cascade;
-	drop table if exists hardware_configs cascade;
-	drop table if exists proxies cascade;
-	drop table if exists endpoint_configs cascade;
-	drop table if exists groups cascade;
-	drop table if exists nodes cascade;
-	drop table if exists enroll cascade;
-	drop table if exists transactions cascade;
-	drop function if exists handle_transaction_rollback() cascade;
-	drop table if exists transaction_log cascade;
-	drop function if exists ensure_single_pending_transaction() cascade;
-	drop function if exists create_new_pending_transaction() cascade;
-	drop function if exists log_changes() cascade;
-	drop function if exists process_rollback() cascade;
-	drop function if exists complete_transaction() cascade;
-	drop function if exists rollback_transaction() cascade;
-	drop type if exists transaction_status cascade;
-	drop type if exists proxy_type cascade;
-	drop type if exists asl_key_exchange_method cascade;
-	drop type if exists operation_type cascade;
-	`
-	return sm.ExecuteInTransaction(context.Background(), func(tx pgx.Tx) error {
-		_, err := tx.Exec(context.Background(), dropSQL)
+	return sm.ExecuteInTransaction(context.Background(), func(tx Tx) error {
+		drop := dropSQL + "drop table if exists schema_migrations cascade;"
+		if sm.store.Dialect() == types.DatabaseSqlite {
+			drop = sqliteDropSQL + "drop table if exists schema_migrations;"
+		}
+		_, err := tx.Exec(context.Background(), drop)
 		if err != nil {
 			log.Err(err).Msg("failed to drop tables")
 			return err
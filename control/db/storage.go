@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoRows is returned by Row.Scan when a query returned no rows, regardless
+// of the storage backend in use.
+var ErrNoRows = pgx.ErrNoRows
+
+// CommandTag reports the outcome of Tx.Exec.
+type CommandTag interface {
+	RowsAffected() int64
+}
+
+// Row is the result of Tx.QueryRow.
+type Row interface {
+	Scan(dest ...any) error
+}
+
+// Rows is the result of Tx.Query.
+type Rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+	Close()
+}
+
+// Tx is the handle passed to ExecuteInTransaction. Queries are written in the
+// Postgres dialect with $n placeholders. Statements which SQLite can not run
+// as written are kept per dialect in a map and chosen by Dialect.
+type Tx interface {
+	Exec(ctx context.Context, sql string, args ...any) (CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) Row
+	// Dialect returns types.DatabasePostgres or types.DatabaseSqlite
+	Dialect() string
+}
+
+// storageTx is a Tx which can be finished by the StateManager.
+type storageTx interface {
+	Tx
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// Storage is the database backend behind a StateManager.
+type Storage interface {
+	// Dialect returns types.DatabasePostgres or types.DatabaseSqlite
+	Dialect() string
+	Begin(ctx context.Context) (storageTx, error)
+	Ping(ctx context.Context) error
+	Close()
+}
+
+// IsNoRows reports whether err is caused by a query returning no rows.
+func IsNoRows(err error) bool {
+	return errors.Is(err, ErrNoRows)
+}
@@ -3,14 +3,24 @@ package db
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/gofrs/uuid/v5"
-	"github.com/jackc/pgx/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
 )
 
+// startTransactionStatement inserts a pending transaction created by $1
+var startTransactionStatement = map[string]string{
+	types.DatabasePostgres: `INSERT INTO transactions (status, created_at, created_by, description, metadata) 
+          VALUES ('pending', NOW(), $1, $2, '{}'::jsonb) RETURNING id`,
+	types.DatabaseSqlite: `INSERT INTO transactions (status, created_at, created_by, description, metadata) 
+          VALUES ('pending', CURRENT_TIMESTAMP, $1, $2, '{}') RETURNING id`,
+}
+
 // StartTransaction initializes a new transaction and returns the transaction ID.
 func (s *StateManager) StartTransaction(ctx context.Context, createdBy, description string) (uuid.UUID, error) {
-	tx, err := s.pool.Begin(ctx)
+	tx, err := s.store.Begin(ctx)
 	if err != nil {
 		return uuid.UUID{}, fmt.Errorf("failed to start transaction: %w", err)
 	}
@@ -20,11 +30,8 @@ func (s *StateManager) StartTransaction(ctx context.Context, createdBy, descript
 		}
 	}()
 
-	query := `INSERT INTO transactions (status, created_at, created_by, description, metadata) 
-          VALUES ('pending', NOW(), $1, $2, '{}'::jsonb) RETURNING id`
-
 	var transactionID uuid.UUID
-	err = tx.QueryRow(ctx, query, createdBy, description).Scan(&transactionID)
+	err = tx.QueryRow(ctx, startTransactionStatement[tx.Dialect()], createdBy, description).Scan(&transactionID)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("failed to insert transaction: %w", err)
 	}
@@ -38,8 +45,8 @@ func (s *StateManager) StartTransaction(ctx context.Context, createdBy, descript
 }
 
 // ExecuteInTransaction executes the given operation within a transaction
-func (s *StateManager) ExecuteInTransaction(ctx context.Context, operation func(pgx.Tx) error) error {
-	tx, err := s.pool.Begin(ctx)
+func (s *StateManager) ExecuteInTransaction(ctx context.Context, operation func(Tx) error) error {
+	tx, err := s.store.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -51,3 +58,195 @@ func (s *StateManager) ExecuteInTransaction(ctx context.Context, operation func(
 
 	return tx.Commit(ctx)
 }
+
+const transactionColumns = `t.id, t.type, t.created_at, t.completed_at, t.description, t.metadata, t.state`
+
+func scanTransaction(row Row) (*types.Transaction, error) {
+	transaction := &types.Transaction{}
+	err := row.Scan(
+		&transaction.ID,
+		&transaction.Type,
+		&transaction.CreatedAt,
+		&transaction.CompletedAt,
+		&transaction.Description,
+		&transaction.Metadata,
+		&transaction.State,
+	)
+	return transaction, err
+}
+
+// GetTransaction returns the transaction with the given id
+func (s *StateManager) GetTransaction(ctx context.Context, id int) (*types.Transaction, error) {
+	var transaction *types.Transaction
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := `SELECT ` + transactionColumns + ` FROM transactions t WHERE t.id = $1`
+
+		var err error
+		transaction, err = scanTransaction(tx.QueryRow(ctx, query, id))
+		return err
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to get transaction")
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// ListOpenTransactions returns the transactions which have not been completed,
+// oldest first
+func (s *StateManager) ListOpenTransactions(ctx context.Context) ([]*types.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions t WHERE t.completed_at IS NULL ORDER BY t.id`
+	transactions, err := s.queryTransactions(ctx, query)
+	if err != nil {
+		log.Err(err).Msg("failed to list open transactions")
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// transactionStateCondition compares the state of transaction t with a
+// parameter, Postgres has to cast it to the enum
+var transactionStateCondition = map[string]string{
+	types.DatabasePostgres: `t.state = %s::transaction_state`,
+	types.DatabaseSqlite:   `t.state = %s`,
+}
+
+// ListTransactions returns the transactions matching filter, newest first
+func (s *StateManager) ListTransactions(ctx context.Context, filter types.HistoryFilter) ([]*types.Transaction, error) {
+	var conditions []string
+	var args []any
+	if filter.NodeSerial != nil {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM transaction_log l WHERE l.transaction_id = t.id AND l.node_serial = `+addArg(&args, *filter.NodeSerial)+`)`)
+	}
+	if filter.VersionSetID != nil {
+		arg := addArg(&args, *filter.VersionSetID)
+		conditions = append(conditions, `(EXISTS (SELECT 1 FROM transaction_log l WHERE l.transaction_id = t.id AND l.version_set_id = `+arg+`)
+			OR EXISTS (SELECT 1 FROM version_transitions v WHERE v.transaction_id = t.id AND v.to_version_id = `+arg+`))`)
+	}
+	if filter.State != nil {
+		conditions = append(conditions, fmt.Sprintf(transactionStateCondition[s.Dialect()], addArg(&args, *filter.State)))
+	}
+	conditions = append(conditions, timeConditions("t.created_at", filter, &args)...)
+
+	query := `SELECT ` + transactionColumns + ` FROM transactions t` + whereClause(conditions) + ` ORDER BY t.id DESC` + limitClause(filter, &args)
+	transactions, err := s.queryTransactions(ctx, query, args...)
+	if err != nil {
+		log.Err(err).Msg("failed to list transactions")
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (s *StateManager) queryTransactions(ctx context.Context, query string, args ...any) ([]*types.Transaction, error) {
+	var transactions []*types.Transaction
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			transaction, err := scanTransaction(rows)
+			if err != nil {
+				return err
+			}
+			transactions = append(transactions, transaction)
+		}
+		return rows.Err()
+	})
+	return transactions, err
+}
+
+// addArg appends value to args and returns its placeholder
+func addArg(args *[]any, value any) string {
+	*args = append(*args, value)
+	return "$" + strconv.Itoa(len(*args))
+}
+
+// timeConditions restricts column to the time range of filter
+func timeConditions(column string, filter types.HistoryFilter, args *[]any) []string {
+	var conditions []string
+	if filter.Since != nil {
+		conditions = append(conditions, column+` >= `+addArg(args, *filter.Since))
+	}
+	if filter.Until != nil {
+		conditions = append(conditions, column+` < `+addArg(args, *filter.Until))
+	}
+	return conditions
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return ` WHERE ` + strings.Join(conditions, ` AND `)
+}
+
+func limitClause(filter types.HistoryFilter, args *[]any) string {
+	if filter.Limit <= 0 {
+		return ""
+	}
+	return ` LIMIT ` + addArg(args, filter.Limit)
+}
+
+// SetTransactionMetadata replaces the metadata of a transaction
+func (s *StateManager) SetTransactionMetadata(ctx context.Context, id int, metadata []byte) error {
+	return s.Update(ctx, "transactions", map[string]any{"metadata": metadata}, "id", id)
+}
+
+// ListTransactionLog returns the transaction_log rows of a transaction in the
+// order they were written
+func (s *StateManager) ListTransactionLog(ctx context.Context, transactionID int) ([]*types.NodeTransactionLog, error) {
+	var entries []*types.NodeTransactionLog
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := `
+		SELECT id, transaction_id, node_serial, version_set_id, state, timestamp, metadata
+		FROM transaction_log
+		WHERE transaction_id = $1
+		ORDER BY id`
+
+		rows, err := tx.Query(ctx, query, transactionID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			entry := &types.NodeTransactionLog{}
+			if err := rows.Scan(
+				&entry.ID,
+				&entry.TransactionID,
+				&entry.NodeSerial,
+				&entry.VersionSetID,
+				&entry.State,
+				&entry.Timestamp,
+				&entry.Metadata,
+			); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to list transaction log")
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetLatestNodeStates returns the most recent transaction_log row of every
+// node taking part in the given transaction
+func (s *StateManager) GetLatestNodeStates(ctx context.Context, transactionID int) (map[string]*types.NodeTransactionLog, error) {
+	entries, err := s.ListTransactionLog(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]*types.NodeTransactionLog)
+	for _, entry := range entries {
+		states[entry.NodeSerial] = entry
+	}
+	return states, nil
+}
@@ -2,11 +2,11 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
-	"github.com/jackc/pgx/v5"
 	"github.com/philslol/kritis3m_scalev2/control/types"
 )
 
@@ -14,7 +14,7 @@ import (
 // CreateVersionSet inserts a new version set into the database.
 func (s *StateManager) CreateVersionSet(ctx context.Context, vs types.VersionSet) (uuid.UUID, error) {
 	var id uuid.UUID
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 			INSERT INTO version_sets (name, description, created_by, metadata)
 			VALUES ($1, $2, $3, $4)
@@ -28,13 +28,22 @@ func (s *StateManager) CreateVersionSet(ctx context.Context, vs types.VersionSet
 	return id, nil
 }
 
-// DeleteVersionSet soft deletes a version set by setting its disabled_at timestamp.
-func (s *StateManager) DeleteVersionSet(ctx context.Context, id uuid.UUID) error {
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
-		query := `
+// deleteVersionSetStatement disables version set $1
+var deleteVersionSetStatement = map[string]string{
+	types.DatabasePostgres: `
 			UPDATE version_sets 
 			SET disabled_at = NOW()
-			WHERE id = $1 AND disabled_at IS NULL`
+			WHERE id = $1 AND disabled_at IS NULL`,
+	types.DatabaseSqlite: `
+			UPDATE version_sets 
+			SET disabled_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND disabled_at IS NULL`,
+}
+
+// DeleteVersionSet soft deletes a version set by setting its disabled_at timestamp.
+func (s *StateManager) DeleteVersionSet(ctx context.Context, id uuid.UUID) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := deleteVersionSetStatement[s.Dialect()]
 		result, err := tx.Exec(ctx, query, id)
 		if err != nil {
 			return fmt.Errorf("failed to delete version set: %w", err)
@@ -52,13 +61,21 @@ func (s *StateManager) DeleteVersionSet(ctx context.Context, id uuid.UUID) error
 	return nil
 }
 
+// versionSetByIDStatement selects version set $1
+var versionSetByIDStatement = map[string]string{
+	types.DatabasePostgres: `
+			SELECT id::text, name, description, state, created_at, activated_at, disabled_at, created_by, metadata
+			FROM version_sets WHERE id = $1::uuid`,
+	types.DatabaseSqlite: `
+			SELECT CAST(id AS TEXT), name, description, state, created_at, activated_at, disabled_at, created_by, metadata
+			FROM version_sets WHERE id = $1`,
+}
+
 // GetVersionSetByID retrieves a version set by its ID.
 func (s *StateManager) GetVersionSetByID(ctx context.Context, id uuid.UUID) (*types.VersionSet, error) {
 	var vs types.VersionSet
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
-		query := `
-			SELECT id::text, name, description, state, created_at, activated_at, disabled_at, created_by, metadata
-			FROM version_sets WHERE id = $1::uuid`
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := versionSetByIDStatement[s.Dialect()]
 		var idStr string
 		err := tx.QueryRow(ctx, query, id.String()).Scan(
 			&idStr, &vs.Name, &vs.Description, &vs.State, &vs.CreatedAt,
@@ -79,7 +96,7 @@ func (s *StateManager) GetVersionSetByID(ctx context.Context, id uuid.UUID) (*ty
 // ListVersionSets retrieves all active version sets.
 func (s *StateManager) ListVersionSets(ctx context.Context) ([]*types.VersionSet, error) {
 	var versionSets []*types.VersionSet
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 			SELECT id, name, description, state, created_at, activated_at, disabled_at, created_by, metadata
 			FROM version_sets
@@ -108,13 +125,22 @@ func (s *StateManager) ListVersionSets(ctx context.Context) ([]*types.VersionSet
 	return versionSets, nil
 }
 
-// ActivateVersionSet marks a version set as active.
-func (s *StateManager) ActivateVersionSet(ctx context.Context, id uuid.UUID) error {
-	return s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
-		query := `
+// activateVersionSetStatement marks version set $1 as active
+var activateVersionSetStatement = map[string]string{
+	types.DatabasePostgres: `
 			UPDATE version_sets 
 			SET activated_at = NOW(), state = 'active'
-			WHERE id = $1 AND disabled_at IS NULL AND activated_at IS NULL`
+			WHERE id = $1 AND disabled_at IS NULL AND activated_at IS NULL`,
+	types.DatabaseSqlite: `
+			UPDATE version_sets 
+			SET activated_at = CURRENT_TIMESTAMP, state = 'active'
+			WHERE id = $1 AND disabled_at IS NULL AND activated_at IS NULL`,
+}
+
+// ActivateVersionSet marks a version set as active.
+func (s *StateManager) ActivateVersionSet(ctx context.Context, id uuid.UUID) error {
+	return s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := activateVersionSetStatement[s.Dialect()]
 		result, err := tx.Exec(ctx, query, id)
 		if err != nil {
 			return fmt.Errorf("failed to activate version set: %w", err)
@@ -127,6 +153,180 @@ func (s *StateManager) ActivateVersionSet(ctx context.Context, id uuid.UUID) err
 	})
 }
 
+// ErrIllegalTransition is returned by TransitionVersionSet for a state change
+// the version set lifecycle does not allow
+var ErrIllegalTransition = errors.New("illegal version set transition")
+
+// ErrNotDraft is returned for changes to a version set which is not a draft
+var ErrNotDraft = errors.New("version set is not a draft")
+
+// activateTransitionStatement moves version set $1 to the active state $2
+var activateTransitionStatement = map[string]string{
+	types.DatabasePostgres: `UPDATE version_sets SET state = $2, activated_at = NOW() WHERE id = $1`,
+	types.DatabaseSqlite:   `UPDATE version_sets SET state = $2, activated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+}
+
+// TransitionVersionSet moves a version set to state to and returns the state
+// it was in. Moving to the current state is a no-op. A version set becoming
+// active disables the previously active one.
+func (s *StateManager) TransitionVersionSet(ctx context.Context, id uuid.UUID, to types.VersionState) (types.VersionState, error) {
+	var from types.VersionState
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		if err := tx.QueryRow(ctx, `SELECT state FROM version_sets WHERE id = $1`, id).Scan(&from); err != nil {
+			return err
+		}
+		if from == to {
+			return nil
+		}
+		if !from.CanTransition(to) {
+			return fmt.Errorf("%w from %s to %s", ErrIllegalTransition, from, to)
+		}
+
+		if to == types.VERSION_STATE_ACTIVE {
+			query := `UPDATE version_sets SET state = 'disabled' WHERE state = 'active' AND id <> $1`
+			if _, err := tx.Exec(ctx, query, id); err != nil {
+				return fmt.Errorf("failed to disable active version set: %w", err)
+			}
+			_, err := tx.Exec(ctx, activateTransitionStatement[s.Dialect()], id, string(to))
+			return err
+		}
+		_, err := tx.Exec(ctx, `UPDATE version_sets SET state = $2 WHERE id = $1`, id, string(to))
+		return err
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to move version set %s to %s", id, to)
+		return from, fmt.Errorf("failed to move version set %s to %s: %w", id, to, err)
+	}
+	return from, nil
+}
+
+// lockDraftStatement locks version set $1 against state changes until the
+// transaction ends. SQLite has no row locks, it serializes writing
+// transactions instead.
+var lockDraftStatement = map[string]string{
+	types.DatabasePostgres: `SELECT state FROM version_sets WHERE id = $1 FOR SHARE`,
+	types.DatabaseSqlite:   `SELECT state FROM version_sets WHERE id = $1`,
+}
+
+// lockDraft locks version set id for the rest of tx and returns ErrNotDraft
+// unless it is a draft. A submit or approval running concurrently waits for
+// tx, so the changes of tx land in the draft or not at all.
+func lockDraft(ctx context.Context, tx Tx, id uuid.UUID) error {
+	var state types.VersionState
+	if err := tx.QueryRow(ctx, lockDraftStatement[tx.Dialect()], id).Scan(&state); err != nil {
+		return err
+	}
+	if state != types.VERSION_STATE_DRAFT {
+		return fmt.Errorf("%w: version set %s is %s", ErrNotDraft, id, state)
+	}
+	return nil
+}
+
+// lockDraftsWhere is lockDraft for the version sets holding the rows of table
+// which match where. Rows which do not exist are left to the statement itself.
+func lockDraftsWhere(ctx context.Context, tx Tx, table string, where string, args ...any) error {
+	column := "version_set_id"
+	if table == "version_sets" {
+		column = "id"
+	}
+	var ids []uuid.UUID
+	err := queryEach(ctx, tx, `SELECT DISTINCT `+column+` FROM `+table+` WHERE `+where, args,
+		func(rows Rows) error {
+			var id uuid.UUID
+			err := rows.Scan(&id)
+			ids = append(ids, id)
+			return err
+		})
+	if err != nil {
+		return fmt.Errorf("failed to get version sets of %s: %w", table, err)
+	}
+	for _, id := range ids {
+		if err := lockDraft(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneStatements copy the entities of version set $1 into version set $2.
+// They run in dependency order, so that the composite foreign keys on
+// (name, version_set_id) and (serial_number, version_set_id) are satisfied.
+var cloneStatements = map[string][]string{
+	types.DatabasePostgres: {
+		`INSERT INTO endpoint_configs (name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id, created_by)
+			SELECT name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, $2::uuid, created_by
+			FROM endpoint_configs WHERE version_set_id = $1`,
+		`INSERT INTO nodes (serial_number, network_index, locality, last_seen, version_set_id, created_by)
+			SELECT serial_number, network_index, locality, last_seen, $2::uuid, created_by
+			FROM nodes WHERE version_set_id = $1`,
+		`INSERT INTO groups (name, log_level, endpoint_config_name, legacy_config_name, version_set_id, created_by)
+			SELECT name, log_level, endpoint_config_name, legacy_config_name, $2::uuid, created_by
+			FROM groups WHERE version_set_id = $1`,
+		`INSERT INTO hardware_configs (node_serial, device, ip_cidr, version_set_id, created_by)
+			SELECT node_serial, device, ip_cidr, $2::uuid, created_by
+			FROM hardware_configs WHERE version_set_id = $1`,
+		`INSERT INTO proxies (name, node_serial, group_name, state, proxy_type, server_endpoint_addr, client_endpoint_addr, version_set_id, created_by)
+			SELECT name, node_serial, group_name, state, proxy_type, server_endpoint_addr, client_endpoint_addr, $2::uuid, created_by
+			FROM proxies WHERE version_set_id = $1`,
+	},
+	types.DatabaseSqlite: {
+		`INSERT INTO endpoint_configs (name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, version_set_id, created_by)
+			SELECT name, mutual_auth, no_encryption, asl_key_exchange_method, cipher, $2, created_by
+			FROM endpoint_configs WHERE version_set_id = $1`,
+		`INSERT INTO nodes (serial_number, network_index, locality, last_seen, version_set_id, created_by)
+			SELECT serial_number, network_index, locality, last_seen, $2, created_by
+			FROM nodes WHERE version_set_id = $1`,
+		`INSERT INTO groups (name, log_level, endpoint_config_name, legacy_config_name, version_set_id, created_by)
+			SELECT name, log_level, endpoint_config_name, legacy_config_name, $2, created_by
+			FROM groups WHERE version_set_id = $1`,
+		`INSERT INTO hardware_configs (node_serial, device, ip_cidr, version_set_id, created_by)
+			SELECT node_serial, device, ip_cidr, $2, created_by
+			FROM hardware_configs WHERE version_set_id = $1`,
+		`INSERT INTO proxies (name, node_serial, group_name, state, proxy_type, server_endpoint_addr, client_endpoint_addr, version_set_id, created_by)
+			SELECT name, node_serial, group_name, state, proxy_type, server_endpoint_addr, client_endpoint_addr, $2, created_by
+			FROM proxies WHERE version_set_id = $1`,
+	},
+}
+
+// CloneVersionSet creates a draft version set named name holding a copy of
+// every entity of the source version set. The description is taken from the
+// source; createdBy defaults to the creator of the source.
+func (s *StateManager) CloneVersionSet(ctx context.Context, sourceID uuid.UUID, name string, createdBy string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		var description *string
+		var sourceCreatedBy string
+		query := `SELECT description, created_by FROM version_sets WHERE id = $1`
+		if err := tx.QueryRow(ctx, query, sourceID).Scan(&description, &sourceCreatedBy); err != nil {
+			return err
+		}
+		if createdBy == "" {
+			createdBy = sourceCreatedBy
+		}
+
+		metadata := fmt.Sprintf(`{"cloned_from": %q}`, sourceID.String())
+		query = `
+			INSERT INTO version_sets (name, description, created_by, metadata)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id`
+		if err := tx.QueryRow(ctx, query, name, description, createdBy, []byte(metadata)).Scan(&id); err != nil {
+			return err
+		}
+
+		for _, statement := range cloneStatements[s.Dialect()] {
+			if _, err := tx.Exec(ctx, statement, sourceID, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Err(err).Msg("failed to clone version set")
+		return uuid.Nil, fmt.Errorf("failed to clone version set %s: %w", sourceID, err)
+	}
+	return id, nil
+}
+
 /*----------------------------- VERSION TRANSITION -----------------------------------------*/
 
 // creates a new transaction and returns the id
@@ -139,7 +339,7 @@ func (s *StateManager) CreateTransaction(ctx context.Context, description string
 		VALUES ($1, $2)
 		RETURNING id
 		`
-	err = s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err = s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		return tx.QueryRow(ctx, query, transaction_type, description).Scan(&tx_id)
 	})
 
@@ -153,7 +353,6 @@ func (s *StateManager) CreateTransaction(ctx context.Context, description string
 
 func (s *StateManager) UpdateTransaction(ctx context.Context, tx_id int, completed_at *time.Time, state *types.TransactionState, description *string) error {
 
-	wherestring := fmt.Sprintf("id = '%d'", tx_id)
 	values := make(map[string]any)
 	if completed_at != nil {
 		values["completed_at"] = completed_at
@@ -161,8 +360,11 @@ func (s *StateManager) UpdateTransaction(ctx context.Context, tx_id int, complet
 	if description != nil {
 		values["description"] = description
 	}
+	if state != nil {
+		values["state"] = *state
+	}
 
-	return s.UpdateWhere(ctx, "transactions", values, wherestring)
+	return s.UpdateWhere(ctx, "transactions", values, "id = $1", tx_id)
 }
 
 func (s *StateManager) LogNodeTransaction(ctx context.Context, transaction *types.NodeTransactionLog) (int, error) {
@@ -171,7 +373,7 @@ func (s *StateManager) LogNodeTransaction(ctx context.Context, transaction *type
 		INSERT INTO transaction_log (transaction_id, node_serial, version_set_id, state, timestamp, metadata)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id`
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		return tx.QueryRow(ctx, query, transaction.TransactionID, transaction.NodeSerial, transaction.VersionSetID, transaction.State, transaction.Timestamp, transaction.Metadata).Scan(&id)
 	})
 	if err != nil {
@@ -183,7 +385,7 @@ func (s *StateManager) LogNodeTransaction(ctx context.Context, transaction *type
 
 func (s *StateManager) CreateVersionTransition(ctx context.Context, transition *types.VersionTransition) (int, error) {
 	var version_transition_id int
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
 			INSERT INTO version_transitions (from_version_transition, to_version_id, status, created_by, metadata, transaction_id)
 			VALUES ($1, $2, $3, $4, $5, $6)
@@ -207,55 +409,109 @@ func (s *StateManager) CreateVersionTransition(ctx context.Context, transition *
 
 func (s *StateManager) GetVersionTransitionByID(ctx context.Context, id string) (*types.VersionTransition, error) {
 	var vt types.VersionTransition
-	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+	var transactionID *int
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
 		query := `
-			SELECT id, from_version_transition, to_version_id, status, started_at, completed_at, created_by, metadata
+			SELECT id, from_version_transition, to_version_id, status, started_at, completed_at, created_by, metadata, transaction_id
 			FROM version_transitions WHERE id = $1`
 
 		return tx.QueryRow(ctx, query, id).Scan(
 			&vt.ID, &vt.FromVersionTransition, &vt.ToVersionSetID, &vt.Status,
-			&vt.StartedAt, &vt.CompletedAt, &vt.CreatedBy, &vt.Metadata,
+			&vt.StartedAt, &vt.CompletedAt, &vt.CreatedBy, &vt.Metadata, &transactionID,
 		)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch version transition: %w", err)
 	}
+	if transactionID != nil {
+		vt.TransactionID = *transactionID
+	}
 	return &vt, nil
 }
 
-// func (s *StateManager) ListVersionTransitions(ctx context.Context) ([]*types.VersionTransition, error) {
-// 	var transitions []*types.VersionTransition
-// 	err := s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
-// 		query := `SELECT id, from_version_id, to_version_id, status, started_at, completed_at, created_by, metadata
-// 			FROM version_transitions`
-// 		rows, err := tx.Query(ctx, query)
-// 		if err != nil {
-// 			return fmt.Errorf("failed to execute query: %w", err)
-// 		}
-// 		defer rows.Close()
-
-// 		for rows.Next() {
-// 			vt := new(types.VersionTransition)
-// 			err := rows.Scan(
-// 				&vt.ID, &vt.FromVersionID, &vt.ToVersionID, &vt.Status,
-// 				&vt.StartedAt, &vt.CompletedAt, &vt.CreatedBy, &vt.Metadata,
-// 			)
-// 			if err != nil {
-// 				return fmt.Errorf("failed to scan row: %w", err)
-// 			}
-// 			transitions = append(transitions, vt)
-// 		}
-// 		return rows.Err()
-// 	})
-// 	if err != nil {
-// 		return nil, fmt.Errorf("failed to list version transitions: %w", err)
-// 	}
-// 	return transitions, nil
-// }
+// GetVersionTransitionByTransaction returns the version transition created by
+// the given transaction
+func (s *StateManager) GetVersionTransitionByTransaction(ctx context.Context, transactionID int) (*types.VersionTransition, error) {
+	var vt types.VersionTransition
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		query := `
+			SELECT id, from_version_transition, to_version_id, status, started_at, completed_at, created_by, metadata, transaction_id
+			FROM version_transitions WHERE transaction_id = $1
+			ORDER BY id
+			LIMIT 1`
+
+		return tx.QueryRow(ctx, query, transactionID).Scan(
+			&vt.ID, &vt.FromVersionTransition, &vt.ToVersionSetID, &vt.Status,
+			&vt.StartedAt, &vt.CompletedAt, &vt.CreatedBy, &vt.Metadata, &vt.TransactionID,
+		)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch version transition of transaction %d: %w", transactionID, err)
+	}
+	return &vt, nil
+}
+
+// transitionStatusCondition compares the status of version transition v with a
+// parameter, Postgres has to cast it to the enum
+var transitionStatusCondition = map[string]string{
+	types.DatabasePostgres: `v.status = %s::version_transition_status`,
+	types.DatabaseSqlite:   `v.status = %s`,
+}
+
+// ListVersionTransitions returns the version transitions matching filter,
+// newest first
+func (s *StateManager) ListVersionTransitions(ctx context.Context, filter types.HistoryFilter) ([]*types.VersionTransition, error) {
+	var conditions []string
+	var args []any
+	if filter.NodeSerial != nil {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM transaction_log l WHERE l.transaction_id = v.transaction_id AND l.node_serial = `+addArg(&args, *filter.NodeSerial)+`)`)
+	}
+	if filter.VersionSetID != nil {
+		conditions = append(conditions, `v.to_version_id = `+addArg(&args, *filter.VersionSetID))
+	}
+	if filter.State != nil {
+		conditions = append(conditions, fmt.Sprintf(transitionStatusCondition[s.Dialect()], addArg(&args, *filter.State)))
+	}
+	conditions = append(conditions, timeConditions("v.started_at", filter, &args)...)
+
+	query := `
+		SELECT v.id, v.from_version_transition, v.to_version_id, v.status, v.started_at, v.completed_at, v.created_by, v.metadata, v.transaction_id
+		FROM version_transitions v` + whereClause(conditions) + ` ORDER BY v.id DESC` + limitClause(filter, &args)
+
+	var transitions []*types.VersionTransition
+	err := s.ExecuteInTransaction(ctx, func(tx Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			vt := new(types.VersionTransition)
+			var transactionID *int
+			err := rows.Scan(
+				&vt.ID, &vt.FromVersionTransition, &vt.ToVersionSetID, &vt.Status,
+				&vt.StartedAt, &vt.CompletedAt, &vt.CreatedBy, &vt.Metadata, &transactionID,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			if transactionID != nil {
+				vt.TransactionID = *transactionID
+			}
+			transitions = append(transitions, vt)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list version transitions: %w", err)
+	}
+	return transitions, nil
+}
 
 // // UpdateVersionSet updates an existing version set
 // func (s *StateManager) UpdateVersionSet(ctx context.Context, vs types.VersionSet) error {
-// 	return s.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
+// 	return s.ExecuteInTransaction(ctx, func(tx Tx) error {
 // 		query := `
 // 			UPDATE version_sets
 // 			SET name = $1, description = $2, state = $3, metadata = $4
@@ -277,7 +533,6 @@ func (s *StateManager) UpdateVersionTransitionStatus(ctx context.Context,
 	version_transition_id int,
 	status string, disabled_at *time.Time) error {
 
-	where_string := fmt.Sprintf("id = '%d'", version_transition_id)
 	updates := make(map[string]any)
 	updates["status"] = status // status is now version_state enum
 	updates["completed_at"] = time.Now()
@@ -285,5 +540,5 @@ func (s *StateManager) UpdateVersionTransitionStatus(ctx context.Context,
 		updates["disabled_at"] = disabled_at
 	}
 
-	return s.UpdateWhere(ctx, "version_transitions", updates, where_string)
+	return s.UpdateWhere(ctx, "version_transitions", updates, "id = $1", version_transition_id)
 }
@@ -28,6 +28,8 @@ func NewBroker(broker_cfg types.BrokerConfig) *Broker {
 	capabilities := mqtt.NewDefaultServerCapabilities()
 	options := &mqtt.Options{
 		Capabilities: capabilities,
+		// lets the control plane attach in-process, see MqttFactory.AttachBroker
+		InlineClient: true,
 	}
 	// options.Capabilities = mqtt.NewDefaultServerCapabilities()
 	// options.Capabilities.Compatibilities.PassiveClientDisconnect = false
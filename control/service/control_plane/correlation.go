@@ -0,0 +1,93 @@
+package control_plane
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Laboratory-for-Safe-and-Secure-Systems/mqtt_broker/packets"
+	mqtt_paho "github.com/Laboratory-for-Safe-and-Secure-Systems/paho.mqtt.golang"
+)
+
+// txProperty is the MQTT 5 user property carrying the transaction id
+const txProperty = "tx_id"
+
+// updateMessageExpiry bounds how long the broker keeps an undelivered update
+// or sync message, a gateway must not apply a stale one
+const updateMessageExpiry = 5 * time.Minute
+
+// txProperties are the MQTT 5 properties of a message of a transaction
+type txProperties struct {
+	contentType   string
+	responseTopic string
+	tx            string
+	expiry        uint32
+}
+
+// publishTx publishes a message of transaction tx to a node and waits for the
+// broker to take it. The message carries the MQTT 5 response topic,
+// correlation data, message expiry, content type of the wire format and a
+// tx_id user property. The broker removes them for gateways connected with
+// MQTT 3.1.1, these only get the tx_id in the payload.
+func (c *client) publishTx(topic string, payload []byte, format wireFormat, tx int32, responseTopic string) error {
+	return c.client.publishV5(topic, 2, payload, txProperties{
+		contentType:   format.contentType(),
+		responseTopic: responseTopic,
+		tx:            strconv.Itoa(int(tx)),
+		expiry:        uint32(updateMessageExpiry / time.Second),
+	})
+}
+
+// publishV5 publishes a message with MQTT 5 properties into the embedded broker
+func (c *inlineClient) publishV5(topic string, qos byte, payload []byte, props txProperties) error {
+	return c.inject(topic, qos, false, payload, packets.Properties{
+		ContentType:           props.contentType,
+		ResponseTopic:         props.responseTopic,
+		CorrelationData:       []byte(props.tx),
+		MessageExpiryInterval: props.expiry,
+		User:                  []packets.UserProperty{{Key: txProperty, Val: props.tx}},
+	})
+}
+
+// reasonError adds the reason code to an error of the broker
+func reasonError(topic string, err error) error {
+	var code packets.Code
+	if errors.As(err, &code) {
+		return fmt.Errorf("broker rejected message to %s: %s (reason code 0x%02x)", topic, code.Reason, code.Code)
+	}
+	return err
+}
+
+// messageTx returns the transaction id of a message from an MQTT 5 gateway,
+// taken from the tx_id user property or the correlation data. ok is false for
+// messages without either, the tx_id of their payload applies then.
+func messageTx(msg mqtt_paho.Message) (tx int32, ok bool) {
+	var userTx string
+	var correlation []byte
+	switch m := msg.(type) {
+	case inlineMessage:
+		for _, p := range m.pk.Properties.User {
+			if p.Key == txProperty {
+				userTx = p.Val
+			}
+		}
+		correlation = m.pk.Properties.CorrelationData
+	case v5Message:
+		if m.pk.Properties == nil {
+			return 0, false
+		}
+		userTx = m.pk.Properties.User.Get(txProperty)
+		correlation = m.pk.Properties.CorrelationData
+	default:
+		return 0, false
+	}
+
+	if id, err := strconv.ParseInt(userTx, 10, 32); err == nil {
+		return int32(id), true
+	}
+	if id, err := strconv.ParseInt(string(correlation), 10, 32); err == nil {
+		return int32(id), true
+	}
+	return 0, false
+}
@@ -0,0 +1,220 @@
+package control_plane
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	mqtt "github.com/Laboratory-for-Safe-and-Secure-Systems/mqtt_broker"
+	"github.com/Laboratory-for-Safe-and-Secure-Systems/mqtt_broker/packets"
+	mqtt_paho "github.com/Laboratory-for-Safe-and-Secure-Systems/paho.mqtt.golang"
+)
+
+// mqttClient is the part of an MQTT client the control plane uses. It is
+// served by a v5Client dialing an external broker or by an inlineClient
+// attached to the embedded broker, both speak MQTT 5.
+type mqttClient interface {
+	Publish(topic string, qos byte, retained bool, payload interface{}) mqtt_paho.Token
+	// publishV5 publishes a message with the MQTT 5 properties of a
+	// transaction and waits for the broker to take it
+	publishV5(topic string, qos byte, payload []byte, props txProperties) error
+	Subscribe(topic string, qos byte, callback mqtt_paho.MessageHandler) mqtt_paho.Token
+	Unsubscribe(topics ...string) mqtt_paho.Token
+	Disconnect(quiesce uint)
+}
+
+// subscriptionQueueSize is the number of messages buffered per subscription
+const subscriptionQueueSize = 64
+
+// subscription hands the messages of a topic filter to its callback on a
+// goroutine of its own, in order. A message which finds the queue full waits
+// for room, which holds up the connection delivering it until the callback
+// caught up: a dropped state report would leave its transaction hanging.
+type subscription struct {
+	topic    string
+	messages chan mqtt_paho.Message
+	stop     chan struct{}
+}
+
+func newSubscription(topic string, callback mqtt_paho.MessageHandler) *subscription {
+	sub := &subscription{
+		topic:    topic,
+		messages: make(chan mqtt_paho.Message, subscriptionQueueSize),
+		stop:     make(chan struct{}),
+	}
+	go func() {
+		for {
+			select {
+			case <-sub.stop:
+				return
+			case msg := <-sub.messages:
+				callback(nil, msg)
+			}
+		}
+	}()
+	return sub
+}
+
+// deliver queues a message for the callback. If the queue is full it waits
+// until there is room or the subscription is closed.
+func (s *subscription) deliver(session string, msg mqtt_paho.Message) {
+	select {
+	case s.messages <- msg:
+		return
+	case <-s.stop:
+		return
+	default:
+	}
+	mqtt_log.Warn().Msgf("Subscription %s of session %s is full, holding message on %s", s.topic, session, msg.Topic())
+	select {
+	case s.messages <- msg:
+	case <-s.stop:
+	}
+}
+
+func (s *subscription) close() {
+	close(s.stop)
+}
+
+// inlineClient is a session on the embedded broker without a network
+// connection. The broker calls the subscriptions of all inline sessions from
+// the connection of the publishing node, they only block it while their
+// queue is full.
+type inlineClient struct {
+	server *mqtt.Server
+	// id identifies the subscriptions of this session on the broker
+	id int
+
+	mu   sync.Mutex
+	subs map[string]*subscription
+}
+
+func newInlineClient(server *mqtt.Server, id int) *inlineClient {
+	return &inlineClient{
+		server: server,
+		id:     id,
+		subs:   make(map[string]*subscription),
+	}
+}
+
+func (c *inlineClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt_paho.Token {
+	var data []byte
+	switch p := payload.(type) {
+	case []byte:
+		data = p
+	case string:
+		data = []byte(p)
+	default:
+		return doneToken{err: fmt.Errorf("unknown payload type %T", payload)}
+	}
+	return doneToken{err: c.inject(topic, qos, retained, data, packets.Properties{})}
+}
+
+// inject hands a message of the inline client to the broker. The broker skips
+// the acknowledgements of the inline client, but a message of qos 1 or 2
+// still takes a packet id of its own.
+func (c *inlineClient) inject(topic string, qos byte, retained bool, payload []byte, props packets.Properties) error {
+	cl, ok := c.server.Clients.Get(mqtt.InlineClientId)
+	if !ok {
+		return mqtt.ErrInlineClientNotEnabled
+	}
+	var packetID uint16
+	if qos > 0 {
+		id, err := cl.NextPacketID()
+		if err != nil {
+			return reasonError(topic, err)
+		}
+		packetID = uint16(id)
+	}
+	err := c.server.InjectPacket(cl, packets.Packet{
+		FixedHeader: packets.FixedHeader{
+			Type:   packets.Publish,
+			Qos:    qos,
+			Retain: retained,
+		},
+		TopicName:  topic,
+		Payload:    payload,
+		Properties: props,
+		PacketID:   packetID,
+	})
+	return reasonError(topic, err)
+}
+
+func (c *inlineClient) Subscribe(topic string, qos byte, callback mqtt_paho.MessageHandler) mqtt_paho.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.subs[topic]; ok {
+		return doneToken{err: fmt.Errorf("already subscribed to %s", topic)}
+	}
+
+	sub := newSubscription(topic, callback)
+	session := strconv.Itoa(c.id)
+	err := c.server.Subscribe(topic, c.id, func(_ *mqtt.Client, _ packets.Subscription, pk packets.Packet) {
+		sub.deliver(session, inlineMessage{pk: pk})
+	})
+	if err != nil {
+		sub.close()
+		return doneToken{err: err}
+	}
+	c.subs[topic] = sub
+	return doneToken{}
+}
+
+func (c *inlineClient) Unsubscribe(topics ...string) mqtt_paho.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var err error
+	for _, topic := range topics {
+		sub, ok := c.subs[topic]
+		if !ok {
+			continue
+		}
+		if uerr := c.server.Unsubscribe(topic, c.id); uerr != nil && err == nil {
+			err = uerr
+		}
+		sub.close()
+		delete(c.subs, topic)
+	}
+	return doneToken{err: err}
+}
+
+func (c *inlineClient) Disconnect(quiesce uint) {
+	c.mu.Lock()
+	topics := make([]string, 0, len(c.subs))
+	for topic := range c.subs {
+		topics = append(topics, topic)
+	}
+	c.mu.Unlock()
+	c.Unsubscribe(topics...)
+}
+
+// doneToken is the token of an operation which completed before it was
+// returned
+type doneToken struct {
+	err error
+}
+
+var closedDone = func() chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}()
+
+func (t doneToken) Wait() bool                     { return true }
+func (t doneToken) WaitTimeout(time.Duration) bool { return true }
+func (t doneToken) Done() <-chan struct{}          { return closedDone }
+func (t doneToken) Error() error                   { return t.err }
+
+// inlineMessage is a message delivered to an inline subscription
+type inlineMessage struct {
+	pk packets.Packet
+}
+
+func (m inlineMessage) Duplicate() bool   { return m.pk.FixedHeader.Dup }
+func (m inlineMessage) Qos() byte         { return m.pk.FixedHeader.Qos }
+func (m inlineMessage) Retained() bool    { return m.pk.FixedHeader.Retain }
+func (m inlineMessage) Topic() string     { return m.pk.TopicName }
+func (m inlineMessage) MessageID() uint16 { return m.pk.PacketID }
+func (m inlineMessage) Payload() []byte   { return m.pk.Payload }
+func (m inlineMessage) Ack()              {}
@@ -0,0 +1,77 @@
+package control_plane
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Laboratory-for-Safe-and-Secure-Systems/mqtt_broker/packets"
+	mqtt_paho "github.com/Laboratory-for-Safe-and-Secure-Systems/paho.mqtt.golang"
+)
+
+// TestSubscriptionFullQueue delivers more messages than the queue holds to a
+// callback which is held up. Every message has to arrive, in order.
+func TestSubscriptionFullQueue(t *testing.T) {
+	const messages = 2 * subscriptionQueueSize
+	release := make(chan struct{})
+	received := make(chan string, messages)
+	sub := newSubscription("gw-1/control/state", func(_ mqtt_paho.Client, msg mqtt_paho.Message) {
+		<-release
+		received <- string(msg.Payload())
+	})
+	defer sub.close()
+
+	delivered := make(chan struct{})
+	go func() {
+		defer close(delivered)
+		for i := 0; i < messages; i++ {
+			sub.deliver("test", inlineMessage{pk: packets.Packet{TopicName: "gw-1/control/state", Payload: []byte{byte(i)}}})
+		}
+	}()
+
+	select {
+	case <-delivered:
+		t.Fatalf("deliver returned with a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("deliver did not return after the callback caught up")
+	}
+	for i := 0; i < messages; i++ {
+		select {
+		case payload := <-received:
+			if payload != string([]byte{byte(i)}) {
+				t.Fatalf("message %d arrived as %d", i, []byte(payload)[0])
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d of %d messages", i, messages)
+		}
+	}
+}
+
+// TestSubscriptionCloseReleasesDeliver closes a subscription while a message
+// waits for room in its queue
+func TestSubscriptionCloseReleasesDeliver(t *testing.T) {
+	hold := make(chan struct{})
+	defer close(hold)
+	sub := newSubscription("gw-1/control/state", func(_ mqtt_paho.Client, msg mqtt_paho.Message) {
+		<-hold
+	})
+
+	delivered := make(chan struct{})
+	go func() {
+		defer close(delivered)
+		for i := 0; i < subscriptionQueueSize+2; i++ {
+			sub.deliver("test", inlineMessage{pk: packets.Packet{TopicName: "gw-1/control/state"}})
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	sub.close()
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("deliver blocked after the subscription was closed")
+	}
+}
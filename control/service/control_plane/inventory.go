@@ -0,0 +1,111 @@
+package control_plane
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	mqtt_paho "github.com/Laboratory-for-Safe-and-Secure-Systems/paho.mqtt.golang"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+/********************************** grpc service for inventory *******************************************/
+
+// helloMsg is the payload of a hello, older gateways send an empty payload.
+// The hello stays JSON in every wire format, a gateway negotiates its format
+// with it.
+type helloMsg struct {
+	AgentVersion    string   `json:"agent_version"`
+	FirmwareVersion string   `json:"firmware_version"`
+	HardwareModel   string   `json:"hardware_model"`
+	Uptime          int64    `json:"uptime"`
+	VersionSetID    string   `json:"version_set_id"`
+	TxID            int32    `json:"tx_id"`
+	KexMethods      []string `json:"kex_methods"`
+	Ciphers         []string `json:"ciphers"`
+	ConfigHash      string   `json:"config_hash"`
+	// WireFormats lists the encodings of the envelope the gateway reads
+	// besides JSON
+	WireFormats []string `json:"wire_formats"`
+	// SchemaVersion is the newest envelope schema the gateway reads, zero for
+	// a gateway which predates the envelope
+	SchemaVersion uint32 `json:"schema_version"`
+}
+
+// WatchHello forwards the hellos of the nodes with their inventory until the
+// stream is closed. A hello with an invalid payload is forwarded without
+// inventory, it still shows that the node is alive.
+func (fac *MqttFactory) WatchHello(_ *emptypb.Empty, stream grpc.ServerStreamingServer[grpc_scale.HelloReport]) error {
+	c, err := fac.GetClient("inventory")
+	if err != nil {
+		mqtt_log.Err(err).Msg("failed to get client")
+		return status.Errorf(codes.Internal, "failed to get client")
+	}
+	defer c.cleanup()
+
+	topic := "+/control/hello"
+	reportChan := make(chan *grpc_scale.HelloReport, 16)
+	token := c.client.Subscribe(topic, 0, func(client mqtt_paho.Client, msg mqtt_paho.Message) {
+		mqtt_log.Debug().Msgf("Received message: %s from topic: %s\n", msg.Payload(), msg.Topic())
+		parts := strings.Split(msg.Topic(), "/")
+		if len(parts) < 3 || parts[0] == "" {
+			mqtt_log.Error().Msgf("Invalid topic format: %s", msg.Topic())
+			return
+		}
+
+		report := &grpc_scale.HelloReport{SerialNumber: parts[0]}
+		// the format is negotiated before the hello is forwarded, a queued
+		// update delivered on it already uses the format
+		var negotiated *helloMsg
+		if len(msg.Payload()) > 0 {
+			var hello helloMsg
+			if err := json.Unmarshal(msg.Payload(), &hello); err != nil {
+				mqtt_log.Err(err).Msgf("invalid hello payload of %s", parts[0])
+			} else {
+				negotiated = &hello
+				report.Inventory = &grpc_scale.NodeInventory{
+					SerialNumber:    parts[0],
+					AgentVersion:    hello.AgentVersion,
+					FirmwareVersion: hello.FirmwareVersion,
+					HardwareModel:   hello.HardwareModel,
+					Uptime:          hello.Uptime,
+					VersionSetId:    hello.VersionSetID,
+					TxId:            hello.TxID,
+					KexMethods:      hello.KexMethods,
+					Ciphers:         hello.Ciphers,
+					ConfigHash:      hello.ConfigHash,
+					ReportedAt:      timestamppb.New(time.Now()),
+				}
+			}
+		}
+		fac.formats.negotiate(parts[0], negotiated)
+
+		select {
+		case reportChan <- report:
+		case <-stream.Context().Done():
+		default:
+			mqtt_log.Warn().Msgf("Hello stream is behind, dropping hello of %s", parts[0])
+		}
+	})
+	c.subs = append(c.subs, topic)
+	token.Wait()
+	if err := token.Error(); err != nil {
+		return status.Errorf(codes.Internal, "failed to subscribe to hellos")
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case report := <-reportChan:
+			if err := stream.Send(report); err != nil {
+				return err
+			}
+		}
+	}
+}
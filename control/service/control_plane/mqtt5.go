@@ -0,0 +1,249 @@
+package control_plane
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	mqtt_paho "github.com/Laboratory-for-Safe-and-Secure-Systems/paho.mqtt.golang"
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// v5Timeout bounds connecting a v5Client and every operation waiting for the
+// broker
+const v5Timeout = 5 * time.Second
+
+// v5KeepAlive is the keep alive of a v5Client in seconds
+const v5KeepAlive = 30
+
+// v5Client is a client of an external broker speaking MQTT 5, so the
+// messages of a transaction keep their properties in split mode as well. It
+// publishes on one session and subscribes on another: the broker keeps the
+// packet ids of both directions of a session in one inflight map, a delivery
+// of qos 1 or 2 could take the id of a message the session publishes and
+// break its acknowledgement. The client calls the router from its
+// connection, so every subscription hands its messages to a goroutine of its
+// own like the ones of an inlineClient.
+type v5Client struct {
+	id     string
+	pub    *paho.Client
+	sub    *paho.Client
+	router *paho.StandardRouter
+
+	mu   sync.Mutex
+	subs map[string]*subscription
+	// closing is set by Disconnect, the client then reports the closed
+	// connections as errors
+	closing atomic.Bool
+}
+
+// dialBroker opens the connection to the external broker, over ASL unless
+// the control plane is configured for plain TCP
+func dialBroker(cfg types.ControlPlaneConfig) (net.Conn, error) {
+	if cfg.TcpOnly {
+		return net.DialTimeout("tcp", cfg.Address, v5Timeout)
+	}
+	dial := mqtt_paho.Get_custom_function(cfg.EndpointConfig)
+	return dial(&url.URL{Scheme: "tls", Host: cfg.Address}, mqtt_paho.ClientOptions{})
+}
+
+// connectV5 connects the sessions of a client with the given id to the
+// external broker, their client ids are id-pub and id-sub
+func connectV5(ctx context.Context, cfg types.ControlPlaneConfig, id string) (*v5Client, error) {
+	c := &v5Client{
+		id:     id,
+		router: paho.NewStandardRouter(),
+		subs:   make(map[string]*subscription),
+	}
+	var err error
+	c.pub, err = c.connect(ctx, cfg, id+"-pub", nil)
+	if err != nil {
+		return nil, err
+	}
+	c.sub, err = c.connect(ctx, cfg, id+"-sub", func(pr paho.PublishReceived) (bool, error) {
+		c.router.Route(pr.Packet.Packet())
+		return true, nil
+	})
+	if err != nil {
+		c.closing.Store(true)
+		c.pub.Disconnect(&paho.Disconnect{ReasonCode: 0})
+		return nil, err
+	}
+	return c, nil
+}
+
+// connect connects one session of the client, onPublish receives the
+// messages the broker delivers to it
+func (c *v5Client) connect(ctx context.Context, cfg types.ControlPlaneConfig, id string, onPublish func(paho.PublishReceived) (bool, error)) (*paho.Client, error) {
+	conn, err := dialBroker(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial broker %s: %w", cfg.Address, err)
+	}
+
+	config := paho.ClientConfig{
+		ClientID: id,
+		Conn:     packets.NewThreadSafeConn(conn),
+		OnClientError: func(err error) {
+			if c.closing.Load() {
+				return
+			}
+			mqtt_log.Error().Err(err).Msgf("Connection of MQTT session %s lost", id)
+		},
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			mqtt_log.Warn().Msgf("Broker disconnected MQTT session %s (reason code 0x%02x)", id, d.ReasonCode)
+		},
+	}
+	if onPublish != nil {
+		config.OnPublishReceived = []func(paho.PublishReceived) (bool, error){onPublish}
+	}
+	client := paho.NewClient(config)
+
+	ack, err := client.Connect(ctx, &paho.Connect{
+		ClientID:   id,
+		KeepAlive:  v5KeepAlive,
+		CleanStart: true,
+	})
+	if err != nil {
+		conn.Close()
+		if ack != nil && ack.Properties != nil && ack.Properties.ReasonString != "" {
+			return nil, fmt.Errorf("%w: %s (reason code 0x%02x)", err, ack.Properties.ReasonString, ack.ReasonCode)
+		}
+		return nil, err
+	}
+	return client, nil
+}
+
+func (c *v5Client) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt_paho.Token {
+	var data []byte
+	switch p := payload.(type) {
+	case []byte:
+		data = p
+	case string:
+		data = []byte(p)
+	default:
+		return doneToken{err: fmt.Errorf("unknown payload type %T", payload)}
+	}
+	return doneToken{err: c.publish(&paho.Publish{
+		QoS:     qos,
+		Retain:  retained,
+		Topic:   topic,
+		Payload: data,
+	})}
+}
+
+func (c *v5Client) publishV5(topic string, qos byte, payload []byte, props txProperties) error {
+	return c.publish(&paho.Publish{
+		QoS:     qos,
+		Topic:   topic,
+		Payload: payload,
+		Properties: &paho.PublishProperties{
+			ContentType:     props.contentType,
+			ResponseTopic:   props.responseTopic,
+			CorrelationData: []byte(props.tx),
+			MessageExpiry:   &props.expiry,
+			User:            paho.UserProperties{{Key: txProperty, Value: props.tx}},
+		},
+	})
+}
+
+// publish waits until the broker acknowledged a message of qos 1 or 2, the
+// client allocates its packet id
+func (c *v5Client) publish(pb *paho.Publish) error {
+	ctx, cancel := context.WithTimeout(context.Background(), v5Timeout)
+	defer cancel()
+	resp, err := c.pub.Publish(ctx, pb)
+	if err != nil {
+		return fmt.Errorf("failed to publish message to %s: %w", pb.Topic, err)
+	}
+	if resp != nil && resp.ReasonCode >= 0x80 {
+		return fmt.Errorf("broker rejected message to %s (reason code 0x%02x)", pb.Topic, resp.ReasonCode)
+	}
+	return nil
+}
+
+// Subscribe subscribes the receiving session to topic with the given qos
+func (c *v5Client) Subscribe(topic string, qos byte, callback mqtt_paho.MessageHandler) mqtt_paho.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.subs[topic]; ok {
+		return doneToken{err: fmt.Errorf("already subscribed to %s", topic)}
+	}
+
+	sub := newSubscription(topic, callback)
+	c.router.RegisterHandler(topic, func(pb *paho.Publish) {
+		sub.deliver(c.id, v5Message{pk: pb})
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), v5Timeout)
+	defer cancel()
+	_, err := c.sub.Subscribe(ctx, &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{{Topic: topic, QoS: qos}},
+	})
+	if err != nil {
+		c.router.UnregisterHandler(topic)
+		sub.close()
+		return doneToken{err: err}
+	}
+	c.subs[topic] = sub
+	return doneToken{}
+}
+
+func (c *v5Client) Unsubscribe(topics ...string) mqtt_paho.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var subscribed []string
+	for _, topic := range topics {
+		sub, ok := c.subs[topic]
+		if !ok {
+			continue
+		}
+		c.router.UnregisterHandler(topic)
+		sub.close()
+		delete(c.subs, topic)
+		subscribed = append(subscribed, topic)
+	}
+	if len(subscribed) == 0 {
+		return doneToken{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), v5Timeout)
+	defer cancel()
+	_, err := c.sub.Unsubscribe(ctx, &paho.Unsubscribe{Topics: subscribed})
+	return doneToken{err: err}
+}
+
+func (c *v5Client) Disconnect(quiesce uint) {
+	c.mu.Lock()
+	for topic, sub := range c.subs {
+		c.router.UnregisterHandler(topic)
+		sub.close()
+		delete(c.subs, topic)
+	}
+	c.mu.Unlock()
+	c.closing.Store(true)
+	if err := c.sub.Disconnect(&paho.Disconnect{ReasonCode: 0}); err != nil {
+		mqtt_log.Debug().Err(err).Msgf("Failed to disconnect MQTT session %s-sub", c.id)
+	}
+	if err := c.pub.Disconnect(&paho.Disconnect{ReasonCode: 0}); err != nil {
+		mqtt_log.Debug().Err(err).Msgf("Failed to disconnect MQTT session %s-pub", c.id)
+	}
+}
+
+// v5Message is a message delivered to a subscription of a v5Client
+type v5Message struct {
+	pk *paho.Publish
+}
+
+func (m v5Message) Duplicate() bool   { return m.pk.Duplicate() }
+func (m v5Message) Qos() byte         { return m.pk.QoS }
+func (m v5Message) Retained() bool    { return m.pk.Retain }
+func (m v5Message) Topic() string     { return m.pk.Topic }
+func (m v5Message) MessageID() uint16 { return m.pk.PacketID }
+func (m v5Message) Payload() []byte   { return m.pk.Payload }
+func (m v5Message) Ack()              {}
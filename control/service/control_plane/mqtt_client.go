@@ -2,7 +2,10 @@ package control_plane
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -10,9 +13,11 @@ import (
 
 	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
 	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
+	mqtt "github.com/Laboratory-for-Safe-and-Secure-Systems/mqtt_broker"
 	mqtt_paho "github.com/Laboratory-for-Safe-and-Secure-Systems/paho.mqtt.golang"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
@@ -21,18 +26,45 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// client is an MQTT session of a single call. Every session connects with a
+// client id of its own, so concurrent calls do not take over each other's
+// session on the broker.
 type client struct {
 	id_name string
-	client  mqtt_paho.Client
+	client  mqttClient
 	subs    []string
+	// formats are the wire formats negotiated by the nodes
+	formats *wireFormats
+}
+
+// sessionKinds are the names GetClient accepts, they prefix the client ids
+var sessionKinds = map[string]bool{
+	"log":          true,
+	"hello":        true,
+	"update_fleet": true,
+	"update_node":  true,
+	"cert_req":     true,
+	"recovery":     true,
+	"sync":         true,
+	"inventory":    true,
 }
 
 type MqttFactory struct {
-	mu            sync.Mutex
-	client_config *mqtt_paho.ClientOptions
-	cfg           types.ControlPlaneConfig
-	clients       []*client
+	// mu guards sessions and broker
+	mu  sync.Mutex
+	cfg types.ControlPlaneConfig
+	// instance tells the client ids of this controller apart from the ones of
+	// other controllers on the same broker
+	instance string
+	sessions uint64
+	// broker is the embedded broker the sessions attach to, nil if the
+	// control plane dials an external broker
+	broker *mqtt.Server
+	// formats are the wire formats negotiated by the nodes in their hellos
+	formats *wireFormats
 	grpc_controlplane.UnimplementedControlPlaneServer
+	grpc_scale.UnimplementedControlPlaneRecoveryServer
+	grpc_scale.UnimplementedControlPlaneInventoryServer
 }
 
 var mqtt_log zerolog.Logger
@@ -42,40 +74,19 @@ func ControlPlaneInit(cfg types.ControlPlaneConfig) *MqttFactory {
 	var factory *MqttFactory
 	mqtt_log = types.CreateLogger("mqtt_client", cfg.Log.Level, cfg.Log.File)
 
-	client_opts := mqtt_paho.NewClientOptions()
-	factory = &MqttFactory{
-		client_config: client_opts,
-		cfg:           cfg,
-		mu:            sync.Mutex{},
-		clients:       make([]*client, 4),
-	}
-	factory.clients[0] = &client{
-		id_name: "log",
-	}
-	factory.clients[1] = &client{
-		id_name: "hello",
-	}
-	factory.clients[2] = &client{
-		id_name: "update_fleet",
-	}
-	factory.clients[3] = &client{
-		id_name: "update_node",
+	instance := make([]byte, 3)
+	if _, err := rand.Read(instance); err != nil {
+		mqtt_log.Err(err).Msg("failed to generate instance id")
 	}
 
-	factory.client_config = factory.client_config.SetCleanSession(true)
-	factory.client_config.SetDefaultPublishHandler(messagePubHandler)
-	factory.client_config.OnConnect = connectHandler
-	factory.client_config.OnConnectionLost = connectLostHandler
-	factory.client_config.OnReconnecting = reconHandler
-
-	if cfg.TcpOnly {
-		factory.client_config.AddBroker("tcp://" + cfg.Address)
-	} else {
-		factory.client_config.AddBroker("tls://" + cfg.Address)
-		factory.client_config.CustomOpenConnectionFn = mqtt_paho.Get_custom_function(cfg.EndpointConfig)
+	// without an external broker the sessions attach to the embedded one,
+	// see AttachBroker
+	factory = &MqttFactory{
+		cfg:      cfg,
+		mu:       sync.Mutex{},
+		instance: hex.EncodeToString(instance),
+		formats:  newWireFormats(),
 	}
-
-	factory.client_config.SetProtocolVersion(3)
 	return factory
 
 }
@@ -91,57 +102,56 @@ func (c *client) cleanup() {
 
 }
 
-func (f *MqttFactory) GetClient(id string) (*client, error) {
-	for _, c := range f.clients {
-		if c.id_name == id {
-			f.client_config.SetClientID(id)
-			c.client = mqtt_paho.NewClient(f.client_config)
-			// Connect with timeout
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-
-			connectChan := make(chan error, 1)
-			go func() {
-				token := c.client.Connect()
-				token.Wait()
-				connectChan <- token.Error()
-			}()
-
-			select {
-			case err := <-connectChan:
-				if err != nil {
-					return nil, fmt.Errorf("failed to connect MQTT client: %w", err)
-				}
-				return c, nil
-			case <-ctx.Done():
-				return nil, fmt.Errorf("connection timeout")
-			}
-
-		}
+// AttachBroker makes the sessions of the control plane attach to the embedded
+// broker in-process instead of dialing it. It has no effect if the control
+// plane is configured for an external broker.
+func (f *MqttFactory) AttachBroker(b *Broker) {
+	if f.cfg.ExternalBroker {
+		return
 	}
-	return nil, fmt.Errorf("client not found")
-}
-
-/********************************** Handler *******************************************/
-
-var messagePubHandler mqtt_paho.MessageHandler = func(client mqtt_paho.Client, msg mqtt_paho.Message) {
-	mqtt_log.Debug().Str("topic", msg.Topic()).Str("payload", string(msg.Payload())).Msg("Received message")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.broker = b.broker
 }
 
-var connectHandler mqtt_paho.OnConnectHandler = func(client mqtt_paho.Client) {
-	mqtt_log.Debug().Msg("Connected to MQTT broker")
-}
+// GetClient connects a new session of the given kind. The caller owns the
+// session and ends it with cleanup.
+func (f *MqttFactory) GetClient(kind string) (*client, error) {
+	if !sessionKinds[kind] {
+		return nil, fmt.Errorf("client not found")
+	}
 
-var connectLostHandler mqtt_paho.ConnectionLostHandler = func(client mqtt_paho.Client, err error) {
-	mqtt_log.Error().Err(err).Msg("Connection lost to MQTT broker")
-}
+	f.mu.Lock()
+	f.sessions++
+	id := fmt.Sprintf("%s-%s-%d", kind, f.instance, f.sessions)
+	if f.broker != nil {
+		c := &client{
+			id_name: id,
+			client:  newInlineClient(f.broker, int(f.sessions)),
+			formats: f.formats,
+		}
+		f.mu.Unlock()
+		return c, nil
+	}
+	f.mu.Unlock()
+	if !f.cfg.ExternalBroker {
+		return nil, fmt.Errorf("control plane is not attached to the embedded broker")
+	}
 
-var reconHandler mqtt_paho.ReconnectHandler = func(client mqtt_paho.Client, opts *mqtt_paho.ClientOptions) {
-	mqtt_log.Debug().Msg("Reconnecting to MQTT broker")
+	ctx, cancel := context.WithTimeout(context.Background(), v5Timeout)
+	defer cancel()
+	v5, err := connectV5(ctx, f.cfg, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect MQTT client: %w", err)
+	}
+	mqtt_log.Debug().Msgf("MQTT session %s connected", id)
+	return &client{
+		id_name: id,
+		client:  v5,
+		formats: f.formats,
+	}, nil
 }
 
-/**********************************End  Handler *******************************************/
-
 func (fac *MqttFactory) SendCertificateRequest(ctx context.Context, req *grpc_controlplane.CertificateRequest) (*grpc_controlplane.CertificateResponse, error) {
 	// check req
 	if req.CertType != grpc_southbound.CertType_CONTROLPLANE && req.CertType != grpc_southbound.CertType_DATAPLANE {
@@ -165,7 +175,7 @@ func (fac *MqttFactory) SendCertificateRequest(ctx context.Context, req *grpc_co
 	}
 
 	// get client
-	c, err := fac.GetClient("update_node")
+	c, err := fac.GetClient("cert_req")
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get client")
 	}
@@ -185,7 +195,7 @@ func (fac *MqttFactory) SendCertificateRequest(ctx context.Context, req *grpc_co
 
 func (fac *MqttFactory) UpdateNode(req *grpc_controlplane.NodeUpdate, stream grpc.ServerStreamingServer[grpc_controlplane.UpdateResponse]) error {
 	// Create channel for the stream and done signal
-	streamChan := make(chan grpc_controlplane.UpdateState)
+	streamChan := make(chan grpc_controlplane.UpdateState, 8)
 	doneChan := make(chan error)
 	timeout := time.After(40 * time.Second) // Add a reasonable timeout
 	c, err := fac.GetClient("update_node")
@@ -195,30 +205,54 @@ func (fac *MqttFactory) UpdateNode(req *grpc_controlplane.NodeUpdate, stream grp
 	}
 	defer c.cleanup()
 
+	// finished releases the handler and the goroutine below once the call returned
+	finished := make(chan struct{})
+	defer close(finished)
+	done := func(err error) {
+		select {
+		case doneChan <- err:
+		case <-finished:
+		case <-stream.Context().Done():
+		}
+	}
+
 	serialNumber := req.NodeUpdateItem.SerialNumber
 
 	// Use high qos
 	topicState := serialNumber + "/control/state"
-	topicConfig := serialNumber + "/config"     // Topic for initial config
-	topicSync := serialNumber + "/control/sync" // Topic for control messages (fixed path)
 
 	// Subscribe to the topic
 	qosToken := c.client.Subscribe(topicState, 2, func(client mqtt_paho.Client, msg mqtt_paho.Message) {
 		mqtt_log.Debug().Msgf("Received message: %s from topic: %s\n", msg.Payload(), msg.Topic())
-		payload := msg.Payload()
 		// Parse payload to v1.UpdateState
 		var updateState grpc_controlplane.UpdateState
-		var control_msg control_msg
+		control_msg, err := c.decodeState(msg)
 
-		err := json.Unmarshal(payload, &control_msg)
-
-		if err != nil {
+		var schemaErr *unsupportedSchemaError
+		if err != nil && !errors.As(err, &schemaErr) {
 			mqtt_log.Err(err).Msg("error unmarshalling update state")
-			doneChan <- err
+			done(err)
+			return
+		}
+		// reports of another transaction of the node are not ours, MQTT 5
+		// gateways correlate them in the message properties
+		txId := control_msg.TxId
+		if !c.reportsTx(serialNumber, msg, control_msg, req.Transaction.TxId) {
+			return
+		}
+		if schemaErr != nil {
+			mqtt_log.Error().Str("node", serialNumber).Msgf("state report of tx %d: %v", txId, schemaErr)
+			done(fmt.Errorf("node %s: %w", serialNumber, schemaErr))
 			return
 		}
 		updateState = grpc_controlplane.UpdateState(control_msg.Status)
-		streamChan <- updateState
+		// a dropped state would leave the update waiting for its timeout, the
+		// handler waits for the stream instead
+		select {
+		case streamChan <- updateState:
+		case <-finished:
+		case <-stream.Context().Done():
+		}
 	})
 	c.subs = append(c.subs, topicState)
 
@@ -227,22 +261,12 @@ func (fac *MqttFactory) UpdateNode(req *grpc_controlplane.NodeUpdate, stream grp
 	}
 	qosToken.Wait()
 
-	jsonReq, err := json.Marshal(req.NodeUpdateItem)
-	if err != nil {
-		mqtt_log.Err(err).Msg("error marshalling request")
-		// Unsubscribe before returning
-		c.client.Unsubscribe(topicState)
-		return status.Errorf(codes.Internal, "failed to marshal request")
-	}
-
 	// Publish config to client
-	configToken := c.client.Publish(topicConfig, 2, false, jsonReq)
-	configToken.Wait()
-	if configToken.Error() != nil {
-		mqtt_log.Err(configToken.Error()).Msg("error publishing update to node")
+	if err := c.sendConfig(req.NodeUpdateItem, req.Transaction.TxId); err != nil {
+		mqtt_log.Err(err).Msg("error publishing update to node")
 		// Unsubscribe before returning
-		c.client.Unsubscribe(topicSync)
-		return configToken.Error()
+		c.client.Unsubscribe(topicState)
+		return err
 	}
 
 	go func() {
@@ -253,7 +277,7 @@ func (fac *MqttFactory) UpdateNode(req *grpc_controlplane.NodeUpdate, stream grp
 			TxId:        req.Transaction.TxId,
 		})
 		if err != nil {
-			doneChan <- err
+			done(err)
 			return
 		}
 
@@ -262,7 +286,7 @@ func (fac *MqttFactory) UpdateNode(req *grpc_controlplane.NodeUpdate, stream grp
 			select {
 			case <-stream.Context().Done():
 				// Context cancelled (stream closed by client)
-				doneChan <- stream.Context().Err()
+				done(stream.Context().Err())
 				return
 			case updateState, ok := <-streamChan:
 				if !ok {
@@ -274,20 +298,16 @@ func (fac *MqttFactory) UpdateNode(req *grpc_controlplane.NodeUpdate, stream grp
 				if updateState == grpc_controlplane.UpdateState_UPDATE_APPLICABLE {
 					// Node is ready to apply the update, send apply request
 					mqtt_log.Debug().Str("node", serialNumber).Msg("Node ready for update, sending apply request")
-					applyToken := c.client.Publish(topicSync, 2, false, fmt.Sprintf(`{"status": %d,"tx_id":%d}`, grpc_controlplane.UpdateState_UPDATE_APPLY_REQ, req.Transaction.TxId))
-					applyToken.Wait()
-					if applyToken.Error() != nil {
-						mqtt_log.Err(applyToken.Error()).Msg("error sending apply request")
-						doneChan <- applyToken.Error()
+					if err := c.sendSync(serialNumber, grpc_controlplane.UpdateState_UPDATE_APPLY_REQ, req.Transaction.TxId); err != nil {
+						mqtt_log.Err(err).Msg("error sending apply request")
+						done(err)
 						return
 					}
 				} else if updateState == grpc_controlplane.UpdateState_UPDATE_APPLIED {
 					// Node has applied the update, send acknowledgment
 					mqtt_log.Debug().Str("node", serialNumber).Msg("Node applied update, sending acknowledgment")
-					ackToken := c.client.Publish(topicSync, 2, false, []byte(fmt.Sprintf(`{"status": %d,"tx_id":%d}`, grpc_controlplane.UpdateState_UPDATE_ACKNOWLEDGED, req.Transaction.TxId)))
-					ackToken.Wait()
-					if ackToken.Error() != nil {
-						mqtt_log.Err(ackToken.Error()).Msg("error sending acknowledgment")
+					if err := c.sendSync(serialNumber, grpc_controlplane.UpdateState_UPDATE_ACKNOWLEDGED, req.Transaction.TxId); err != nil {
+						mqtt_log.Err(err).Msg("error sending acknowledgment")
 						updateState = grpc_controlplane.UpdateState_UPDATE_ERROR
 					}
 					updateState = grpc_controlplane.UpdateState_UPDATE_APPLIED
@@ -300,7 +320,7 @@ func (fac *MqttFactory) UpdateNode(req *grpc_controlplane.NodeUpdate, stream grp
 					TxId:        req.Transaction.TxId,
 				})
 				if err != nil {
-					doneChan <- err
+					done(err)
 					return
 				}
 
@@ -312,7 +332,7 @@ func (fac *MqttFactory) UpdateNode(req *grpc_controlplane.NodeUpdate, stream grp
 					updateState == grpc_controlplane.UpdateState_UPDATE_ERROR {
 					mqtt_log.Info().Msgf("Update process finished for node %s state: %s; tx_id: %d",
 						serialNumber, updateState.String(), req.Transaction.TxId)
-					doneChan <- nil
+					done(nil)
 					return
 				}
 			}
@@ -343,30 +363,36 @@ func (fac *MqttFactory) Hello(ep *empty.Empty, stream grpc.ServerStreamingServer
 	defer c.cleanup()
 
 	topic := "+/control/hello"
-	messageChan := make(chan string)
+	messageChan := make(chan string, 16)
 	token := c.client.Subscribe(topic, 0, func(client mqtt_paho.Client, msg mqtt_paho.Message) {
 		mqtt_log.Debug().Msgf("Received message: %s from topic: %s\n", msg.Payload(), msg.Topic())
 		parts := strings.Split(msg.Topic(), "/")
 		if len(parts) > 0 {
 			serialNumber := parts[0]
-			messageChan <- serialNumber
+			// the node says hello again, a slow stream loses this one
+			select {
+			case messageChan <- serialNumber:
+			case <-stream.Context().Done():
+			default:
+				mqtt_log.Warn().Msgf("Hello stream is behind, dropping hello of %s", serialNumber)
+			}
 		}
 	})
 	c.subs = append(c.subs, topic)
 	token.Wait()
-	go func() {
-		for message := range messageChan {
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case message := <-messageChan:
 			err := stream.Send(&grpc_controlplane.HelloResponse{
 				SerialNumber: message,
 			})
 			if err != nil {
-				return
+				return err
 			}
 		}
-	}()
-	<-stream.Context().Done()
-	return nil
-
+	}
 }
 func (fac *MqttFactory) Log(ep *empty.Empty, stream grpc.ServerStreamingServer[grpc_controlplane.LogResponse]) error {
 	c, err := fac.GetClient("log")
@@ -394,45 +420,55 @@ func (fac *MqttFactory) Log(ep *empty.Empty, stream grpc.ServerStreamingServer[g
 		parts := strings.Split(msg.Topic(), "/")
 		if len(parts) > 0 {
 			serialNumber := parts[0]
-			messageChan <- struct {
+			select {
+			case messageChan <- struct {
 				serialNumber string
 				payload      string
 			}{
 				serialNumber: serialNumber,
 				payload:      string(msg.Payload()),
+			}:
+			case <-stream.Context().Done():
+			default:
+				mqtt_log.Warn().Msgf("Log stream is behind, dropping logs of %s", serialNumber)
 			}
 		}
 	})
 	c.subs = append(c.subs, topic)
 
 	token.Wait()
-	go func() {
-		for msg := range messageChan {
-			// Parse the array of log messages
-			var logMessages []logMessage
-			err := json.Unmarshal([]byte(msg.payload), &logMessages)
-			if err != nil {
-				mqtt_log.Err(err).Msg("error unmarshalling log message")
-				continue
-			}
+	for {
+		var msg struct {
+			serialNumber string
+			payload      string
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case msg = <-messageChan:
+		}
 
-			// Send each log message in the array
-			for _, logMsg := range logMessages {
-				err = stream.Send(&grpc_controlplane.LogResponse{
-					Message:      logMsg.Message,
-					Level:        &logMsg.Level,
-					Module:       &logMsg.Module,
-					SerialNumber: msg.serialNumber,
-				})
-				if err != nil {
-					return
-				}
-			}
+		// Parse the array of log messages
+		var logMessages []logMessage
+		err := json.Unmarshal([]byte(msg.payload), &logMessages)
+		if err != nil {
+			mqtt_log.Err(err).Msg("error unmarshalling log message")
+			continue
 		}
-	}()
 
-	<-stream.Context().Done()
-	return nil
+		// Send each log message in the array
+		for _, logMsg := range logMessages {
+			err = stream.Send(&grpc_controlplane.LogResponse{
+				Message:      logMsg.Message,
+				Level:        &logMsg.Level,
+				Module:       &logMsg.Module,
+				SerialNumber: msg.serialNumber,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
 }
 
 /********************************** End grpc service for control_plane *******************************************/
@@ -506,11 +542,10 @@ func (fac *MqttFactory) UpdateFleet(req *grpc_controlplane.FleetUpdate, stream g
 		serialNumber := parts[0]
 
 		// Parse payload to get update state
-		var control_msg control_msg
-		payload := msg.Payload()
-		err := json.Unmarshal(payload, &control_msg)
+		control_msg, err := c.decodeState(msg)
 
-		if err != nil {
+		var schemaErr *unsupportedSchemaError
+		if err != nil && !errors.As(err, &schemaErr) {
 			mqtt_log.Err(err).Msg("error unmarshalling update state")
 			return
 		}
@@ -518,14 +553,32 @@ func (fac *MqttFactory) UpdateFleet(req *grpc_controlplane.FleetUpdate, stream g
 		updateState := grpc_controlplane.UpdateState(control_msg.Status)
 		txId := control_msg.TxId
 		errorMsg := control_msg.Msg
+		// the node cannot be followed any more, it fails the update
+		if schemaErr != nil {
+			updateState = grpc_controlplane.UpdateState_UPDATE_ERROR
+			errorMsg = schemaErr.Error()
+		}
+
+		// the subscription sees the reports of all nodes, concurrent updates
+		// of other nodes and transactions are not ours
+		if !status.expectedNodes[serialNumber] || !c.reportsTx(serialNumber, msg, control_msg, req.Transaction.TxId) {
+			return
+		}
 
-		// Send to processing channel
-		nodeChan <- nodeUpdateMessage{
+		// Send to processing channel, a dropped state would leave the update
+		// waiting for its timeout
+		select {
+		case nodeChan <- nodeUpdateMessage{
 			SerialNumber: serialNumber,
 			State:        updateState,
 			TxId:         txId,
 			Timestamp:    time.Now(),
 			Error:        errorMsg,
+		}:
+		case <-ctx.Done():
+			return
+		case <-stream.Context().Done():
+			return
 		}
 
 		mqtt_log.Debug().
@@ -547,10 +600,8 @@ func (fac *MqttFactory) UpdateFleet(req *grpc_controlplane.FleetUpdate, stream g
 	// Wait for completion or timeout
 	select {
 	case err := <-doneChan:
-		// Clean up subscription
+		// Clean up subscription, the deferred cancel releases a blocked handler
 		c.client.Unsubscribe(topicState)
-		close(nodeChan) // Close channel to prevent goroutine leaks
-		//close stream
 
 		if err != nil {
 			return fmt.Errorf("fleet update failed: %w", err)
@@ -559,7 +610,6 @@ func (fac *MqttFactory) UpdateFleet(req *grpc_controlplane.FleetUpdate, stream g
 		return nil
 	case <-ctx.Done():
 		c.client.Unsubscribe(topicState)
-		close(nodeChan) // Close channel to prevent goroutine leaks
 		return fmt.Errorf("fleet update timed out after %v", 5*time.Minute)
 	}
 }
@@ -725,17 +775,7 @@ func publishConfigs(
 		case <-ctx.Done():
 			return fmt.Errorf("context canceled while publishing configs")
 		default:
-			payload, err := json.Marshal(node)
-			if err != nil {
-				mqtt_log.Error().Err(err).Str("node", node.SerialNumber).Msg("Failed to marshal node")
-				return fmt.Errorf("failed to marshal node %s: %w", node.SerialNumber, err)
-			}
-			// Use the new topic structure
-			topicConfig := node.SerialNumber + "/config"
-			configToken := c.client.Publish(topicConfig, 2, false, payload)
-			configToken.Wait()
-
-			if err := configToken.Error(); err != nil {
+			if err := c.sendConfig(node, req.Transaction.TxId); err != nil {
 				mqtt_log.Error().Err(err).Str("node", node.SerialNumber).Msg("Failed to publish config")
 				return fmt.Errorf("failed to publish config to node %s: %w", node.SerialNumber, err)
 			}
@@ -906,18 +946,7 @@ func sendUpdateApplyRequest(
 		case <-ctx.Done():
 			return fmt.Errorf("context canceled while sending apply request")
 		default:
-			// Use the new topic structure for sync control messages
-			topicSync := node.SerialNumber + "/control/sync"
-
-			// Create payload with status and transaction ID
-			payload := fmt.Sprintf(`{"status": %d, "tx_id": %d}`,
-				grpc_controlplane.UpdateState_UPDATE_APPLY_REQ,
-				req.Transaction.TxId)
-
-			token := c.client.Publish(topicSync, 2, false, []byte(payload))
-			token.Wait()
-
-			if err := token.Error(); err != nil {
+			if err := c.sendSync(node.SerialNumber, grpc_controlplane.UpdateState_UPDATE_APPLY_REQ, req.Transaction.TxId); err != nil {
 				mqtt_log.Error().Err(err).Str("node", node.SerialNumber).Msg("Failed to send apply request")
 				return fmt.Errorf("failed to send apply request to node %s: %w", node.SerialNumber, err)
 			}
@@ -942,18 +971,7 @@ func sendUpdateAcknowledgement(
 		case <-ctx.Done():
 			return fmt.Errorf("context canceled while sending acknowledgement")
 		default:
-			// Use the new topic structure for sync control messages
-			topicSync := node.SerialNumber + "/control/sync"
-
-			// Create payload with status and transaction ID
-			payload := fmt.Sprintf(`{"status": %d, "tx_id": %d}`,
-				grpc_controlplane.UpdateState_UPDATE_ACKNOWLEDGED,
-				req.Transaction.TxId)
-
-			token := c.client.Publish(topicSync, 2, false, []byte(payload))
-			token.Wait()
-
-			if err := token.Error(); err != nil {
+			if err := c.sendSync(node.SerialNumber, grpc_controlplane.UpdateState_UPDATE_ACKNOWLEDGED, req.Transaction.TxId); err != nil {
 				mqtt_log.Error().Err(err).Str("node", node.SerialNumber).Msg("Failed to send acknowledgement")
 				return fmt.Errorf("failed to send acknowledgement to node %s: %w", node.SerialNumber, err)
 			}
@@ -978,18 +996,7 @@ func sendRollbackRequest(
 		case <-ctx.Done():
 			return fmt.Errorf("context canceled while sending rollback request")
 		default:
-			// Use the new topic structure for sync control messages
-			topicSync := node.SerialNumber + "/control/sync"
-
-			// Create payload with status and transaction ID
-			payload := fmt.Sprintf(`{"status": %d, "tx_id": %d}`,
-				grpc_controlplane.UpdateState_UPDATE_ROLLBACK,
-				req.Transaction.TxId)
-
-			token := c.client.Publish(topicSync, 2, false, []byte(payload))
-			token.Wait()
-
-			if err := token.Error(); err != nil {
+			if err := c.sendSync(node.SerialNumber, grpc_controlplane.UpdateState_UPDATE_ROLLBACK, req.Transaction.TxId); err != nil {
 				mqtt_log.Error().Err(err).Str("node", node.SerialNumber).Msg("Failed to send rollback request")
 				// Continue with other nodes even if one fails
 				continue
@@ -0,0 +1,458 @@
+package control_plane
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
+	mqtt "github.com/Laboratory-for-Safe-and-Secure-Systems/mqtt_broker"
+	"github.com/Laboratory-for-Safe-and-Secure-Systems/mqtt_broker/packets"
+	mqtt_paho "github.com/Laboratory-for-Safe-and-Secure-Systems/paho.mqtt.golang"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// concurrentCalls is the number of calls of every kind running at once
+const concurrentCalls = 4
+
+// foreignTx is the transaction of the reports no call of the test owns
+const foreignTx = 9999
+
+// testBroker is the embedded broker with a control plane attached to it and
+// one dialing it over TCP. ControlPlaneInit sets the logger of the package,
+// so all runs of the tests share them.
+var testBroker struct {
+	once     sync.Once
+	err      error
+	broker   *Broker
+	connects *connectRecorder
+	inline   *MqttFactory
+	external *MqttFactory
+}
+
+// startTestBroker starts testBroker on the first call, it serves until the
+// tests end
+func startTestBroker(t *testing.T) {
+	t.Helper()
+	testBroker.once.Do(func() {
+		logs := types.LogConfig{Level: zerolog.WarnLevel}
+		address, err := freeAddress()
+		if err != nil {
+			testBroker.err = err
+			return
+		}
+		testBroker.broker = NewBroker(types.BrokerConfig{Adress: address, TcpOnly: true, Log: logs})
+		testBroker.connects = &connectRecorder{}
+		if err := testBroker.broker.broker.AddHook(testBroker.connects, nil); err != nil {
+			testBroker.err = err
+			return
+		}
+		testBroker.inline = ControlPlaneInit(types.ControlPlaneConfig{Log: logs})
+		testBroker.inline.AttachBroker(testBroker.broker)
+		testBroker.external = ControlPlaneInit(types.ControlPlaneConfig{ExternalBroker: true, TcpOnly: true, Address: address, Log: logs})
+
+		go testBroker.broker.Serve(context.Background())
+		testBroker.err = waitForListener(address)
+	})
+	if testBroker.err != nil {
+		t.Fatalf("failed to start the broker: %v", testBroker.err)
+	}
+}
+
+// TestConcurrentSessions runs UpdateNode, UpdateFleet and SendCertificateRequest
+// concurrently against the embedded broker, attached in-process and dialed
+// with MQTT 5. Every call has to finish its own transaction on a session of
+// its own, while the gateway reports a foreign transaction before every
+// state. Run it with -race.
+func TestConcurrentSessions(t *testing.T) {
+	startTestBroker(t)
+	gateway := newTestGateway(t, newInlineClient(testBroker.broker.broker, 1<<20))
+
+	t.Run("inline", func(t *testing.T) {
+		runConcurrentCalls(t, testBroker.inline, gateway, "inline")
+	})
+	t.Run("mqtt5", func(t *testing.T) {
+		testBroker.connects.reset()
+		runConcurrentCalls(t, testBroker.external, gateway, "mqtt5")
+
+		// every client publishes and subscribes on a session of its own
+		ids := testBroker.connects.clientIDs()
+		if len(ids) != 2*3*concurrentCalls {
+			t.Fatalf("broker saw %d sessions %v, want %d", len(ids), ids, 2*3*concurrentCalls)
+		}
+		seen := make(map[string]bool)
+		for _, id := range ids {
+			if seen[id] {
+				t.Errorf("client id %s connected more than once", id)
+			}
+			seen[id] = true
+		}
+	})
+}
+
+// stateRoundTrips is the number of configs TestV5StateRoundTrip publishes
+const stateRoundTrips = 64
+
+// TestV5StateRoundTrip publishes configs of qos 2 to a gateway dialing the
+// embedded broker with MQTT 5, which reports a state of qos 2 for each. Both
+// clients publish while the broker delivers to them, every state has to
+// arrive once and with qos 2.
+func TestV5StateRoundTrip(t *testing.T) {
+	startTestBroker(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cfg := testBroker.external.cfg
+
+	gateway, err := connectV5(ctx, cfg, "roundtrip-gateway")
+	if err != nil {
+		t.Fatalf("failed to connect gateway: %v", err)
+	}
+	defer gateway.Disconnect(0)
+	controller, err := connectV5(ctx, cfg, "roundtrip-controller")
+	if err != nil {
+		t.Fatalf("failed to connect controller: %v", err)
+	}
+	defer controller.Disconnect(0)
+
+	token := gateway.Subscribe("roundtrip/config", 2, func(_ mqtt_paho.Client, msg mqtt_paho.Message) {
+		tx, ok := messageTx(msg)
+		if !ok {
+			t.Errorf("config without transaction")
+			return
+		}
+		if msg.Qos() != 2 {
+			t.Errorf("config of tx %d arrived with qos %d", tx, msg.Qos())
+		}
+		payload, err := json.Marshal(control_msg{Status: int32(grpc_controlplane.UpdateState_UPDATE_APPLICABLE), Serial_number: "roundtrip", TxId: tx})
+		if err != nil {
+			t.Errorf("failed to encode state: %v", err)
+			return
+		}
+		if err := gateway.publishV5("roundtrip/control/state", 2, payload, txProperties{tx: strconv.Itoa(int(tx))}); err != nil {
+			t.Errorf("failed to report state of tx %d: %v", tx, err)
+		}
+	})
+	if token.Error() != nil {
+		t.Fatalf("gateway failed to subscribe: %v", token.Error())
+	}
+
+	states := make(chan mqtt_paho.Message, stateRoundTrips)
+	token = controller.Subscribe("roundtrip/control/state", 2, func(_ mqtt_paho.Client, msg mqtt_paho.Message) {
+		states <- msg
+	})
+	if token.Error() != nil {
+		t.Fatalf("controller failed to subscribe: %v", token.Error())
+	}
+
+	var wg sync.WaitGroup
+	for tx := 1; tx <= stateRoundTrips; tx++ {
+		wg.Add(1)
+		go func(tx int) {
+			defer wg.Done()
+			if err := controller.publishV5("roundtrip/config", 2, []byte("{}"), txProperties{tx: strconv.Itoa(tx)}); err != nil {
+				t.Errorf("failed to publish config of tx %d: %v", tx, err)
+			}
+		}(tx)
+	}
+	wg.Wait()
+
+	seen := make(map[int32]bool)
+	for len(seen) < stateRoundTrips {
+		select {
+		case msg := <-states:
+			tx, ok := messageTx(msg)
+			if !ok {
+				t.Fatalf("state without transaction")
+			}
+			if msg.Qos() != 2 {
+				t.Errorf("state of tx %d arrived with qos %d", tx, msg.Qos())
+			}
+			if seen[tx] {
+				t.Errorf("state of tx %d arrived more than once", tx)
+			}
+			seen[tx] = true
+		case <-ctx.Done():
+			t.Fatalf("got %d of %d states", len(seen), stateRoundTrips)
+		}
+	}
+}
+
+// runConcurrentCalls starts all calls at once on the nodes of the given prefix
+// and checks that every call saw its own transaction only
+func runConcurrentCalls(t *testing.T, fac *MqttFactory, gateway *testGateway, prefix string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentCalls; i++ {
+		wg.Add(3)
+
+		go func(tx int32, serialNumber string) {
+			defer wg.Done()
+			stream := &testStream[grpc_controlplane.UpdateResponse]{ctx: ctx}
+			err := fac.UpdateNode(&grpc_controlplane.NodeUpdate{
+				NodeUpdateItem: &grpc_controlplane.NodeUpdateItem{SerialNumber: serialNumber},
+				Transaction:    &grpc_controlplane.Transaction{TxId: tx},
+			}, stream)
+			if err != nil {
+				t.Errorf("UpdateNode of %s: %v", serialNumber, err)
+				return
+			}
+			responses := stream.responses()
+			for _, resp := range responses {
+				if resp.TxId != tx || resp.UpdateState == grpc_controlplane.UpdateState_UPDATE_ERROR {
+					t.Errorf("UpdateNode of %s (tx %d) got %s of tx %d", serialNumber, tx, resp.UpdateState, resp.TxId)
+				}
+			}
+			if last := responses[len(responses)-1]; last.UpdateState != grpc_controlplane.UpdateState_UPDATE_APPLIED {
+				t.Errorf("UpdateNode of %s ended in %s", serialNumber, last.UpdateState)
+			}
+		}(int32(100+i), fmt.Sprintf("%s-node-%d", prefix, i))
+
+		go func(tx int32, nodes []string) {
+			defer wg.Done()
+			items := make([]*grpc_controlplane.NodeUpdateItem, len(nodes))
+			for j, serialNumber := range nodes {
+				items[j] = &grpc_controlplane.NodeUpdateItem{SerialNumber: serialNumber}
+			}
+			stream := &testStream[grpc_controlplane.FleetResponse]{ctx: ctx}
+			err := fac.UpdateFleet(&grpc_controlplane.FleetUpdate{
+				NodeUpdateItems: items,
+				Transaction:     &grpc_controlplane.Transaction{TxId: tx},
+			}, stream)
+			if err != nil {
+				t.Errorf("UpdateFleet of tx %d: %v", tx, err)
+				return
+			}
+			for _, resp := range stream.responses() {
+				if resp.TxId != tx || resp.UpdateState == grpc_controlplane.UpdateState_UPDATE_ERROR {
+					t.Errorf("UpdateFleet of tx %d got %s of tx %d", tx, resp.UpdateState, resp.TxId)
+				}
+				if resp.SerialNumber != "" && resp.SerialNumber != nodes[0] && resp.SerialNumber != nodes[1] {
+					t.Errorf("UpdateFleet of tx %d got a report of %s", tx, resp.SerialNumber)
+				}
+			}
+		}(int32(200+i), []string{fmt.Sprintf("%s-fleet-%d-a", prefix, i), fmt.Sprintf("%s-fleet-%d-b", prefix, i)})
+
+		go func(serialNumber string) {
+			defer wg.Done()
+			_, err := fac.SendCertificateRequest(ctx, &grpc_controlplane.CertificateRequest{
+				SerialNumber: serialNumber,
+				CertType:     grpc_southbound.CertType_DATAPLANE,
+				HostName:     serialNumber,
+			})
+			if err != nil {
+				t.Errorf("SendCertificateRequest of %s: %v", serialNumber, err)
+			}
+		}(fmt.Sprintf("%s-cert-%d", prefix, i))
+	}
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		t.Fatalf("calls did not finish, a session lost the messages of its transaction")
+	}
+
+	for i := 0; i < concurrentCalls; i++ {
+		serialNumber := fmt.Sprintf("%s-cert-%d", prefix, i)
+		if n := gateway.waitForCertRequest(serialNumber); n != 1 {
+			t.Errorf("gateway got %d certificate requests of %s, want 1", n, serialNumber)
+		}
+	}
+}
+
+// testStream is the server side of a streaming call, it records what the call
+// sent
+type testStream[T any] struct {
+	grpc.ServerStream
+	ctx context.Context
+
+	mu   sync.Mutex
+	sent []*T
+}
+
+func (s *testStream[T]) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testStream[T]) Send(m *T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func (s *testStream[T]) responses() []*T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*T(nil), s.sent...)
+}
+
+// testGateway plays every node: it reports a config as applicable and an
+// apply request as applied, each after a report of a foreign transaction
+type testGateway struct {
+	t      *testing.T
+	client mqttClient
+
+	mu           sync.Mutex
+	certRequests map[string]int
+}
+
+func newTestGateway(t *testing.T, client mqttClient) *testGateway {
+	t.Helper()
+	g := &testGateway{t: t, client: client, certRequests: make(map[string]int)}
+	subs := map[string]mqtt_paho.MessageHandler{
+		"+/config":           g.onConfig,
+		"+/control/sync":     g.onSync,
+		"+/control/cert_req": g.onCertRequest,
+	}
+	for topic, handler := range subs {
+		if token := client.Subscribe(topic, 2, handler); token.Error() != nil {
+			t.Fatalf("gateway failed to subscribe to %s: %v", topic, token.Error())
+		}
+	}
+	t.Cleanup(func() { client.Disconnect(0) })
+	return g
+}
+
+func (g *testGateway) onConfig(_ mqtt_paho.Client, msg mqtt_paho.Message) {
+	tx, ok := messageTx(msg)
+	if !ok {
+		g.t.Errorf("config on %s without transaction", msg.Topic())
+		return
+	}
+	serialNumber, _, _ := strings.Cut(msg.Topic(), "/")
+	g.report(serialNumber, tx, grpc_controlplane.UpdateState_UPDATE_APPLICABLE)
+}
+
+func (g *testGateway) onSync(_ mqtt_paho.Client, msg mqtt_paho.Message) {
+	var sync legacySync
+	if err := json.Unmarshal(msg.Payload(), &sync); err != nil {
+		g.t.Errorf("invalid sync message on %s: %v", msg.Topic(), err)
+		return
+	}
+	if grpc_controlplane.UpdateState(sync.Status) == grpc_controlplane.UpdateState_UPDATE_APPLY_REQ {
+		serialNumber, _, _ := strings.Cut(msg.Topic(), "/")
+		g.report(serialNumber, sync.TxID, grpc_controlplane.UpdateState_UPDATE_APPLIED)
+	}
+}
+
+func (g *testGateway) onCertRequest(_ mqtt_paho.Client, msg mqtt_paho.Message) {
+	var req grpc_controlplane.CertificateRequest
+	if err := json.Unmarshal(msg.Payload(), &req); err != nil {
+		g.t.Errorf("invalid certificate request on %s: %v", msg.Topic(), err)
+		return
+	}
+	g.mu.Lock()
+	g.certRequests[req.SerialNumber]++
+	g.mu.Unlock()
+}
+
+// report publishes a failure of the foreign transaction and then the state
+// of transaction tx
+func (g *testGateway) report(serialNumber string, tx int32, state grpc_controlplane.UpdateState) {
+	g.publishState(serialNumber, foreignTx, grpc_controlplane.UpdateState_UPDATE_ERROR)
+	g.publishState(serialNumber, tx, state)
+}
+
+func (g *testGateway) publishState(serialNumber string, tx int32, state grpc_controlplane.UpdateState) {
+	payload, err := json.Marshal(control_msg{Status: int32(state), Serial_number: serialNumber, TxId: tx})
+	if err != nil {
+		g.t.Errorf("failed to encode state: %v", err)
+		return
+	}
+	err = g.client.publishV5(serialNumber+"/control/state", 2, payload, txProperties{tx: strconv.Itoa(int(tx))})
+	if err != nil {
+		g.t.Errorf("failed to report state of %s: %v", serialNumber, err)
+	}
+}
+
+// waitForCertRequest returns the number of certificate requests the gateway
+// got for a node. The call returns once the broker took the request, so it
+// waits a moment for the first one.
+func (g *testGateway) waitForCertRequest(serialNumber string) int {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		g.mu.Lock()
+		n := g.certRequests[serialNumber]
+		g.mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			return n
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// connectRecorder records the client ids of the sessions connecting to the
+// broker, except the one of the inline client
+type connectRecorder struct {
+	mqtt.HookBase
+
+	mu  sync.Mutex
+	ids []string
+}
+
+func (h *connectRecorder) ID() string {
+	return "connect-recorder"
+}
+
+func (h *connectRecorder) Provides(b byte) bool {
+	return b == mqtt.OnConnect
+}
+
+func (h *connectRecorder) OnConnect(cl *mqtt.Client, pk packets.Packet) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ids = append(h.ids, cl.ID)
+	return nil
+}
+
+func (h *connectRecorder) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ids = nil
+}
+
+func (h *connectRecorder) clientIDs() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.ids...)
+}
+
+// freeAddress returns a local address no listener uses
+func freeAddress() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return l.Addr().String(), nil
+}
+
+// waitForListener waits until a listener accepts connections on address
+func waitForListener(address string) error {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", address)
+		if err == nil {
+			return conn.Close()
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
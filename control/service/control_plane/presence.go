@@ -0,0 +1,87 @@
+package control_plane
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	mqtt "github.com/Laboratory-for-Safe-and-Secure-Systems/mqtt_broker"
+	"github.com/Laboratory-for-Safe-and-Secure-Systems/mqtt_broker/packets"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// presenceHook reports the clients connecting to and disconnecting from the
+// broker. It runs on the connection goroutines of the broker, so events are
+// dropped rather than blocking a client if the controller falls behind.
+type presenceHook struct {
+	mqtt.HookBase
+	events chan<- types.PresenceEvent
+	// connections maps a client to the time its connection was established
+	connections sync.Map
+}
+
+func (h *presenceHook) ID() string {
+	return "presence"
+}
+
+func (h *presenceHook) Provides(b byte) bool {
+	return bytes.Contains([]byte{
+		mqtt.OnSessionEstablished,
+		mqtt.OnDisconnect,
+	}, []byte{b})
+}
+
+func (h *presenceHook) OnSessionEstablished(cl *mqtt.Client, pk packets.Packet) {
+	if cl.Net.Inline {
+		return
+	}
+	// the database keeps microseconds, the connect time has to compare equal
+	connectedAt := time.Now().Truncate(time.Microsecond)
+	h.connections.Store(cl, connectedAt)
+	h.send(types.PresenceEvent{
+		ClientID:    cl.ID,
+		Connected:   true,
+		ConnectedAt: connectedAt,
+		Time:        connectedAt,
+		RemoteAddr:  cl.Net.Remote,
+	})
+}
+
+func (h *presenceHook) OnDisconnect(cl *mqtt.Client, err error, expire bool) {
+	value, ok := h.connections.LoadAndDelete(cl)
+	if !ok {
+		// the session was never established
+		return
+	}
+	reason := "client disconnected"
+	if err != nil {
+		reason = err.Error()
+	} else if cause := cl.StopCause(); cause != nil {
+		reason = cause.Error()
+	}
+	h.send(types.PresenceEvent{
+		ClientID:    cl.ID,
+		ConnectedAt: value.(time.Time),
+		Time:        time.Now(),
+		RemoteAddr:  cl.Net.Remote,
+		Reason:      reason,
+	})
+}
+
+func (h *presenceHook) send(event types.PresenceEvent) {
+	select {
+	case h.events <- event:
+	default:
+		est_log.Warn().Msgf("Presence event of client %s dropped, the controller is not keeping up", event.ClientID)
+	}
+}
+
+// ReportPresence makes the broker send the connects and disconnects of its
+// clients to events. It must be called before Serve.
+func (b *Broker) ReportPresence(events chan<- types.PresenceEvent) error {
+	if err := b.broker.AddHook(&presenceHook{events: events}, nil); err != nil {
+		b.log.Err(err).Msg("Error adding presence hook")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package control_plane
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	mqtt_paho "github.com/Laboratory-for-Safe-and-Secure-Systems/paho.mqtt.golang"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+/********************************** grpc service for recovery *******************************************/
+
+// WatchNodeStates forwards the state reports of the nodes until the stream is
+// closed. Once subscribed, it asks the nodes of req.QuerySerialNumbers for
+// their state of the transaction, see sendQuery.
+func (fac *MqttFactory) WatchNodeStates(req *grpc_scale.WatchNodeStatesRequest, stream grpc.ServerStreamingServer[grpc_scale.NodeStateReport]) error {
+	c, err := fac.GetClient("recovery")
+	if err != nil {
+		mqtt_log.Err(err).Msg("failed to get client")
+		return status.Errorf(codes.Internal, "failed to get client")
+	}
+	defer c.cleanup()
+
+	topic := "+/control/state"
+	reportChan := make(chan *grpc_scale.NodeStateReport, 16)
+	token := c.client.Subscribe(topic, 2, func(client mqtt_paho.Client, msg mqtt_paho.Message) {
+		mqtt_log.Debug().Msgf("Received message: %s from topic: %s\n", msg.Payload(), msg.Topic())
+		parts := strings.Split(msg.Topic(), "/")
+		if len(parts) < 3 {
+			mqtt_log.Error().Msgf("Invalid topic format: %s", msg.Topic())
+			return
+		}
+
+		control_msg, err := c.decodeState(msg)
+		var schemaErr *unsupportedSchemaError
+		if err != nil && !errors.As(err, &schemaErr) {
+			mqtt_log.Err(err).Msg("error unmarshalling update state")
+			return
+		}
+		if len(req.TxIds) > 0 && !slices.Contains(req.TxIds, control_msg.TxId) {
+			return
+		}
+		// the state of the node is unknown, the transaction fails on it
+		if schemaErr != nil {
+			control_msg.Status = int32(grpc_controlplane.UpdateState_UPDATE_ERROR)
+			control_msg.Msg = schemaErr.Error()
+		}
+
+		// a dropped report would leave the node silent to the recovery
+		select {
+		case reportChan <- &grpc_scale.NodeStateReport{
+			SerialNumber: parts[0],
+			TxId:         control_msg.TxId,
+			UpdateState:  control_msg.Status,
+			Timestamp:    timestamppb.New(time.Now()),
+			Msg:          control_msg.Msg,
+		}:
+		case <-stream.Context().Done():
+		}
+	})
+	c.subs = append(c.subs, topic)
+	token.Wait()
+	if err := token.Error(); err != nil {
+		return status.Errorf(codes.Internal, "failed to subscribe to node states")
+	}
+
+	if len(req.QuerySerialNumbers) > 0 {
+		if len(req.TxIds) != 1 {
+			return status.Errorf(codes.InvalidArgument, "a state query needs exactly one transaction")
+		}
+		for _, serialNumber := range req.QuerySerialNumbers {
+			err := c.sendQuery(serialNumber, req.TxIds[0])
+			switch {
+			case errors.Is(err, errQueryUnsupported):
+				mqtt_log.Info().Str("node", serialNumber).Msg("Node can not be asked for its state, waiting for a report")
+			case err != nil:
+				mqtt_log.Error().Err(err).Str("node", serialNumber).Msg("Failed to send state query")
+				return status.Errorf(codes.Internal, "failed to send state query to node %s", serialNumber)
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case report := <-reportChan:
+			if err := stream.Send(report); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// SendSync publishes an acknowledgement or a rollback request of a transaction
+// to the given nodes
+func (fac *MqttFactory) SendSync(ctx context.Context, req *grpc_scale.SendSyncRequest) (*emptypb.Empty, error) {
+	state := grpc_controlplane.UpdateState(req.UpdateState)
+	switch state {
+	case grpc_controlplane.UpdateState_UPDATE_ACKNOWLEDGED, grpc_controlplane.UpdateState_UPDATE_ROLLBACK:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid sync state %s", state)
+	}
+
+	c, err := fac.GetClient("sync")
+	if err != nil {
+		mqtt_log.Err(err).Msg("failed to get client")
+		return nil, status.Errorf(codes.Internal, "failed to get client")
+	}
+	defer c.cleanup()
+
+	for _, serialNumber := range req.SerialNumbers {
+		if err := c.sendSync(serialNumber, state, req.TxId); err != nil {
+			mqtt_log.Error().Err(err).Str("node", serialNumber).Msg("Failed to send sync message")
+			return nil, status.Errorf(codes.Internal, "failed to send sync message to node %s", serialNumber)
+		}
+		mqtt_log.Debug().Str("node", serialNumber).Str("state", state.String()).Msg("Sync message sent")
+	}
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,319 @@
+package control_plane
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	mqtt_paho "github.com/Laboratory-for-Safe-and-Secure-Systems/paho.mqtt.golang"
+	grpc_wire "github.com/philslol/kritis3m_scalev2/proto/wire"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// wireSchemaVersion is the newest payload schema of the envelopes. The
+// controller reads envelopes of this version and older ones, and sends a node
+// envelopes of the version it negotiated.
+//
+// Version 2 adds the query envelope.
+const wireSchemaVersion = 2
+
+// wireQuerySchemaVersion is the first schema version with the query envelope
+const wireQuerySchemaVersion = 2
+
+// message types of a WireEnvelope
+const (
+	wireTypeConfig = "config"
+	wireTypeSync   = "sync"
+	wireTypeState  = "state"
+	// wireTypeQuery has no payload, it asks a gateway to report its state of
+	// the transaction again
+	wireTypeQuery = "query"
+)
+
+// errQueryUnsupported is returned by sendQuery for nodes which negotiated a
+// schema version without the query envelope
+var errQueryUnsupported = errors.New("the wire schema of the node has no state query")
+
+type wireFormat int
+
+const (
+	// wireLegacy is the unversioned JSON of the gateways which predate the
+	// envelope, a gateway is sent it until its hello tells otherwise
+	wireLegacy wireFormat = iota
+	// wireJSON is a WireEnvelope encoded with protojson, the fallback of the
+	// gateways which read the envelope but not protobuf
+	wireJSON
+	// wireProtobuf is a WireEnvelope encoded with protobuf
+	wireProtobuf
+)
+
+func (f wireFormat) String() string {
+	switch f {
+	case wireProtobuf:
+		return "protobuf"
+	case wireJSON:
+		return "json"
+	default:
+		return "legacy"
+	}
+}
+
+// contentType is the MQTT 5 content type of a message in the format, the
+// legacy format has none
+func (f wireFormat) contentType() string {
+	switch f {
+	case wireProtobuf:
+		return "application/x-protobuf"
+	case wireJSON:
+		return "application/json"
+	default:
+		return ""
+	}
+}
+
+// messageFormat returns the format named by the content type of an MQTT 5
+// message. ok is false for messages without a known content type.
+func messageFormat(msg mqtt_paho.Message) (format wireFormat, ok bool) {
+	var contentType string
+	switch m := msg.(type) {
+	case inlineMessage:
+		contentType = m.pk.Properties.ContentType
+	case v5Message:
+		if m.pk.Properties != nil {
+			contentType = m.pk.Properties.ContentType
+		}
+	}
+	for _, f := range []wireFormat{wireJSON, wireProtobuf} {
+		if contentType == f.contentType() {
+			return f, true
+		}
+	}
+	return wireLegacy, false
+}
+
+// unsupportedSchemaError is the error of an envelope with a schema version
+// the controller does not know. The envelope still tells the transaction.
+type unsupportedSchemaError struct {
+	version uint32
+}
+
+func (e *unsupportedSchemaError) Error() string {
+	return fmt.Sprintf("unsupported schema version %d, the controller supports up to %d", e.version, wireSchemaVersion)
+}
+
+// wireNode is what a node negotiated: the format and the schema version of
+// the envelopes it is sent. The schema version is zero for the legacy format.
+type wireNode struct {
+	format wireFormat
+	schema uint32
+}
+
+// wireFormats holds what every node negotiated in its last hello
+type wireFormats struct {
+	mu    sync.Mutex
+	nodes map[string]wireNode
+}
+
+func newWireFormats() *wireFormats {
+	return &wireFormats{nodes: make(map[string]wireNode)}
+}
+
+// negotiate picks the format of a node from its hello, hello is nil if the
+// hello had no valid payload. A gateway which reads the envelope tells its
+// schema version, it gets protobuf if it lists it and the JSON envelope
+// otherwise, in the older of its and the controller's schema version. A
+// gateway without a schema version predates the envelope. Every hello
+// negotiates again, a gateway may have been downgraded in between.
+func (w *wireFormats) negotiate(serialNumber string, hello *helloMsg) {
+	var node wireNode
+	switch {
+	case hello == nil || hello.SchemaVersion == 0:
+	case slices.Contains(hello.WireFormats, wireProtobuf.String()):
+		node = wireNode{format: wireProtobuf, schema: min(hello.SchemaVersion, wireSchemaVersion)}
+	default:
+		node = wireNode{format: wireJSON, schema: min(hello.SchemaVersion, wireSchemaVersion)}
+	}
+
+	w.mu.Lock()
+	previous, known := w.nodes[serialNumber]
+	w.nodes[serialNumber] = node
+	w.mu.Unlock()
+	if !known || previous != node {
+		mqtt_log.Info().Msgf("Node %s uses the %s wire format, schema version %d", serialNumber, node.format, node.schema)
+	}
+}
+
+// get returns the format of a node, legacy until it said hello
+func (w *wireFormats) get(serialNumber string) wireFormat {
+	return w.node(serialNumber).format
+}
+
+// node returns what a node negotiated
+func (w *wireFormats) node(serialNumber string) wireNode {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.nodes[serialNumber]
+}
+
+// envelopeJSON encodes the JSON envelope with the field names of wire.proto
+var envelopeJSON = protojson.MarshalOptions{UseProtoNames: true}
+
+// encodeEnvelope encodes an envelope of the schema version in the format, the
+// caller sets type and payload
+func encodeEnvelope(format wireFormat, schema uint32, tx int32, envelope *grpc_wire.WireEnvelope) ([]byte, error) {
+	envelope.SchemaVersion = schema
+	envelope.TxId = tx
+	envelope.Timestamp = timestamppb.New(time.Now())
+	if format == wireJSON {
+		return envelopeJSON.Marshal(envelope)
+	}
+	return proto.Marshal(envelope)
+}
+
+// decodeEnvelope parses an envelope in the format. Fields of a newer schema
+// version are skipped, the caller checks the version.
+func decodeEnvelope(format wireFormat, payload []byte) (*grpc_wire.WireEnvelope, error) {
+	var envelope grpc_wire.WireEnvelope
+	var err error
+	if format == wireJSON {
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(payload, &envelope)
+	} else {
+		err = proto.Unmarshal(payload, &envelope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s envelope: %w", format, err)
+	}
+	if envelope.SchemaVersion == 0 {
+		return nil, errors.New("envelope without schema version")
+	}
+	return &envelope, nil
+}
+
+// legacySync is the sync message of the legacy format
+type legacySync struct {
+	Status int32 `json:"status"`
+	TxID   int32 `json:"tx_id"`
+}
+
+// sendConfig publishes the config of a node for transaction tx in the format
+// of the node
+func (c *client) sendConfig(item *grpc_controlplane.NodeUpdateItem, tx int32) error {
+	node := c.formats.node(item.SerialNumber)
+	format := node.format
+	var payload []byte
+	var err error
+	if format == wireLegacy {
+		payload, err = json.Marshal(item)
+	} else {
+		payload, err = encodeEnvelope(format, node.schema, tx, &grpc_wire.WireEnvelope{
+			Type:    wireTypeConfig,
+			Payload: &grpc_wire.WireEnvelope_Config{Config: item},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode config of %s: %w", item.SerialNumber, err)
+	}
+	return c.publishTx(item.SerialNumber+"/config", payload, format, tx, item.SerialNumber+"/control/state")
+}
+
+// sendSync asks a node to move transaction tx to the given state, in the
+// format of the node
+func (c *client) sendSync(serialNumber string, state grpc_controlplane.UpdateState, tx int32) error {
+	node := c.formats.node(serialNumber)
+	format := node.format
+	var payload []byte
+	var err error
+	if format == wireLegacy {
+		payload, err = json.Marshal(legacySync{Status: int32(state), TxID: tx})
+	} else {
+		payload, err = encodeEnvelope(format, node.schema, tx, &grpc_wire.WireEnvelope{
+			Type:    wireTypeSync,
+			Payload: &grpc_wire.WireEnvelope_Sync{Sync: &grpc_wire.WireSync{UpdateState: state}},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode sync message of %s: %w", serialNumber, err)
+	}
+	return c.publishTx(serialNumber+"/control/sync", payload, format, tx, serialNumber+"/control/state")
+}
+
+// sendQuery asks a node to report its state of transaction tx on its state
+// topic. Nodes which negotiated no query get errQueryUnsupported, the
+// controller has no way to ask them.
+func (c *client) sendQuery(serialNumber string, tx int32) error {
+	node := c.formats.node(serialNumber)
+	if node.schema < wireQuerySchemaVersion {
+		return errQueryUnsupported
+	}
+	payload, err := encodeEnvelope(node.format, node.schema, tx, &grpc_wire.WireEnvelope{Type: wireTypeQuery})
+	if err != nil {
+		return fmt.Errorf("failed to encode state query of %s: %w", serialNumber, err)
+	}
+	return c.publishTx(serialNumber+"/control/sync", payload, node.format, tx, serialNumber+"/control/state")
+}
+
+// reportsTx reports whether a decoded state report of a node belongs to
+// transaction tx. Retained reports are stale. Envelopes and MQTT 5 messages
+// carry their transaction; only a node of the legacy format, which predates
+// both, may leave it out, its untagged reports belong to the transaction it is
+// updated in.
+func (c *client) reportsTx(serialNumber string, msg mqtt_paho.Message, state control_msg, tx int32) bool {
+	if msg.Retained() {
+		return false
+	}
+	if state.TxId == 0 {
+		return c.formats.get(serialNumber) == wireLegacy
+	}
+	return state.TxId == tx
+}
+
+// decodeState parses a state report of a node. The content type of an MQTT 5
+// report names its format, a report without one is in the format the node
+// negotiated. For an envelope of an unknown schema version it returns the
+// transaction of the envelope with an *unsupportedSchemaError. The tx_id of an
+// MQTT 5 message takes precedence over the one of the payload.
+func (c *client) decodeState(msg mqtt_paho.Message) (control_msg, error) {
+	var state control_msg
+	format, ok := messageFormat(msg)
+	if !ok {
+		serialNumber, _, _ := strings.Cut(msg.Topic(), "/")
+		format = c.formats.get(serialNumber)
+	}
+
+	if format == wireLegacy {
+		if err := json.Unmarshal(msg.Payload(), &state); err != nil {
+			return state, err
+		}
+	} else {
+		envelope, err := decodeEnvelope(format, msg.Payload())
+		if err != nil {
+			return state, err
+		}
+		state.TxId = envelope.TxId
+		if envelope.SchemaVersion > wireSchemaVersion {
+			if id, ok := messageTx(msg); ok {
+				state.TxId = id
+			}
+			return state, &unsupportedSchemaError{version: envelope.SchemaVersion}
+		}
+		report := envelope.GetState()
+		if envelope.Type != wireTypeState || report == nil {
+			return state, fmt.Errorf("unexpected %q envelope on a state topic", envelope.Type)
+		}
+		state.Status = int32(report.UpdateState)
+		state.Module = report.Module
+		state.Msg = report.Msg
+	}
+
+	if id, ok := messageTx(msg); ok {
+		state.TxId = id
+	}
+	return state, nil
+}
@@ -0,0 +1,361 @@
+package control_plane
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	"github.com/eclipse/paho.golang/paho"
+	grpc_wire "github.com/philslol/kritis3m_scalev2/proto/wire"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	envelopes := map[string]func() *grpc_wire.WireEnvelope{
+		wireTypeConfig: func() *grpc_wire.WireEnvelope {
+			return &grpc_wire.WireEnvelope{
+				Type:    wireTypeConfig,
+				Payload: &grpc_wire.WireEnvelope_Config{Config: &grpc_controlplane.NodeUpdateItem{SerialNumber: "gw-1", NetworkIndex: 2, Locality: "hall"}},
+			}
+		},
+		wireTypeSync: func() *grpc_wire.WireEnvelope {
+			return &grpc_wire.WireEnvelope{
+				Type:    wireTypeSync,
+				Payload: &grpc_wire.WireEnvelope_Sync{Sync: &grpc_wire.WireSync{UpdateState: grpc_controlplane.UpdateState_UPDATE_APPLY_REQ}},
+			}
+		},
+		wireTypeState: func() *grpc_wire.WireEnvelope {
+			return &grpc_wire.WireEnvelope{
+				Type:    wireTypeState,
+				Payload: &grpc_wire.WireEnvelope_State{State: &grpc_wire.WireState{UpdateState: grpc_controlplane.UpdateState_UPDATE_ERROR, Module: "proxy", Msg: "bind failed"}},
+			}
+		},
+		wireTypeQuery: func() *grpc_wire.WireEnvelope {
+			return &grpc_wire.WireEnvelope{Type: wireTypeQuery}
+		},
+	}
+	tests := []struct {
+		format wireFormat
+		schema uint32
+		typ    string
+		isJSON bool
+	}{
+		{format: wireProtobuf, schema: wireSchemaVersion, typ: wireTypeConfig},
+		{format: wireProtobuf, schema: wireSchemaVersion, typ: wireTypeSync},
+		{format: wireProtobuf, schema: wireSchemaVersion, typ: wireTypeState},
+		{format: wireProtobuf, schema: wireSchemaVersion, typ: wireTypeQuery},
+		{format: wireProtobuf, schema: 1, typ: wireTypeConfig},
+		{format: wireJSON, schema: wireSchemaVersion, typ: wireTypeConfig, isJSON: true},
+		{format: wireJSON, schema: wireSchemaVersion, typ: wireTypeSync, isJSON: true},
+		{format: wireJSON, schema: wireSchemaVersion, typ: wireTypeState, isJSON: true},
+		{format: wireJSON, schema: wireSchemaVersion, typ: wireTypeQuery, isJSON: true},
+		{format: wireJSON, schema: 1, typ: wireTypeSync, isJSON: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/v%d/%s", tt.format, tt.schema, tt.typ), func(t *testing.T) {
+			envelope := envelopes[tt.typ]()
+			payload, err := encodeEnvelope(tt.format, tt.schema, 42, envelope)
+			if err != nil {
+				t.Fatalf("encodeEnvelope: %v", err)
+			}
+			if envelope.SchemaVersion != tt.schema || envelope.TxId != 42 || envelope.Timestamp == nil {
+				t.Errorf("encodeEnvelope set schema version %d, tx %d, timestamp %v", envelope.SchemaVersion, envelope.TxId, envelope.Timestamp)
+			}
+			if isJSON := json.Valid(payload); isJSON != tt.isJSON {
+				t.Errorf("payload is JSON = %t, want %t: %s", isJSON, tt.isJSON, payload)
+			}
+
+			decoded, err := decodeEnvelope(tt.format, payload)
+			if err != nil {
+				t.Fatalf("decodeEnvelope: %v", err)
+			}
+			if !proto.Equal(decoded, envelope) {
+				t.Errorf("decodeEnvelope = %v, want %v", decoded, envelope)
+			}
+		})
+	}
+}
+
+func TestDecodeEnvelopeInvalid(t *testing.T) {
+	unversioned, err := proto.Marshal(&grpc_wire.WireEnvelope{Type: wireTypeState, TxId: 1})
+	if err != nil {
+		t.Fatalf("failed to encode envelope: %v", err)
+	}
+	tests := []struct {
+		name    string
+		format  wireFormat
+		payload []byte
+	}{
+		{name: "protobuf without schema version", format: wireProtobuf, payload: unversioned},
+		{name: "json without schema version", format: wireJSON, payload: []byte(`{"type":"state","tx_id":1}`)},
+		{name: "legacy json as envelope", format: wireJSON, payload: []byte(`{"status":5,"tx_id":1`)},
+		{name: "garbage as protobuf", format: wireProtobuf, payload: []byte{0xff, 0xff, 0xff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if envelope, err := decodeEnvelope(tt.format, tt.payload); err == nil {
+				t.Errorf("decodeEnvelope = %v, want an error", envelope)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name  string
+		hello *helloMsg
+		want  wireNode
+	}{
+		{name: "hello without payload", hello: nil, want: wireNode{format: wireLegacy}},
+		{name: "hello without schema version", hello: &helloMsg{AgentVersion: "1.0.0"}, want: wireNode{format: wireLegacy}},
+		{name: "formats without schema version", hello: &helloMsg{WireFormats: []string{"protobuf", "json"}}, want: wireNode{format: wireLegacy}},
+		{name: "protobuf", hello: &helloMsg{SchemaVersion: wireSchemaVersion, WireFormats: []string{"json", "protobuf"}}, want: wireNode{format: wireProtobuf, schema: wireSchemaVersion}},
+		{name: "json only", hello: &helloMsg{SchemaVersion: wireSchemaVersion, WireFormats: []string{"json"}}, want: wireNode{format: wireJSON, schema: wireSchemaVersion}},
+		{name: "no formats", hello: &helloMsg{SchemaVersion: wireSchemaVersion}, want: wireNode{format: wireJSON, schema: wireSchemaVersion}},
+		{name: "older schema version", hello: &helloMsg{SchemaVersion: 1, WireFormats: []string{"protobuf"}}, want: wireNode{format: wireProtobuf, schema: 1}},
+		{name: "newer schema version", hello: &helloMsg{SchemaVersion: wireSchemaVersion + 1, WireFormats: []string{"protobuf"}}, want: wireNode{format: wireProtobuf, schema: wireSchemaVersion}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formats := newWireFormats()
+			// every hello negotiates again, the previous format must not stick
+			formats.negotiate("gw-1", &helloMsg{SchemaVersion: wireSchemaVersion, WireFormats: []string{"protobuf"}})
+			formats.negotiate("gw-1", tt.hello)
+			if got := formats.node("gw-1"); got != tt.want {
+				t.Errorf("node = %+v, want %+v", got, tt.want)
+			}
+			if got := formats.get("gw-2"); got != wireLegacy {
+				t.Errorf("format of a node without hello = %s, want legacy", got)
+			}
+		})
+	}
+}
+
+func TestSendQueryUnsupported(t *testing.T) {
+	tests := []struct {
+		name  string
+		hello *helloMsg
+	}{
+		{name: "node without hello"},
+		{name: "legacy", hello: &helloMsg{AgentVersion: "1.0.0"}},
+		{name: "schema version without query", hello: &helloMsg{SchemaVersion: wireQuerySchemaVersion - 1, WireFormats: []string{"protobuf"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &client{formats: newWireFormats()}
+			if tt.hello != nil {
+				c.formats.negotiate("gw-1", tt.hello)
+			}
+			// the query is refused before anything is published
+			if err := c.sendQuery("gw-1", 7); !errors.Is(err, errQueryUnsupported) {
+				t.Errorf("sendQuery = %v, want errQueryUnsupported", err)
+			}
+		})
+	}
+}
+
+// stateEnvelope encodes a state report in the format with the given schema
+// version
+func stateEnvelope(t *testing.T, format wireFormat, schemaVersion uint32, tx int32) []byte {
+	t.Helper()
+	envelope := &grpc_wire.WireEnvelope{
+		Type:    wireTypeState,
+		Payload: &grpc_wire.WireEnvelope_State{State: &grpc_wire.WireState{UpdateState: grpc_controlplane.UpdateState_UPDATE_APPLIED, Module: "proxy", Msg: "ok"}},
+	}
+	payload, err := encodeEnvelope(format, wireSchemaVersion, tx, envelope)
+	if err != nil {
+		t.Fatalf("encodeEnvelope: %v", err)
+	}
+	if schemaVersion == wireSchemaVersion {
+		return payload
+	}
+	envelope.SchemaVersion = schemaVersion
+	if format == wireJSON {
+		payload, err = envelopeJSON.Marshal(envelope)
+	} else {
+		payload, err = proto.Marshal(envelope)
+	}
+	if err != nil {
+		t.Fatalf("failed to encode envelope: %v", err)
+	}
+	return payload
+}
+
+func TestDecodeState(t *testing.T) {
+	applied := control_msg{Status: int32(grpc_controlplane.UpdateState_UPDATE_APPLIED), Module: "proxy", Msg: "ok", TxId: 7}
+	legacy, err := json.Marshal(control_msg{Status: int32(grpc_controlplane.UpdateState_UPDATE_APPLICABLE), Serial_number: "gw-1", TxId: 7})
+	if err != nil {
+		t.Fatalf("failed to encode legacy state: %v", err)
+	}
+	// a newer gateway may add fields the controller does not know
+	newerJSON := []byte(`{"schema_version":3,"type":"state","tx_id":7,"state":{"update_state":"UPDATE_APPLIED"},"rollout":"canary"}`)
+
+	tests := []struct {
+		name string
+		// negotiated is the format of the last hello of the node
+		negotiated  wireFormat
+		contentType string
+		messageTx   string
+		payload     []byte
+		want        control_msg
+		// unsupported is the schema version of an *unsupportedSchemaError
+		unsupported uint32
+		wantErr     bool
+	}{
+		{
+			name:       "legacy",
+			negotiated: wireLegacy,
+			payload:    legacy,
+			want:       control_msg{Status: int32(grpc_controlplane.UpdateState_UPDATE_APPLICABLE), Serial_number: "gw-1", TxId: 7},
+		},
+		{
+			name:       "negotiated protobuf",
+			negotiated: wireProtobuf,
+			payload:    stateEnvelope(t, wireProtobuf, wireSchemaVersion, 7),
+			want:       applied,
+		},
+		{
+			name:       "negotiated json",
+			negotiated: wireJSON,
+			payload:    stateEnvelope(t, wireJSON, wireSchemaVersion, 7),
+			want:       applied,
+		},
+		{
+			name:        "content type protobuf overrides legacy",
+			negotiated:  wireLegacy,
+			contentType: wireProtobuf.contentType(),
+			payload:     stateEnvelope(t, wireProtobuf, wireSchemaVersion, 7),
+			want:        applied,
+		},
+		{
+			name:        "content type json overrides protobuf",
+			negotiated:  wireProtobuf,
+			contentType: wireJSON.contentType(),
+			payload:     stateEnvelope(t, wireJSON, wireSchemaVersion, 7),
+			want:        applied,
+		},
+		{
+			name:        "unknown content type uses the negotiated format",
+			negotiated:  wireProtobuf,
+			contentType: "text/plain",
+			payload:     stateEnvelope(t, wireProtobuf, wireSchemaVersion, 7),
+			want:        applied,
+		},
+		{
+			name:       "tx_id property overrides the envelope",
+			negotiated: wireProtobuf,
+			messageTx:  "8",
+			payload:    stateEnvelope(t, wireProtobuf, wireSchemaVersion, 7),
+			want:       control_msg{Status: applied.Status, Module: applied.Module, Msg: applied.Msg, TxId: 8},
+		},
+		{
+			name:        "newer schema version",
+			negotiated:  wireProtobuf,
+			payload:     stateEnvelope(t, wireProtobuf, wireSchemaVersion+1, 7),
+			want:        control_msg{TxId: 7},
+			unsupported: wireSchemaVersion + 1,
+		},
+		{
+			name:        "newer schema version with unknown json fields",
+			negotiated:  wireLegacy,
+			contentType: wireJSON.contentType(),
+			messageTx:   "9",
+			payload:     newerJSON,
+			want:        control_msg{TxId: 9},
+			unsupported: 3,
+		},
+		{
+			name:       "envelope of another type",
+			negotiated: wireProtobuf,
+			payload: func() []byte {
+				payload, err := encodeEnvelope(wireProtobuf, wireSchemaVersion, 7, &grpc_wire.WireEnvelope{
+					Type:    wireTypeSync,
+					Payload: &grpc_wire.WireEnvelope_Sync{Sync: &grpc_wire.WireSync{UpdateState: grpc_controlplane.UpdateState_UPDATE_APPLY_REQ}},
+				})
+				if err != nil {
+					t.Fatalf("encodeEnvelope: %v", err)
+				}
+				return payload
+			}(),
+			wantErr: true,
+		},
+		{
+			name:       "legacy payload of a node which negotiated protobuf",
+			negotiated: wireProtobuf,
+			payload:    legacy,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &client{formats: newWireFormats()}
+			switch tt.negotiated {
+			case wireProtobuf:
+				c.formats.negotiate("gw-1", &helloMsg{SchemaVersion: wireSchemaVersion, WireFormats: []string{"protobuf"}})
+			case wireJSON:
+				c.formats.negotiate("gw-1", &helloMsg{SchemaVersion: wireSchemaVersion})
+			}
+			props := &paho.PublishProperties{ContentType: tt.contentType}
+			if tt.messageTx != "" {
+				props.User = paho.UserProperties{{Key: txProperty, Value: tt.messageTx}}
+			}
+			msg := v5Message{pk: &paho.Publish{Topic: "gw-1/control/state", Payload: tt.payload, Properties: props}}
+
+			state, err := c.decodeState(msg)
+			var unsupported *unsupportedSchemaError
+			switch {
+			case tt.unsupported != 0:
+				if !errors.As(err, &unsupported) || unsupported.version != tt.unsupported {
+					t.Fatalf("decodeState error = %v, want unsupported schema version %d", err, tt.unsupported)
+				}
+			case tt.wantErr:
+				if err == nil {
+					t.Fatalf("decodeState = %+v, want an error", state)
+				}
+				return
+			case err != nil:
+				t.Fatalf("decodeState: %v", err)
+			}
+			if state != tt.want {
+				t.Errorf("decodeState = %+v, want %+v", state, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportsTx(t *testing.T) {
+	tests := []struct {
+		name       string
+		negotiated wireFormat
+		retained   bool
+		tx         int32
+		want       bool
+	}{
+		{name: "own transaction", negotiated: wireProtobuf, tx: 7, want: true},
+		{name: "other transaction", negotiated: wireProtobuf, tx: 8},
+		{name: "untagged envelope", negotiated: wireProtobuf, tx: 0},
+		{name: "untagged json envelope", negotiated: wireJSON, tx: 0},
+		{name: "legacy own transaction", negotiated: wireLegacy, tx: 7, want: true},
+		{name: "legacy other transaction", negotiated: wireLegacy, tx: 8},
+		{name: "legacy untagged", negotiated: wireLegacy, tx: 0, want: true},
+		{name: "retained", negotiated: wireProtobuf, retained: true, tx: 7},
+		{name: "legacy retained untagged", negotiated: wireLegacy, retained: true, tx: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &client{formats: newWireFormats()}
+			switch tt.negotiated {
+			case wireProtobuf:
+				c.formats.negotiate("gw-1", &helloMsg{SchemaVersion: wireSchemaVersion, WireFormats: []string{"protobuf"}})
+			case wireJSON:
+				c.formats.negotiate("gw-1", &helloMsg{SchemaVersion: wireSchemaVersion})
+			}
+			msg := v5Message{pk: &paho.Publish{Topic: "gw-1/control/state", Retain: tt.retained}}
+			if got := c.reportsTx("gw-1", msg, control_msg{TxId: tt.tx}, 7); got != tt.want {
+				t.Errorf("reportsTx = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package southbound
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"time"
@@ -9,19 +10,20 @@ import (
 	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
 	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
 	"github.com/gofrs/uuid/v5"
-	"github.com/jackc/pgx/v5"
+	"github.com/philslol/kritis3m_scalev2/control/db"
 	"github.com/philslol/kritis3m_scalev2/control/types"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
-func getControlPlaneClient(addr string) (grpc_controlplane.ControlPlaneClient, *grpc.ClientConn, error) {
-	grpcOptions := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+// activationTimeout bounds the update of an activation
+const activationTimeout = 5 * time.Minute
 
-	conn, err := grpc.NewClient(addr, grpcOptions...)
+func getControlPlaneClient(addr string) (grpc_controlplane.ControlPlaneClient, *grpc.ClientConn, error) {
+	conn, err := dialController(addr)
 	if err != nil {
 		log.Error().Err(err).Msg("Could not connect to control plane")
 		return nil, nil, status.Error(codes.Internal, "Failed to connect to control plane")
@@ -31,11 +33,12 @@ func getControlPlaneClient(addr string) (grpc_controlplane.ControlPlaneClient, *
 	return client, conn, nil
 }
 
+// ActivateFleet pushes a version set to the fleet, or to a group of it. The
+// update runs in the background; the response carries the id of its
+// transaction, which can be followed with WatchTransaction. Offline nodes are
+// left out of the transaction, their updates are queued. If no node is online
+// the whole update is queued and the response says so.
 func (sb *SouthboundService) ActivateFleet(ctx context.Context, req *grpc_southbound.ActivateFleetRequest) (*grpc_southbound.ActivateResponse, error) {
-	// Add timeout to context
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute) // Longer timeout for fleet updates
-	defer cancel()
-
 	// Validate request
 	if req.VersionSetId == "" {
 		return nil, status.Error(codes.InvalidArgument, "VersionSetId is required")
@@ -48,96 +51,346 @@ func (sb *SouthboundService) ActivateFleet(ctx context.Context, req *grpc_southb
 		return nil, status.Error(codes.InvalidArgument, "Invalid VersionSetId format")
 	}
 
+	tx, queued, err := sb.activateFleet(ctx, uuid_version_set, req.GetGroupName(), activationOptions{
+		force:           metadataFlag(ctx, types.ForceActivationKey),
+		ignoreWindow:    metadataFlag(ctx, types.IgnoreWindowKey),
+		minAgentVersion: metadataValue(ctx, types.MinAgentVersionKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if queued {
+		return queuedResponse(tx)
+	}
+	return activateResponse(tx)
+}
+
+// activationOptions relax the checks before an activation
+type activationOptions struct {
+	// force skips the validation of the version set
+	force bool
+	// ignoreWindow allows the activation outside of maintenance windows
+	ignoreWindow bool
+	// minAgentVersion restricts the activation to nodes running at least
+	// this agent version
+	minAgentVersion string
+}
+
+// activateFleet starts the update of ActivateFleet, an empty groupName updates
+// the whole fleet. It returns the id of the transaction and whether the whole
+// update was queued because none of its nodes is online.
+func (sb *SouthboundService) activateFleet(ctx context.Context, uuid_version_set uuid.UUID, groupName string, opts activationOptions) (int, bool, error) {
+	if err := sb.checkActivation(ctx, uuid_version_set, opts.force); err != nil {
+		return 0, false, err
+	}
+
 	// Determine update type and get fleet update
 	var fleetUpdate *grpc_controlplane.FleetUpdate
 	var description string
 	var transactionType types.TransactionType
 	var version_transition_id int
 	var fromVersionTransition *int = nil
+	var err error
 
-	if req.GroupName != nil && *req.GroupName != "" {
+	if groupName != "" {
 		// This is a group update
-		description = fmt.Sprintf("Group Update for %s in VersionSet %s", *req.GroupName, req.VersionSetId)
+		description = fmt.Sprintf("Group Update for %s in VersionSet %s", groupName, uuid_version_set)
 		transactionType = types.TransactionTypeGroupUpdate
-		fleetUpdate, err = sb.db.GetFleetUpdateOptimized(ctx, req.VersionSetId, *req.GroupName)
 	} else {
 		// This is a version update
-		description = fmt.Sprintf("Version Update to %s", req.VersionSetId)
+		description = fmt.Sprintf("Version Update to %s", uuid_version_set)
 		transactionType = types.TransactionTypeVersionUpdate
-		fleetUpdate, err = sb.db.GetFleetUpdateOptimized(ctx, req.VersionSetId, "")
 	}
+	// offline nodes are part of the update, they get it queued
+	fleetUpdate, err = sb.fullFleetUpdate(ctx, uuid_version_set, groupName)
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get fleet update")
-		return nil, status.Error(codes.Internal, "Failed to get fleet update")
+		return 0, false, status.Error(codes.Internal, "Failed to get fleet update")
 	}
 
 	if fleetUpdate == nil || len(fleetUpdate.NodeUpdateItems) == 0 {
-		return nil, status.Error(codes.NotFound, "No nodes found for update")
+		return 0, false, status.Error(codes.NotFound, "No nodes found for update")
+	}
+	fleetUpdate.NodeUpdateItems, err = sb.filterByAgentVersion(ctx, fleetUpdate.NodeUpdateItems, opts.minAgentVersion)
+	if err != nil {
+		return 0, false, err
+	}
+
+	serials := make([]string, 0, len(fleetUpdate.NodeUpdateItems))
+	for _, item := range fleetUpdate.NodeUpdateItems {
+		serials = append(serials, item.SerialNumber)
+	}
+	if err := sb.checkMaintenanceWindows(ctx, uuid_version_set, serials, opts.ignoreWindow); err != nil {
+		return 0, false, err
+	}
+	if err := sb.prepareActivation(ctx, uuid_version_set); err != nil {
+		return 0, false, err
+	}
+
+	// offline nodes would fail the whole update, they get it on their next hello
+	var offline []*grpc_controlplane.NodeUpdateItem
+	fleetUpdate.NodeUpdateItems, offline, err = sb.splitOffline(ctx, uuid_version_set, fleetUpdate.NodeUpdateItems)
+	if err != nil {
+		return 0, false, err
 	}
 
 	// Create transaction
 	tx, err := sb.db.CreateTransaction(ctx, description, transactionType)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create transaction")
-		return nil, status.Error(codes.Internal, "Failed to create transaction")
+		return 0, false, status.Error(codes.Internal, "Failed to create transaction")
 	}
 
 	// If this is a version update, create a version transition
 	if transactionType == types.TransactionTypeVersionUpdate {
-		transition := &types.VersionTransition{
-			ToVersionSetID: uuid_version_set,
-			Status:         "pending",
-			CreatedBy:      "system",
-			TransactionID:  int(tx),
-			StartedAt:      time.Now(),
+		version_transition_id, fromVersionTransition, err = sb.beginVersionTransition(ctx, tx, uuid_version_set, opts.minAgentVersion)
+		if err != nil {
+			return 0, false, status.Error(codes.Internal, "Failed to create version transition")
+		}
+	}
+
+	var transitionID *int
+	if transactionType == types.TransactionTypeVersionUpdate {
+		transitionID = &version_transition_id
+	}
+
+	// with no node online there is nothing to push, the queued updates are
+	// the whole activation
+	if len(fleetUpdate.NodeUpdateItems) == 0 {
+		return sb.queueFleet(ctx, tx, offline, types.QueuedActivation{
+			VersionSetID:          uuid_version_set,
+			TransitionID:          transitionID,
+			FromVersionTransition: fromVersionTransition,
+		})
+	}
+
+	// the queued updates are delivered once the online nodes applied theirs
+	for _, item := range offline {
+		if _, err := sb.enqueueNodeUpdate(ctx, item, uuid_version_set, &tx); err != nil {
+			log.Error().Err(err).Msgf("Failed to queue update of offline node %s", item.SerialNumber)
+		}
+	}
+
+	go sb.runActivation(tx, fleetUpdate, uuid_version_set, transitionID, fromVersionTransition)
+
+	return tx, false, nil
+}
+
+// queueFleet queues the updates of a fleet update none of whose nodes is
+// online under its transaction tx, so each node gets its update on its next
+// hello. tx and its version transition stay open until the queued updates
+// finished, see finishQueuedActivation.
+func (sb *SouthboundService) queueFleet(ctx context.Context, tx int, offline []*grpc_controlplane.NodeUpdateItem, activation types.QueuedActivation) (int, bool, error) {
+	// the metadata releases the queued updates, it is written before them
+	activation.Queued = len(offline)
+	metadata, err := json.Marshal(activation)
+	if err != nil {
+		return 0, false, status.Error(codes.Internal, "Failed to encode queued activation")
+	}
+	if err := sb.db.SetTransactionMetadata(ctx, tx, metadata); err != nil {
+		sb.failQueuedActivation(ctx, tx, activation, "Failed to record the queued activation")
+		return 0, false, status.Error(codes.Internal, "Failed to update transaction")
+	}
+
+	queued := 0
+	for _, item := range offline {
+		if _, err := sb.enqueueNodeUpdate(ctx, item, activation.VersionSetID, &tx); err != nil {
+			log.Error().Err(err).Msgf("Failed to queue update of offline node %s", item.SerialNumber)
+			continue
 		}
-		var last_version_transition_id int
-		err := sb.db.ExecuteInTransaction(ctx, func(tx pgx.Tx) error {
-			query := `
-			SELECT id FROM version_transitions
-			WHERE status = 'active' and disabled_at is NULL
-			LIMIT 1
-			`
-			rows, err := tx.Query(ctx, query)
+		queued++
+	}
+	if queued == 0 {
+		sb.failQueuedActivation(ctx, tx, activation, "Failed to queue the updates")
+		return 0, false, status.Errorf(codes.Internal, "failed to queue the updates of the %d offline nodes", len(offline))
+	}
+
+	log.Info().Msgf("None of the nodes of transaction %d is online, updates of %d of %d nodes queued", tx, queued, len(offline))
+	return tx, true, nil
+}
+
+// failQueuedActivation fails the transaction of a queued activation whose
+// updates could not be queued, and its version transition. Nothing was
+// pushed, so there is nothing to roll back.
+func (sb *SouthboundService) failQueuedActivation(ctx context.Context, tx int, activation types.QueuedActivation, description string) {
+	if err := sb.completeTransaction(ctx, tx, types.TransactionStateError, description); err != nil {
+		log.Error().Err(err).Msg("Failed to update transaction")
+	}
+	if activation.TransitionID != nil {
+		if err := sb.db.UpdateVersionTransitionStatus(ctx, *activation.TransitionID, string(types.VersionTransitionFailed), nil); err != nil {
+			log.Error().Err(err).Msg("Failed to update version transition status")
+		}
+	}
+	sb.supersedeQueuedUpdates(ctx, tx, description)
+}
+
+// fullFleetUpdate returns the update of every node of a version set, or of a
+// group of it, whether the node is online or not
+func (sb *SouthboundService) fullFleetUpdate(ctx context.Context, versionSetID uuid.UUID, groupName string) (*grpc_controlplane.FleetUpdate, error) {
+	if groupName != "" {
+		return sb.db.GetGroupFleetUpdate(ctx, groupName, versionSetID.String())
+	}
+	return sb.db.GetVersionFleetUpdate(ctx, versionSetID.String())
+}
+
+// runActivation executes the fleet update of ActivateFleet and finishes its
+// transaction and version transition
+func (sb *SouthboundService) runActivation(tx int, fleetUpdate *grpc_controlplane.FleetUpdate, versionSetID uuid.UUID, transitionID *int, fromVersionTransition *int) {
+	ctx, cancel := context.WithTimeout(context.Background(), activationTimeout)
+	defer cancel()
+
+	result, err := sb.runFleetUpdate(ctx, fleetUpdate, tx, versionSetID, nil)
+	if err != nil {
+		log.Error().Err(err).Msgf("Fleet update of transaction %d failed", tx)
+	}
+
+	// bookkeeping must succeed even if the update timed out
+	dbCtx := context.WithoutCancel(ctx)
+
+	if updateErr := sb.completeTransaction(dbCtx, tx, result.state, result.description); updateErr != nil {
+		log.Error().Err(updateErr).Msg("Failed to update transaction")
+	}
+
+	applied := err == nil && result.state == types.TransactionStateApplied
+	if transitionID != nil {
+		sb.finishVersionTransition(dbCtx, tx, *transitionID, fromVersionTransition, versionSetID, applied)
+	} else if !applied {
+		sb.supersedeQueuedUpdates(dbCtx, tx, fmt.Sprintf("Transaction %d failed", tx))
+	}
+}
+
+// activateResponse returns the transaction id of an activation in the metadata
+func activateResponse(tx int) (*grpc_southbound.ActivateResponse, error) {
+	metadata, err := structpb.NewStruct(map[string]any{"tx_id": tx})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to build response")
+	}
+	return &grpc_southbound.ActivateResponse{
+		Retcode:  0,
+		Metadata: metadata,
+	}, nil
+}
+
+// transitionMetadata is stored in the metadata of the version transition of
+// an activation or rollout
+type transitionMetadata struct {
+	// MinAgentVersion is the filter of the activation, the reconciler leaves
+	// out the nodes it excluded
+	MinAgentVersion string `json:"min_agent_version,omitempty"`
+}
+
+// beginVersionTransition records a pending version transition to versionSetID
+// for transaction tx, restricted to nodes running at least minAgentVersion if
+// it is set. It returns the id of the new transition and of the currently
+// active one, which is nil on a fresh installation.
+func (sb *SouthboundService) beginVersionTransition(ctx context.Context, tx int, versionSetID uuid.UUID, minAgentVersion string) (int, *int, error) {
+	var fromVersionTransition *int
+	transition := &types.VersionTransition{
+		ToVersionSetID: versionSetID,
+		Status:         "pending",
+		CreatedBy:      actingUser(ctx),
+		TransactionID:  tx,
+		StartedAt:      time.Now(),
+	}
+	if minAgentVersion != "" {
+		metadata, err := json.Marshal(transitionMetadata{MinAgentVersion: minAgentVersion})
+		if err != nil {
+			return 0, nil, err
+		}
+		transition.Metadata = metadata
+	}
+	var last_version_transition_id int
+	err := sb.db.ExecuteInTransaction(ctx, func(tx db.Tx) error {
+		query := `
+		SELECT id FROM version_transitions
+		WHERE status = 'active' and disabled_at is NULL
+		LIMIT 1
+		`
+		rows, err := tx.Query(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			err = rows.Scan(&last_version_transition_id)
 			if err != nil {
 				return err
 			}
-			defer rows.Close()
-			for rows.Next() {
-				err = rows.Scan(&last_version_transition_id)
-				if err != nil {
-					return err
-				}
-			}
-			return nil
-		})
+		}
+		return nil
+	})
 
-		if err != nil {
-			log.Warn().Err(err).Msg("Failed to get last version transition id")
-			fromVersionTransition = nil
-		} else if last_version_transition_id != 0 {
-			fromVersionTransition = &last_version_transition_id
-		} else {
-			fromVersionTransition = nil
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get last version transition id")
+	} else if last_version_transition_id != 0 {
+		fromVersionTransition = &last_version_transition_id
+	}
+	transition.FromVersionTransition = fromVersionTransition
+
+	version_transition_id, err := sb.db.CreateVersionTransition(ctx, transition)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create version transition")
+		return 0, nil, err
+	}
+	return version_transition_id, fromVersionTransition, nil
+}
+
+// finishVersionTransition marks the version transition of transaction tx
+// active, superseding fromVersionTransition, or failed. The version set of an
+// active transition becomes the active version set. A failed transition is
+// rolled back to the version set of fromVersionTransition and the updates it
+// queued for offline nodes are superseded.
+func (sb *SouthboundService) finishVersionTransition(ctx context.Context, tx int, version_transition_id int, fromVersionTransition *int, versionSetID uuid.UUID, applied bool) {
+	status := "failed"
+	if applied {
+		status = "active"
+
+		if _, err := sb.transitionVersionSet(ctx, versionSetID, types.VERSION_STATE_ACTIVE); err != nil {
+			log.Error().Err(err).Msgf("Failed to mark version set %s active", versionSetID)
 		}
-		transition.FromVersionTransition = fromVersionTransition
 
-		version_transition_id, err = sb.db.CreateVersionTransition(ctx, transition)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to create version transition")
-			return nil, status.Error(codes.Internal, "Failed to create version transition")
+		// revoke old version transition
+		disabled_at := time.Now()
+		if fromVersionTransition != nil {
+			if err := sb.db.UpdateVersionTransitionStatus(ctx, *fromVersionTransition, "disabled", &disabled_at); err != nil {
+				log.Error().Err(err).Msg("Failed to revoke old version transition")
+			}
 		}
+	}
+	if err := sb.db.UpdateVersionTransitionStatus(ctx, version_transition_id, status, nil); err != nil {
+		log.Error().Err(err).Msg("Failed to update version transition status")
+	}
 
+	if !applied {
+		sb.supersedeQueuedUpdates(ctx, tx, fmt.Sprintf("Version transition %d failed", version_transition_id))
 	}
 
+	// the nodes were told to roll back, bring the database in line with them
+	if !applied && fromVersionTransition != nil {
+		go sb.rollbackVersionTransition(version_transition_id, *fromVersionTransition)
+	}
+}
+
+// fleetResult is the outcome of runFleetUpdate
+type fleetResult struct {
+	state       types.TransactionState
+	description string
+	// serial numbers of the nodes which reported an error
+	failed []string
+}
+
+// runFleetUpdate streams fleetUpdate through the control plane and logs every
+// node response against transaction tx, with the given metadata. It returns
+// once the control plane closes the stream or ctx expires.
+func (sb *SouthboundService) runFleetUpdate(ctx context.Context, fleetUpdate *grpc_controlplane.FleetUpdate, tx int, versionSetID uuid.UUID, metadata []byte) (fleetResult, error) {
 	// Get client with error handling
 	client, conn, err := getControlPlaneClient(sb.addr)
 	if err != nil {
-		if logErr := sb.logTransactionFailure(ctx, tx, "fleet", uuid_version_set, "Failed to connect to control plane"); logErr != nil {
+		if logErr := sb.logTransactionFailure(ctx, tx, "fleet", versionSetID, "Failed to connect to control plane"); logErr != nil {
 			log.Error().Err(logErr).Msg("Failed to log transaction failure")
 		}
-		return nil, err
+		return fleetResult{state: types.TransactionStateError, description: "Failed to connect to control plane"}, err
 	}
 	defer conn.Close()
 
@@ -149,163 +402,106 @@ func (sb *SouthboundService) ActivateFleet(ctx context.Context, req *grpc_southb
 	// Start update stream
 	stream, err := client.UpdateFleet(ctx, fleetUpdate)
 	if err != nil {
-		if logErr := sb.logTransactionFailure(ctx, tx, "fleet", uuid_version_set, fmt.Sprintf("Failed to start fleet update: %v", err)); logErr != nil {
+		if logErr := sb.logTransactionFailure(ctx, tx, "fleet", versionSetID, fmt.Sprintf("Failed to start fleet update: %v", err)); logErr != nil {
 			log.Error().Err(logErr).Msg("Failed to log transaction failure")
 		}
-		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to start fleet update: %v", err))
-	}
-
-	var retcode int32
-	var lastError error
-	var finalState types.TransactionState
-	var finalDescription string
-
-	// Create done channel for graceful shutdown
-	done := make(chan struct{})
-	defer close(done)
-
-	// Start goroutine to handle stream receiving
-	go func() {
-
-		for {
-			select {
-			case <-ctx.Done():
-				// Context was cancelled, set error state and return
-				finalState = types.TransactionStateError
-				finalDescription = fmt.Sprintf("Operation cancelled: %v", ctx.Err())
-				return
-			case <-done:
-				return
-			default:
-				resp, err := stream.Recv()
-				if err != nil {
-					if err == io.EOF {
-						// Connection closed by peer, mark as success if no errors occurred
-						if finalState == "" {
-							finalState = types.TransactionStateApplied
-							finalDescription = "Update completed successfully"
-						}
-						done <- struct{}{}
-						return
-					}
-					lastError = err
-					log.Error().Err(err).Msg("Failed to receive fleet update response")
-					finalState = types.TransactionStateError
-					finalDescription = fmt.Sprintf("Stream error: %v", err)
-					return
-				}
-
-				// Map update state to transaction state
-				var state types.TransactionState
-				switch resp.UpdateState {
-				case grpc_controlplane.UpdateState_UPDATE_APPLIED:
-					state = types.TransactionStateApplied
-				case grpc_controlplane.UpdateState_UPDATE_ERROR:
-					state = types.TransactionStateError
-				case grpc_controlplane.UpdateState_UPDATE_APPLY_REQ:
-					state = types.TransactionStateApplicable
-				case grpc_controlplane.UpdateState_UPDATE_APPLICABLE:
-					state = types.TransactionStateApplicable
-				case grpc_controlplane.UpdateState_UPDATE_PUBLISHED:
-					state = types.TransactionStatePublished
-				}
-
-				// Log node transaction
-				_, err = sb.db.LogNodeTransaction(ctx, &types.NodeTransactionLog{
-					TransactionID: int(tx),
-					NodeSerial:    resp.SerialNumber,
-					VersionSetID:  uuid_version_set,
-					State:         state,
-					Timestamp:     resp.Timestamp.AsTime(),
-				})
-				if err != nil {
-					log.Error().Err(err).Msg("Failed to log node transaction")
-				}
-
-				// Handle error state
-				if state == types.TransactionStateError {
-					finalState = types.TransactionStateError
-					finalDescription = fmt.Sprintf("Node %s reported error: %s", resp.SerialNumber, *resp.Meta)
-					return
-				}
-			}
-		}
-	}()
-
-	// Wait for completion or timeout
-	select {
-	case <-ctx.Done():
-		if ctx.Err() == context.DeadlineExceeded {
-			if logErr := sb.logTransactionFailure(ctx, tx, "fleet", uuid_version_set, "Operation timed out"); logErr != nil {
-				log.Error().Err(logErr).Msg("Failed to log timeout")
-			}
-			// get update type fleet or group
-			if transactionType == types.TransactionTypeVersionUpdate {
-				err = sb.db.UpdateVersionTransitionStatus(ctx, version_transition_id, "failed", nil)
-				if err != nil {
-					log.Error().Err(err).Msg("Failed to update version transition status")
-				}
-			}
+		return fleetResult{state: types.TransactionStateError, description: fmt.Sprintf("Failed to start fleet update: %v", err)},
+			status.Error(codes.Internal, fmt.Sprintf("Failed to start fleet update: %v", err))
+	}
 
-			return nil, status.Error(codes.DeadlineExceeded, "Operation timed out")
+	// after a node reported an error the stream is drained, so that the control
+	// plane has finished its rollback when this function returns
+	result := fleetResult{}
+	for {
+		resp, err := stream.Recv()
+		if len(result.failed) > 0 && (err != nil || ctx.Err() != nil) {
+			result.state = types.TransactionStateError
+			return result, nil
 		}
-		return nil, ctx.Err()
-	case <-done:
-		if lastError != nil {
-			return nil, status.Error(codes.Internal, fmt.Sprintf("Operation failed: %v", lastError))
+		if err == io.EOF {
+			// Connection closed by peer, mark as success as no errors occurred
+			return fleetResult{state: types.TransactionStateApplied, description: "Update completed successfully"}, nil
 		}
-
-		// Update transaction with final state
-		completed_at := time.Now()
-		err = sb.db.UpdateTransaction(ctx, int(tx), &completed_at, &finalState, &finalDescription)
 		if err != nil {
-			log.Error().Err(err).Msg("Failed to update transaction")
-		}
-
-		// Update version transition if needed
-		if transactionType == types.TransactionTypeVersionUpdate {
-			status := "failed"
-			if finalState == types.TransactionStateApplied {
-				status = "active"
-
-				// revoke old version transition
-				disabled_at := time.Now()
-				if fromVersionTransition != nil {
-					err = sb.db.UpdateVersionTransitionStatus(ctx, *fromVersionTransition, "disabled", &disabled_at)
-					if err != nil {
-						log.Error().Err(err).Msg("Failed to revoke old version transition")
-					}
+			if ctx.Err() == context.DeadlineExceeded {
+				if logErr := sb.logTransactionFailure(context.WithoutCancel(ctx), tx, "fleet", versionSetID, "Operation timed out"); logErr != nil {
+					log.Error().Err(logErr).Msg("Failed to log timeout")
 				}
+				return fleetResult{state: types.TransactionStateError, description: "Operation timed out"}, status.Error(codes.DeadlineExceeded, "Operation timed out")
+			}
+			if ctx.Err() != nil {
+				return fleetResult{state: types.TransactionStateError, description: fmt.Sprintf("Operation cancelled: %v", ctx.Err())}, ctx.Err()
 			}
-			err = sb.db.UpdateVersionTransitionStatus(ctx, version_transition_id, status, nil)
+			log.Error().Err(err).Msg("Failed to receive fleet update response")
+			return fleetResult{state: types.TransactionStateError, description: fmt.Sprintf("Stream error: %v", err)},
+				status.Error(codes.Internal, fmt.Sprintf("Operation failed: %v", err))
+		}
+
+		state := transactionStateFromUpdate(resp.UpdateState)
+
+		// global state changes carry no serial number
+		if resp.SerialNumber != "" {
+			err = sb.logNode(ctx, &types.NodeTransactionLog{
+				TransactionID: tx,
+				NodeSerial:    resp.SerialNumber,
+				VersionSetID:  versionSetID,
+				State:         state,
+				Timestamp:     resp.Timestamp.AsTime(),
+				Metadata:      metadata,
+			})
 			if err != nil {
-				log.Error().Err(err).Msg("Failed to update version transition status")
+				log.Error().Err(err).Msg("Failed to log node transaction")
 			}
 		}
 
-		// Set return code based on final state
-		if finalState == types.TransactionStateError {
-			retcode = -1
-		} else {
-			retcode = 0
+		// Handle error state
+		if state == types.TransactionStateError && resp.SerialNumber != "" {
+			if len(result.failed) == 0 {
+				result.description = fmt.Sprintf("Node %s reported error: %s", resp.SerialNumber, resp.GetMeta())
+			}
+			result.failed = append(result.failed, resp.SerialNumber)
 		}
+	}
+}
 
-		return &grpc_southbound.ActivateResponse{
-			Retcode: retcode,
-		}, nil
+// transactionStateFromUpdate maps the update state reported by the control
+// plane to the state stored in the transaction log
+func transactionStateFromUpdate(updateState grpc_controlplane.UpdateState) types.TransactionState {
+	switch updateState {
+	case grpc_controlplane.UpdateState_UPDATE_APPLIED:
+		return types.TransactionStateApplied
+	case grpc_controlplane.UpdateState_UPDATE_ERROR:
+		return types.TransactionStateError
+	case grpc_controlplane.UpdateState_UPDATE_APPLY_REQ:
+		return types.TransactionStateApplicable
+	case grpc_controlplane.UpdateState_UPDATE_APPLICABLE:
+		return types.TransactionStateApplicable
+	case grpc_controlplane.UpdateState_UPDATE_PUBLISHED:
+		return types.TransactionStatePublished
 	}
+	return ""
 }
 
+// ActivateNode pushes a version set to a single node. Like ActivateFleet it
+// returns the transaction id right away and runs the update in the background.
+// The update of an offline node is queued until its next hello.
 func (sb *SouthboundService) ActivateNode(ctx context.Context, req *grpc_southbound.ActivateNodeRequest) (*grpc_southbound.ActivateResponse, error) {
-	// Add timeout to context
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute) // Longer timeout for node updates
-	defer cancel()
-
 	// Check arguments
 	if req.SerialNumber == "" || req.VersionSetId == "" {
 		return nil, status.Error(codes.InvalidArgument, "SerialNumber and VersionSetId are required")
 	}
 
+	// Parse UUID once
+	uuid_version_set, err := uuid.FromString(req.VersionSetId)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to parse version set id")
+		return nil, status.Error(codes.InvalidArgument, "Invalid VersionSetId format")
+	}
+
+	if err := sb.checkActivation(ctx, uuid_version_set, metadataFlag(ctx, types.ForceActivationKey)); err != nil {
+		return nil, err
+	}
+
 	// Get node update from database
 	nodeUpdate, err := sb.db.NodeUpdate(req.SerialNumber, req.VersionSetId, ctx)
 	if err != nil {
@@ -315,8 +511,29 @@ func (sb *SouthboundService) ActivateNode(ctx context.Context, req *grpc_southbo
 	if nodeUpdate == nil {
 		return nil, status.Error(codes.NotFound, "Node not found or no updates available")
 	}
+	if _, err := sb.filterByAgentVersion(ctx, []*grpc_controlplane.NodeUpdateItem{nodeUpdate}, metadataValue(ctx, types.MinAgentVersionKey)); err != nil {
+		return nil, err
+	}
+	err = sb.checkMaintenanceWindows(ctx, uuid_version_set, []string{req.SerialNumber}, metadataFlag(ctx, types.IgnoreWindowKey))
+	if err != nil {
+		return nil, err
+	}
+	if err := sb.prepareActivation(ctx, uuid_version_set); err != nil {
+		return nil, err
+	}
+
+	_, offline, err := sb.splitOffline(ctx, uuid_version_set, []*grpc_controlplane.NodeUpdateItem{nodeUpdate})
+	if err != nil {
+		return nil, err
+	}
+	if len(offline) > 0 {
+		tx, err := sb.enqueueNodeUpdate(ctx, nodeUpdate, uuid_version_set, nil)
+		if err != nil {
+			return nil, err
+		}
+		return queuedResponse(tx)
+	}
 
-	// Create transaction first to ensure we have a valid transaction ID
 	description := fmt.Sprintf("Activate Node %s", req.SerialNumber)
 	tx, err := sb.db.CreateTransaction(ctx, description, types.TransactionTypeNodeUpdate)
 	if err != nil {
@@ -324,146 +541,103 @@ func (sb *SouthboundService) ActivateNode(ctx context.Context, req *grpc_southbo
 		return nil, status.Error(codes.Internal, "Failed to create transaction")
 	}
 
-	// Parse UUID once
-	uuid_version_set, err := uuid.FromString(req.VersionSetId)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to parse version set id")
-		return nil, status.Error(codes.Internal, "Failed to parse version set id")
+	go sb.runNodeActivation(tx, nodeUpdate, uuid_version_set)
+
+	return activateResponse(tx)
+}
+
+// runNodeActivation streams a node update through the control plane and
+// completes transaction tx with the final state of the node, which it returns
+func (sb *SouthboundService) runNodeActivation(tx int, nodeUpdate *grpc_controlplane.NodeUpdateItem, versionSetID uuid.UUID) types.TransactionState {
+	ctx, cancel := context.WithTimeout(context.Background(), activationTimeout)
+	defer cancel()
+
+	// bookkeeping must succeed even if the update timed out
+	dbCtx := context.WithoutCancel(ctx)
+	serialNumber := nodeUpdate.SerialNumber
+
+	fail := func(description string) {
+		if err := sb.logTransactionFailure(dbCtx, tx, serialNumber, versionSetID, description); err != nil {
+			log.Error().Err(err).Msg("Failed to log transaction failure")
+		}
+		if err := sb.completeTransaction(dbCtx, tx, types.TransactionStateError, description); err != nil {
+			log.Error().Err(err).Msg("Failed to update transaction")
+		}
 	}
 
-	// Get client with error handling
 	client, conn, err := getControlPlaneClient(sb.addr)
 	if err != nil {
-		// Log transaction failure before returning
-		if logErr := sb.logTransactionFailure(ctx, tx, req.SerialNumber, uuid_version_set, "Failed to connect to control plane"); logErr != nil {
-			log.Error().Err(logErr).Msg("Failed to log transaction failure")
-		}
-		return nil, err
+		fail("Failed to connect to control plane")
+		return types.TransactionStateError
 	}
 	defer conn.Close()
 
-	// Create the node update request with proper transaction ID type
-	update := &grpc_controlplane.NodeUpdate{
+	stream, err := client.UpdateNode(ctx, &grpc_controlplane.NodeUpdate{
 		NodeUpdateItem: nodeUpdate,
 		Transaction: &grpc_controlplane.Transaction{
 			TxId: int32(tx),
 		},
+	})
+	if err != nil {
+		fail(fmt.Sprintf("Failed to start update: %v", err))
+		return types.TransactionStateError
 	}
 
-	// Start update stream with timeout context
-	stream, err := client.UpdateNode(ctx, update)
-	if err != nil {
-		if logErr := sb.logTransactionFailure(ctx, tx, req.SerialNumber, uuid_version_set, fmt.Sprintf("Failed to start update: %v", err)); logErr != nil {
-			log.Error().Err(logErr).Msg("Failed to log transaction failure")
+	for {
+		stream_resp, err := stream.Recv()
+		if err == io.EOF {
+			fail("Update stream closed before the node finished")
+			return types.TransactionStateError
+		}
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				fail("Operation timed out")
+				return types.TransactionStateError
+			}
+			log.Err(err).Msg("Failed to receive response")
+			fail(fmt.Sprintf("Stream error: %v", err))
+			return types.TransactionStateError
 		}
-		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to start node update: %v", err))
-	}
-
-	var retcode int32
-	var lastError error
-
-	// Create done channel for graceful shutdown
-	done := make(chan error)
-	defer close(done)
-
-	// Start goroutine to handle stream receiving
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-done:
-				return
-			default:
-				stream_resp, err := stream.Recv()
-				if err != nil {
-					if err == io.EOF {
-						return
-					}
-					lastError = err
-					log.Err(err).Msg("Failed to receive response")
-					return
-				}
-
-				// Log transaction
-				var state types.TransactionState
-				if stream_resp.UpdateState == grpc_controlplane.UpdateState_UPDATE_APPLIED {
-					state = types.TransactionStateApplied
-				} else if stream_resp.UpdateState == grpc_controlplane.UpdateState_UPDATE_ERROR {
-					state = types.TransactionStateError
-				} else if stream_resp.UpdateState == grpc_controlplane.UpdateState_UPDATE_APPLY_REQ {
-					state = types.TransactionStateApplicable
-				} else if stream_resp.UpdateState == grpc_controlplane.UpdateState_UPDATE_APPLICABLE {
-					state = types.TransactionStateApplicable
-				} else if stream_resp.UpdateState == grpc_controlplane.UpdateState_UPDATE_PUBLISHED {
-					state = types.TransactionStatePublished
-				}
 
-				_, err = sb.db.LogNodeTransaction(ctx, &types.NodeTransactionLog{
-					TransactionID: int(tx),
-					NodeSerial:    req.SerialNumber,
-					VersionSetID:  uuid_version_set,
-					State:         state,
-					Timestamp:     stream_resp.Timestamp.AsTime(),
-				})
-				if err != nil {
-					lastError = err
-					log.Error().Err(err).Msg("Failed to log transaction")
-					return
-				}
+		err = sb.logNode(dbCtx, &types.NodeTransactionLog{
+			TransactionID: tx,
+			NodeSerial:    serialNumber,
+			VersionSetID:  versionSetID,
+			State:         transactionStateFromUpdate(stream_resp.UpdateState),
+			Timestamp:     stream_resp.Timestamp.AsTime(),
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to log transaction")
+		}
 
-				// Handle terminal states
-				if stream_resp.UpdateState == grpc_controlplane.UpdateState_UPDATE_ERROR || stream_resp.UpdateState == grpc_controlplane.UpdateState_UPDATE_APPLIED {
-					if stream_resp.UpdateState == grpc_controlplane.UpdateState_UPDATE_ERROR {
-						retcode = -1
-					} else if stream_resp.UpdateState == grpc_controlplane.UpdateState_UPDATE_APPLIED {
-						retcode = 0
-					}
-
-					// Update transaction completion
-					where := fmt.Sprintf("id = %d", tx)
-					err = sb.db.UpdateWhere(ctx, "transactions", map[string]any{
-						"completed_at": time.Now(),
-					}, where)
-
-					if err != nil {
-						lastError = err
-						log.Error().Err(err).Msg("Failed to set transaction completed")
-					}
-					done <- nil
-					return
-				}
+		// Handle terminal states
+		switch stream_resp.UpdateState {
+		case grpc_controlplane.UpdateState_UPDATE_APPLIED:
+			if err := sb.completeTransaction(dbCtx, tx, types.TransactionStateApplied, "Update completed successfully"); err != nil {
+				log.Error().Err(err).Msg("Failed to set transaction completed")
 			}
-		}
-	}()
-
-	// Wait for completion or timeout
-	select {
-	case <-ctx.Done():
-		if ctx.Err() == context.DeadlineExceeded {
-			if logErr := sb.logTransactionFailure(ctx, tx, req.SerialNumber, uuid_version_set, "Operation timed out"); logErr != nil {
-				log.Error().Err(logErr).Msg("Failed to log timeout")
+			return types.TransactionStateApplied
+		case grpc_controlplane.UpdateState_UPDATE_ERROR:
+			if err := sb.completeTransaction(dbCtx, tx, types.TransactionStateError, fmt.Sprintf("Node %s reported error", serialNumber)); err != nil {
+				log.Error().Err(err).Msg("Failed to set transaction completed")
 			}
-			return nil, status.Error(codes.DeadlineExceeded, "Operation timed out")
-		}
-		return nil, ctx.Err()
-	case <-done:
-		if lastError != nil {
-			return nil, status.Error(codes.Internal, fmt.Sprintf("Operation failed: %v", lastError))
+			return types.TransactionStateError
 		}
-		return &grpc_southbound.ActivateResponse{
-			Retcode: retcode,
-		}, nil
 	}
 }
 
 // Helper function to log transaction failures
 func (sb *SouthboundService) logTransactionFailure(ctx context.Context, tx int, serialNumber string, versionSetID uuid.UUID, errorMsg string) error {
-	_, err := sb.db.LogNodeTransaction(ctx, &types.NodeTransactionLog{
+	metadata, err := json.Marshal(types.NodeLogMetadata{Reason: errorMsg})
+	if err != nil {
+		return err
+	}
+	return sb.logNode(ctx, &types.NodeTransactionLog{
 		TransactionID: tx,
 		NodeSerial:    serialNumber,
 		VersionSetID:  versionSetID,
-		State:         types.TransactionState(grpc_controlplane.UpdateState_UPDATE_ERROR),
+		State:         types.TransactionStateError,
+		Timestamp:     time.Now(),
+		Metadata:      metadata,
 	})
-	return err
 }
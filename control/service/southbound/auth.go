@@ -0,0 +1,205 @@
+package southbound
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net"
+	"slices"
+	"strings"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	grpc_est "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/est"
+	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// systemUser is recorded for changes the controller makes by itself, like
+// rollbacks of failed updates
+const systemUser = "system"
+
+// operatorServices are the services of the operators, called with the token
+// of one of the users
+var operatorServices = []string{
+	grpc_southbound.Southbound_ServiceDesc.ServiceName,
+	grpc_scale.Scale_ServiceDesc.ServiceName,
+}
+
+// controllerServices are called by the controller itself, with controllerToken.
+// They update nodes without the checks of the operator services.
+var controllerServices = []string{
+	grpc_controlplane.ControlPlane_ServiceDesc.ServiceName,
+	grpc_scale.ControlPlaneRecovery_ServiceDesc.ServiceName,
+	grpc_scale.ControlPlaneInventory_ServiceDesc.ServiceName,
+}
+
+// publicServices are not authenticated. The EST server reports the
+// certificates it issued with it, its client has no credentials.
+var publicServices = []string{
+	grpc_est.EstService_ServiceDesc.ServiceName,
+}
+
+// controllerToken authenticates the calls of the controller to the
+// controllerServices. It is created per process and never leaves it.
+var controllerToken = rand.Text()
+
+// controllerCredentials attach controllerToken to the calls of a connection
+type controllerCredentials struct{}
+
+func (controllerCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{types.AuthorizationKey: "Bearer " + controllerToken}, nil
+}
+
+// RequireTransportSecurity is false, the controller dials its own listener
+func (controllerCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// dialController connects to the controllerServices at addr
+func dialController(addr string) (*grpc.ClientConn, error) {
+	return grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(controllerCredentials{}))
+}
+
+type userKey struct{}
+
+// SetUsers configures the users which authenticate with a token
+func (sb *SouthboundService) SetUsers(users []types.CliUser) {
+	sb.users = users
+}
+
+// UnaryInterceptor rejects calls by callers which may not make them, see
+// authorize. The user of the call is passed on in its context, see
+// authenticatedUser.
+func (sb *SouthboundService) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := sb.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor is UnaryInterceptor for streaming calls
+func (sb *SouthboundService) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := sb.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &userStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// userStream carries the authenticated user in the context of a stream
+type userStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *userStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorize returns the context of a call to method with the user who made
+// it. Methods are named /<service>/<method>. Calls to the operatorServices
+// need a user, see authenticate, and calls to the controllerServices
+// controllerToken. Both only come over loopback connections, the server has
+// no TLS and the tokens travel in plaintext. Calls to services on none of the
+// lists are rejected.
+func (sb *SouthboundService) authorize(ctx context.Context, method string) (context.Context, error) {
+	service, _, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	switch {
+	case slices.Contains(publicServices, service):
+		return ctx, nil
+	case !loopbackPeer(ctx):
+		return nil, status.Error(codes.PermissionDenied, "tokens are only accepted over loopback connections")
+	case slices.Contains(controllerServices, service):
+		if !isController(ctx) {
+			return nil, status.Errorf(codes.PermissionDenied, "%s is internal to the controller", service)
+		}
+		return withUser(ctx, systemUser), nil
+	case slices.Contains(operatorServices, service):
+		user, err := sb.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return withUser(ctx, user), nil
+	}
+	return nil, status.Errorf(codes.PermissionDenied, "unknown service %s", service)
+}
+
+// loopbackPeer reports whether a call comes from the host itself
+func loopbackPeer(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return false
+	}
+	switch addr := p.Addr.(type) {
+	case *net.TCPAddr:
+		return addr.IP.IsLoopback()
+	case *net.UnixAddr:
+		return true
+	}
+	return false
+}
+
+// isController reports whether a call carries controllerToken
+func isController(ctx context.Context) bool {
+	token, ok := strings.CutPrefix(metadataValue(ctx, types.AuthorizationKey), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(controllerToken)) == 1
+}
+
+func withUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// authenticatedUser returns the user a call was made by. Every call of the
+// operator services has one, the interceptors reject the others. Calls of the
+// controller are made by systemUser.
+func authenticatedUser(ctx context.Context) (string, error) {
+	user, ok := ctx.Value(userKey{}).(string)
+	if !ok || user == "" {
+		return "", status.Error(codes.Unauthenticated, "the call is not authenticated")
+	}
+	return user, nil
+}
+
+// actingUser returns the user of ctx, or systemUser for work the controller
+// started by itself
+func actingUser(ctx context.Context) string {
+	if user, err := authenticatedUser(ctx); err == nil {
+		return user
+	}
+	return systemUser
+}
+
+// authenticate returns the user whose token a call carries. Calls without a
+// known token are rejected with Unauthenticated.
+func (sb *SouthboundService) authenticate(ctx context.Context) (string, error) {
+	token, ok := strings.CutPrefix(metadataValue(ctx, types.AuthorizationKey), "Bearer ")
+	if !ok || token == "" {
+		return "", status.Error(codes.Unauthenticated, "the call carries no token")
+	}
+	sum := sha256.Sum256([]byte(token))
+	for _, user := range sb.users {
+		want, err := hex.DecodeString(user.TokenSha256)
+		if err != nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare(sum[:], want) == 1 {
+			return user.Name, nil
+		}
+	}
+	return "", status.Error(codes.Unauthenticated, "unknown token")
+}
@@ -0,0 +1,150 @@
+package southbound
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"testing"
+
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// callContext is the context of an incoming call from addr which carries
+// token as bearer token, none if it is empty
+func callContext(addr net.Addr, token string) context.Context {
+	ctx := context.Background()
+	if addr != nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	}
+	if token != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(types.AuthorizationKey, "Bearer "+token))
+	}
+	return ctx
+}
+
+func tokenUser(name, token string) types.CliUser {
+	sum := sha256.Sum256([]byte(token))
+	return types.CliUser{Name: name, TokenSha256: hex.EncodeToString(sum[:])}
+}
+
+func TestAuthorize(t *testing.T) {
+	sb := &SouthboundService{}
+	sb.SetUsers([]types.CliUser{tokenUser("alice", "alice-token"), tokenUser("bob", "bob-token"), {Name: "broken", TokenSha256: "not hex"}})
+	loopback := &net.TCPAddr{IP: net.IPv6loopback, Port: 40000}
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.7"), Port: 40000}
+
+	type testCase struct {
+		name    string
+		service string
+		addr    net.Addr
+		token   string
+		want    codes.Code
+		user    string // empty for calls without a user, like those of the publicServices
+	}
+	var tests []testCase
+	for _, service := range operatorServices {
+		tests = append(tests,
+			testCase{name: service + " alice", service: service, addr: loopback, token: "alice-token", want: codes.OK, user: "alice"},
+			testCase{name: service + " bob", service: service, addr: loopback, token: "bob-token", want: codes.OK, user: "bob"},
+			testCase{name: service + " without token", service: service, addr: loopback, want: codes.Unauthenticated},
+			testCase{name: service + " wrong token", service: service, addr: loopback, token: "mallory-token", want: codes.Unauthenticated},
+			testCase{name: service + " controller token", service: service, addr: loopback, token: controllerToken, want: codes.Unauthenticated},
+			testCase{name: service + " remote", service: service, addr: remote, token: "alice-token", want: codes.PermissionDenied},
+			testCase{name: service + " without peer", service: service, token: "alice-token", want: codes.PermissionDenied},
+		)
+	}
+	for _, service := range controllerServices {
+		tests = append(tests,
+			testCase{name: service + " controller", service: service, addr: loopback, token: controllerToken, want: codes.OK, user: systemUser},
+			testCase{name: service + " operator token", service: service, addr: loopback, token: "alice-token", want: codes.PermissionDenied},
+			testCase{name: service + " without token", service: service, addr: loopback, want: codes.PermissionDenied},
+			testCase{name: service + " remote", service: service, addr: remote, token: controllerToken, want: codes.PermissionDenied},
+		)
+	}
+	for _, service := range publicServices {
+		tests = append(tests,
+			testCase{name: service + " without token", service: service, addr: loopback, want: codes.OK},
+			testCase{name: service + " remote", service: service, addr: remote, want: codes.OK},
+		)
+	}
+	tests = append(tests,
+		testCase{name: "unknown service", service: "kritis3m.Unknown", addr: loopback, token: "alice-token", want: codes.PermissionDenied},
+		testCase{name: "unix socket", service: grpc_scale.Scale_ServiceDesc.ServiceName, addr: &net.UnixAddr{Name: "/run/scale.sock", Net: "unix"}, token: "alice-token", want: codes.OK, user: "alice"},
+		testCase{name: "loopback ipv4", service: grpc_scale.ControlPlaneRecovery_ServiceDesc.ServiceName, addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}, token: controllerToken, want: codes.OK, user: systemUser},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := sb.authorize(callContext(tt.addr, tt.token), "/"+tt.service+"/Call")
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("authorize = %v, want %v", err, tt.want)
+			}
+			if err != nil || tt.user == "" {
+				return
+			}
+			if user, err := authenticatedUser(ctx); err != nil || user != tt.user {
+				t.Errorf("authenticatedUser = %q, %v, want %q", user, err, tt.user)
+			}
+		})
+	}
+}
+
+func TestIsController(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want bool
+	}{
+		{name: "controller token", md: metadata.Pairs(types.AuthorizationKey, "Bearer "+controllerToken), want: true},
+		{name: "without metadata", want: false},
+		{name: "without token", md: metadata.Pairs("other", "value"), want: false},
+		{name: "wrong token", md: metadata.Pairs(types.AuthorizationKey, "Bearer "+controllerToken+"x"), want: false},
+		{name: "without bearer", md: metadata.Pairs(types.AuthorizationKey, controllerToken), want: false},
+		{name: "empty token", md: metadata.Pairs(types.AuthorizationKey, "Bearer "), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			if got := isController(ctx); got != tt.want {
+				t.Errorf("isController = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	sb := &SouthboundService{}
+	sb.SetUsers([]types.CliUser{tokenUser("alice", "alice-token"), {Name: "broken", TokenSha256: "not hex"}})
+
+	tests := []struct {
+		name  string
+		token string
+		want  codes.Code
+		user  string
+	}{
+		{name: "known token", token: "alice-token", want: codes.OK, user: "alice"},
+		{name: "without token", want: codes.Unauthenticated},
+		{name: "unknown token", token: "bob-token", want: codes.Unauthenticated},
+		{name: "controller token", token: controllerToken, want: codes.Unauthenticated},
+		{name: "hash instead of token", token: tokenUser("alice", "alice-token").TokenSha256, want: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := sb.authenticate(callContext(nil, tt.token))
+			if got := status.Code(err); got != tt.want || user != tt.user {
+				t.Errorf("authenticate = %q, %v, want %q, %v", user, err, tt.user, tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package southbound
 
 import (
 	"context"
-	"time"
 
 	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
 	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
@@ -66,10 +65,13 @@ func (sb *SouthboundService) TriggerFleetCertReq(ctx context.Context, req *grpc_
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to list nodes: %v", err)
 	}
+	online, err := sb.db.OnlineNodes(ctx, *versionSetID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get online nodes: %v", err)
+	}
 	queryNodes := []*types.Node{}
-	//filter nodes, where now() - last_seen > 2 minutes
 	for _, node := range nodes {
-		if time.Since(*node.LastSeen) < 2*time.Minute {
+		if online[node.SerialNumber] {
 			queryNodes = append(queryNodes, node)
 		}
 	}
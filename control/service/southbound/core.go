@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"time"
 
 	grpc_est "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/est"
@@ -12,6 +13,7 @@ import (
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/philslol/kritis3m_scalev2/control/db"
 	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
 	"github.com/rs/zerolog"
 )
 
@@ -19,15 +21,40 @@ import (
 type SouthboundService struct {
 	db   *db.StateManager
 	addr string
+	// started separates the transactions of this process from interrupted ones
+	started time.Time
+
+	// rollouts holds the rollouts running in this process by transaction id
+	rolloutsMu sync.Mutex
+	rollouts   map[int]runningRollout
+
+	// watchers holds the event channels of WatchTransaction streams by transaction id
+	watchMu  sync.Mutex
+	watchers map[int]map[chan transactionEvent]struct{}
+
+	// queueExpiry is how long an update queued for an offline node is kept
+	queueExpiry time.Duration
+	// queuedMu serialises finishQueuedActivation, deliveries of the same
+	// activation may finish at once
+	queuedMu sync.Mutex
+
+	// users authenticate with a token, see authenticate
+	users []types.CliUser
+
 	grpc_southbound.UnimplementedSouthboundServer
 	grpc_est.UnimplementedEstServiceServer
+	grpc_scale.UnimplementedScaleServer
 }
 
 // NewSouthbound creates a new instance of SouthboundService
 func NewSouthbound(db *db.StateManager, addr string) *SouthboundService {
 	return &SouthboundService{
-		db:   db,
-		addr: addr,
+		db:          db,
+		addr:        addr,
+		started:     time.Now(),
+		rollouts:    make(map[int]runningRollout),
+		watchers:    make(map[int]map[chan transactionEvent]struct{}),
+		queueExpiry: types.DefaultQueueExpiry,
 	}
 }
 
@@ -42,6 +69,8 @@ type HelloService struct {
 	db     *db.StateManager
 	addr   string
 	logger zerolog.Logger
+	// onHello is called with the serial number of every node saying hello
+	onHello func(serialNumber string)
 }
 
 func NewHelloService(db *db.StateManager, addr string, log_config types.LogConfig) *HelloService {
@@ -135,15 +164,21 @@ func (ls *LogService) LogNodeTransaction(ctx context.Context) error {
 	}
 }
 
+// OnHello sets a function called with the serial number of every node saying
+// hello, after the hello was recorded
+func (hs *HelloService) OnHello(fn func(serialNumber string)) {
+	hs.onHello = fn
+}
+
 func (hs *HelloService) Hello(ctx context.Context) error {
 	errChan := make(chan error, 1)
-	client, conn, err := getControlPlaneClient(hs.addr)
+	client, conn, err := getInventoryClient(hs.addr)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	stream, err := client.Hello(ctx, &empty.Empty{})
+	stream, err := client.WatchHello(ctx, &empty.Empty{})
 	if err != nil {
 		hs.logger.Error().Err(err).Msg("Error creating hello stream")
 		return err
@@ -167,15 +202,21 @@ func (hs *HelloService) Hello(ctx context.Context) error {
 					return
 				}
 				hs.logger.Debug().Msgf("Received hello from node: %s", response.SerialNumber)
-				// Use a parameterized query instead of string interpolation
-				where_string := fmt.Sprintf("serial_number = '%s'", response.SerialNumber)
 				db_context, db_cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				defer db_cancel()
 
 				//it is not intendet to close hello service, when db has an error
-				err = hs.db.UpdateWhere(db_context, "nodes", map[string]any{"last_seen": time.Now()}, where_string)
+				seen := time.Now()
+				inventory := inventoryFromProto(response.Inventory)
+				err = hs.db.RecordHello(db_context, response.SerialNumber, seen, inventory)
 				if err != nil {
-					hs.logger.Error().Err(err).Msg("Error updating node last seen")
+					hs.logger.Error().Err(err).Msg("Error recording node hello")
+				}
+				if err := hs.checkDrift(db_context, inventory, seen); err != nil {
+					hs.logger.Error().Err(err).Msgf("Error checking config drift of %s", response.SerialNumber)
+				}
+				db_cancel()
+				if hs.onHello != nil {
+					hs.onHello(response.SerialNumber)
 				}
 			}
 		}
@@ -0,0 +1,81 @@
+package southbound
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// checkDrift compares the config hash of a hello with the hash of the config
+// the node should run. Nodes which do not report a hash, or whose update is
+// still in progress, are not checked.
+func (hs *HelloService) checkDrift(ctx context.Context, inv *types.NodeInventory, seen time.Time) error {
+	if inv == nil || inv.ConfigHash == "" {
+		return nil
+	}
+	versionSetID, inFlight, err := hs.db.AppliedVersionSet(ctx, inv.SerialNumber)
+	if err != nil || inFlight || versionSetID == uuid.Nil {
+		return err
+	}
+	item, err := hs.db.NodeUpdate(inv.SerialNumber, versionSetID.String(), ctx)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		hs.logger.Debug().Msgf("Node %s has no config in version set %s, drift is not checked", inv.SerialNumber, versionSetID)
+		return nil
+	}
+	expected, err := types.ConfigHash(item)
+	if err != nil {
+		return err
+	}
+
+	event, err := hs.db.RecordDrift(ctx, &types.DriftEvent{
+		SerialNumber:         inv.SerialNumber,
+		VersionSetID:         versionSetID,
+		ExpectedHash:         expected,
+		ReportedHash:         inv.ConfigHash,
+		ReportedVersionSetID: inv.VersionSetID,
+		DetectedAt:           seen,
+	})
+	if err != nil {
+		return err
+	}
+	if event != nil {
+		hs.logger.Warn().Msgf("Node %s drifted from version set %s: expected config %s, node reports %s",
+			event.SerialNumber, event.VersionSetID, event.ExpectedHash, event.ReportedHash)
+	}
+	return nil
+}
+
+func (sb *SouthboundService) ListDriftEvents(ctx context.Context, req *grpc_scale.ListDriftEventsRequest) (*grpc_scale.ListDriftEventsResponse, error) {
+	events, err := sb.db.ListDriftEvents(ctx, types.DriftFilter{
+		SerialNumber:    req.GetSerialNumber(),
+		IncludeResolved: req.GetIncludeResolved(),
+		Limit:           int(req.GetLimit()),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list drift events: %v", err)
+	}
+
+	resp := &grpc_scale.ListDriftEventsResponse{}
+	for _, e := range events {
+		resp.Events = append(resp.Events, &grpc_scale.DriftEvent{
+			Id:                   int32(e.ID),
+			SerialNumber:         e.SerialNumber,
+			VersionSetId:         e.VersionSetID.String(),
+			ExpectedHash:         e.ExpectedHash,
+			ReportedHash:         e.ReportedHash,
+			ReportedVersionSetId: e.ReportedVersionSetID,
+			DetectedAt:           timestamppb.New(e.DetectedAt),
+			ResolvedAt:           optionalTimestamp(e.ResolvedAt),
+		})
+	}
+	return resp, nil
+}
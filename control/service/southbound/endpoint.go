@@ -2,7 +2,6 @@ package southbound
 
 import (
 	"context"
-	"fmt"
 
 	"google.golang.org/protobuf/types/known/emptypb"
 
@@ -52,24 +51,27 @@ func (sb *SouthboundService) ListEndpointConfigs(ctx context.Context, req *grpc_
 }
 
 func (sb *SouthboundService) CreateEndpointConfig(ctx context.Context, req *grpc_southbound.CreateEndpointConfigRequest) (*grpc_southbound.EndpointConfig, error) {
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
 	config := &types.EndpointConfig{
 		Name:                 req.Name,
 		MutualAuth:           req.MutualAuth,
 		NoEncryption:         req.NoEncryption,
 		ASLKeyExchangeMethod: req.AslKeyExchangeMethod.String(),
 		Cipher:               req.Cipher,
-		CreatedBy:            req.CreatedBy,
+		CreatedBy:            user,
 	}
 	log.Debug().Msgf("Creating endpoint config: %v", config)
 
 	if req.VersionSetId != "" {
 		config.VersionSetID = uuid.FromStringOrNil(req.VersionSetId)
 	}
-
-	err := sb.db.CreateEndpointConfig(ctx, config)
+	err = sb.db.CreateEndpointConfig(ctx, config)
 	if err != nil {
 		log.Err(err)
-		return nil, err
+		return nil, draftError(err, "create endpoint config")
 	}
 
 	return &grpc_southbound.EndpointConfig{
@@ -122,6 +124,7 @@ func (sb *SouthboundService) GetEndpointConfig(ctx context.Context, req *grpc_so
 func (sb *SouthboundService) UpdateEndpointConfig(ctx context.Context, req *grpc_southbound.UpdateEndpointConfigRequest) (*emptypb.Empty, error) {
 	updates := make(map[string]interface{})
 	var where_string string
+	var where_args []any
 
 	if req.Name != nil {
 		updates["name"] = *req.Name
@@ -141,31 +144,28 @@ func (sb *SouthboundService) UpdateEndpointConfig(ctx context.Context, req *grpc
 
 	switch req.Query.(type) {
 	case *grpc_southbound.UpdateEndpointConfigRequest_Id:
-		where_string := fmt.Sprintf("id = %d", req.GetId())
-		err := sb.db.UpdateWhere(ctx, "endpoint_configs", updates, where_string)
-		if err != nil {
-			log.Err(err)
-			return nil, err
-		}
+		where_string = "id = $1"
+		where_args = []any{req.GetId()}
 	case *grpc_southbound.UpdateEndpointConfigRequest_EndpointConfigQuery:
 		name := req.GetEndpointConfigQuery().Name
 		versionSetID := uuid.FromStringOrNil(req.GetEndpointConfigQuery().VersionSetId)
-		where_string = fmt.Sprintf("name = '%s' AND version_set_id = '%s'", name, versionSetID)
+		where_string = "name = $1 AND version_set_id = $2"
+		where_args = []any{name, versionSetID}
 	}
 
-	err := sb.db.UpdateWhere(ctx, "endpoint_configs", updates, where_string)
+	err := sb.db.UpdateDraftWhere(ctx, "endpoint_configs", updates, nil, where_string, where_args...)
 	if err != nil {
 		log.Err(err)
-		return nil, err
+		return nil, draftError(err, "update endpoint config")
 	}
 
 	return &emptypb.Empty{}, nil
 }
 
 func (sb *SouthboundService) DeleteEndpointConfig(ctx context.Context, req *grpc_southbound.DeleteEndpointConfigRequest) (*emptypb.Empty, error) {
-	err := sb.db.Delete(ctx, "endpoint_configs", "id", fmt.Sprintf("%d", req.Id))
+	err := sb.db.DeleteDraftWhere(ctx, "endpoint_configs", "id = $1", req.Id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to delete endpoint config: %w", err)
+		return nil, draftError(err, "delete endpoint config")
 	}
 
 	return &emptypb.Empty{}, nil
@@ -14,17 +14,20 @@ import (
 )
 
 func (s *SouthboundService) CreateGroup(ctx context.Context, req *grpc_southbound.CreateGroupRequest) (*grpc_southbound.GroupResponse, error) {
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
 	group := &types.Group{
 		Name:               req.GetName(),
 		LogLevel:           int(req.GetLogLevel()),
-		CreatedBy:          req.GetCreatedBy(),
+		CreatedBy:          user,
 		EndpointConfigName: req.GetEndpointConfigName(),
 		LegacyConfigName:   req.GetLegacyConfigName(),
 		VersionSetID:       uuid.FromStringOrNil(req.GetVersionSetId()),
 	}
-
 	if err := s.db.CreateGroup(ctx, group); err != nil {
-		return nil, fmt.Errorf("failed to create group: %v", err)
+		return nil, draftError(err, "create group")
 	}
 
 	return convertGroupToResponse(group), nil
@@ -85,19 +88,25 @@ func (s *SouthboundService) UpdateGroup(ctx context.Context, req *grpc_southboun
 	updates := make(map[string]interface{})
 
 	var where_string string
+	var where_args []any
 	switch req.Query.(type) {
 	case *grpc_southbound.UpdateGroupRequest_Id:
 		updates["id"] = int(req.GetId())
-		where_string = fmt.Sprintf("id = %d", req.GetId())
+		where_string = "id = $1"
+		where_args = []any{req.GetId()}
 	case *grpc_southbound.UpdateGroupRequest_GroupQuery:
 		name := req.GetGroupQuery().GetGroupName()
 		versionID := uuid.FromStringOrNil(req.GetGroupQuery().GetVersionSetId())
-		where_string = fmt.Sprintf("name = %s AND version_set_id = %s", name, versionID)
+		where_string = "name = $1 AND version_set_id = $2"
+		where_args = []any{name, versionID}
 	default:
 		return nil, status.Errorf(codes.InvalidArgument, "invalid query")
 	}
+	var moveTo []uuid.UUID
 	if req.VersionSetId != nil {
-		updates["version_set_id"] = uuid.FromStringOrNil(*req.VersionSetId)
+		versionID := uuid.FromStringOrNil(*req.VersionSetId)
+		moveTo = append(moveTo, versionID)
+		updates["version_set_id"] = versionID
 	}
 	if req.LogLevel != nil {
 		updates["log_level"] = int(req.GetLogLevel())
@@ -108,17 +117,16 @@ func (s *SouthboundService) UpdateGroup(ctx context.Context, req *grpc_southboun
 	if req.LegacyConfigName != nil {
 		updates["legacy_config_id"] = req.LegacyConfigName
 	}
-	if err := s.db.UpdateWhere(ctx, "groups", updates, where_string); err != nil {
-		//internal error
+	if err := s.db.UpdateDraftWhere(ctx, "groups", updates, moveTo, where_string, where_args...); err != nil {
 		log.Error().Err(err).Msg("failed to update group")
-		return nil, status.Errorf(codes.Internal, "failed to update group: %v", err)
+		return nil, draftError(err, "update group")
 	}
 	return &empty.Empty{}, nil
 }
 
 func (s *SouthboundService) DeleteGroup(ctx context.Context, req *grpc_southbound.DeleteGroupRequest) (*empty.Empty, error) {
-	if err := s.db.Delete(ctx, "groups", "id", fmt.Sprintf("%d", req.GetId())); err != nil {
-		return nil, fmt.Errorf("failed to delete group: %v", err)
+	if err := s.db.DeleteDraftWhere(ctx, "groups", "id = $1", req.GetId()); err != nil {
+		return nil, draftError(err, "delete group")
 	}
 
 	return &empty.Empty{}, nil
@@ -0,0 +1,176 @@
+package southbound
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (sb *SouthboundService) ListTransactions(ctx context.Context, req *grpc_scale.ListTransactionsRequest) (*grpc_scale.ListTransactionsResponse, error) {
+	filter, err := historyFilterFromProto(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := sb.db.ListTransactions(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list transactions")
+		return nil, status.Error(codes.Internal, "Failed to list transactions")
+	}
+
+	resp := &grpc_scale.ListTransactionsResponse{}
+	for _, transaction := range transactions {
+		resp.Transactions = append(resp.Transactions, transactionToProto(transaction))
+	}
+	return resp, nil
+}
+
+func (sb *SouthboundService) GetTransaction(ctx context.Context, req *grpc_scale.GetTransactionRequest) (*grpc_scale.TransactionDetails, error) {
+	transaction, err := sb.db.GetTransaction(ctx, int(req.TxId))
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, status.Errorf(codes.NotFound, "transaction %d not found", req.TxId)
+		}
+		return nil, status.Error(codes.Internal, "Failed to get transaction")
+	}
+
+	entries, err := sb.db.ListTransactionLog(ctx, transaction.ID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to get transaction log")
+	}
+
+	resp := &grpc_scale.TransactionDetails{
+		Transaction: transactionToProto(transaction),
+	}
+	for _, entry := range entries {
+		resp.Log = append(resp.Log, &grpc_scale.TransactionLogEntry{
+			Id:           int32(entry.ID),
+			NodeSerial:   entry.NodeSerial,
+			VersionSetId: entry.VersionSetID.String(),
+			State:        string(entry.State),
+			Timestamp:    timestamppb.New(entry.Timestamp),
+			Metadata:     jsonString(entry.Metadata),
+		})
+	}
+
+	if transaction.Type == types.TransactionTypeVersionUpdate {
+		transition, err := sb.db.GetVersionTransitionByTransaction(ctx, transaction.ID)
+		if err != nil && !db.IsNoRows(err) {
+			return nil, status.Error(codes.Internal, "Failed to get version transition")
+		}
+		if transition != nil {
+			resp.VersionTransition = versionTransitionToProto(transition)
+		}
+	}
+	return resp, nil
+}
+
+func (sb *SouthboundService) ListVersionTransitions(ctx context.Context, req *grpc_scale.ListVersionTransitionsRequest) (*grpc_scale.ListVersionTransitionsResponse, error) {
+	filter, err := historyFilterFromProto(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions, err := sb.db.ListVersionTransitions(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list version transitions")
+		return nil, status.Error(codes.Internal, "Failed to list version transitions")
+	}
+
+	resp := &grpc_scale.ListVersionTransitionsResponse{}
+	for _, transition := range transitions {
+		resp.VersionTransitions = append(resp.VersionTransitions, versionTransitionToProto(transition))
+	}
+	return resp, nil
+}
+
+func (sb *SouthboundService) GetVersionTransition(ctx context.Context, req *grpc_scale.GetVersionTransitionRequest) (*grpc_scale.VersionTransition, error) {
+	transition, err := sb.db.GetVersionTransitionByID(ctx, strconv.Itoa(int(req.Id)))
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, status.Errorf(codes.NotFound, "version transition %d not found", req.Id)
+		}
+		return nil, status.Error(codes.Internal, "Failed to get version transition")
+	}
+	return versionTransitionToProto(transition), nil
+}
+
+func historyFilterFromProto(filter *grpc_scale.HistoryFilter) (types.HistoryFilter, error) {
+	result := types.HistoryFilter{}
+	if filter == nil {
+		return result, nil
+	}
+
+	result.NodeSerial = filter.NodeSerial
+	result.State = filter.State
+	result.Limit = int(filter.Limit)
+	if filter.VersionSetId != nil {
+		versionSetID, err := uuid.FromString(filter.GetVersionSetId())
+		if err != nil {
+			return result, status.Error(codes.InvalidArgument, "Invalid VersionSetId format")
+		}
+		result.VersionSetID = &versionSetID
+	}
+	if filter.Since != nil {
+		since := filter.Since.AsTime()
+		result.Since = &since
+	}
+	if filter.Until != nil {
+		until := filter.Until.AsTime()
+		result.Until = &until
+	}
+	return result, nil
+}
+
+func transactionToProto(transaction *types.Transaction) *grpc_scale.Transaction {
+	resp := &grpc_scale.Transaction{
+		Id:          int32(transaction.ID),
+		Type:        string(transaction.Type),
+		CreatedAt:   timestamppb.New(transaction.CreatedAt),
+		Description: transaction.Description,
+	}
+	if transaction.CompletedAt != nil {
+		resp.CompletedAt = timestamppb.New(*transaction.CompletedAt)
+	}
+	if transaction.State != nil {
+		state := string(*transaction.State)
+		resp.State = &state
+	}
+	return resp
+}
+
+func versionTransitionToProto(transition *types.VersionTransition) *grpc_scale.VersionTransition {
+	resp := &grpc_scale.VersionTransition{
+		Id:             int32(transition.ID),
+		ToVersionSetId: transition.ToVersionSetID.String(),
+		TransactionId:  int32(transition.TransactionID),
+		Status:         string(transition.Status),
+		StartedAt:      timestamppb.New(transition.StartedAt),
+		CreatedBy:      transition.CreatedBy,
+		Metadata:       jsonString(transition.Metadata),
+	}
+	if transition.FromVersionTransition != nil {
+		from := int32(*transition.FromVersionTransition)
+		resp.FromVersionTransition = &from
+	}
+	if transition.CompletedAt != nil {
+		resp.CompletedAt = timestamppb.New(*transition.CompletedAt)
+	}
+	return resp
+}
+
+func jsonString(metadata []byte) *string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	s := string(metadata)
+	return &s
+}
@@ -3,7 +3,6 @@ package southbound
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
 	"github.com/gofrs/uuid/v5"
@@ -13,22 +12,25 @@ import (
 
 // Override the unimplemented methods from core.go
 func (sb *SouthboundService) CreateHardwareConfig(ctx context.Context, req *grpc_southbound.CreateHardwareConfigRequest) (*grpc_southbound.HardwareConfigResponse, error) {
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
 	versionSetID, err := uuid.FromString(req.VersionSetId)
 	if err != nil {
 		return nil, fmt.Errorf("invalid version set ID: %w", err)
 	}
-
 	config := &types.HardwareConfig{
 		NodeSerial:   req.NodeSerialNumber,
 		Device:       req.Device,
 		IPCIDR:       req.IpCidr,
 		VersionSetID: versionSetID,
-		CreatedBy:    "system", // You might want to get this from context or auth
+		CreatedBy:    user,
 	}
 
 	err = sb.db.CreateHwConfig(ctx, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create hardware config: %w", err)
+		return nil, draftError(err, "create hardware config")
 	}
 
 	return &grpc_southbound.HardwareConfigResponse{
@@ -98,6 +100,7 @@ func (sb *SouthboundService) GetHardwareConfig(ctx context.Context, req *grpc_so
 
 func (sb *SouthboundService) UpdateHardwareConfig(ctx context.Context, req *grpc_southbound.UpdateHardwareConfigRequest) (*empty.Empty, error) {
 	updates := make(map[string]interface{})
+	var moveTo []uuid.UUID
 
 	if req.Device != nil {
 		updates["device"] = *req.Device
@@ -110,21 +113,22 @@ func (sb *SouthboundService) UpdateHardwareConfig(ctx context.Context, req *grpc
 		if err != nil {
 			return nil, fmt.Errorf("invalid version set ID: %w", err)
 		}
+		moveTo = append(moveTo, versionSetID)
 		updates["version_set_id"] = versionSetID
 	}
 
-	err := sb.db.Update(ctx, "hardware_configs", updates, "id", strconv.Itoa(int(req.Id)))
+	err := sb.db.UpdateDraftWhere(ctx, "hardware_configs", updates, moveTo, "id = $1", req.Id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update hardware config: %w", err)
+		return nil, draftError(err, "update hardware config")
 	}
 
 	return &empty.Empty{}, nil
 }
 
 func (sb *SouthboundService) DeleteHardwareConfig(ctx context.Context, req *grpc_southbound.DeleteHardwareConfigRequest) (*empty.Empty, error) {
-	err := sb.db.Delete(ctx, "hardware_configs", "id", strconv.Itoa(int(req.Id)))
+	err := sb.db.DeleteDraftWhere(ctx, "hardware_configs", "id = $1", req.Id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to delete hardware config: %w", err)
+		return nil, draftError(err, "delete hardware config")
 	}
 
 	return &empty.Empty{}, nil
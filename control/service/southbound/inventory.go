@@ -0,0 +1,123 @@
+package southbound
+
+import (
+	"context"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func getInventoryClient(addr string) (grpc_scale.ControlPlaneInventoryClient, *grpc.ClientConn, error) {
+	conn, err := dialController(addr)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not connect to control plane")
+		return nil, nil, status.Error(codes.Internal, "Failed to connect to control plane")
+	}
+
+	client := grpc_scale.NewControlPlaneInventoryClient(conn)
+	return client, conn, nil
+}
+
+func (sb *SouthboundService) GetNodeInventory(ctx context.Context, req *grpc_scale.GetNodeInventoryRequest) (*grpc_scale.NodeInventory, error) {
+	if req.SerialNumber == "" {
+		return nil, status.Error(codes.InvalidArgument, "SerialNumber is required")
+	}
+	inv, err := sb.db.GetNodeInventory(ctx, req.SerialNumber)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, status.Errorf(codes.NotFound, "node %s did not report an inventory", req.SerialNumber)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get inventory: %v", err)
+	}
+	return inventoryToProto(inv), nil
+}
+
+// filterByAgentVersion drops the updates of nodes which did not report at
+// least minVersion. Nodes without inventory are dropped as well, an empty
+// minVersion keeps all updates.
+func (sb *SouthboundService) filterByAgentVersion(ctx context.Context, items []*grpc_controlplane.NodeUpdateItem, minVersion string) ([]*grpc_controlplane.NodeUpdateItem, error) {
+	if minVersion == "" {
+		return items, nil
+	}
+	if _, err := types.CompareVersions(minVersion, minVersion); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid minimum agent version: %v", err)
+	}
+	versions, err := sb.db.AgentVersions(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to get agent versions")
+	}
+
+	kept := make([]*grpc_controlplane.NodeUpdateItem, 0, len(items))
+	for _, item := range items {
+		if meetsAgentVersion(versions, item.SerialNumber, minVersion) {
+			kept = append(kept, item)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "no node runs agent version %s or newer", minVersion)
+	}
+	return kept, nil
+}
+
+// meetsAgentVersion reports whether node serial runs at least minVersion
+// according to versions, the reported agent versions by serial number
+func meetsAgentVersion(versions map[string]string, serial, minVersion string) bool {
+	version, ok := versions[serial]
+	if !ok {
+		log.Info().Msgf("Skipping node %s, it did not report an agent version", serial)
+		return false
+	}
+	cmp, err := types.CompareVersions(version, minVersion)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Skipping node %s, its agent version is invalid", serial)
+		return false
+	}
+	if cmp < 0 {
+		log.Info().Msgf("Skipping node %s, agent %s is older than %s", serial, version, minVersion)
+		return false
+	}
+	return true
+}
+
+func inventoryToProto(inv *types.NodeInventory) *grpc_scale.NodeInventory {
+	return &grpc_scale.NodeInventory{
+		SerialNumber:    inv.SerialNumber,
+		AgentVersion:    inv.AgentVersion,
+		FirmwareVersion: inv.FirmwareVersion,
+		HardwareModel:   inv.HardwareModel,
+		Uptime:          inv.Uptime,
+		VersionSetId:    inv.VersionSetID,
+		TxId:            inv.TxID,
+		KexMethods:      inv.KexMethods,
+		Ciphers:         inv.Ciphers,
+		ConfigHash:      inv.ConfigHash,
+		ReportedAt:      timestamppb.New(inv.ReportedAt),
+	}
+}
+
+// inventoryFromProto returns nil for a hello without inventory
+func inventoryFromProto(inv *grpc_scale.NodeInventory) *types.NodeInventory {
+	if inv == nil {
+		return nil
+	}
+	return &types.NodeInventory{
+		SerialNumber:    inv.SerialNumber,
+		AgentVersion:    inv.AgentVersion,
+		FirmwareVersion: inv.FirmwareVersion,
+		HardwareModel:   inv.HardwareModel,
+		Uptime:          inv.Uptime,
+		VersionSetID:    inv.VersionSetId,
+		TxID:            inv.TxId,
+		KexMethods:      inv.KexMethods,
+		Ciphers:         inv.Ciphers,
+		ConfigHash:      inv.ConfigHash,
+		ReportedAt:      inv.ReportedAt.AsTime(),
+	}
+}
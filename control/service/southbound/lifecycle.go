@@ -0,0 +1,70 @@
+package southbound
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// draftError maps the error of a change to a version set or its entities.
+// The database refuses changes to version sets which are not drafts, in the
+// transaction of the change. Frozen and active version sets must match what
+// the gateways run or are about to run, changes go into a clone.
+func draftError(err error, action string) error {
+	switch {
+	case errors.Is(err, db.ErrNotDraft):
+		return status.Errorf(codes.FailedPrecondition, "%v, only drafts can be changed, clone it instead", err)
+	case db.IsNoRows(err):
+		return status.Error(codes.NotFound, "version set not found")
+	}
+	return status.Errorf(codes.Internal, "failed to %s: %v", action, err)
+}
+
+// transitionVersionSet moves a version set to another state of its lifecycle
+// and returns the state it was in
+func (sb *SouthboundService) transitionVersionSet(ctx context.Context, id uuid.UUID, to types.VersionState) (types.VersionState, error) {
+	from, err := sb.db.TransitionVersionSet(ctx, id, to)
+	switch {
+	case err == nil:
+		if from != to {
+			log.Info().Msgf("Version set %s moved from %s to %s", id, from, to)
+		}
+		return from, nil
+	case db.IsNoRows(err):
+		return "", status.Error(codes.NotFound, "version set not found")
+	case errors.Is(err, db.ErrIllegalTransition):
+		return "", status.Errorf(codes.FailedPrecondition, "version set %s cannot move from %s to %s", id, from, to)
+	default:
+		return "", status.Errorf(codes.Internal, "failed to change state of version set: %v", err)
+	}
+}
+
+// prepareActivation checks that a version set may be pushed to the gateways.
+// Drafts have to be submitted and approved first, see requireApproval. The
+// version set only becomes active once a version update was applied, see
+// finishVersionTransition.
+func (sb *SouthboundService) prepareActivation(ctx context.Context, id uuid.UUID) error {
+	vs, err := sb.db.GetVersionSetByID(ctx, id)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return status.Error(codes.NotFound, "version set not found")
+		}
+		return status.Errorf(codes.Internal, "failed to get version set: %v", err)
+	}
+	switch vs.State {
+	case types.VERSION_STATE_DISABLED:
+		return status.Errorf(codes.FailedPrecondition, "version set %s is disabled and cannot be activated, clone it instead", id)
+	case types.VERSION_STATE_DRAFT:
+		return status.Errorf(codes.FailedPrecondition, "version set %s is a draft, submit it for review first", id)
+	case types.VERSION_STATE_PENDING_DEPLOYMENT:
+		return sb.requireApproval(ctx, id)
+	}
+	// the active version set runs on the fleet already
+	return nil
+}
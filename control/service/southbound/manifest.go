@@ -0,0 +1,155 @@
+package southbound
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (sb *SouthboundService) ApplyManifest(ctx context.Context, req *grpc_scale.ApplyManifestRequest) (*grpc_scale.ApplyManifestResponse, error) {
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var manifest types.Manifest
+	if err := json.Unmarshal(req.GetManifest(), &manifest); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid manifest: %v", err)
+	}
+	manifest.Normalize()
+	if err := manifest.CheckReferences(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	manifest.VersionSet.CreatedBy = user
+
+	plan, err := sb.db.ApplyManifest(ctx, &manifest, req.GetDryRun())
+	if err != nil {
+		if errors.Is(err, db.ErrNotDraft) {
+			return nil, status.Errorf(codes.FailedPrecondition, "%v, only drafts can be changed, clone it instead", err)
+		}
+		if errors.Is(err, db.ErrInvalidManifest) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to apply manifest: %v", err)
+	}
+
+	return planToResponse(plan), nil
+}
+
+func (sb *SouthboundService) ExportVersionSet(ctx context.Context, req *grpc_scale.ExportVersionSetRequest) (*grpc_scale.ExportVersionSetResponse, error) {
+	id, err := uuid.FromString(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
+	}
+
+	vs, err := sb.db.GetVersionSetByID(ctx, id)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, status.Error(codes.NotFound, "version set not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get version set: %v", err)
+	}
+
+	manifest, origins, err := sb.db.ExportManifest(ctx, id)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to export version set: %v", err)
+	}
+	hash, err := manifest.Hash()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to hash version set: %v", err)
+	}
+
+	bundle := &types.Bundle{
+		Format:      types.BundleFormat,
+		ExportedAt:  time.Now().UTC(),
+		SourceID:    vs.ID,
+		State:       vs.State,
+		CreatedAt:   vs.CreatedAt,
+		ActivatedAt: vs.ActivatedAt,
+		Content:     *manifest,
+		ContentHash: hash,
+		Origins:     origins,
+	}
+	if json.Valid(vs.Metadata) {
+		bundle.Metadata = vs.Metadata
+	}
+
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode bundle: %v", err)
+	}
+	return &grpc_scale.ExportVersionSetResponse{Bundle: data}, nil
+}
+
+func (sb *SouthboundService) ImportVersionSet(ctx context.Context, req *grpc_scale.ImportVersionSetRequest) (*grpc_scale.ImportVersionSetResponse, error) {
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var bundle types.Bundle
+	if err := json.Unmarshal(req.GetBundle(), &bundle); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid bundle: %v", err)
+	}
+	if err := bundle.Verify(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid bundle: %v", err)
+	}
+
+	manifest := bundle.Content
+	manifest.VersionSet.ID = ""
+	if req.Name != nil {
+		manifest.VersionSet.Name = req.GetName()
+	}
+	manifest.VersionSet.CreatedBy = user
+
+	id, err := sb.db.ImportManifest(ctx, &manifest, bundle.Origins, importMetadata(&bundle))
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidManifest) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid bundle: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to import version set: %v", err)
+	}
+	log.Info().Msgf("Imported version set %s from bundle of %s as %s", manifest.VersionSet.Name, bundle.SourceID, id)
+
+	return &grpc_scale.ImportVersionSetResponse{Id: id.String(), ContentHash: bundle.ContentHash}, nil
+}
+
+// importMetadata keeps the metadata of the exported version set and records
+// where it was imported from
+func importMetadata(bundle *types.Bundle) []byte {
+	var metadata map[string]any
+	if len(bundle.Metadata) > 0 {
+		// metadata which is not an object is dropped
+		_ = json.Unmarshal(bundle.Metadata, &metadata)
+	}
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	metadata["imported_from"] = bundle.SourceID.String()
+	metadata["content_hash"] = bundle.ContentHash
+	data, _ := json.Marshal(metadata)
+	return data
+}
+
+func planToResponse(plan *types.ManifestPlan) *grpc_scale.ApplyManifestResponse {
+	resp := &grpc_scale.ApplyManifestResponse{Applied: plan.Applied}
+	if plan.VersionSetID != uuid.Nil {
+		resp.VersionSetId = plan.VersionSetID.String()
+	}
+	for _, step := range plan.Steps {
+		resp.Steps = append(resp.Steps, &grpc_scale.PlanStep{
+			Action: string(step.Action),
+			Kind:   step.Kind,
+			Name:   step.Name,
+			Fields: step.Fields,
+		})
+	}
+	return resp
+}
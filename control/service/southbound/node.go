@@ -2,7 +2,6 @@ package southbound
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/gofrs/uuid/v5"
 	"github.com/rs/zerolog/log"
@@ -96,23 +95,26 @@ func (sb *SouthboundService) ListNodes(ctx context.Context, req *grpc_southbound
 }
 
 func (sb *SouthboundService) CreateNode(ctx context.Context, req *grpc_southbound.CreateNodeRequest) (*grpc_southbound.NodeResponse, error) {
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
 	versionSetID, err := uuid.FromString(req.GetVersionSetId())
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
 	}
-
 	node := &types.Node{
 		SerialNumber: req.GetSerialNumber(),
 		NetworkIndex: int(req.GetNetworkIndex()),
 		Locality:     req.GetLocality(),
 		VersionSetID: versionSetID,
-		CreatedBy:    req.GetUser(),
+		CreatedBy:    user,
 	}
 
 	createdNode, err := sb.db.CreateNode(ctx, node)
 	if err != nil {
 		log.Err(err).Msg("failed to create node")
-		return nil, status.Error(codes.Internal, "failed to create node")
+		return nil, draftError(err, "create node")
 	}
 
 	return &grpc_southbound.NodeResponse{
@@ -219,15 +221,15 @@ func (sb *SouthboundService) UpdateNode(ctx context.Context, req *grpc_southboun
 	if req.GetLastSeen() != nil {
 		updates["last_seen"] = req.GetLastSeen().AsTime()
 	}
+	// last_seen is reported by the gateways and no part of the configuration
+	configChange := len(updates) > 0 && !(len(updates) == 1 && req.GetLastSeen() != nil)
 
+	var where_string string
+	var where_args []any
 	switch query := req.GetQuery().(type) {
 	case *grpc_southbound.UpdateNodeRequest_Id:
-		where_string := fmt.Sprintf("id = %d", req.GetId())
-		err := sb.db.UpdateWhere(ctx, "nodes", updates, where_string)
-		if err != nil {
-			log.Err(err).Msg("failed to update node")
-			return nil, status.Error(codes.Internal, "failed to update node")
-		}
+		where_string = "id = $1"
+		where_args = []any{req.GetId()}
 
 	case *grpc_southbound.UpdateNodeRequest_NodeQuery:
 		versionSetID, err := uuid.FromString(query.NodeQuery.GetVersionSetId())
@@ -235,17 +237,24 @@ func (sb *SouthboundService) UpdateNode(ctx context.Context, req *grpc_southboun
 			return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
 		}
 		serialNumber := query.NodeQuery.GetSerialNumber()
-		where_string := fmt.Sprintf("serial_number = %s AND version_set_id = %s", serialNumber, versionSetID.String())
-		err = sb.db.UpdateWhere(ctx, "nodes", updates, where_string)
-		if err != nil {
-			log.Err(err).Msg("failed to update node")
-			return nil, fmt.Errorf("failed to update node: %w", err)
-		}
+		where_string = "serial_number = $1 AND version_set_id = $2"
+		where_args = []any{serialNumber, versionSetID}
 
 	default:
 		return nil, status.Error(codes.InvalidArgument, "invalid query type")
 	}
 
+	var err error
+	if configChange {
+		err = sb.db.UpdateDraftWhere(ctx, "nodes", updates, nil, where_string, where_args...)
+	} else {
+		err = sb.db.UpdateWhere(ctx, "nodes", updates, where_string, where_args...)
+	}
+	if err != nil {
+		log.Err(err).Msg("failed to update node")
+		return nil, draftError(err, "update node")
+	}
+
 	return &emptypb.Empty{}, nil
 }
 
@@ -254,11 +263,10 @@ func (sb *SouthboundService) DeleteNode(ctx context.Context, req *grpc_southboun
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
 	}
-
 	err = sb.db.DeleteNode(ctx, req.GetSerialNumber(), versionSetID)
 	if err != nil {
 		log.Err(err).Msg("failed to delete node")
-		return nil, status.Error(codes.Internal, "failed to delete node")
+		return nil, draftError(err, "delete node")
 	}
 
 	return &emptypb.Empty{}, nil
@@ -0,0 +1,344 @@
+package southbound
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// queueExpiryInterval is how often queued updates are checked for expiry
+const queueExpiryInterval = time.Minute
+
+// SetOfflineQueue configures how long updates of offline nodes are queued
+func (sb *SouthboundService) SetOfflineQueue(cfg types.OfflineQueueConfig) {
+	if cfg.Expiry > 0 {
+		sb.queueExpiry = cfg.Expiry
+	}
+}
+
+// enqueueNodeUpdate queues the update of an offline node in a node_update
+// transaction of its own, which stays open until the update is delivered on
+// the next hello of the node, expires or is superseded. source is the fleet
+// update or rollout the node belongs to, nil for the activation of a single
+// node. It returns the id of the transaction.
+func (sb *SouthboundService) enqueueNodeUpdate(ctx context.Context, nodeUpdate *grpc_controlplane.NodeUpdateItem, versionSetID uuid.UUID, source *int) (int, error) {
+	description := fmt.Sprintf("Queued update of node %s to VersionSet %s", nodeUpdate.SerialNumber, versionSetID)
+	tx, err := sb.db.CreateTransaction(ctx, description, types.TransactionTypeNodeUpdate)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create transaction")
+		return 0, status.Error(codes.Internal, "Failed to create transaction")
+	}
+
+	superseded, err := sb.db.EnqueueNodeUpdate(ctx, &types.PendingUpdate{
+		SerialNumber:        nodeUpdate.SerialNumber,
+		VersionSetID:        versionSetID,
+		TransactionID:       tx,
+		SourceTransactionID: source,
+		Item:                nodeUpdate,
+		ExpiresAt:           time.Now().Add(sb.queueExpiry),
+	})
+	if err != nil {
+		if err := sb.completeTransaction(ctx, tx, types.TransactionStateError, "Failed to queue update"); err != nil {
+			log.Error().Err(err).Msg("Failed to update transaction")
+		}
+		return 0, status.Errorf(codes.Internal, "failed to queue update of %s", nodeUpdate.SerialNumber)
+	}
+
+	// the node never saw the superseded updates, nothing is logged for it
+	for _, old := range superseded {
+		if err := sb.completeTransaction(ctx, old, types.TransactionStateError, fmt.Sprintf("Superseded by transaction %d", tx)); err != nil {
+			log.Error().Err(err).Msgf("Failed to complete superseded transaction %d", old)
+		}
+	}
+	log.Info().Msgf("Node %s is offline, update to version set %s queued in transaction %d", nodeUpdate.SerialNumber, versionSetID, tx)
+	return tx, nil
+}
+
+// supersedeQueuedUpdates supersedes the updates which the fleet update or
+// rollout sourceTx queued for offline nodes and completes their transactions.
+// The rest of the fleet did not keep the update, the nodes must not get it
+// on their next hello.
+func (sb *SouthboundService) supersedeQueuedUpdates(ctx context.Context, sourceTx int, reason string) {
+	superseded, err := sb.db.SupersedeQueuedUpdates(ctx, sourceTx, reason)
+	if err != nil {
+		return
+	}
+	for _, tx := range superseded {
+		if err := sb.completeTransaction(ctx, tx, types.TransactionStateError, reason); err != nil {
+			log.Error().Err(err).Msgf("Failed to complete superseded transaction %d", tx)
+		}
+	}
+	if len(superseded) > 0 {
+		log.Info().Msgf("%d updates queued by transaction %d were superseded: %s", len(superseded), sourceTx, reason)
+	}
+}
+
+// splitOffline removes the nodes which are offline from items and returns them
+func (sb *SouthboundService) splitOffline(ctx context.Context, versionSetID uuid.UUID, items []*grpc_controlplane.NodeUpdateItem) ([]*grpc_controlplane.NodeUpdateItem, []*grpc_controlplane.NodeUpdateItem, error) {
+	online, err := sb.db.OnlineNodes(ctx, versionSetID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get online nodes")
+		return nil, nil, status.Error(codes.Internal, "Failed to get online nodes")
+	}
+
+	var reachable, offline []*grpc_controlplane.NodeUpdateItem
+	for _, item := range items {
+		if online[item.SerialNumber] {
+			reachable = append(reachable, item)
+		} else {
+			offline = append(offline, item)
+		}
+	}
+	return reachable, offline, nil
+}
+
+// queuedResponse is the activateResponse of an update queued for an offline node
+func queuedResponse(tx int) (*grpc_southbound.ActivateResponse, error) {
+	metadata, err := structpb.NewStruct(map[string]any{"tx_id": tx, "queued": true})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to build response")
+	}
+	return &grpc_southbound.ActivateResponse{
+		Retcode:  0,
+		Metadata: metadata,
+	}, nil
+}
+
+// DeliverQueuedUpdate pushes the queued update of a node which just said
+// hello. It runs the update in the background and returns right away. An
+// update outside of the maintenance windows of its node stays queued for a
+// later hello.
+func (sb *SouthboundService) DeliverQueuedUpdate(serialNumber string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update, err := sb.db.QueuedUpdate(ctx, serialNumber)
+	if err != nil {
+		if !db.IsNoRows(err) {
+			log.Error().Err(err).Msgf("Failed to get queued update of %s", serialNumber)
+		}
+		return
+	}
+
+	ready, description, err := sb.queuedUpdateReady(ctx, update)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to check queued update %d", update.ID)
+		return
+	}
+	if description != "" {
+		if err := sb.db.FinishPendingUpdate(ctx, update.ID, types.PendingUpdateSuperseded, description); err != nil {
+			return
+		}
+		if err := sb.completeTransaction(ctx, update.TransactionID, types.TransactionStateError, description); err != nil {
+			log.Error().Err(err).Msg("Failed to update transaction")
+		}
+		return
+	}
+	if !ready {
+		log.Debug().Msgf("Queued update of %s waits for transaction %d to finish", serialNumber, *update.SourceTransactionID)
+		return
+	}
+
+	if err := sb.checkMaintenanceWindows(ctx, update.VersionSetID, []string{serialNumber}, false); err != nil {
+		log.Debug().Msgf("Queued update of %s waits for a maintenance window: %v", serialNumber, err)
+		return
+	}
+
+	claimed, err := sb.db.ClaimQueuedUpdate(ctx, update.ID, time.Now())
+	if err != nil || !claimed {
+		return
+	}
+	log.Info().Msgf("Node %s is back, delivering queued update of transaction %d", serialNumber, update.TransactionID)
+
+	go func() {
+		state := sb.runNodeActivation(update.TransactionID, update.Item, update.VersionSetID)
+		finished, message := types.PendingUpdateDelivered, "update applied"
+		if state != types.TransactionStateApplied {
+			finished, message = types.PendingUpdateFailed, fmt.Sprintf("update ended in state %s", state)
+		}
+		if err := sb.db.FinishPendingUpdate(context.Background(), update.ID, finished, message); err != nil {
+			log.Error().Err(err).Msgf("Failed to finish queued update %d", update.ID)
+			return
+		}
+		if update.SourceTransactionID != nil {
+			sb.finishQueuedActivation(context.Background(), *update.SourceTransactionID)
+		}
+	}()
+}
+
+// queuedUpdateReady tells whether a queued update may be delivered. An update
+// queued by a version update or rollout waits until its version transition is
+// active, one queued by a group update until its transaction applied. If the
+// update must not be delivered at all, the reason is returned as description.
+func (sb *SouthboundService) queuedUpdateReady(ctx context.Context, update *types.PendingUpdate) (bool, string, error) {
+	vs, err := sb.db.GetVersionSetByID(ctx, update.VersionSetID)
+	if err != nil {
+		return false, "", err
+	}
+	if vs.State == types.VERSION_STATE_DISABLED {
+		return false, fmt.Sprintf("Version set %s was disabled before node %s returned", update.VersionSetID, update.SerialNumber), nil
+	}
+	if update.SourceTransactionID == nil {
+		return true, "", nil
+	}
+	source := *update.SourceTransactionID
+
+	transaction, err := sb.db.GetTransaction(ctx, source)
+	if err != nil {
+		return false, "", err
+	}
+	// none of the nodes was online, the queued updates are the activation
+	// and its version transition waits for them
+	if queuedActivation(transaction) != nil && transaction.CompletedAt == nil {
+		return true, "", nil
+	}
+
+	transition, err := sb.db.GetVersionTransitionByTransaction(ctx, source)
+	switch {
+	case err == nil:
+		switch transition.Status {
+		case types.VersionTransitionActive:
+			return true, "", nil
+		case types.VersionTransitionPending, types.VersionTransitionRollback:
+			return false, "", nil
+		default:
+			return false, fmt.Sprintf("Version transition %d of transaction %d is %s", transition.ID, source, transition.Status), nil
+		}
+	case !db.IsNoRows(err):
+		return false, "", err
+	}
+
+	switch {
+	case transaction.State == nil:
+		return false, "", nil
+	case *transaction.State != types.TransactionStateApplied:
+		return false, fmt.Sprintf("Transaction %d which queued the update ended in state %s", source, *transaction.State), nil
+	}
+	return true, "", nil
+}
+
+// RunOfflineQueue expires queued updates whose node did not return in time
+// and finishes the queued activations whose updates all finished. It runs
+// until ctx is cancelled.
+func (sb *SouthboundService) RunOfflineQueue(ctx context.Context) {
+	ticker := time.NewTicker(queueExpiryInterval)
+	defer ticker.Stop()
+	for {
+		sb.expireQueuedUpdates(ctx)
+		sb.finishQueuedActivations(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (sb *SouthboundService) expireQueuedUpdates(ctx context.Context) {
+	expired, err := sb.db.ExpireQueuedUpdates(ctx, time.Now())
+	if err != nil {
+		return
+	}
+	for _, u := range expired {
+		description := fmt.Sprintf("Node %s did not return before %s", u.SerialNumber, u.ExpiresAt.Format(time.RFC3339))
+		if err := sb.logTransactionFailure(ctx, u.TransactionID, u.SerialNumber, u.VersionSetID, description); err != nil {
+			log.Error().Err(err).Msg("Failed to log transaction failure")
+		}
+		if err := sb.completeTransaction(ctx, u.TransactionID, types.TransactionStateError, description); err != nil {
+			log.Error().Err(err).Msg("Failed to update transaction")
+		}
+		log.Warn().Msgf("Queued update of %s in transaction %d expired", u.SerialNumber, u.TransactionID)
+	}
+}
+
+// queuedActivation returns the metadata of a queued activation, nil if
+// transaction is none
+func queuedActivation(transaction *types.Transaction) *types.QueuedActivation {
+	if len(transaction.Metadata) == 0 {
+		return nil
+	}
+	activation := &types.QueuedActivation{}
+	if err := json.Unmarshal(transaction.Metadata, activation); err != nil || activation.Queued == 0 {
+		return nil
+	}
+	return activation
+}
+
+// finishQueuedActivations finishes the open queued activations whose updates
+// all finished. Updates which expired or were superseded by another
+// activation finish without a delivery.
+func (sb *SouthboundService) finishQueuedActivations(ctx context.Context) {
+	open, err := sb.db.ListOpenTransactions(ctx)
+	if err != nil {
+		return
+	}
+	for _, transaction := range open {
+		if queuedActivation(transaction) != nil {
+			sb.finishQueuedActivation(ctx, transaction.ID)
+		}
+	}
+}
+
+// finishQueuedActivation completes the queued activation tx once its queued
+// updates finished, like runActivation completes a fleet update: it applied
+// if no delivery failed and at least one node got the update. The first
+// failed delivery fails it right away, which supersedes the updates still
+// queued and rolls back the version transition.
+func (sb *SouthboundService) finishQueuedActivation(ctx context.Context, tx int) {
+	sb.queuedMu.Lock()
+	defer sb.queuedMu.Unlock()
+
+	transaction, err := sb.db.GetTransaction(ctx, tx)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to get queued activation %d", tx)
+		return
+	}
+	activation := queuedActivation(transaction)
+	if activation == nil || transaction.CompletedAt != nil {
+		return
+	}
+
+	counts, err := sb.db.SourceUpdateCounts(ctx, tx)
+	if err != nil {
+		return
+	}
+	failed := counts[types.PendingUpdateFailed]
+	delivered := counts[types.PendingUpdateDelivered]
+	if failed == 0 && counts[types.PendingUpdateQueued]+counts[types.PendingUpdateDelivering] > 0 {
+		return
+	}
+
+	applied := failed == 0 && delivered > 0
+	state := types.TransactionStateApplied
+	description := fmt.Sprintf("Queued updates of %d of %d nodes delivered", delivered, activation.Queued)
+	switch {
+	case failed > 0:
+		state = types.TransactionStateError
+		description = fmt.Sprintf("Queued update of %d of %d nodes failed", failed, activation.Queued)
+	case !applied:
+		state = types.TransactionStateError
+		description = fmt.Sprintf("None of the %d nodes got its queued update", activation.Queued)
+	}
+	if err := sb.completeTransaction(ctx, tx, state, description); err != nil {
+		log.Error().Err(err).Msg("Failed to update transaction")
+		return
+	}
+	log.Info().Msgf("Queued activation %d finished: %s", tx, description)
+
+	if activation.TransitionID != nil {
+		sb.finishVersionTransition(ctx, tx, *activation.TransitionID, activation.FromVersionTransition, activation.VersionSetID, applied)
+	} else if !applied {
+		sb.supersedeQueuedUpdates(ctx, tx, fmt.Sprintf("Transaction %d failed", tx))
+	}
+}
@@ -0,0 +1,169 @@
+package southbound
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+// testFleet is a version set with two nodes on an empty SQLite database,
+// submitted by alice and approved by bob. None of the nodes is online.
+type testFleet struct {
+	sb           *SouthboundService
+	versionSetID uuid.UUID
+	serials      []string
+}
+
+func newTestFleet(t *testing.T) *testFleet {
+	t.Helper()
+	ctx := context.Background()
+	sm, err := db.NewStateManager(ctx, types.DatabaseConfig{
+		Type:       types.DatabaseSqlite,
+		SqlitePath: filepath.Join(t.TempDir(), "scale.db"),
+	})
+	if err != nil {
+		t.Fatalf("failed to open sqlite database: %v", err)
+	}
+	t.Cleanup(sm.Close)
+	if _, err := sm.MigrateUp(ctx, 0); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	id, err := sm.CreateVersionSet(ctx, types.VersionSet{Name: "fleet", CreatedBy: "alice", State: types.VERSION_STATE_DRAFT})
+	if err != nil {
+		t.Fatalf("create version set: %v", err)
+	}
+	if err := sm.CreateEndpointConfig(ctx, &types.EndpointConfig{Name: "ep", MutualAuth: true, ASLKeyExchangeMethod: "ASL_KEX_DEFAULT", VersionSetID: id, CreatedBy: "alice"}); err != nil {
+		t.Fatalf("create endpoint config: %v", err)
+	}
+	if err := sm.CreateGroup(ctx, &types.Group{Name: "plant", LogLevel: 2, EndpointConfigName: "ep", LegacyConfigName: "ep", VersionSetID: id, CreatedBy: "alice"}); err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	serials := []string{"gw-1", "gw-2"}
+	for i, serial := range serials {
+		if _, err := sm.CreateNode(ctx, &types.Node{SerialNumber: serial, NetworkIndex: i + 1, Locality: "hall", VersionSetID: id, CreatedBy: "alice"}); err != nil {
+			t.Fatalf("create node: %v", err)
+		}
+		if _, err := sm.CreateProxy(ctx, &types.Proxy{Name: "web-" + serial, NodeSerial: serial, GroupName: "plant", State: true, ProxyType: types.PROXY_TYPE_REVERSE,
+			ServerEndpointAddr: "0.0.0.0:443", ClientEndpointAddr: "127.0.0.1:80", VersionSetID: id, CreatedBy: "alice"}); err != nil {
+			t.Fatalf("create proxy: %v", err)
+		}
+	}
+
+	if err := sm.SubmitVersionSet(ctx, &types.Review{VersionSetID: id, User: "alice"}); err != nil {
+		t.Fatalf("submit version set: %v", err)
+	}
+	if err := sm.ApproveVersionSet(ctx, &types.Review{VersionSetID: id, User: "bob"}); err != nil {
+		t.Fatalf("approve version set: %v", err)
+	}
+	return &testFleet{sb: NewSouthbound(sm, "127.0.0.1:0"), versionSetID: id, serials: serials}
+}
+
+// finishDelivery stands in for the delivery of the queued update of a node
+// which said hello
+func (f *testFleet) finishDelivery(t *testing.T, serial string, status types.PendingUpdateStatus) {
+	t.Helper()
+	ctx := context.Background()
+	update, err := f.sb.db.QueuedUpdate(ctx, serial)
+	if err != nil {
+		t.Fatalf("queued update of %s: %v", serial, err)
+	}
+	ready, description, err := f.sb.queuedUpdateReady(ctx, update)
+	if err != nil || !ready {
+		t.Fatalf("queuedUpdateReady of %s = %v, %q, %v, want ready", serial, ready, description, err)
+	}
+	if claimed, err := f.sb.db.ClaimQueuedUpdate(ctx, update.ID, time.Now()); err != nil || !claimed {
+		t.Fatalf("claim queued update of %s = %v, %v", serial, claimed, err)
+	}
+	if err := f.sb.db.FinishPendingUpdate(ctx, update.ID, status, "test"); err != nil {
+		t.Fatalf("finish queued update of %s: %v", serial, err)
+	}
+	f.sb.finishQueuedActivation(ctx, *update.SourceTransactionID)
+}
+
+// assertActivation checks the state of the transaction, its version
+// transition and the version set of an activation
+func (f *testFleet) assertActivation(t *testing.T, tx int, state *types.TransactionState, transition types.VersionTransitionStatus, versionSet types.VersionState) {
+	t.Helper()
+	ctx := context.Background()
+	transaction, err := f.sb.db.GetTransaction(ctx, tx)
+	if err != nil {
+		t.Fatalf("get transaction: %v", err)
+	}
+	switch {
+	case state == nil && transaction.CompletedAt != nil:
+		t.Errorf("transaction completed in state %v, want open", *transaction.State)
+	case state != nil && (transaction.State == nil || *transaction.State != *state):
+		t.Errorf("transaction state = %v, want %s", transaction.State, *state)
+	}
+	got, err := f.sb.db.GetVersionTransitionByTransaction(ctx, tx)
+	if err != nil {
+		t.Fatalf("get version transition: %v", err)
+	}
+	if got.Status != transition {
+		t.Errorf("version transition status = %s, want %s", got.Status, transition)
+	}
+	vs, err := f.sb.db.GetVersionSetByID(ctx, f.versionSetID)
+	if err != nil {
+		t.Fatalf("get version set: %v", err)
+	}
+	if vs.State != versionSet {
+		t.Errorf("version set state = %s, want %s", vs.State, versionSet)
+	}
+}
+
+func TestActivateFleetAllOffline(t *testing.T) {
+	applied, failed := types.TransactionStateApplied, types.TransactionStateError
+
+	t.Run("delivered", func(t *testing.T) {
+		f := newTestFleet(t)
+		tx, queued, err := f.sb.activateFleet(context.Background(), f.versionSetID, "", activationOptions{})
+		if err != nil || !queued {
+			t.Fatalf("activateFleet = %d, %v, %v, want a queued activation", tx, queued, err)
+		}
+		f.assertActivation(t, tx, nil, types.VersionTransitionPending, types.VERSION_STATE_PENDING_DEPLOYMENT)
+
+		f.finishDelivery(t, "gw-1", types.PendingUpdateDelivered)
+		f.assertActivation(t, tx, nil, types.VersionTransitionPending, types.VERSION_STATE_PENDING_DEPLOYMENT)
+
+		f.finishDelivery(t, "gw-2", types.PendingUpdateDelivered)
+		f.assertActivation(t, tx, &applied, types.VersionTransitionActive, types.VERSION_STATE_ACTIVE)
+	})
+
+	t.Run("delivery failed", func(t *testing.T) {
+		f := newTestFleet(t)
+		tx, queued, err := f.sb.activateFleet(context.Background(), f.versionSetID, "", activationOptions{})
+		if err != nil || !queued {
+			t.Fatalf("activateFleet = %d, %v, %v, want a queued activation", tx, queued, err)
+		}
+
+		f.finishDelivery(t, "gw-1", types.PendingUpdateFailed)
+		f.assertActivation(t, tx, &failed, types.VersionTransitionFailed, types.VERSION_STATE_PENDING_DEPLOYMENT)
+		if _, err := f.sb.db.QueuedUpdate(context.Background(), "gw-2"); !db.IsNoRows(err) {
+			t.Errorf("queued update of gw-2 = %v, want superseded", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		f := newTestFleet(t)
+		tx, queued, err := f.sb.activateFleet(context.Background(), f.versionSetID, "", activationOptions{})
+		if err != nil || !queued {
+			t.Fatalf("activateFleet = %d, %v, %v, want a queued activation", tx, queued, err)
+		}
+
+		f.sb.expireQueuedUpdates(context.Background())
+		f.sb.finishQueuedActivations(context.Background())
+		f.assertActivation(t, tx, nil, types.VersionTransitionPending, types.VERSION_STATE_PENDING_DEPLOYMENT)
+
+		if _, err := f.sb.db.ExpireQueuedUpdates(context.Background(), time.Now().Add(2*types.DefaultQueueExpiry)); err != nil {
+			t.Fatalf("expire queued updates: %v", err)
+		}
+		f.sb.finishQueuedActivations(context.Background())
+		f.assertActivation(t, tx, &failed, types.VersionTransitionFailed, types.VERSION_STATE_PENDING_DEPLOYMENT)
+	})
+}
@@ -0,0 +1,80 @@
+package southbound
+
+import (
+	"context"
+	"time"
+
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// presenceQueueSize is the number of broker events buffered for RunPresence
+const presenceQueueSize = 1024
+
+// NewPresenceQueue returns the channel the broker reports presence events to
+func NewPresenceQueue() chan types.PresenceEvent {
+	return make(chan types.PresenceEvent, presenceQueueSize)
+}
+
+// RunPresence stores the connects and disconnects reported by the broker
+// until ctx is cancelled. The controller's own MQTT clients are recorded as
+// well, they have no node and do not show up in ListNodeStatus.
+func (sb *SouthboundService) RunPresence(ctx context.Context, events <-chan types.PresenceEvent) {
+	if err := sb.db.ResetPresence(ctx, "controller restarted"); err != nil {
+		log.Error().Err(err).Msg("Failed to reset node presence")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			if event.Connected {
+				log.Debug().Msgf("Client %s connected from %s", event.ClientID, event.RemoteAddr)
+			} else {
+				log.Debug().Msgf("Client %s disconnected: %s", event.ClientID, event.Reason)
+			}
+			dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			if err := sb.db.RecordPresence(dbCtx, event); err != nil {
+				log.Error().Err(err).Msg("Failed to record node presence")
+			}
+			cancel()
+		}
+	}
+}
+
+func (sb *SouthboundService) ListNodeStatus(ctx context.Context, req *grpc_scale.ListNodeStatusRequest) (*grpc_scale.ListNodeStatusResponse, error) {
+	statuses, err := sb.db.ListNodeStatus(ctx, req.GetSerialNumber())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list node status: %v", err)
+	}
+	if req.GetSerialNumber() != "" && len(statuses) == 0 {
+		return nil, status.Errorf(codes.NotFound, "node %s not found", req.GetSerialNumber())
+	}
+
+	resp := &grpc_scale.ListNodeStatusResponse{}
+	for _, st := range statuses {
+		resp.Nodes = append(resp.Nodes, &grpc_scale.NodeStatus{
+			SerialNumber:     st.SerialNumber,
+			Online:           st.Online,
+			Connected:        st.Connected,
+			ConnectedAt:      optionalTimestamp(st.ConnectedAt),
+			DisconnectedAt:   optionalTimestamp(st.DisconnectedAt),
+			RemoteAddr:       st.RemoteAddr,
+			DisconnectReason: st.DisconnectReason,
+			LastSeen:         optionalTimestamp(st.LastSeen),
+		})
+	}
+	return resp, nil
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -2,8 +2,6 @@ package southbound
 
 import (
 	"context"
-	"fmt"
-	"strconv"
 	"strings"
 
 	empty "github.com/golang/protobuf/ptypes/empty"
@@ -17,11 +15,14 @@ import (
 )
 
 func (sb *SouthboundService) CreateProxy(ctx context.Context, req *grpc_southbound.CreateProxyRequest) (*grpc_southbound.ProxyResponse, error) {
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
 	versionSetUUID, err := uuid.FromString(req.VersionSetId)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
 	}
-
 	// Convert ProxyType enum to lowercase string
 	proxyTypeStr := strings.ToLower(req.ProxyType.String())
 	log.Debug().Msgf("proxyTypeStr: %s", proxyTypeStr)
@@ -35,12 +36,12 @@ func (sb *SouthboundService) CreateProxy(ctx context.Context, req *grpc_southbou
 		ServerEndpointAddr: req.ServerEndpointAddr,
 		ClientEndpointAddr: req.ClientEndpointAddr,
 		VersionSetID:       versionSetUUID,
-		CreatedBy:          req.CreatedBy,
+		CreatedBy:          user,
 	}
 
 	createdProxy, err := sb.db.CreateProxy(ctx, proxy)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create proxy: %v", err)
+		return nil, draftError(err, "create proxy")
 	}
 
 	return &grpc_southbound.ProxyResponse{
@@ -163,10 +164,9 @@ func (sb *SouthboundService) UpdateProxy(ctx context.Context, req *grpc_southbou
 
 	switch query := req.GetQuery().(type) {
 	case *grpc_southbound.UpdateProxyRequest_Id:
-		where_string := fmt.Sprintf("id = %d", query.Id)
-		err = sb.db.UpdateWhere(ctx, "proxies", updates, where_string)
+		err = sb.db.UpdateDraftWhere(ctx, "proxies", updates, nil, "id = $1", query.Id)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to update proxy: %v", err)
+			return nil, draftError(err, "update proxy")
 		}
 	case *grpc_southbound.UpdateProxyRequest_NameQuery:
 		versionSetID, err := uuid.FromString(query.NameQuery.GetVersionSetId())
@@ -174,10 +174,9 @@ func (sb *SouthboundService) UpdateProxy(ctx context.Context, req *grpc_southbou
 			return nil, status.Errorf(codes.Internal, "uuid conversion failed: %v", err)
 		}
 		proxy_name := query.NameQuery.GetName()
-		where_string := fmt.Sprintf("name = '%s' AND version_set_id = '%s'", proxy_name, versionSetID.String())
-		err = sb.db.UpdateWhere(ctx, "proxies", updates, where_string)
+		err = sb.db.UpdateDraftWhere(ctx, "proxies", updates, nil, "name = $1 AND version_set_id = $2", proxy_name, versionSetID)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to update proxy: %v", err)
+			return nil, draftError(err, "update proxy")
 		}
 	}
 
@@ -185,9 +184,9 @@ func (sb *SouthboundService) UpdateProxy(ctx context.Context, req *grpc_southbou
 }
 
 func (sb *SouthboundService) DeleteProxy(ctx context.Context, req *grpc_southbound.DeleteProxyRequest) (*empty.Empty, error) {
-	err := sb.db.Delete(ctx, "proxies", "id", strconv.Itoa(int(req.Id)))
+	err := sb.db.DeleteDraftWhere(ctx, "proxies", "id = $1", req.Id)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to delete proxy: %v", err)
+		return nil, draftError(err, "delete proxy")
 	}
 
 	return &empty.Empty{}, nil
@@ -0,0 +1,186 @@
+package southbound
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	"github.com/rs/zerolog/log"
+)
+
+// reconcileBackoff delays the next update of a node whose update failed
+type reconcileBackoff struct {
+	failures int
+	next     time.Time
+}
+
+// RunReconciler pushes the active version set to online nodes which did not
+// apply it, because they were offline during its activation or their update
+// failed, and to nodes which drifted from it. Nodes the activation excluded by
+// their agent version are left alone. It runs until ctx is cancelled.
+func (sb *SouthboundService) RunReconciler(ctx context.Context, cfg types.ReconcilerConfig) {
+	if !cfg.Enabled {
+		log.Info().Msg("Reconciler is disabled")
+		return
+	}
+
+	backoff := make(map[string]*reconcileBackoff)
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		sb.reconcile(ctx, cfg, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcile updates up to cfg.MaxNodesPerRun nodes, one after another
+func (sb *SouthboundService) reconcile(ctx context.Context, cfg types.ReconcilerConfig, backoff map[string]*reconcileBackoff) {
+	versionSetID, err := sb.activeVersionSetID(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Reconciler failed to get the active version set")
+		return
+	}
+	if versionSetID == uuid.Nil {
+		return
+	}
+
+	// a running fleet or group update, rollouts included, may have moved nodes
+	// to another version set on purpose
+	open, err := sb.db.ListOpenTransactions(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Reconciler failed to list open transactions")
+		return
+	}
+	for _, t := range open {
+		if t.Type != types.TransactionTypeNodeUpdate {
+			log.Debug().Msgf("Reconciler waits for transaction %d", t.ID)
+			return
+		}
+	}
+
+	candidates, err := sb.db.ReconcileCandidates(ctx, versionSetID)
+	if err != nil {
+		log.Error().Err(err).Msg("Reconciler failed to get the nodes to update")
+		return
+	}
+	candidates, err = sb.filterReconcileCandidates(ctx, versionSetID, candidates)
+	if err != nil {
+		log.Error().Err(err).Msg("Reconciler failed to filter the nodes to update")
+		return
+	}
+
+	// nodes which are offline or in sync again start over without back-off
+	pending := make(map[string]bool, len(candidates))
+	for _, c := range candidates {
+		pending[c.SerialNumber] = true
+	}
+	for serial := range backoff {
+		if !pending[serial] {
+			delete(backoff, serial)
+		}
+	}
+
+	updated := 0
+	for _, c := range candidates {
+		if updated >= cfg.MaxNodesPerRun || ctx.Err() != nil {
+			return
+		}
+		if b, ok := backoff[c.SerialNumber]; ok && time.Now().Before(b.next) {
+			continue
+		}
+		if err := sb.checkMaintenanceWindows(ctx, versionSetID, []string{c.SerialNumber}, false); err != nil {
+			log.Debug().Msgf("Reconciler skips node %s: %v", c.SerialNumber, err)
+			continue
+		}
+
+		state, err := sb.reconcileNode(ctx, versionSetID, c)
+		if err != nil {
+			log.Error().Err(err).Msgf("Reconciler failed to update node %s", c.SerialNumber)
+		}
+		if state == "" {
+			// nothing was pushed
+			continue
+		}
+		updated++
+		if state == types.TransactionStateApplied {
+			log.Info().Msgf("Reconciler updated %s node %s to version set %s", c.Reason, c.SerialNumber, versionSetID)
+			delete(backoff, c.SerialNumber)
+			continue
+		}
+
+		b, ok := backoff[c.SerialNumber]
+		if !ok {
+			b = &reconcileBackoff{}
+			backoff[c.SerialNumber] = b
+		}
+		b.failures++
+		delay := cfg.Backoff << (b.failures - 1)
+		if delay > cfg.MaxBackoff || delay <= 0 {
+			delay = cfg.MaxBackoff
+		}
+		b.next = time.Now().Add(delay)
+		log.Warn().Msgf("Reconciler failed to update node %s %d times, retrying in %s", c.SerialNumber, b.failures, delay)
+	}
+}
+
+// filterReconcileCandidates leaves out the candidates the activation of
+// versionSetID excluded by their agent version. They stay on their version set
+// until it is activated again for them.
+func (sb *SouthboundService) filterReconcileCandidates(ctx context.Context, versionSetID uuid.UUID, candidates []*types.ReconcileCandidate) ([]*types.ReconcileCandidate, error) {
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+	active := string(types.VersionTransitionActive)
+	transitions, err := sb.db.ListVersionTransitions(ctx, types.HistoryFilter{VersionSetID: &versionSetID, State: &active, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	var metadata transitionMetadata
+	if len(transitions) > 0 && len(transitions[0].Metadata) > 0 {
+		if err := json.Unmarshal(transitions[0].Metadata, &metadata); err != nil {
+			return nil, fmt.Errorf("invalid metadata of version transition %d: %w", transitions[0].ID, err)
+		}
+	}
+	if metadata.MinAgentVersion == "" {
+		return candidates, nil
+	}
+
+	versions, err := sb.db.AgentVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]*types.ReconcileCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if meetsAgentVersion(versions, c.SerialNumber, metadata.MinAgentVersion) {
+			kept = append(kept, c)
+		}
+	}
+	return kept, nil
+}
+
+// reconcileNode runs the UpdateNode flow for a node in a transaction of its
+// own and returns the final state, an empty state if nothing was pushed
+func (sb *SouthboundService) reconcileNode(ctx context.Context, versionSetID uuid.UUID, c *types.ReconcileCandidate) (types.TransactionState, error) {
+	nodeUpdate, err := sb.db.NodeUpdate(c.SerialNumber, versionSetID.String(), ctx)
+	if err != nil {
+		return "", err
+	}
+	if nodeUpdate == nil {
+		log.Debug().Msgf("Node %s has no config in version set %s", c.SerialNumber, versionSetID)
+		return "", nil
+	}
+
+	description := fmt.Sprintf("Reconcile %s node %s to VersionSet %s", c.Reason, c.SerialNumber, versionSetID)
+	tx, err := sb.db.CreateTransaction(ctx, description, types.TransactionTypeNodeUpdate)
+	if err != nil {
+		return "", err
+	}
+	return sb.runNodeActivation(tx, nodeUpdate, versionSetID), nil
+}
@@ -0,0 +1,63 @@
+package southbound
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+func TestFilterReconcileCandidates(t *testing.T) {
+	tests := []struct {
+		name            string
+		minAgentVersion string
+		want            []string
+	}{
+		{name: "without filter", want: []string{"gw-1", "gw-2", "gw-3"}},
+		{name: "min agent version", minAgentVersion: "2.0", want: []string{"gw-1"}},
+		{name: "min agent version of all nodes", minAgentVersion: "1.0", want: []string{"gw-1", "gw-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newTestFleet(t)
+			// gw-3 never reported an agent version
+			for serial, version := range map[string]string{"gw-1": "2.1", "gw-2": "1.4"} {
+				if err := f.sb.db.RecordHello(ctx, serial, time.Now(), &types.NodeInventory{AgentVersion: version}); err != nil {
+					t.Fatalf("record hello of %s: %v", serial, err)
+				}
+			}
+			tx, err := f.sb.db.CreateTransaction(ctx, "activation", types.TransactionTypeVersionUpdate)
+			if err != nil {
+				t.Fatalf("create transaction: %v", err)
+			}
+			transition, _, err := f.sb.beginVersionTransition(ctx, tx, f.versionSetID, tt.minAgentVersion)
+			if err != nil {
+				t.Fatalf("begin version transition: %v", err)
+			}
+			if err := f.sb.db.UpdateVersionTransitionStatus(ctx, transition, string(types.VersionTransitionActive), nil); err != nil {
+				t.Fatalf("activate version transition: %v", err)
+			}
+
+			candidates := []*types.ReconcileCandidate{
+				{SerialNumber: "gw-1", Reason: types.ReconcileOutdated},
+				{SerialNumber: "gw-2", Reason: types.ReconcileOutdated},
+				{SerialNumber: "gw-3", Reason: types.ReconcileDrifted},
+			}
+			kept, err := f.sb.filterReconcileCandidates(ctx, f.versionSetID, candidates)
+			if err != nil {
+				t.Fatalf("filterReconcileCandidates: %v", err)
+			}
+			var got []string
+			for _, c := range kept {
+				got = append(got, c.SerialNumber)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterReconcileCandidates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
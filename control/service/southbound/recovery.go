@@ -0,0 +1,345 @@
+package southbound
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoveryWindow is how long the nodes of an interrupted transaction are
+// given to answer the request for their state
+const recoveryWindow = 30 * time.Second
+
+// recoveryRetry is the pause before the nodes which did not answer are asked
+// again
+const recoveryRetry = time.Minute
+
+// recoveryAttempts bounds how often the nodes of a transaction are asked for
+// their state. Nodes silent after the last attempt fail, so that the
+// transaction ends and no longer blocks the reconciler and the fleet.
+const recoveryAttempts = 5
+
+// errNodesSilent is returned by recoverTransaction while nodes of the
+// transaction have not told their state
+var errNodesSilent = errors.New("nodes did not report their state")
+
+func getRecoveryClient(addr string) (grpc_scale.ControlPlaneRecoveryClient, *grpc.ClientConn, error) {
+	conn, err := dialController(addr)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not connect to control plane")
+		return nil, nil, status.Error(codes.Internal, "Failed to connect to control plane")
+	}
+
+	client := grpc_scale.NewControlPlaneRecoveryClient(conn)
+	return client, conn, nil
+}
+
+// RecoverTransactions finishes the transactions which a previous run of the
+// controller left open. Every transaction is reconciled with the states its
+// nodes reported, then:
+//   - a running rollout rolls back its unfinished nodes and resumes,
+//   - a halted rollout is left to ResumeRollout or AbortRollout,
+//   - a queued activation keeps waiting for its queued updates,
+//   - any other transaction is applied if all of its nodes applied the update
+//     and failed otherwise, which rolls back its version transition.
+func (sb *SouthboundService) RecoverTransactions(ctx context.Context) error {
+	transactions, err := sb.db.ListOpenTransactions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list open transactions: %w", err)
+	}
+
+	// updates queued for offline nodes outlive a restart, deliveries which
+	// were running are recovered like any other transaction
+	if n, err := sb.db.FailInterruptedDeliveries(ctx, sb.started); err != nil {
+		log.Error().Err(err).Msg("Failed to fail interrupted deliveries")
+	} else if n > 0 {
+		log.Info().Msgf("%d deliveries of queued updates were interrupted", n)
+	}
+	pending, err := sb.db.PendingTransactionIDs(ctx, sb.started)
+	if err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+		if transaction.CreatedAt.After(sb.started) || pending[transaction.ID] {
+			continue
+		}
+		log.Info().Msgf("Recovering interrupted transaction %d", transaction.ID)
+		if err := sb.recoverTransaction(ctx, transaction, 1); err != nil {
+			log.Warn().Err(err).Msgf("Transaction %d is not recovered yet, retrying in %s", transaction.ID, recoveryRetry)
+			go sb.retryRecovery(ctx, transaction)
+		}
+	}
+	return nil
+}
+
+// retryRecovery recovers transaction every recoveryRetry until it succeeds,
+// recoveryAttempts are made or ctx is done. The last attempt fails the nodes
+// which are still silent, it only returns an error if the database or the
+// control plane fail.
+func (sb *SouthboundService) retryRecovery(ctx context.Context, transaction *types.Transaction) {
+	ticker := time.NewTicker(recoveryRetry)
+	defer ticker.Stop()
+	for attempt := 2; attempt <= recoveryAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := sb.recoverTransaction(ctx, transaction, attempt)
+		if err == nil {
+			return
+		}
+		if attempt == recoveryAttempts {
+			log.Error().Err(err).Msgf("Transaction %d is not recovered after %d attempts, it is left open", transaction.ID, recoveryAttempts)
+			return
+		}
+		log.Warn().Err(err).Msgf("Transaction %d is not recovered yet, retrying in %s", transaction.ID, recoveryRetry)
+	}
+}
+
+// recoverTransaction makes the attempt-th attempt to recover transaction. It
+// returns errNodesSilent while nodes did not answer, unless it is the last
+// attempt: then the silent nodes fail like the nodes which were interrupted.
+func (sb *SouthboundService) recoverTransaction(ctx context.Context, transaction *types.Transaction, attempt int) error {
+	tx := transaction.ID
+
+	var rollout *types.Rollout
+	if len(transaction.Metadata) > 0 {
+		rollout = &types.Rollout{}
+		if err := json.Unmarshal(transaction.Metadata, rollout); err != nil || rollout.Waves == nil {
+			rollout = nil
+		}
+	}
+
+	if rollout != nil {
+		switch rollout.Status {
+		case types.RolloutHalted:
+			log.Info().Msgf("Rollout %d is halted, it is left for resume or abort", tx)
+			return nil
+		case types.RolloutCompleted, types.RolloutAborted:
+			// the controller stopped while finishing the rollout
+			return sb.endRollout(ctx, tx, rollout, rollout.Status, fmt.Sprintf("Rollout %s", rollout.Status))
+		}
+	}
+
+	// the queued updates of a queued activation outlive a restart, it ends
+	// when they finished
+	if queuedActivation(transaction) != nil {
+		sb.finishQueuedActivation(ctx, tx)
+		return nil
+	}
+
+	states, versionSetID, silent, err := sb.reconcileNodeStates(ctx, tx)
+	if err != nil {
+		return err
+	}
+	// a node which did not answer may still apply the update, its state is
+	// unknown rather than failed until the attempts are used up
+	if len(silent) > 0 && attempt < recoveryAttempts {
+		return fmt.Errorf("%w: %s", errNodesSilent, strings.Join(silent, ", "))
+	}
+
+	var unfinished, serials []string
+	allApplied := len(states) > 0
+	for serial, state := range states {
+		serials = append(serials, serial)
+		if state != types.TransactionStateApplied {
+			allApplied = false
+		}
+		if state != types.TransactionStateApplied && state != types.TransactionStateError {
+			unfinished = append(unfinished, serial)
+		}
+	}
+
+	sort.Strings(serials)
+	sort.Strings(unfinished)
+
+	// nodes still waiting for the controller and silent nodes are marked
+	// failed, both are rolled back below
+	for _, serial := range unfinished {
+		reason := "Interrupted by a controller restart"
+		if slices.Contains(silent, serial) {
+			reason = fmt.Sprintf("Did not report its state after a controller restart, asked %d times", recoveryAttempts)
+		}
+		if err := sb.logTransactionFailure(ctx, tx, serial, versionSetID, reason); err != nil {
+			log.Error().Err(err).Msg("Failed to log node transaction")
+		}
+	}
+
+	if rollout != nil {
+		// unfinished nodes are rolled back and pending again, the rollout retries them
+		if len(unfinished) > 0 {
+			if err := sb.sendSync(ctx, tx, unfinished, grpc_controlplane.UpdateState_UPDATE_ROLLBACK); err != nil {
+				return err
+			}
+		}
+		log.Info().Msgf("Resuming rollout %d after restart", tx)
+		sb.startRollout(tx, rollout)
+		return nil
+	}
+
+	state := types.TransactionStateError
+	description := "Interrupted by a controller restart, rolled back"
+	if allApplied {
+		// the nodes may still wait for the acknowledgement
+		if err := sb.sendSync(ctx, tx, serials, grpc_controlplane.UpdateState_UPDATE_ACKNOWLEDGED); err != nil {
+			return err
+		}
+		state = types.TransactionStateApplied
+		description = "Update completed, recovered after a controller restart"
+	} else if len(unfinished) == 0 {
+		// all nodes finished, at least one with an error, the control plane rolled back already
+		description = "Update failed, recovered after a controller restart"
+	} else {
+		// like a failed fleet update, all nodes of the transaction roll back
+		if err := sb.sendSync(ctx, tx, serials, grpc_controlplane.UpdateState_UPDATE_ROLLBACK); err != nil {
+			return err
+		}
+	}
+
+	if err := sb.completeTransaction(ctx, tx, state, description); err != nil {
+		return fmt.Errorf("failed to complete transaction: %w", err)
+	}
+	log.Info().Msgf("Transaction %d recovered as %s", tx, state)
+
+	if transaction.Type != types.TransactionTypeVersionUpdate {
+		return nil
+	}
+	transition, err := sb.db.GetVersionTransitionByTransaction(ctx, tx)
+	if err != nil {
+		return err
+	}
+	switch transition.Status {
+	case types.VersionTransitionPending:
+		sb.finishVersionTransition(ctx, tx, transition.ID, transition.FromVersionTransition, transition.ToVersionSetID, allApplied)
+	case types.VersionTransitionRollback:
+		// a failed rollback is not rolled back again
+		if allApplied {
+			sb.finishVersionTransition(ctx, tx, transition.ID, transition.FromVersionTransition, transition.ToVersionSetID, true)
+		} else {
+			if err := sb.db.UpdateVersionTransitionStatus(ctx, transition.ID, string(types.VersionTransitionFailed), nil); err != nil {
+				log.Error().Err(err).Msg("Failed to update rollback transition status")
+			}
+			sb.supersedeQueuedUpdates(ctx, tx, fmt.Sprintf("Rollback transition %d failed", transition.ID))
+		}
+	}
+	return nil
+}
+
+// reconcileNodeStates returns the last state of every node of transaction tx.
+// Nodes which have not reached applied or error yet are sent a state query
+// and given recoveryWindow to answer, their answers are added to the
+// transaction log. Nodes whose wire schema has no query are only waited for.
+// silent lists the nodes which did not answer.
+func (sb *SouthboundService) reconcileNodeStates(ctx context.Context, tx int) (states map[string]types.TransactionState, versionSetID uuid.UUID, silent []string, err error) {
+	latest, err := sb.db.GetLatestNodeStates(ctx, tx)
+	if err != nil {
+		return nil, uuid.Nil, nil, err
+	}
+
+	states = make(map[string]types.TransactionState, len(latest))
+	asked := make(map[string]bool)
+	for serial, entry := range latest {
+		states[serial] = entry.State
+		versionSetID = entry.VersionSetID
+		if entry.State != types.TransactionStateApplied && entry.State != types.TransactionStateError {
+			asked[serial] = true
+		}
+	}
+	if len(asked) == 0 {
+		return states, versionSetID, nil, nil
+	}
+
+	client, conn, err := getRecoveryClient(sb.addr)
+	if err != nil {
+		return nil, uuid.Nil, nil, err
+	}
+	defer conn.Close()
+
+	serials := make([]string, 0, len(asked))
+	for serial := range asked {
+		serials = append(serials, serial)
+	}
+	sort.Strings(serials)
+
+	// the control plane queries the nodes once the watch is in place, a node
+	// which reported before the restart reports again
+	watchCtx, cancel := context.WithTimeout(ctx, recoveryWindow)
+	defer cancel()
+	stream, err := client.WatchNodeStates(watchCtx, &grpc_scale.WatchNodeStatesRequest{
+		TxIds:              []int32{int32(tx)},
+		QuerySerialNumbers: serials,
+	})
+	if err != nil {
+		return nil, uuid.Nil, nil, fmt.Errorf("failed to watch node states: %w", err)
+	}
+
+	for len(asked) > 0 {
+		report, err := stream.Recv()
+		if status.Code(err) == codes.DeadlineExceeded {
+			// the recovery window elapsed
+			break
+		}
+		if err != nil {
+			return nil, uuid.Nil, nil, fmt.Errorf("failed to watch node states: %w", err)
+		}
+		state := transactionStateFromUpdate(grpc_controlplane.UpdateState(report.UpdateState))
+		if state == "" {
+			continue
+		}
+		states[report.SerialNumber] = state
+		delete(asked, report.SerialNumber)
+
+		err = sb.logNode(ctx, &types.NodeTransactionLog{
+			TransactionID: tx,
+			NodeSerial:    report.SerialNumber,
+			VersionSetID:  versionSetID,
+			State:         state,
+			Timestamp:     report.Timestamp.AsTime(),
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to log node transaction")
+		}
+	}
+
+	for _, serial := range serials {
+		if asked[serial] {
+			silent = append(silent, serial)
+		}
+	}
+	return states, versionSetID, silent, nil
+}
+
+// sendSync publishes updateState for transaction tx to the given nodes
+func (sb *SouthboundService) sendSync(ctx context.Context, tx int, serials []string, updateState grpc_controlplane.UpdateState) error {
+	client, conn, err := getRecoveryClient(sb.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = client.SendSync(ctx, &grpc_scale.SendSyncRequest{
+		TxId:          int32(tx),
+		SerialNumbers: serials,
+		UpdateState:   int32(updateState),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send %s for transaction %d: %w", updateState, tx, err)
+	}
+	return nil
+}
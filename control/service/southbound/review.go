@@ -0,0 +1,186 @@
+package southbound
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (sb *SouthboundService) SubmitVersionSet(ctx context.Context, req *grpc_scale.ReviewRequest) (*grpc_scale.Review, error) {
+	review, err := sb.reviewFromRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := sb.activeVersionSetID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := sb.db.DiffVersionSets(ctx, active, review.VersionSetID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to diff version set: %v", err)
+	}
+	// an empty diff is still recorded as reviewed
+	review.Diff = append([]*types.NodeDiff{}, diff...)
+
+	if err := sb.db.SubmitVersionSet(ctx, review); err != nil {
+		return nil, reviewError(err)
+	}
+	log.Info().Msgf("Version set %s submitted for review by %s", review.VersionSetID, review.User)
+	return reviewToProto(review), nil
+}
+
+func (sb *SouthboundService) ApproveVersionSet(ctx context.Context, req *grpc_scale.ReviewRequest) (*grpc_scale.Review, error) {
+	review, err := sb.reviewFromRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := sb.db.ApproveVersionSet(ctx, review); err != nil {
+		return nil, reviewError(err)
+	}
+	log.Info().Msgf("Version set %s approved by %s", review.VersionSetID, review.User)
+	return reviewToProto(review), nil
+}
+
+func (sb *SouthboundService) RejectVersionSet(ctx context.Context, req *grpc_scale.ReviewRequest) (*grpc_scale.Review, error) {
+	review, err := sb.reviewFromRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if review.Comment == "" {
+		return nil, status.Error(codes.InvalidArgument, "a rejection needs a comment")
+	}
+	if err := sb.db.RejectVersionSet(ctx, review); err != nil {
+		return nil, reviewError(err)
+	}
+	log.Info().Msgf("Version set %s rejected by %s", review.VersionSetID, review.User)
+	return reviewToProto(review), nil
+}
+
+func (sb *SouthboundService) CommentVersionSet(ctx context.Context, req *grpc_scale.ReviewRequest) (*grpc_scale.Review, error) {
+	review, err := sb.reviewFromRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if review.Comment == "" {
+		return nil, status.Error(codes.InvalidArgument, "comment is required")
+	}
+	if err := sb.db.CommentVersionSet(ctx, review); err != nil {
+		return nil, reviewError(err)
+	}
+	return reviewToProto(review), nil
+}
+
+func (sb *SouthboundService) ListReviews(ctx context.Context, req *grpc_scale.ListReviewsRequest) (*grpc_scale.ListReviewsResponse, error) {
+	filter := types.ReviewFilter{
+		User:  req.GetUser(),
+		Limit: int(req.GetLimit()),
+	}
+	if req.VersionSetId != nil {
+		id, err := uuid.FromString(req.GetVersionSetId())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
+		}
+		filter.VersionSetID = &id
+	}
+	if req.GetSince() != nil {
+		since := req.GetSince().AsTime()
+		filter.Since = &since
+	}
+	if req.GetUntil() != nil {
+		until := req.GetUntil().AsTime()
+		filter.Until = &until
+	}
+
+	reviews, err := sb.db.ListReviews(ctx, filter)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list reviews: %v", err)
+	}
+	resp := &grpc_scale.ListReviewsResponse{}
+	for _, r := range reviews {
+		resp.Reviews = append(resp.Reviews, reviewToProto(r))
+	}
+	return resp, nil
+}
+
+// requireApproval refuses the activation of a version set whose latest
+// submission was not approved by a second user
+func (sb *SouthboundService) requireApproval(ctx context.Context, id uuid.UUID) error {
+	submission, approval, err := sb.db.VersionSetApproval(ctx, id)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get approval: %v", err)
+	}
+	if submission == nil {
+		return status.Errorf(codes.FailedPrecondition, "version set %s was not submitted for review", id)
+	}
+	if approval == nil {
+		return status.Errorf(codes.FailedPrecondition, "version set %s submitted by %s awaits approval by another user", id, submission.User)
+	}
+	return nil
+}
+
+// activeVersionSetID returns the active version set, uuid.Nil if there is none
+func (sb *SouthboundService) activeVersionSetID(ctx context.Context) (uuid.UUID, error) {
+	versionSets, err := sb.db.ListVersionSets(ctx)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.Internal, "failed to list version sets: %v", err)
+	}
+	for _, vs := range versionSets {
+		if vs.State == types.VERSION_STATE_ACTIVE {
+			return vs.ID, nil
+		}
+	}
+	return uuid.Nil, nil
+}
+
+// reviewFromRequest returns the review of req by the authenticated user of
+// the call, the four-eyes approval relies on who that user is
+func (sb *SouthboundService) reviewFromRequest(ctx context.Context, req *grpc_scale.ReviewRequest) (*types.Review, error) {
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.FromString(req.GetVersionSetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
+	}
+	return &types.Review{
+		VersionSetID: id,
+		User:         user,
+		Comment:      req.GetComment(),
+	}, nil
+}
+
+func reviewError(err error) error {
+	switch {
+	case db.IsNoRows(err):
+		return status.Error(codes.NotFound, "version set not found")
+	case errors.Is(err, db.ErrNotDraft), errors.Is(err, db.ErrNotPending),
+		errors.Is(err, db.ErrAlreadyApproved):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, db.ErrSelfApproval):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "failed to record review: %v", err)
+	}
+}
+
+func reviewToProto(r *types.Review) *grpc_scale.Review {
+	return &grpc_scale.Review{
+		Id:           int32(r.ID),
+		VersionSetId: r.VersionSetID.String(),
+		Action:       string(r.Action),
+		User:         r.User,
+		Comment:      r.Comment,
+		Diff:         nodeDiffsToProto(r.Diff),
+		CreatedAt:    timestamppb.New(r.CreatedAt),
+	}
+}
@@ -0,0 +1,130 @@
+package southbound
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	"github.com/rs/zerolog/log"
+)
+
+// rollbackTimeout bounds a rollback, it matches the timeout of ActivateFleet
+const rollbackTimeout = 5 * time.Minute
+
+// rollbackMetadata is stored in the metadata of a rollback version transition
+type rollbackMetadata struct {
+	RollbackOf int `json:"rollback_of"`
+}
+
+// rollbackVersionTransition re-establishes the version set of transition
+// previousID after transition failedID could not be applied to the fleet.
+// The control plane already told the nodes to roll back; the previous version
+// set is pushed again under its own transaction and version transition, so
+// that the database reflects what the gateways are running afterwards. Nodes
+// which are offline get the rollback queued until their next hello. A node
+// whose rollback could not be queued fails the rollback transition, which
+// leaves it to the reconciler.
+func (sb *SouthboundService) rollbackVersionTransition(failedID int, previousID int) {
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+
+	previous, err := sb.db.GetVersionTransitionByID(ctx, strconv.Itoa(previousID))
+	if err != nil {
+		log.Error().Err(err).Msgf("Rollback of version transition %d: failed to get previous transition %d", failedID, previousID)
+		return
+	}
+	versionSetID := previous.ToVersionSetID
+
+	fleetUpdate, err := sb.db.GetVersionFleetUpdate(ctx, versionSetID.String())
+	if err != nil {
+		log.Error().Err(err).Msgf("Rollback of version transition %d: failed to get fleet update", failedID)
+		return
+	}
+	if fleetUpdate == nil || len(fleetUpdate.NodeUpdateItems) == 0 {
+		log.Warn().Msgf("Rollback of version transition %d: version set %s has no nodes, keeping transition %d active",
+			failedID, versionSetID, previousID)
+		return
+	}
+
+	// offline nodes get the rollback on their next hello like any other update
+	var offline []*grpc_controlplane.NodeUpdateItem
+	fleetUpdate.NodeUpdateItems, offline, err = sb.splitOffline(ctx, versionSetID, fleetUpdate.NodeUpdateItems)
+	if err != nil {
+		log.Error().Err(err).Msgf("Rollback of version transition %d: failed to get online nodes", failedID)
+		return
+	}
+
+	description := fmt.Sprintf("Rollback to version set %s after failed version transition %d", versionSetID, failedID)
+	tx, err := sb.db.CreateTransaction(ctx, description, types.TransactionTypeVersionUpdate)
+	if err != nil {
+		log.Error().Err(err).Msgf("Rollback of version transition %d: failed to create transaction", failedID)
+		return
+	}
+
+	metadata, err := json.Marshal(rollbackMetadata{RollbackOf: failedID})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal rollback metadata")
+		return
+	}
+	rollbackID, err := sb.db.CreateVersionTransition(ctx, &types.VersionTransition{
+		FromVersionTransition: &previousID,
+		ToVersionSetID:        versionSetID,
+		Status:                types.VersionTransitionRollback,
+		CreatedBy:             systemUser,
+		TransactionID:         tx,
+		StartedAt:             time.Now(),
+		Metadata:              metadata,
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("Rollback of version transition %d: failed to create version transition", failedID)
+		return
+	}
+
+	queued := 0
+	for _, item := range offline {
+		if _, err := sb.enqueueNodeUpdate(ctx, item, versionSetID, &tx); err != nil {
+			log.Error().Err(err).Msgf("Rollback of version transition %d: failed to queue rollback of offline node %s", failedID, item.SerialNumber)
+			continue
+		}
+		queued++
+	}
+
+	log.Info().Msgf("Rolling back version transition %d to version set %s with transaction %d, %d of %d offline nodes queued",
+		failedID, versionSetID, tx, queued, len(offline))
+	result := fleetResult{state: types.TransactionStateApplied, description: fmt.Sprintf("Rollback queued for %d offline nodes", queued)}
+	if len(fleetUpdate.NodeUpdateItems) > 0 {
+		result, err = sb.runFleetUpdate(ctx, fleetUpdate, tx, versionSetID, nil)
+	}
+	if err == nil && queued < len(offline) {
+		result.state = types.TransactionStateError
+		result.description = fmt.Sprintf("Failed to queue the rollback of %d offline nodes", len(offline)-queued)
+	}
+
+	dbCtx := context.WithoutCancel(ctx)
+	if updateErr := sb.completeTransaction(dbCtx, tx, result.state, result.description); updateErr != nil {
+		log.Error().Err(updateErr).Msg("Failed to update rollback transaction")
+	}
+
+	if err != nil || result.state != types.TransactionStateApplied {
+		log.Error().Err(err).Msgf("Rollback of version transition %d failed: %s", failedID, result.description)
+		if updateErr := sb.db.UpdateVersionTransitionStatus(dbCtx, rollbackID, string(types.VersionTransitionFailed), nil); updateErr != nil {
+			log.Error().Err(updateErr).Msg("Failed to update rollback transition status")
+		}
+		sb.supersedeQueuedUpdates(dbCtx, tx, fmt.Sprintf("Rollback transition %d failed", rollbackID))
+		return
+	}
+
+	// the rollback transition supersedes the previous one
+	disabled_at := time.Now()
+	if updateErr := sb.db.UpdateVersionTransitionStatus(dbCtx, previousID, string(types.VersionTransitionDisabled), &disabled_at); updateErr != nil {
+		log.Error().Err(updateErr).Msg("Failed to disable previous version transition")
+	}
+	if updateErr := sb.db.UpdateVersionTransitionStatus(dbCtx, rollbackID, string(types.VersionTransitionActive), nil); updateErr != nil {
+		log.Error().Err(updateErr).Msg("Failed to update rollback transition status")
+	}
+	log.Info().Msgf("Rollback of version transition %d completed, version set %s is active again", failedID, versionSetID)
+}
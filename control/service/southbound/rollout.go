@@ -0,0 +1,592 @@
+package southbound
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// waveTimeout bounds a single wave of a rollout, it matches the timeout of ActivateFleet
+const waveTimeout = 5 * time.Minute
+
+// runningRollout is a rollout executed by this process
+type runningRollout struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartRollout activates a version set on the fleet, or a group of it, in waves
+// as described by the rollout policy. The rollout runs in the background, its
+// progress is available through GetRollout.
+func (sb *SouthboundService) StartRollout(ctx context.Context, req *grpc_scale.StartRolloutRequest) (*grpc_scale.RolloutResponse, error) {
+	if req.VersionSetId == "" {
+		return nil, status.Error(codes.InvalidArgument, "VersionSetId is required")
+	}
+	versionSetID, err := uuid.FromString(req.VersionSetId)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to parse version set id")
+		return nil, status.Error(codes.InvalidArgument, "Invalid VersionSetId format")
+	}
+	policy, err := rolloutPolicyFromProto(req.Policy)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := sb.checkActivation(ctx, versionSetID, req.GetForce()); err != nil {
+		return nil, err
+	}
+
+	// offline nodes are part of the plan, their update is queued in their wave
+	fleetUpdate, err := sb.fullFleetUpdate(ctx, versionSetID, req.GetGroupName())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get fleet update")
+		return nil, status.Error(codes.Internal, "Failed to get fleet update")
+	}
+	if fleetUpdate == nil || len(fleetUpdate.NodeUpdateItems) == 0 {
+		return nil, status.Error(codes.NotFound, "No nodes found for update")
+	}
+	fleetUpdate.NodeUpdateItems, err = sb.filterByAgentVersion(ctx, fleetUpdate.NodeUpdateItems, req.GetMinAgentVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	serials := make([]string, 0, len(fleetUpdate.NodeUpdateItems))
+	for _, item := range fleetUpdate.NodeUpdateItems {
+		serials = append(serials, item.SerialNumber)
+	}
+	if err := sb.checkMaintenanceWindows(ctx, versionSetID, serials, req.GetIgnoreWindow()); err != nil {
+		return nil, err
+	}
+	if err := sb.prepareActivation(ctx, versionSetID); err != nil {
+		return nil, err
+	}
+	rollout := &types.Rollout{
+		VersionSetID: versionSetID,
+		Policy:       policy,
+		Status:       types.RolloutRunning,
+		Waves:        planWaves(serials, policy),
+	}
+
+	var description string
+	var transactionType types.TransactionType
+	if req.GetGroupName() != "" {
+		group := req.GetGroupName()
+		rollout.GroupName = &group
+		description = fmt.Sprintf("Staged Group Update for %s in VersionSet %s", group, req.VersionSetId)
+		transactionType = types.TransactionTypeGroupUpdate
+	} else {
+		description = fmt.Sprintf("Staged Version Update to %s", req.VersionSetId)
+		transactionType = types.TransactionTypeVersionUpdate
+	}
+
+	tx, err := sb.db.CreateTransaction(ctx, description, transactionType)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create transaction")
+		return nil, status.Error(codes.Internal, "Failed to create transaction")
+	}
+
+	if transactionType == types.TransactionTypeVersionUpdate {
+		transitionID, fromVersionTransition, err := sb.beginVersionTransition(ctx, tx, versionSetID, req.GetMinAgentVersion())
+		if err != nil {
+			return nil, status.Error(codes.Internal, "Failed to create version transition")
+		}
+		rollout.VersionTransitionID = &transitionID
+		rollout.FromVersionTransition = fromVersionTransition
+	}
+
+	if err := sb.saveRollout(ctx, tx, rollout); err != nil {
+		return nil, status.Error(codes.Internal, "Failed to store rollout")
+	}
+
+	log.Info().Msgf("Starting rollout %d of version set %s in %d waves", tx, versionSetID, len(rollout.Waves))
+	sb.startRollout(tx, rollout)
+	return sb.rolloutResponse(ctx, tx, rollout)
+}
+
+// ResumeRollout continues a halted or interrupted rollout with the first wave
+// which has not been applied completely.
+func (sb *SouthboundService) ResumeRollout(ctx context.Context, req *grpc_scale.ResumeRolloutRequest) (*grpc_scale.RolloutResponse, error) {
+	tx := int(req.TxId)
+	rollout, err := sb.loadRollout(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if sb.isRolloutRunning(tx) {
+		return nil, status.Errorf(codes.FailedPrecondition, "rollout %d is running", tx)
+	}
+	if rollout.Status != types.RolloutHalted && rollout.Status != types.RolloutRunning {
+		return nil, status.Errorf(codes.FailedPrecondition, "rollout %d is %s", tx, rollout.Status)
+	}
+
+	if req.FailureThreshold != nil {
+		if req.GetFailureThreshold() < 0 {
+			return nil, status.Error(codes.InvalidArgument, "failure threshold must not be negative")
+		}
+		rollout.Policy.FailureThreshold = int(req.GetFailureThreshold())
+	}
+	if len(rollout.Failed) > rollout.Policy.FailureThreshold {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"%d nodes failed, raise the failure threshold of %d to resume", len(rollout.Failed), rollout.Policy.FailureThreshold)
+	}
+
+	rollout.Status = types.RolloutRunning
+	if err := sb.saveRollout(ctx, tx, rollout); err != nil {
+		return nil, status.Error(codes.Internal, "Failed to store rollout")
+	}
+
+	log.Info().Msgf("Resuming rollout %d", tx)
+	sb.startRollout(tx, rollout)
+	return sb.rolloutResponse(ctx, tx, rollout)
+}
+
+// AbortRollout stops a rollout. A version update is marked failed and rolled
+// back to the previously active version set.
+func (sb *SouthboundService) AbortRollout(ctx context.Context, req *grpc_scale.AbortRolloutRequest) (*grpc_scale.RolloutResponse, error) {
+	tx := int(req.TxId)
+
+	sb.rolloutsMu.Lock()
+	running, ok := sb.rollouts[tx]
+	sb.rolloutsMu.Unlock()
+	if ok {
+		// the rollout finishes itself as aborted once the current wave returned
+		running.cancel()
+		select {
+		case <-running.done:
+		case <-ctx.Done():
+			return nil, status.Error(codes.DeadlineExceeded, "Timed out waiting for the rollout to stop")
+		}
+		rollout, err := sb.loadRollout(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		return sb.rolloutResponse(ctx, tx, rollout)
+	}
+
+	rollout, err := sb.loadRollout(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if rollout.Status != types.RolloutHalted && rollout.Status != types.RolloutRunning {
+		return nil, status.Errorf(codes.FailedPrecondition, "rollout %d is %s", tx, rollout.Status)
+	}
+
+	if err := sb.endRollout(ctx, tx, rollout, types.RolloutAborted, "Rollout aborted"); err != nil {
+		return nil, status.Error(codes.Internal, "Failed to store rollout")
+	}
+	return sb.rolloutResponse(ctx, tx, rollout)
+}
+
+// GetRollout returns the plan and the progress of a rollout
+func (sb *SouthboundService) GetRollout(ctx context.Context, req *grpc_scale.GetRolloutRequest) (*grpc_scale.RolloutResponse, error) {
+	rollout, err := sb.loadRollout(ctx, int(req.TxId))
+	if err != nil {
+		return nil, err
+	}
+	return sb.rolloutResponse(ctx, int(req.TxId), rollout)
+}
+
+// planWaves splits the nodes into a canary wave followed by waves of
+// WavePercent percent of all nodes. Nodes are ordered by serial number, so
+// that the plan is reproducible.
+func planWaves(serials []string, policy types.RolloutPolicy) [][]string {
+	rest := slices.Clone(serials)
+	sort.Strings(rest)
+	total := len(rest)
+
+	var waves [][]string
+	if policy.CanaryNodes > 0 && len(rest) > 0 {
+		n := min(policy.CanaryNodes, len(rest))
+		waves = append(waves, rest[:n])
+		rest = rest[n:]
+	}
+
+	size := len(rest)
+	if policy.WavePercent > 0 {
+		size = max((total*policy.WavePercent+99)/100, 1)
+	}
+	for len(rest) > 0 {
+		n := min(size, len(rest))
+		waves = append(waves, rest[:n])
+		rest = rest[n:]
+	}
+	return waves
+}
+
+func rolloutPolicyFromProto(policy *grpc_scale.RolloutPolicy) (types.RolloutPolicy, error) {
+	if policy == nil {
+		return types.RolloutPolicy{}, nil
+	}
+	if policy.CanaryNodes < 0 {
+		return types.RolloutPolicy{}, fmt.Errorf("canary nodes must not be negative")
+	}
+	if policy.WavePercent < 0 || policy.WavePercent > 100 {
+		return types.RolloutPolicy{}, fmt.Errorf("wave percent must be between 0 and 100")
+	}
+	if policy.FailureThreshold < 0 {
+		return types.RolloutPolicy{}, fmt.Errorf("failure threshold must not be negative")
+	}
+	if policy.WavePause.AsDuration() < 0 {
+		return types.RolloutPolicy{}, fmt.Errorf("wave pause must not be negative")
+	}
+	return types.RolloutPolicy{
+		CanaryNodes:      int(policy.CanaryNodes),
+		WavePercent:      int(policy.WavePercent),
+		WavePause:        policy.WavePause.AsDuration(),
+		FailureThreshold: int(policy.FailureThreshold),
+	}, nil
+}
+
+func (sb *SouthboundService) saveRollout(ctx context.Context, tx int, rollout *types.Rollout) error {
+	metadata, err := json.Marshal(rollout)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal rollout")
+		return err
+	}
+	if err := sb.db.SetTransactionMetadata(ctx, tx, metadata); err != nil {
+		log.Error().Err(err).Msgf("Failed to store rollout %d", tx)
+		return err
+	}
+	return nil
+}
+
+func (sb *SouthboundService) loadRollout(ctx context.Context, tx int) (*types.Rollout, error) {
+	transaction, err := sb.db.GetTransaction(ctx, tx)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, status.Errorf(codes.NotFound, "transaction %d not found", tx)
+		}
+		return nil, status.Error(codes.Internal, "Failed to get transaction")
+	}
+	if len(transaction.Metadata) == 0 {
+		return nil, status.Errorf(codes.NotFound, "transaction %d is not a rollout", tx)
+	}
+
+	rollout := &types.Rollout{}
+	if err := json.Unmarshal(transaction.Metadata, rollout); err != nil || rollout.Waves == nil {
+		return nil, status.Errorf(codes.NotFound, "transaction %d is not a rollout", tx)
+	}
+	return rollout, nil
+}
+
+func (sb *SouthboundService) isRolloutRunning(tx int) bool {
+	sb.rolloutsMu.Lock()
+	defer sb.rolloutsMu.Unlock()
+	_, ok := sb.rollouts[tx]
+	return ok
+}
+
+// startRollout executes rollout in the background, unless it is running already
+func (sb *SouthboundService) startRollout(tx int, rollout *types.Rollout) {
+	sb.rolloutsMu.Lock()
+	defer sb.rolloutsMu.Unlock()
+	if _, ok := sb.rollouts[tx]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	running := runningRollout{cancel: cancel, done: make(chan struct{})}
+	sb.rollouts[tx] = running
+
+	go func() {
+		defer func() {
+			sb.rolloutsMu.Lock()
+			delete(sb.rollouts, tx)
+			sb.rolloutsMu.Unlock()
+			cancel()
+			close(running.done)
+		}()
+		if err := sb.runRollout(ctx, tx, rollout); err != nil {
+			log.Error().Err(err).Msgf("Rollout %d stopped, its transaction stays open until it is recovered", tx)
+		}
+	}()
+}
+
+// runRollout applies the waves of rollout one after another. Nodes which
+// applied the update in an earlier run are skipped. Cancelling ctx aborts the
+// rollout. It returns an error if the outcome of the rollout could not be
+// stored.
+func (sb *SouthboundService) runRollout(ctx context.Context, tx int, rollout *types.Rollout) error {
+	dbCtx := context.WithoutCancel(ctx)
+
+	states, err := sb.db.GetLatestNodeStates(dbCtx, tx)
+	if err != nil {
+		return sb.haltRollout(dbCtx, tx, rollout, "Failed to load rollout progress")
+	}
+
+	ranWave := false
+	for i, wave := range rollout.Waves {
+		pending := pendingRolloutNodes(wave, states, rollout)
+		if len(pending) == 0 {
+			continue
+		}
+
+		if ranWave && rollout.Policy.WavePause > 0 {
+			log.Info().Msgf("Rollout %d: pausing %v before wave %d", tx, rollout.Policy.WavePause, i)
+			select {
+			case <-ctx.Done():
+				return sb.endRollout(dbCtx, tx, rollout, types.RolloutAborted, "Rollout aborted")
+			case <-time.After(rollout.Policy.WavePause):
+			}
+		}
+		ranWave = true
+
+		// a failed wave is rolled back on all of its nodes by the control plane,
+		// it is repeated without the failed nodes until the threshold is reached
+		for len(pending) > 0 {
+			log.Info().Msgf("Rollout %d: starting wave %d with %d nodes", tx, i, len(pending))
+			result, err := sb.runWave(ctx, tx, rollout, i, pending)
+			if ctx.Err() != nil {
+				return sb.endRollout(dbCtx, tx, rollout, types.RolloutAborted, "Rollout aborted")
+			}
+			if err == nil && result.state == types.TransactionStateApplied && len(result.failed) == 0 {
+				if err := sb.saveRollout(dbCtx, tx, rollout); err != nil {
+					return sb.haltRollout(dbCtx, tx, rollout, "Failed to store rollout progress")
+				}
+				break
+			}
+			if len(result.failed) == 0 {
+				return sb.haltRollout(dbCtx, tx, rollout, fmt.Sprintf("Wave %d failed: %s", i, result.description))
+			}
+
+			if addFailedNodes(rollout, result.failed) {
+				return sb.haltRollout(dbCtx, tx, rollout, fmt.Sprintf("Wave %d failed, %d failed nodes exceed the failure threshold of %d: %s",
+					i, len(rollout.Failed), rollout.Policy.FailureThreshold, result.description))
+			}
+			if err := sb.saveRollout(dbCtx, tx, rollout); err != nil {
+				return sb.haltRollout(dbCtx, tx, rollout, "Failed to store rollout progress")
+			}
+			if result.state == types.TransactionStateApplied {
+				break
+			}
+			pending = pendingRolloutNodes(pending, nil, rollout)
+		}
+	}
+
+	description := "Rollout completed successfully"
+	if len(rollout.Failed) > 0 {
+		description = fmt.Sprintf("Rollout completed, %d nodes failed", len(rollout.Failed))
+	}
+	return sb.endRollout(dbCtx, tx, rollout, types.RolloutCompleted, description)
+}
+
+// runWave pushes the update to the given nodes of a rollout. The update of a
+// node which is offline is queued until its next hello, the node is added to
+// the queued nodes of the rollout and does not count as failed.
+func (sb *SouthboundService) runWave(ctx context.Context, tx int, rollout *types.Rollout, wave int, serials []string) (fleetResult, error) {
+	var groupName string
+	if rollout.GroupName != nil {
+		groupName = *rollout.GroupName
+	}
+
+	metadata, err := json.Marshal(types.RolloutLogMetadata{Wave: wave})
+	if err != nil {
+		return fleetResult{state: types.TransactionStateError, description: "Failed to marshal wave metadata"}, err
+	}
+
+	fleetUpdate, err := sb.fullFleetUpdate(ctx, rollout.VersionSetID, groupName)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get fleet update")
+		return fleetResult{state: types.TransactionStateError, description: "Failed to get fleet update"}, err
+	}
+
+	var items []*grpc_controlplane.NodeUpdateItem
+	if fleetUpdate != nil {
+		for _, item := range fleetUpdate.NodeUpdateItems {
+			if slices.Contains(serials, item.SerialNumber) {
+				items = append(items, item)
+			}
+		}
+	}
+
+	// nodes without an update in the version set fail
+	var missing []string
+	for _, serial := range serials {
+		if !slices.ContainsFunc(items, func(item *grpc_controlplane.NodeUpdateItem) bool { return item.SerialNumber == serial }) {
+			missing = append(missing, serial)
+		}
+	}
+	sb.logRolloutFailures(ctx, tx, rollout, wave, missing, "no update for the node")
+
+	items, offline, err := sb.splitOffline(ctx, rollout.VersionSetID, items)
+	if err != nil {
+		return fleetResult{state: types.TransactionStateError, description: "Failed to get online nodes"}, err
+	}
+	var unqueued []string
+	for _, item := range offline {
+		if _, err := sb.enqueueNodeUpdate(ctx, item, rollout.VersionSetID, &tx); err != nil {
+			log.Error().Err(err).Msgf("Rollout %d: failed to queue update of offline node %s", tx, item.SerialNumber)
+			unqueued = append(unqueued, item.SerialNumber)
+			continue
+		}
+		rollout.Queued = append(rollout.Queued, item.SerialNumber)
+	}
+	sb.logRolloutFailures(ctx, tx, rollout, wave, unqueued, "node offline, failed to queue the update")
+
+	failed := append(missing, unqueued...)
+	if len(items) == 0 {
+		if len(failed) > 0 {
+			return fleetResult{state: types.TransactionStateError, description: "No node of the wave could be updated", failed: failed}, nil
+		}
+		return fleetResult{state: types.TransactionStateApplied, description: "All nodes of the wave are offline, their updates are queued"}, nil
+	}
+
+	waveCtx, cancel := context.WithTimeout(ctx, waveTimeout)
+	defer cancel()
+	result, err := sb.runFleetUpdate(waveCtx, &grpc_controlplane.FleetUpdate{NodeUpdateItems: items}, tx, rollout.VersionSetID, metadata)
+	result.failed = append(result.failed, failed...)
+	return result, err
+}
+
+// logRolloutFailures logs an error with the reason for the given nodes of a wave
+func (sb *SouthboundService) logRolloutFailures(ctx context.Context, tx int, rollout *types.Rollout, wave int, serials []string, reason string) {
+	if len(serials) == 0 {
+		return
+	}
+	metadata, _ := json.Marshal(types.RolloutLogMetadata{Wave: wave, Reason: reason})
+	for _, serial := range serials {
+		err := sb.logNode(ctx, &types.NodeTransactionLog{
+			TransactionID: tx,
+			NodeSerial:    serial,
+			VersionSetID:  rollout.VersionSetID,
+			State:         types.TransactionStateError,
+			Timestamp:     time.Now(),
+			Metadata:      metadata,
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to log node transaction")
+		}
+	}
+}
+
+// addFailedNodes adds the failed nodes of a wave to the failed nodes of
+// rollout and reports whether they exceed its failure threshold
+func addFailedNodes(rollout *types.Rollout, failed []string) bool {
+	for _, serial := range failed {
+		if !slices.Contains(rollout.Failed, serial) {
+			rollout.Failed = append(rollout.Failed, serial)
+		}
+	}
+	return len(rollout.Failed) > rollout.Policy.FailureThreshold
+}
+
+// pendingRolloutNodes returns the nodes of wave which neither applied the
+// update according to states nor failed nor had it queued
+func pendingRolloutNodes(wave []string, states map[string]*types.NodeTransactionLog, rollout *types.Rollout) []string {
+	var pending []string
+	for _, serial := range wave {
+		if slices.Contains(rollout.Failed, serial) || slices.Contains(rollout.Queued, serial) {
+			continue
+		}
+		if state, ok := states[serial]; ok && state.State == types.TransactionStateApplied {
+			continue
+		}
+		pending = append(pending, serial)
+	}
+	return pending
+}
+
+// haltRollout stops a rollout without completing its transaction, so that it
+// can be resumed or aborted
+func (sb *SouthboundService) haltRollout(ctx context.Context, tx int, rollout *types.Rollout, description string) error {
+	log.Warn().Msgf("Rollout %d halted: %s", tx, description)
+	rollout.Status = types.RolloutHalted
+	if err := sb.saveRollout(ctx, tx, rollout); err != nil {
+		return fmt.Errorf("failed to store halted rollout %d: %w", tx, err)
+	}
+	if err := sb.db.UpdateTransaction(ctx, tx, nil, nil, &description); err != nil {
+		log.Error().Err(err).Msg("Failed to update transaction")
+	}
+	sb.publishTransactionEvent(0, &grpc_scale.TransactionEvent{
+		TxId:        int32(tx),
+		State:       string(types.RolloutHalted),
+		Timestamp:   timestamppb.New(time.Now()),
+		Description: &description,
+	})
+	return nil
+}
+
+// endRollout completes the transaction of a rollout and finishes its version
+// transition. If the final status of the rollout can not be stored, the
+// transaction is left open and the error returned.
+func (sb *SouthboundService) endRollout(ctx context.Context, tx int, rollout *types.Rollout, rolloutStatus types.RolloutStatus, description string) error {
+	log.Info().Msgf("Rollout %d %s: %s", tx, rolloutStatus, description)
+	rollout.Status = rolloutStatus
+	if err := sb.saveRollout(ctx, tx, rollout); err != nil {
+		return fmt.Errorf("failed to store %s rollout %d: %w", rolloutStatus, tx, err)
+	}
+
+	state := types.TransactionStateApplied
+	if rolloutStatus != types.RolloutCompleted {
+		state = types.TransactionStateError
+	}
+	if err := sb.completeTransaction(ctx, tx, state, description); err != nil {
+		log.Error().Err(err).Msg("Failed to update transaction")
+	}
+
+	if rollout.VersionTransitionID != nil {
+		sb.finishVersionTransition(ctx, tx, *rollout.VersionTransitionID, rollout.FromVersionTransition, rollout.VersionSetID, rolloutStatus == types.RolloutCompleted)
+	} else if rolloutStatus != types.RolloutCompleted {
+		sb.supersedeQueuedUpdates(ctx, tx, fmt.Sprintf("Rollout %d %s", tx, rolloutStatus))
+	}
+	return nil
+}
+
+func (sb *SouthboundService) rolloutResponse(ctx context.Context, tx int, rollout *types.Rollout) (*grpc_scale.RolloutResponse, error) {
+	states, err := sb.db.GetLatestNodeStates(ctx, tx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to get rollout progress")
+	}
+
+	resp := &grpc_scale.RolloutResponse{
+		TxId:         int32(tx),
+		VersionSetId: rollout.VersionSetID.String(),
+		GroupName:    rollout.GroupName,
+		Policy: &grpc_scale.RolloutPolicy{
+			CanaryNodes:      int32(rollout.Policy.CanaryNodes),
+			WavePercent:      int32(rollout.Policy.WavePercent),
+			WavePause:        durationpb.New(rollout.Policy.WavePause),
+			FailureThreshold: int32(rollout.Policy.FailureThreshold),
+		},
+		Status:      string(rollout.Status),
+		FailedNodes: rollout.Failed,
+		QueuedNodes: rollout.Queued,
+	}
+	if transaction, err := sb.db.GetTransaction(ctx, tx); err == nil {
+		resp.Description = transaction.Description
+	}
+
+	current := true
+	for i, wave := range rollout.Waves {
+		waveState := "pending"
+		if len(pendingRolloutNodes(wave, states, rollout)) == 0 {
+			waveState = "completed"
+		} else if current {
+			current = false
+			if rollout.Status == types.RolloutRunning {
+				waveState = "running"
+			} else {
+				waveState = "failed"
+			}
+		}
+		resp.Waves = append(resp.Waves, &grpc_scale.RolloutWave{
+			Index:         int32(i),
+			SerialNumbers: wave,
+			State:         waveState,
+		})
+	}
+	return resp, nil
+}
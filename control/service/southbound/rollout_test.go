@@ -0,0 +1,166 @@
+package southbound
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/philslol/kritis3m_scalev2/control/types"
+)
+
+func TestPlanWaves(t *testing.T) {
+	five := []string{"gw-5", "gw-3", "gw-1", "gw-4", "gw-2"}
+
+	tests := []struct {
+		name    string
+		serials []string
+		policy  types.RolloutPolicy
+		want    [][]string
+	}{
+		{name: "no nodes", policy: types.RolloutPolicy{CanaryNodes: 1, WavePercent: 50}},
+		{name: "no policy", serials: five, want: [][]string{{"gw-1", "gw-2", "gw-3", "gw-4", "gw-5"}}},
+		{name: "canary larger than the fleet", serials: five, policy: types.RolloutPolicy{CanaryNodes: 8, WavePercent: 20},
+			want: [][]string{{"gw-1", "gw-2", "gw-3", "gw-4", "gw-5"}}},
+		{name: "canary of the whole fleet", serials: five, policy: types.RolloutPolicy{CanaryNodes: 5},
+			want: [][]string{{"gw-1", "gw-2", "gw-3", "gw-4", "gw-5"}}},
+		{name: "wave percent 0", serials: five, policy: types.RolloutPolicy{CanaryNodes: 1},
+			want: [][]string{{"gw-1"}, {"gw-2", "gw-3", "gw-4", "gw-5"}}},
+		{name: "wave percent 100", serials: five, policy: types.RolloutPolicy{CanaryNodes: 2, WavePercent: 100},
+			want: [][]string{{"gw-1", "gw-2"}, {"gw-3", "gw-4", "gw-5"}}},
+		{name: "wave percent rounded up", serials: five, policy: types.RolloutPolicy{WavePercent: 30},
+			want: [][]string{{"gw-1", "gw-2"}, {"gw-3", "gw-4"}, {"gw-5"}}},
+		{name: "wave percent of the fleet with canary", serials: five, policy: types.RolloutPolicy{CanaryNodes: 1, WavePercent: 40},
+			want: [][]string{{"gw-1"}, {"gw-2", "gw-3"}, {"gw-4", "gw-5"}}},
+		{name: "wave percent below one node", serials: five, policy: types.RolloutPolicy{WavePercent: 1},
+			want: [][]string{{"gw-1"}, {"gw-2"}, {"gw-3"}, {"gw-4"}, {"gw-5"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planWaves(tt.serials, tt.policy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planWaves = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRolloutWaveRetries follows the retries of a wave in runRollout: after
+// every failed run the wave is repeated without its failed nodes, until the
+// failed nodes of the rollout exceed the failure threshold
+func TestRolloutWaveRetries(t *testing.T) {
+	wave := []string{"gw-1", "gw-2", "gw-3", "gw-4"}
+
+	tests := []struct {
+		name      string
+		threshold int
+		// earlier failed and queued nodes of the rollout
+		failed []string
+		queued []string
+		// failed nodes of the runs of the wave
+		runs [][]string
+		// pending nodes before every run
+		wantPending [][]string
+		wantHalted  bool
+		wantFailed  []string
+	}{
+		{
+			name:        "no failures",
+			runs:        [][]string{nil},
+			wantPending: [][]string{wave},
+		},
+		{
+			name:        "retried without the failed node",
+			threshold:   2,
+			runs:        [][]string{{"gw-2"}, {"gw-4"}, nil},
+			wantPending: [][]string{wave, {"gw-1", "gw-3", "gw-4"}, {"gw-1", "gw-3"}},
+			wantFailed:  []string{"gw-2", "gw-4"},
+		},
+		{
+			name:        "threshold reached",
+			threshold:   1,
+			runs:        [][]string{{"gw-2"}, {"gw-4"}},
+			wantPending: [][]string{wave, {"gw-1", "gw-3", "gw-4"}},
+			wantHalted:  true,
+			wantFailed:  []string{"gw-2", "gw-4"},
+		},
+		{
+			name:        "threshold 0",
+			runs:        [][]string{{"gw-3"}},
+			wantPending: [][]string{wave},
+			wantHalted:  true,
+			wantFailed:  []string{"gw-3"},
+		},
+		{
+			name:        "failures of earlier waves count",
+			threshold:   2,
+			failed:      []string{"gw-8", "gw-9"},
+			runs:        [][]string{{"gw-1"}},
+			wantPending: [][]string{wave},
+			wantHalted:  true,
+			wantFailed:  []string{"gw-8", "gw-9", "gw-1"},
+		},
+		{
+			name:        "failed node reported twice",
+			threshold:   1,
+			runs:        [][]string{{"gw-1", "gw-1"}, nil},
+			wantPending: [][]string{wave, {"gw-2", "gw-3", "gw-4"}},
+			wantFailed:  []string{"gw-1"},
+		},
+		{
+			name:        "queued and failed nodes are not run",
+			threshold:   4,
+			failed:      []string{"gw-1"},
+			queued:      []string{"gw-4"},
+			runs:        [][]string{{"gw-2"}, nil},
+			wantPending: [][]string{{"gw-2", "gw-3"}, {"gw-3"}},
+			wantFailed:  []string{"gw-1", "gw-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollout := &types.Rollout{
+				Policy: types.RolloutPolicy{FailureThreshold: tt.threshold},
+				Waves:  [][]string{wave},
+				Failed: tt.failed,
+				Queued: tt.queued,
+			}
+
+			var gotPending [][]string
+			halted := false
+			pending := pendingRolloutNodes(wave, nil, rollout)
+			for _, failed := range tt.runs {
+				gotPending = append(gotPending, pending)
+				if len(failed) == 0 {
+					break
+				}
+				if halted = addFailedNodes(rollout, failed); halted {
+					break
+				}
+				pending = pendingRolloutNodes(pending, nil, rollout)
+			}
+
+			if !reflect.DeepEqual(gotPending, tt.wantPending) {
+				t.Errorf("pending nodes of the runs = %v, want %v", gotPending, tt.wantPending)
+			}
+			if halted != tt.wantHalted {
+				t.Errorf("halted = %v, want %v", halted, tt.wantHalted)
+			}
+			if !reflect.DeepEqual(rollout.Failed, tt.wantFailed) {
+				t.Errorf("failed nodes = %v, want %v", rollout.Failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestPendingRolloutNodes(t *testing.T) {
+	states := map[string]*types.NodeTransactionLog{
+		"gw-1": {State: types.TransactionStateApplied},
+		"gw-2": {State: types.TransactionStateError},
+	}
+	rollout := &types.Rollout{Failed: []string{"gw-3"}, Queued: []string{"gw-4"}}
+
+	got := pendingRolloutNodes([]string{"gw-1", "gw-2", "gw-3", "gw-4", "gw-5"}, states, rollout)
+	if want := []string{"gw-2", "gw-5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pendingRolloutNodes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,371 @@
+package southbound
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// schedulerInterval is how often the scheduler looks for due activations
+const schedulerInterval = 30 * time.Second
+
+// checkMaintenanceWindows refuses an activation of the given nodes if one of
+// their localities has maintenance windows and none of them is open. With
+// ignore the activation is only logged.
+func (sb *SouthboundService) checkMaintenanceWindows(ctx context.Context, versionSetID uuid.UUID, serials []string, ignore bool) error {
+	windows, err := sb.db.ListMaintenanceWindows(ctx, "")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list maintenance windows")
+		return status.Error(codes.Internal, "Failed to list maintenance windows")
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+	localities, err := sb.db.NodeLocalities(ctx, versionSetID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get node localities")
+		return status.Error(codes.Internal, "Failed to get node localities")
+	}
+
+	byLocality := make(map[string][]*types.MaintenanceWindow)
+	for _, w := range windows {
+		byLocality[w.Locality] = append(byLocality[w.Locality], w)
+	}
+
+	now := time.Now()
+	closed := make(map[string]time.Time)
+	for _, serial := range serials {
+		locality := localities[serial]
+		localityWindows := byLocality[locality]
+		if len(localityWindows) == 0 {
+			continue
+		}
+		if _, seen := closed[locality]; seen {
+			continue
+		}
+		var next time.Time
+		open := false
+		for _, w := range localityWindows {
+			if w.Contains(now) {
+				open = true
+				break
+			}
+			if n := w.NextOpen(now); !n.IsZero() && (next.IsZero() || n.Before(next)) {
+				next = n
+			}
+		}
+		if !open {
+			closed[locality] = next
+		}
+	}
+	if len(closed) == 0 {
+		return nil
+	}
+
+	var problems []string
+	for locality, next := range closed {
+		if next.IsZero() {
+			problems = append(problems, locality)
+		} else {
+			problems = append(problems, fmt.Sprintf("%s (next window opens %s)", locality, next.Format(time.RFC3339)))
+		}
+	}
+	sort.Strings(problems)
+	if ignore {
+		log.Warn().Msgf("Activating version set %s outside the maintenance windows of %s", versionSetID, strings.Join(problems, ", "))
+		return nil
+	}
+	return status.Errorf(codes.FailedPrecondition, "outside the maintenance windows of %s, schedule the activation or ignore the windows",
+		strings.Join(problems, ", "))
+}
+
+func (sb *SouthboundService) CreateMaintenanceWindow(ctx context.Context, req *grpc_scale.MaintenanceWindow) (*grpc_scale.MaintenanceWindow, error) {
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	days, err := types.ParseWeekdays(strings.Join(req.GetDays(), ","))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	window := &types.MaintenanceWindow{
+		Name:      req.GetName(),
+		Locality:  req.GetLocality(),
+		Days:      days,
+		StartTime: req.GetStartTime(),
+		Duration:  req.GetDuration().AsDuration(),
+		Timezone:  req.GetTimezone(),
+		CreatedBy: user,
+	}
+	if window.Timezone == "" {
+		window.Timezone = "UTC"
+	}
+	if err := window.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := sb.db.CreateMaintenanceWindow(ctx, window); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create maintenance window: %v", err)
+	}
+	log.Info().Msgf("Created maintenance window %s for locality %s", window.Name, window.Locality)
+	return maintenanceWindowToProto(window), nil
+}
+
+func (sb *SouthboundService) ListMaintenanceWindows(ctx context.Context, req *grpc_scale.ListMaintenanceWindowsRequest) (*grpc_scale.ListMaintenanceWindowsResponse, error) {
+	windows, err := sb.db.ListMaintenanceWindows(ctx, req.GetLocality())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list maintenance windows: %v", err)
+	}
+	resp := &grpc_scale.ListMaintenanceWindowsResponse{}
+	for _, w := range windows {
+		resp.Windows = append(resp.Windows, maintenanceWindowToProto(w))
+	}
+	return resp, nil
+}
+
+func (sb *SouthboundService) DeleteMaintenanceWindow(ctx context.Context, req *grpc_scale.DeleteMaintenanceWindowRequest) (*empty.Empty, error) {
+	if err := sb.db.DeleteMaintenanceWindow(ctx, req.GetName()); err != nil {
+		if db.IsNoRows(err) {
+			return nil, status.Error(codes.NotFound, "maintenance window not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to delete maintenance window: %v", err)
+	}
+	return &empty.Empty{}, nil
+}
+
+func (sb *SouthboundService) ScheduleActivation(ctx context.Context, req *grpc_scale.ScheduleActivationRequest) (*grpc_scale.ScheduledActivation, error) {
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versionSetID, err := uuid.FromString(req.GetVersionSetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
+	}
+	if req.GetRunAt() == nil {
+		return nil, status.Error(codes.InvalidArgument, "run_at is required")
+	}
+	runAt := req.GetRunAt().AsTime()
+	if runAt.Before(time.Now()) {
+		return nil, status.Errorf(codes.InvalidArgument, "run_at %s is in the past", runAt.Format(time.RFC3339))
+	}
+
+	vs, err := sb.db.GetVersionSetByID(ctx, versionSetID)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, status.Error(codes.NotFound, "version set not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get version set: %v", err)
+	}
+	if vs.State == types.VERSION_STATE_DISABLED {
+		return nil, status.Errorf(codes.FailedPrecondition, "version set %s is disabled", versionSetID)
+	}
+	if err := sb.checkScheduledWindow(ctx, versionSetID, runAt, req.GetIgnoreWindow()); err != nil {
+		return nil, err
+	}
+
+	activation := &types.ScheduledActivation{
+		VersionSetID: versionSetID,
+		GroupName:    req.GroupName,
+		RunAt:        runAt,
+		Force:        req.GetForce(),
+		IgnoreWindow: req.GetIgnoreWindow(),
+		CreatedBy:    user,
+	}
+	if err := sb.db.CreateScheduledActivation(ctx, activation); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to schedule activation: %v", err)
+	}
+	log.Info().Msgf("Scheduled activation %d of version set %s at %s", activation.ID, versionSetID, runAt.Format(time.RFC3339))
+	return scheduledActivationToProto(activation), nil
+}
+
+// checkScheduledWindow refuses to schedule an activation at a time no window
+// of the nodes of the version set is open. The windows are checked again when
+// the activation fires.
+func (sb *SouthboundService) checkScheduledWindow(ctx context.Context, versionSetID uuid.UUID, runAt time.Time, ignore bool) error {
+	if ignore {
+		return nil
+	}
+	windows, err := sb.db.ListMaintenanceWindows(ctx, "")
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to list maintenance windows: %v", err)
+	}
+	localities, err := sb.db.NodeLocalities(ctx, versionSetID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get node localities: %v", err)
+	}
+
+	used := make(map[string]bool)
+	for _, locality := range localities {
+		used[locality] = true
+	}
+	restricted := make(map[string]bool)
+	open := make(map[string]bool)
+	for _, w := range windows {
+		if !used[w.Locality] {
+			continue
+		}
+		restricted[w.Locality] = true
+		if w.Contains(runAt) {
+			open[w.Locality] = true
+		}
+	}
+
+	var closed []string
+	for locality := range restricted {
+		if !open[locality] {
+			closed = append(closed, locality)
+		}
+	}
+	if len(closed) > 0 {
+		sort.Strings(closed)
+		return status.Errorf(codes.FailedPrecondition, "%s is outside the maintenance windows of %s",
+			runAt.Format(time.RFC3339), strings.Join(closed, ", "))
+	}
+	return nil
+}
+
+func (sb *SouthboundService) ListScheduledActivations(ctx context.Context, req *grpc_scale.ListScheduledActivationsRequest) (*grpc_scale.ListScheduledActivationsResponse, error) {
+	activations, err := sb.db.ListScheduledActivations(ctx, req.GetAll())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list scheduled activations: %v", err)
+	}
+	resp := &grpc_scale.ListScheduledActivationsResponse{}
+	for _, a := range activations {
+		resp.Activations = append(resp.Activations, scheduledActivationToProto(a))
+	}
+	return resp, nil
+}
+
+func (sb *SouthboundService) CancelScheduledActivation(ctx context.Context, req *grpc_scale.CancelScheduledActivationRequest) (*grpc_scale.ScheduledActivation, error) {
+	id := int(req.GetId())
+	cancelled, err := sb.db.CancelScheduledActivation(ctx, id)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to cancel scheduled activation: %v", err)
+	}
+
+	activation, err := sb.db.GetScheduledActivation(ctx, id)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, status.Error(codes.NotFound, "scheduled activation not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get scheduled activation: %v", err)
+	}
+	if !cancelled {
+		return nil, status.Errorf(codes.FailedPrecondition, "scheduled activation %d is %s", id, activation.Status)
+	}
+	log.Info().Msgf("Cancelled scheduled activation %d", id)
+	return scheduledActivationToProto(activation), nil
+}
+
+// RunScheduler fires the scheduled activations when they are due, until ctx
+// is cancelled. Activations claimed by a previous run which stopped before
+// starting them are marked failed.
+func (sb *SouthboundService) RunScheduler(ctx context.Context) {
+	if count, err := sb.db.FailInterruptedActivations(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to update interrupted scheduled activations")
+	} else if count > 0 {
+		log.Warn().Msgf("%d scheduled activations were interrupted by a restart", count)
+	}
+
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+	for {
+		sb.fireDueActivations(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (sb *SouthboundService) fireDueActivations(ctx context.Context) {
+	due, err := sb.db.ClaimDueActivations(ctx, time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get due activations")
+		return
+	}
+
+	for _, activation := range due {
+		log.Info().Msgf("Firing scheduled activation %d of version set %s", activation.ID, activation.VersionSetID)
+		var groupName string
+		if activation.GroupName != nil {
+			groupName = *activation.GroupName
+		}
+
+		// the activation runs on behalf of the user who scheduled it
+		tx, _, err := sb.activateFleet(withUser(ctx, activation.CreatedBy), activation.VersionSetID, groupName, activationOptions{
+			force:        activation.Force,
+			ignoreWindow: activation.IgnoreWindow,
+		})
+		if err != nil {
+			message := status.Convert(err).Message()
+			log.Error().Msgf("Scheduled activation %d failed: %s", activation.ID, message)
+			if err := sb.db.FinishScheduledActivation(ctx, activation.ID, types.ScheduleFailed, nil, message); err != nil {
+				log.Error().Err(err).Msg("Failed to record outcome of scheduled activation")
+			}
+			continue
+		}
+		if err := sb.db.FinishScheduledActivation(ctx, activation.ID, types.ScheduleStarted, &tx, ""); err != nil {
+			log.Error().Err(err).Msg("Failed to record outcome of scheduled activation")
+		}
+	}
+}
+
+func maintenanceWindowToProto(w *types.MaintenanceWindow) *grpc_scale.MaintenanceWindow {
+	days := []string{}
+	if len(w.Days) > 0 {
+		days = strings.Split(types.FormatWeekdays(w.Days), ",")
+	}
+	return &grpc_scale.MaintenanceWindow{
+		Id:        int32(w.ID),
+		Name:      w.Name,
+		Locality:  w.Locality,
+		Days:      days,
+		StartTime: w.StartTime,
+		Duration:  durationpb.New(w.Duration),
+		Timezone:  w.Timezone,
+		CreatedBy: w.CreatedBy,
+		CreatedAt: timestamppb.New(w.CreatedAt),
+	}
+}
+
+func scheduledActivationToProto(a *types.ScheduledActivation) *grpc_scale.ScheduledActivation {
+	resp := &grpc_scale.ScheduledActivation{
+		Id:           int32(a.ID),
+		VersionSetId: a.VersionSetID.String(),
+		GroupName:    a.GroupName,
+		RunAt:        timestamppb.New(a.RunAt),
+		Status:       string(a.Status),
+		Force:        a.Force,
+		IgnoreWindow: a.IgnoreWindow,
+		Message:      a.Message,
+		CreatedBy:    a.CreatedBy,
+		CreatedAt:    timestamppb.New(a.CreatedAt),
+	}
+	if a.TransactionID != nil {
+		txID := int32(*a.TransactionID)
+		resp.TxId = &txID
+	}
+	if a.TransactionState != nil {
+		resp.TxState = string(*a.TransactionState)
+	}
+	if a.FinishedAt != nil {
+		resp.FinishedAt = timestamppb.New(*a.FinishedAt)
+	}
+	return resp
+}
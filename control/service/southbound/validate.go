@@ -0,0 +1,100 @@
+package southbound
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func (sb *SouthboundService) ValidateVersionSet(ctx context.Context, req *grpc_scale.ValidateVersionSetRequest) (*grpc_scale.ValidateVersionSetResponse, error) {
+	id, err := uuid.FromString(req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
+	}
+
+	findings, err := sb.validateVersionSet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &grpc_scale.ValidateVersionSetResponse{Valid: !types.HasErrors(findings)}
+	for _, f := range findings {
+		resp.Findings = append(resp.Findings, &grpc_scale.Finding{
+			Rule:     f.Rule,
+			Severity: string(f.Severity),
+			Kind:     f.Kind,
+			Name:     f.Name,
+			Message:  f.Message,
+		})
+	}
+	return resp, nil
+}
+
+func (sb *SouthboundService) validateVersionSet(ctx context.Context, id uuid.UUID) ([]types.Finding, error) {
+	manifest, _, err := sb.db.ExportManifest(ctx, id)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, status.Error(codes.NotFound, "version set not found")
+		}
+		log.Error().Err(err).Msgf("Failed to load version set %s for validation", id)
+		return nil, status.Error(codes.Internal, "Failed to load version set")
+	}
+	return manifest.Validate(), nil
+}
+
+// checkActivation validates a version set before it is activated. Errors
+// refuse the activation with FailedPrecondition unless force is set.
+func (sb *SouthboundService) checkActivation(ctx context.Context, id uuid.UUID, force bool) error {
+	findings, err := sb.validateVersionSet(ctx, id)
+	if err != nil {
+		return err
+	}
+	return activationError(id, findings, force)
+}
+
+// activationError refuses an activation if a finding is an error, warnings
+// never block. force lets the activation pass despite errors.
+func activationError(id uuid.UUID, findings []types.Finding, force bool) error {
+	var problems []string
+	for _, f := range findings {
+		if f.Severity == types.SeverityError {
+			problems = append(problems, fmt.Sprintf("%s: %s %s: %s", f.Rule, f.Kind, f.Name, f.Message))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	if force {
+		log.Warn().Msgf("Activating version set %s despite %d validation errors", id, len(problems))
+		return nil
+	}
+	return status.Errorf(codes.FailedPrecondition, "version set %s fails validation, force to activate anyway: %s",
+		id, strings.Join(problems, "; "))
+}
+
+// metadataFlag reports whether key is set to true in the incoming metadata
+func metadataFlag(ctx context.Context, key string) bool {
+	return metadataValue(ctx, key) == "true"
+}
+
+// metadataValue returns the first value of key in the incoming metadata
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,38 @@
+package southbound
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestActivationError(t *testing.T) {
+	id := uuid.Must(uuid.NewV4())
+	warning := types.Finding{Rule: "unused-group", Severity: types.SeverityWarning, Kind: "group", Name: "office", Message: "group is not used by any proxy"}
+	failure := types.Finding{Rule: "proxy-port-conflict", Severity: types.SeverityError, Kind: "proxy", Name: "api", Message: "listens on port 443 of node gw-1 like proxy \"web\""}
+
+	tests := []struct {
+		name     string
+		findings []types.Finding
+		force    bool
+		want     codes.Code
+	}{
+		{name: "no findings", want: codes.OK},
+		{name: "warnings only", findings: []types.Finding{warning}, want: codes.OK},
+		{name: "errors", findings: []types.Finding{warning, failure}, want: codes.FailedPrecondition},
+		{name: "errors with force", findings: []types.Finding{warning, failure}, force: true, want: codes.OK},
+		{name: "warnings with force", findings: []types.Finding{warning}, force: true, want: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := activationError(id, tt.findings, tt.force)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("activationError = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
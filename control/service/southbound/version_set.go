@@ -8,7 +8,10 @@ import (
 	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
 	"github.com/gofrs/uuid/v5"
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/philslol/kritis3m_scalev2/control/db"
 	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -18,6 +21,10 @@ func (sb *SouthboundService) CreateVersionSet(ctx context.Context, req *grpc_sou
 	if req == nil {
 		return nil, status.Error(codes.InvalidArgument, "request cannot be nil")
 	}
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	// Convert protobuf metadata to JSON bytes
 	metadataBytes, err := json.Marshal(req.GetMetadata())
@@ -34,7 +41,7 @@ func (sb *SouthboundService) CreateVersionSet(ctx context.Context, req *grpc_sou
 	vs := types.VersionSet{
 		Name:        req.GetName(),
 		Description: rsp_description,
-		CreatedBy:   req.GetCreatedBy(),
+		CreatedBy:   user,
 		Metadata:    metadataBytes,
 	}
 
@@ -121,10 +128,9 @@ func (sb *SouthboundService) UpdateVersionSet(ctx context.Context, req *grpc_sou
 	if len(updates) == 0 {
 		return &empty.Empty{}, nil
 	}
-
-	err = sb.db.Update(ctx, "version_sets", updates, "id", id)
+	err = sb.db.UpdateDraftWhere(ctx, "version_sets", updates, []uuid.UUID{id}, "id = $1", id)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update version set: %v", err)
+		return nil, draftError(err, "update version set")
 	}
 
 	return &empty.Empty{}, nil
@@ -140,6 +146,18 @@ func (sb *SouthboundService) DeleteVersionSet(ctx context.Context, req *grpc_sou
 		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
 	}
 
+	// the version set the gateways run or are about to run must stay
+	vs, err := sb.db.GetVersionSetByID(ctx, id)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, status.Error(codes.NotFound, "version set not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get version set: %v", err)
+	}
+	if vs.State == types.VERSION_STATE_ACTIVE || vs.State == types.VERSION_STATE_PENDING_DEPLOYMENT {
+		return nil, status.Errorf(codes.FailedPrecondition, "version set %s is %s and cannot be deleted", id, vs.State)
+	}
+
 	err = sb.db.Delete(ctx, "version_sets", "id", id.String())
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to delete version set: %v", err)
@@ -148,6 +166,9 @@ func (sb *SouthboundService) DeleteVersionSet(ctx context.Context, req *grpc_sou
 	return &empty.Empty{}, nil
 }
 
+// ActivateVersionSet rolls the version set out to the whole fleet like
+// ActivateFleet does. The version set becomes active once the nodes applied
+// it, the response holds the version set as of the start of the update.
 func (sb *SouthboundService) ActivateVersionSet(ctx context.Context, req *grpc_southbound.ActivateVersionSetRequest) (*grpc_southbound.VersionSetResponse, error) {
 	if req == nil || req.GetId() == "" {
 		return nil, status.Error(codes.InvalidArgument, "version set ID is required")
@@ -158,19 +179,23 @@ func (sb *SouthboundService) ActivateVersionSet(ctx context.Context, req *grpc_s
 		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
 	}
 
-	updates := map[string]interface{}{
-		"state": "active",
-	}
-
-	err = sb.db.Update(ctx, "version_sets", updates, "id", id)
+	tx, queued, err := sb.activateFleet(ctx, id, "", activationOptions{
+		force:           metadataFlag(ctx, types.ForceActivationKey),
+		ignoreWindow:    metadataFlag(ctx, types.IgnoreWindowKey),
+		minAgentVersion: metadataValue(ctx, types.MinAgentVersionKey),
+	})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to activate version set: %v", err)
+		return nil, err
+	}
+	if queued {
+		log.Info().Msgf("No node is online, version set %s is queued in transaction %d", id, tx)
+	} else {
+		log.Info().Msgf("Version set %s is rolled out in transaction %d", id, tx)
 	}
 
-	// Fetch the activated version set to return complete data
 	vs, err := sb.db.GetVersionSetByID(ctx, id)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "version set activated but failed to fetch: %v", err)
+		return nil, status.Errorf(codes.Internal, "version set activation started but failed to fetch: %v", err)
 	}
 
 	return convertVersionSetToResponse(vs)
@@ -186,13 +211,8 @@ func (sb *SouthboundService) DisableVersionSet(ctx context.Context, req *grpc_so
 		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
 	}
 
-	updates := map[string]interface{}{
-		"state": "disabled",
-	}
-
-	err = sb.db.Update(ctx, "version_sets", updates, "id", id)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to disable version set: %v", err)
+	if _, err := sb.transitionVersionSet(ctx, id, types.VERSION_STATE_DISABLED); err != nil {
+		return nil, err
 	}
 
 	// Fetch the disabled version set to return complete data
@@ -204,6 +224,76 @@ func (sb *SouthboundService) DisableVersionSet(ctx context.Context, req *grpc_so
 	return convertVersionSetToResponse(vs)
 }
 
+func (sb *SouthboundService) CloneVersionSet(ctx context.Context, req *grpc_scale.CloneVersionSetRequest) (*grpc_scale.CloneVersionSetResponse, error) {
+	if req == nil || req.GetSourceId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "source version set ID is required")
+	}
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	sourceID, err := uuid.FromString(req.GetSourceId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
+	}
+
+	user, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := sb.db.CloneVersionSet(ctx, sourceID, req.GetName(), user)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return nil, status.Error(codes.NotFound, "version set not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to clone version set: %v", err)
+	}
+
+	return &grpc_scale.CloneVersionSetResponse{Id: id.String()}, nil
+}
+
+func (sb *SouthboundService) DiffVersionSets(ctx context.Context, req *grpc_scale.DiffVersionSetsRequest) (*grpc_scale.DiffVersionSetsResponse, error) {
+	if req == nil || req.GetFromId() == "" || req.GetToId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "both version set IDs are required")
+	}
+
+	from, err := uuid.FromString(req.GetFromId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
+	}
+	to, err := uuid.FromString(req.GetToId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid version set ID: %v", err)
+	}
+
+	diffs, err := sb.db.DiffVersionSets(ctx, from, to)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to diff version sets: %v", err)
+	}
+
+	return &grpc_scale.DiffVersionSetsResponse{Nodes: nodeDiffsToProto(diffs)}, nil
+}
+
+func nodeDiffsToProto(diffs []*types.NodeDiff) []*grpc_scale.NodeDiff {
+	var nodes []*grpc_scale.NodeDiff
+	for _, diff := range diffs {
+		node := &grpc_scale.NodeDiff{SerialNumber: diff.SerialNumber}
+		for _, change := range diff.Changes {
+			node.Changes = append(node.Changes, &grpc_scale.ConfigChange{
+				Kind:     change.Kind,
+				Name:     change.Name,
+				Change:   string(change.Change),
+				Field:    change.Field,
+				OldValue: change.Old,
+				NewValue: change.New,
+			})
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
 // Helper function to convert a VersionSet to a VersionSetResponse
 func convertVersionSetToResponse(vs *types.VersionSet) (*grpc_southbound.VersionSetResponse, error) {
 	if vs == nil {
@@ -0,0 +1,171 @@
+package southbound
+
+import (
+	"context"
+	"time"
+
+	"github.com/philslol/kritis3m_scalev2/control/db"
+	"github.com/philslol/kritis3m_scalev2/control/types"
+	grpc_scale "github.com/philslol/kritis3m_scalev2/proto/scale"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// watchBuffer is the number of events buffered per watcher, a watcher which
+// falls further behind is disconnected
+const watchBuffer = 256
+
+// transactionEvent is published to the watchers of a transaction. logID is
+// the transaction_log row of a node event, it deduplicates live events and
+// the replayed log.
+type transactionEvent struct {
+	logID int
+	event *grpc_scale.TransactionEvent
+}
+
+// WatchTransaction replays the transaction log of a transaction and then
+// streams its live events until the transaction is completed.
+func (sb *SouthboundService) WatchTransaction(req *grpc_scale.WatchTransactionRequest, stream grpc.ServerStreamingServer[grpc_scale.TransactionEvent]) error {
+	ctx := stream.Context()
+	tx := int(req.TxId)
+
+	// subscribe first, so that no event between the replay and the live stream is lost
+	events := sb.subscribeTransaction(tx)
+	defer sb.unsubscribeTransaction(tx, events)
+
+	transaction, err := sb.db.GetTransaction(ctx, tx)
+	if err != nil {
+		if db.IsNoRows(err) {
+			return status.Errorf(codes.NotFound, "transaction %d not found", tx)
+		}
+		return status.Error(codes.Internal, "Failed to get transaction")
+	}
+
+	entries, err := sb.db.ListTransactionLog(ctx, tx)
+	if err != nil {
+		return status.Error(codes.Internal, "Failed to get transaction log")
+	}
+	lastLogID := 0
+	for _, entry := range entries {
+		if err := stream.Send(nodeEvent(entry)); err != nil {
+			return err
+		}
+		lastLogID = entry.ID
+	}
+
+	if transaction.CompletedAt != nil {
+		var state string
+		if transaction.State != nil {
+			state = string(*transaction.State)
+		}
+		return stream.Send(&grpc_scale.TransactionEvent{
+			TxId:        req.TxId,
+			State:       state,
+			Timestamp:   timestamppb.New(*transaction.CompletedAt),
+			Completed:   true,
+			Description: transaction.Description,
+		})
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "Watcher fell behind the transaction")
+			}
+			if event.logID != 0 && event.logID <= lastLogID {
+				continue
+			}
+			if err := stream.Send(event.event); err != nil {
+				return err
+			}
+			if event.event.Completed {
+				return nil
+			}
+		}
+	}
+}
+
+func nodeEvent(entry *types.NodeTransactionLog) *grpc_scale.TransactionEvent {
+	event := &grpc_scale.TransactionEvent{
+		TxId:         int32(entry.TransactionID),
+		SerialNumber: entry.NodeSerial,
+		State:        string(entry.State),
+		Timestamp:    timestamppb.New(entry.Timestamp),
+	}
+	if reason := types.LogReason(entry.Metadata); reason != "" {
+		event.Description = &reason
+	}
+	return event
+}
+
+func (sb *SouthboundService) subscribeTransaction(tx int) chan transactionEvent {
+	events := make(chan transactionEvent, watchBuffer)
+	sb.watchMu.Lock()
+	defer sb.watchMu.Unlock()
+	if sb.watchers[tx] == nil {
+		sb.watchers[tx] = make(map[chan transactionEvent]struct{})
+	}
+	sb.watchers[tx][events] = struct{}{}
+	return events
+}
+
+func (sb *SouthboundService) unsubscribeTransaction(tx int, events chan transactionEvent) {
+	sb.watchMu.Lock()
+	defer sb.watchMu.Unlock()
+	if _, ok := sb.watchers[tx][events]; ok {
+		delete(sb.watchers[tx], events)
+		close(events)
+	}
+	if len(sb.watchers[tx]) == 0 {
+		delete(sb.watchers, tx)
+	}
+}
+
+// publishTransactionEvent hands event to all watchers of its transaction
+// without blocking, watchers with a full buffer are dropped
+func (sb *SouthboundService) publishTransactionEvent(logID int, event *grpc_scale.TransactionEvent) {
+	sb.watchMu.Lock()
+	defer sb.watchMu.Unlock()
+	tx := int(event.TxId)
+	for events := range sb.watchers[tx] {
+		select {
+		case events <- transactionEvent{logID: logID, event: event}:
+		default:
+			log.Warn().Msgf("Dropping slow watcher of transaction %d", tx)
+			delete(sb.watchers[tx], events)
+			close(events)
+		}
+	}
+}
+
+// logNode writes a transaction_log row and publishes it to the watchers of the transaction
+func (sb *SouthboundService) logNode(ctx context.Context, entry *types.NodeTransactionLog) error {
+	id, err := sb.db.LogNodeTransaction(ctx, entry)
+	if err != nil {
+		return err
+	}
+	entry.ID = id
+	sb.publishTransactionEvent(id, nodeEvent(entry))
+	return nil
+}
+
+// completeTransaction sets the final state of a transaction and ends the
+// streams of its watchers
+func (sb *SouthboundService) completeTransaction(ctx context.Context, tx int, state types.TransactionState, description string) error {
+	completed_at := time.Now()
+	err := sb.db.UpdateTransaction(ctx, tx, &completed_at, &state, &description)
+	sb.publishTransactionEvent(0, &grpc_scale.TransactionEvent{
+		TxId:        int32(tx),
+		State:       string(state),
+		Timestamp:   timestamppb.New(completed_at),
+		Completed:   true,
+		Description: &description,
+	})
+	return err
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// BundleFormat is the version of the bundle layout written by export
+const BundleFormat = 1
+
+// Bundle is a portable copy of a version set. It is written by version set
+// export and recreated as a new draft by import.
+type Bundle struct {
+	Format      int             `json:"format"`
+	ExportedAt  time.Time       `json:"exported_at"`
+	SourceID    uuid.UUID       `json:"source_id"`
+	State       VersionState    `json:"state"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ActivatedAt *time.Time      `json:"activated_at,omitempty"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+	Content     Manifest        `json:"content"`
+	// ContentHash is the hex SHA-256 of the JSON encoding of Content
+	ContentHash string `json:"content_hash"`
+	// Origins are the origins of the entities of Content by OriginKey. They
+	// are not covered by ContentHash.
+	Origins map[string]Origin `json:"origins,omitempty"`
+}
+
+// Origin records who created an entity of a version set and when
+type Origin struct {
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OriginKey returns the key of an entity in Bundle.Origins. Kind and key are
+// those of the plan steps, e.g. "proxy/web" or "hardware_config/gw-1/eth0/10.0.0.1/24".
+func OriginKey(kind, key string) string {
+	return kind + "/" + key
+}
+
+// Hash returns the hex SHA-256 of the JSON encoding of the manifest
+func (m *Manifest) Hash() (string, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks the format and the content hash of a bundle and that all
+// references of its content resolve.
+func (b *Bundle) Verify() error {
+	if b.Format != BundleFormat {
+		return fmt.Errorf("unsupported bundle format %d", b.Format)
+	}
+	hash, err := b.Content.Hash()
+	if err != nil {
+		return err
+	}
+	if hash != b.ContentHash {
+		return fmt.Errorf("content hash mismatch: bundle says %s, content is %s", b.ContentHash, hash)
+	}
+	return b.Content.CheckReferences()
+}
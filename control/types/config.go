@@ -1,8 +1,12 @@
 package types
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net"
+	"os"
 	"strings"
 	"time"
 
@@ -35,6 +39,9 @@ type Config struct {
 
 	Broker       BrokerConfig
 	ControlPlane ControlPlaneConfig
+	Presence     PresenceConfig
+	Reconciler   ReconcilerConfig
+	OfflineQueue OfflineQueueConfig
 	ESTServer    ESTServerConfig
 
 	CLILog   LogConfig
@@ -55,7 +62,40 @@ type BrokerConfig struct {
 	TcpOnly        bool
 }
 
+// PresenceConfig controls when a node counts as online
+type PresenceConfig struct {
+	// OnlineWindow is how long a node counts as online after its last hello,
+	// nodes connected to the broker are online regardless
+	OnlineWindow time.Duration
+}
+
+// ReconcilerConfig controls the background loop which pushes the active
+// version set to nodes which missed it or drifted from it
+type ReconcilerConfig struct {
+	Enabled bool
+	// Interval between two runs of the reconciler
+	Interval time.Duration
+	// MaxNodesPerRun limits the nodes updated by one run, nodes are updated
+	// one after another
+	MaxNodesPerRun int
+	// Backoff is the delay before a node whose update failed is retried, it
+	// doubles with every further failure up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// OfflineQueueConfig controls the updates queued for nodes which are offline
+// when they are activated
+type OfflineQueueConfig struct {
+	// Expiry is how long a queued update waits for its node to return
+	Expiry time.Duration
+}
+
 type ControlPlaneConfig struct {
+	// ExternalBroker makes the control plane dial the broker at Address
+	// instead of attaching to the embedded broker in-process. Address,
+	// EndpointConfig and TcpOnly are only used then.
+	ExternalBroker bool
 	Address        string
 	Log            LogConfig
 	EndpointConfig asl.EndpointConfig
@@ -70,18 +110,51 @@ type LogConfig struct {
 
 // Config holds the database configuration
 type DatabaseConfig struct {
+	// Type selects the backend, DatabasePostgres (default) or DatabaseSqlite
+	Type string
+	// SqlitePath is the database file used by the sqlite backend
+	SqlitePath string
+
 	Host         string `mapstructure:"host"`
 	Port         int    `mapstructure:"port"`
 	User         string `mapstructure:"user"`
 	Password     string `mapstructure:"password"`
 	DatabaseName string `mapstructure:"dbname"`
 	SSLMode      string `mapstructure:"sslmode"`
-	LogConfig    LogConfig
+	// PasswordFile and PasswordEnv take precedence over Password, in that order.
+	// One of them is required unless SSLCert authenticates the controller.
+	PasswordFile string `mapstructure:"password_file"`
+	PasswordEnv  string `mapstructure:"password_env"`
+	// TLS material for sslmode verify-ca/verify-full and client certificate auth
+	SSLRootCert string `mapstructure:"sslrootcert"`
+	SSLCert     string `mapstructure:"sslcert"`
+	SSLKey      string `mapstructure:"sslkey"`
+
+	MaxConns        int32         `mapstructure:"max_conns"`
+	MinConns        int32         `mapstructure:"min_conns"`
+	MaxConnLifetime time.Duration `mapstructure:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `mapstructure:"max_conn_idle_time"`
+	LogConfig       LogConfig
 }
 
 type CliConfig struct {
 	Timeout    time.Duration
 	ServerAddr string
+	// Token authenticates the cli as one of the Users of the server.
+	// TokenFile and TokenEnv take precedence over Token, in that order, see
+	// CliToken.
+	Token     string
+	TokenFile string
+	TokenEnv  string
+	// Users are the users the server accepts tokens of
+	Users []CliUser
+}
+
+// CliUser is a user of the grpc api authenticated by a token. Only the
+// SHA-256 of the token is configured.
+type CliUser struct {
+	Name        string `mapstructure:"name"`
+	TokenSha256 string `mapstructure:"token_sha256"`
 }
 
 // ESTServerConfig holds the configuration for the EST server
@@ -235,10 +308,53 @@ func GetDatabaseConfig() (*DatabaseConfig, error) {
 	// Unmarshal the specific section into the struct
 	var database_config DatabaseConfig
 
-	if err := viper.Sub("database.postgres").Unmarshal(&database_config); err != nil {
+	viper.SetDefault("database.type", DatabasePostgres)
+	database_config.Type = viper.GetString("database.type")
+
+	switch database_config.Type {
+	case DatabaseSqlite:
+		database_config.SqlitePath = util.AbsolutePathFromConfigPath(viper.GetString("database.sqlite.path"))
+		if database_config.SqlitePath == "" {
+			return nil, fmt.Errorf("missing database.sqlite.path configuration")
+		}
+		database_config.LogConfig = parse_Log("database.log")
+		return &database_config, nil
+	case DatabasePostgres:
+	default:
+		return nil, fmt.Errorf("unsupported database type %q", database_config.Type)
+	}
+
+	sub := viper.Sub("database.postgres")
+	if sub == nil {
+		return nil, fmt.Errorf("missing database.postgres configuration")
+	}
+	if err := sub.Unmarshal(&database_config); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	database_config.SSLRootCert = util.AbsolutePathFromConfigPath(database_config.SSLRootCert)
+	database_config.SSLCert = util.AbsolutePathFromConfigPath(database_config.SSLCert)
+	database_config.SSLKey = util.AbsolutePathFromConfigPath(database_config.SSLKey)
+
+	if database_config.PasswordFile != "" {
+		path := util.AbsolutePathFromConfigPath(database_config.PasswordFile)
+		password, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read database password file %s: %w", path, err)
+		}
+		database_config.Password = strings.TrimRight(string(password), "\r\n")
+	} else if database_config.PasswordEnv != "" {
+		password, ok := os.LookupEnv(database_config.PasswordEnv)
+		if !ok {
+			return nil, fmt.Errorf("database password environment variable %s is not set", database_config.PasswordEnv)
+		}
+		database_config.Password = password
+	}
+	// client certificate authentication is the only setup without a password
+	if database_config.Password == "" && database_config.SSLCert == "" {
+		return nil, fmt.Errorf("missing database.postgres password, set password, password_file or password_env")
+	}
+
 	database_config.LogConfig = parse_Log("database.log")
 	return &database_config, nil
 }
@@ -314,17 +430,68 @@ func GetBrokerConfig() (*BrokerConfig, error) {
 	return &broker_config, nil
 }
 
+func GetPresenceConfig() PresenceConfig {
+	window := viper.GetDuration("presence.online_window")
+	if window <= 0 {
+		window = DefaultOnlineWindow
+	}
+	return PresenceConfig{OnlineWindow: window}
+}
+
+func GetReconcilerConfig() ReconcilerConfig {
+	viper.SetDefault("reconciler.enabled", true)
+	viper.SetDefault("reconciler.interval", DefaultReconcileInterval)
+	viper.SetDefault("reconciler.max_nodes_per_run", DefaultReconcileNodesPerRun)
+	viper.SetDefault("reconciler.backoff", DefaultReconcileBackoff)
+	viper.SetDefault("reconciler.max_backoff", DefaultReconcileMaxBackoff)
+
+	cfg := ReconcilerConfig{
+		Enabled:        viper.GetBool("reconciler.enabled"),
+		Interval:       viper.GetDuration("reconciler.interval"),
+		MaxNodesPerRun: viper.GetInt("reconciler.max_nodes_per_run"),
+		Backoff:        viper.GetDuration("reconciler.backoff"),
+		MaxBackoff:     viper.GetDuration("reconciler.max_backoff"),
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultReconcileInterval
+	}
+	if cfg.MaxNodesPerRun <= 0 {
+		cfg.MaxNodesPerRun = DefaultReconcileNodesPerRun
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = DefaultReconcileBackoff
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = cfg.Backoff
+	}
+	return cfg
+}
+
+func GetOfflineQueueConfig() OfflineQueueConfig {
+	expiry := viper.GetDuration("offline_queue.expiry")
+	if expiry <= 0 {
+		expiry = DefaultQueueExpiry
+	}
+	return OfflineQueueConfig{Expiry: expiry}
+}
+
 func GetControlPlaneConfig() (*ControlPlaneConfig, error) {
 	var control_plane_config ControlPlaneConfig
 
 	log := parse_Log("control_plane_config.log")
+	control_plane_config.Log = log
+	control_plane_config.ExternalBroker = viper.GetBool("control_plane_config.external_broker")
+	if !control_plane_config.ExternalBroker {
+		// attached to the embedded broker, there is nothing to dial
+		return &control_plane_config, nil
+	}
+
 	ep, err := parse_ASLEndpointConfig("control_plane_config.endpoint_config")
 	if err != nil {
 		return nil, err
 	}
 	control_plane_config.TcpOnly = viper.GetBool("control_plane_config.tcp_only")
 
-	control_plane_config.Log = log
 	control_plane_config.EndpointConfig = *ep
 	control_plane_config.Address = viper.GetString("control_plane_config.server_address")
 	if control_plane_config.Address == "" {
@@ -428,6 +595,9 @@ func GetKritis3mScaleConfig() (*Config, error) {
 		Database:     *database_config,
 		Broker:       *broker,
 		ControlPlane: *ctrl_plane_cfg,
+		Presence:     GetPresenceConfig(),
+		Reconciler:   GetReconcilerConfig(),
+		OfflineQueue: GetOfflineQueueConfig(),
 		ESTServer:    *estServer,
 		Log:          parse_Log(""),
 		CliConfig:    GetCliConfig(),
@@ -442,9 +612,81 @@ func GetCliConfig() CliConfig {
 	//convert to seconds
 	timeout = timeout * time.Second
 	serverAddr := viper.GetString("grpc_listen_addr")
+	var users []CliUser
+	if err := viper.UnmarshalKey("cli_users", &users); err != nil {
+		log.Err(err).Msg("failed to parse cli_users, no user is accepted")
+		users = nil
+	}
 	return CliConfig{
 		Timeout:    timeout,
 		ServerAddr: serverAddr,
+		Token:      viper.GetString("cli_token"),
+		TokenFile:  viper.GetString("cli_token_file"),
+		TokenEnv:   viper.GetString("cli_token_env"),
+		Users:      users,
+	}
+
+}
+
+// CliToken returns the token the cli authenticates with. It is only resolved
+// by the cli, so that the server does not need the file or variable.
+func (c CliConfig) CliToken() (string, error) {
+	if c.TokenFile != "" {
+		path := util.AbsolutePathFromConfigPath(c.TokenFile)
+		token, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read cli token file %s: %w", path, err)
+		}
+		return strings.TrimRight(string(token), "\r\n"), nil
 	}
+	if c.TokenEnv != "" {
+		token, ok := os.LookupEnv(c.TokenEnv)
+		if !ok {
+			return "", fmt.Errorf("cli token environment variable %s is not set", c.TokenEnv)
+		}
+		return token, nil
+	}
+	return c.Token, nil
+}
+
+// CheckListener returns an error unless ServerAddr is a loopback address. The
+// grpc server has no TLS, the tokens of the cli and of the controller travel
+// in plaintext and must not leave the host.
+func (c CliConfig) CheckListener() error {
+	if !IsLoopbackAddr(c.ServerAddr) {
+		return fmt.Errorf("grpc_listen_addr %q is not a loopback address, the tokens of the cli would travel in plaintext. "+
+			"Listen on 127.0.0.1 and reach it from other hosts through an ssh tunnel", c.ServerAddr)
+	}
+	return nil
+}
+
+// IsLoopbackAddr reports whether the host of addr, a host:port, is localhost
+// or a loopback IP
+func IsLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
 
+// CheckUsers returns an error unless at least one user is configured and all
+// of them have a valid token_sha256. Without users the server rejects every
+// call of the cli.
+func (c CliConfig) CheckUsers() error {
+	if len(c.Users) == 0 {
+		return fmt.Errorf("no cli_users are configured, every cli call would be rejected. " +
+			"Create a token with 'kritis3m_scale token <name>' and add the printed user to cli_users")
+	}
+	for _, user := range c.Users {
+		sum, err := hex.DecodeString(user.TokenSha256)
+		if user.Name == "" || err != nil || len(sum) != sha256.Size {
+			return fmt.Errorf("cli user %q needs a name and the hex encoded sha256 of its token", user.Name)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,147 @@
+package types
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
+	"github.com/gofrs/uuid/v5"
+)
+
+// DriftEvent records that a node reported a config hash which differs from the
+// hash of the config the controller pushed to it. The event is resolved once
+// the node reports the expected hash again.
+type DriftEvent struct {
+	ID           int       `json:"id"`
+	SerialNumber string    `json:"serial_number"`
+	VersionSetID uuid.UUID `json:"version_set_id"`
+	ExpectedHash string    `json:"expected_hash"`
+	ReportedHash string    `json:"reported_hash"`
+	// ReportedVersionSetID is the version set the node claims to run
+	ReportedVersionSetID string     `json:"reported_version_set_id,omitempty"`
+	DetectedAt           time.Time  `json:"detected_at"`
+	ResolvedAt           *time.Time `json:"resolved_at,omitempty"`
+}
+
+// DriftFilter narrows the listing of drift events, by default only unresolved
+// events are listed
+type DriftFilter struct {
+	SerialNumber    string
+	IncludeResolved bool
+	Limit           int
+}
+
+// ConfigHashVersion is the first line of the canonical encoding of
+// ConfigHash. It changes with every change of the encoding.
+const ConfigHashVersion = "kritis3m-config-hash/1"
+
+// ConfigHash is the hash of the config of a node: the hex encoded SHA-256 of
+// the canonical encoding of its NodeUpdateItem. Gateways report the same hash
+// of the update they applied, so the encoding only depends on values a
+// gateway receives in every wire format and is simple to reproduce in other
+// languages.
+//
+// The canonical encoding is UTF-8 text of lines "<name> <value>\n". Strings
+// are written as their length in bytes, a colon and the bytes, "4:gw-1".
+// Absent and empty strings are the same, "0:". Integers and enums are written
+// in decimal, booleans as true or false. The lines are, in this order:
+//
+//	kritis3m-config-hash/1
+//	serial_number, network_index, locality
+//	hardware_configs <count>
+//	  per hardware config, sorted by device, ip_cidr and node_serial_number:
+//	  node_serial_number, device, ip_cidr
+//	groups <count>
+//	  per group, sorted by group_name:
+//	  group_name, group_log_level,
+//	  endpoint_config, legacy_config: "<name> -" if absent, otherwise
+//	    "<name> +" followed by name, mutual_auth, no_encryption,
+//	    asl_key_exchange_method, cipher
+//	  proxies <count>
+//	  per proxy, sorted by name:
+//	  name, server_endpoint_addr, client_endpoint_addr, proxy_type
+//
+// Ids, version set ids, states and authors are bookkeeping of the controller
+// and left out.
+func ConfigHash(item *grpc_controlplane.NodeUpdateItem) (string, error) {
+	if item == nil {
+		return "", fmt.Errorf("no config to hash")
+	}
+	sum := sha256.Sum256(canonicalConfig(item))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalConfig returns the canonical encoding of item, see ConfigHash
+func canonicalConfig(item *grpc_controlplane.NodeUpdateItem) []byte {
+	var b bytes.Buffer
+	line := func(name string, value any) {
+		if s, ok := value.(string); ok {
+			value = strconv.Itoa(len(s)) + ":" + s
+		}
+		fmt.Fprintf(&b, "%s %v\n", name, value)
+	}
+	endpoint := func(name string, config *grpc_southbound.EndpointConfig) {
+		if config == nil {
+			fmt.Fprintf(&b, "%s -\n", name)
+			return
+		}
+		fmt.Fprintf(&b, "%s +\n", name)
+		line("name", config.Name)
+		line("mutual_auth", config.MutualAuth)
+		line("no_encryption", config.NoEncryption)
+		line("asl_key_exchange_method", config.AslKeyExchangeMethod)
+		line("cipher", config.GetCipher())
+	}
+
+	b.WriteString(ConfigHashVersion + "\n")
+	line("serial_number", item.SerialNumber)
+	line("network_index", item.NetworkIndex)
+	line("locality", item.Locality)
+
+	hwConfigs := slices.Clone(item.HardwareConfig)
+	slices.SortFunc(hwConfigs, func(a, b *grpc_southbound.HardwareConfig) int {
+		return cmp.Or(
+			cmp.Compare(a.Device, b.Device),
+			cmp.Compare(a.IpCidr, b.IpCidr),
+			cmp.Compare(a.NodeSerialNumber, b.NodeSerialNumber),
+		)
+	})
+	line("hardware_configs", len(hwConfigs))
+	for _, hw := range hwConfigs {
+		line("node_serial_number", hw.NodeSerialNumber)
+		line("device", hw.Device)
+		line("ip_cidr", hw.IpCidr)
+	}
+
+	groups := slices.Clone(item.GroupProxyUpdate)
+	slices.SortFunc(groups, func(a, b *grpc_controlplane.GroupProxyUpdate) int {
+		return cmp.Compare(a.GroupName, b.GroupName)
+	})
+	line("groups", len(groups))
+	for _, group := range groups {
+		line("group_name", group.GroupName)
+		line("group_log_level", group.GroupLogLevel)
+		endpoint("endpoint_config", group.EndpointConfig)
+		endpoint("legacy_config", group.LegacyConfig)
+
+		proxies := slices.Clone(group.Proxies)
+		slices.SortFunc(proxies, func(a, b *grpc_controlplane.UpdateProxy) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+		line("proxies", len(proxies))
+		for _, proxy := range proxies {
+			line("name", proxy.Name)
+			line("server_endpoint_addr", proxy.ServerEndpointAddr)
+			line("client_endpoint_addr", proxy.ClientEndpointAddr)
+			line("proxy_type", int32(proxy.ProxyType))
+		}
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,125 @@
+package types
+
+import (
+	"testing"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	grpc_southbound "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/southbound"
+	"google.golang.org/protobuf/proto"
+)
+
+// configHashVector is the config of the test vector of ConfigHash. Gateways
+// implementing the canonical encoding should reproduce configHashVectorHash.
+func configHashVector() *grpc_controlplane.NodeUpdateItem {
+	cipher := "AES-256-GCM"
+	return &grpc_controlplane.NodeUpdateItem{
+		SerialNumber: "gw-1",
+		NetworkIndex: 1,
+		Locality:     "hall",
+		VersionSetId: "0b7d7a3e-5a4c-4f43-9a57-3cf2a2a1c001",
+		HardwareConfig: []*grpc_southbound.HardwareConfig{
+			{Id: 7, NodeSerialNumber: "gw-1", Device: "eth1", IpCidr: "192.168.1.1/24"},
+			{Id: 3, NodeSerialNumber: "gw-1", Device: "eth0", IpCidr: "10.0.0.1/24"},
+		},
+		GroupProxyUpdate: []*grpc_controlplane.GroupProxyUpdate{
+			{
+				GroupName:      "plant",
+				GroupLogLevel:  2,
+				EndpointConfig: &grpc_southbound.EndpointConfig{Id: 1, Name: "ep", MutualAuth: true, AslKeyExchangeMethod: "ASL_KEX_DEFAULT", Cipher: &cipher},
+				LegacyConfig:   &grpc_southbound.EndpointConfig{Id: 2, Name: "legacy", NoEncryption: true, AslKeyExchangeMethod: "ASL_KEX_CLASSIC_X25519"},
+				Proxies: []*grpc_controlplane.UpdateProxy{
+					{Name: "web", ServerEndpointAddr: "0.0.0.0:443", ClientEndpointAddr: "10.0.0.2:80", ProxyType: grpc_southbound.ProxyType_REVERSE},
+					{Name: "api", ServerEndpointAddr: "0.0.0.0:8443", ClientEndpointAddr: "10.0.0.2:8080", ProxyType: grpc_southbound.ProxyType_FORWARD},
+				},
+			},
+			{
+				GroupName:      "office",
+				GroupLogLevel:  1,
+				EndpointConfig: &grpc_southbound.EndpointConfig{Id: 1, Name: "ep", MutualAuth: true, AslKeyExchangeMethod: "ASL_KEX_DEFAULT"},
+			},
+		},
+	}
+}
+
+const configHashVectorHash = "5962eecc342026f5d2062f2b7236267edb58dd2a971fcc385fad03e858b76d00"
+
+func TestConfigHashVector(t *testing.T) {
+	hash, err := ConfigHash(configHashVector())
+	if err != nil {
+		t.Fatalf("ConfigHash: %v", err)
+	}
+	if hash != configHashVectorHash {
+		t.Errorf("ConfigHash = %s, want %s\ncanonical encoding:\n%s", hash, configHashVectorHash, canonicalConfig(configHashVector()))
+	}
+}
+
+func TestConfigHashCanonical(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(item *grpc_controlplane.NodeUpdateItem)
+		same   bool
+	}{
+		{
+			name: "groups, proxies and hardware configs in another order",
+			change: func(item *grpc_controlplane.NodeUpdateItem) {
+				groups := item.GroupProxyUpdate
+				groups[0], groups[1] = groups[1], groups[0]
+				proxies := groups[1].Proxies
+				proxies[0], proxies[1] = proxies[1], proxies[0]
+				item.HardwareConfig[0], item.HardwareConfig[1] = item.HardwareConfig[1], item.HardwareConfig[0]
+			},
+			same: true,
+		},
+		{
+			name: "bookkeeping of the controller",
+			change: func(item *grpc_controlplane.NodeUpdateItem) {
+				item.VersionSetId = "0b7d7a3e-5a4c-4f43-9a57-3cf2a2a1c002"
+				item.HardwareConfig[0].Id = 70
+				item.HardwareConfig[0].CreatedBy = "alice"
+				item.GroupProxyUpdate[0].EndpointConfig.Id = 10
+				item.GroupProxyUpdate[0].EndpointConfig.State = "active"
+			},
+			same: true,
+		},
+		{
+			name: "absent cipher is an empty cipher",
+			change: func(item *grpc_controlplane.NodeUpdateItem) {
+				empty := ""
+				item.GroupProxyUpdate[1].EndpointConfig.Cipher = &empty
+			},
+			same: true,
+		},
+		{
+			name: "proxy address",
+			change: func(item *grpc_controlplane.NodeUpdateItem) {
+				item.GroupProxyUpdate[0].Proxies[0].ClientEndpointAddr = "10.0.0.3:80"
+			},
+		},
+		{
+			name: "missing legacy config",
+			change: func(item *grpc_controlplane.NodeUpdateItem) {
+				item.GroupProxyUpdate[0].LegacyConfig = nil
+			},
+		},
+		{
+			name: "string boundaries",
+			change: func(item *grpc_controlplane.NodeUpdateItem) {
+				item.SerialNumber = "gw-1\nnetwork_index 1"
+				item.NetworkIndex = 0
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := proto.Clone(configHashVector()).(*grpc_controlplane.NodeUpdateItem)
+			tt.change(item)
+			hash, err := ConfigHash(item)
+			if err != nil {
+				t.Fatalf("ConfigHash: %v", err)
+			}
+			if same := hash == configHashVectorHash; same != tt.same {
+				t.Errorf("hash equal to the vector = %t, want %t", same, tt.same)
+			}
+		})
+	}
+}
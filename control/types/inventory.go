@@ -0,0 +1,82 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinAgentVersionKey is the gRPC metadata key which restricts ActivateFleet and
+// ActivateNode to nodes reporting at least the given agent version.
+const MinAgentVersionKey = "x-min-agent-version"
+
+// NodeInventory is reported by a gateway in the payload of its hello message.
+// Gateways sending an empty hello have no inventory.
+type NodeInventory struct {
+	SerialNumber    string `json:"serial_number"`
+	AgentVersion    string `json:"agent_version"`
+	FirmwareVersion string `json:"firmware_version"`
+	HardwareModel   string `json:"hardware_model"`
+	// Uptime of the gateway in seconds
+	Uptime int64 `json:"uptime"`
+	// VersionSetID and TxID are the version set applied on the gateway and the
+	// transaction which applied it
+	VersionSetID string   `json:"version_set_id"`
+	TxID         int32    `json:"tx_id"`
+	KexMethods   []string `json:"kex_methods"`
+	Ciphers      []string `json:"ciphers"`
+	// ConfigHash is the hash of the update applied on the gateway, see the
+	// function ConfigHash
+	ConfigHash string    `json:"config_hash"`
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// CompareVersions compares two dotted versions like 2.3 or v2.3.1 by their
+// numeric components, a missing component counts as 0. A pre-release or build
+// suffix is ignored. It returns -1, 0 or 1.
+func CompareVersions(a, b string) (int, error) {
+	pa, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func parseVersion(version string) ([]int, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(trimmed, "-+"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	if trimmed == "" {
+		return nil, fmt.Errorf("empty version")
+	}
+	var parts []int
+	for _, component := range strings.Split(trimmed, ".") {
+		n, err := strconv.Atoi(component)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", version)
+		}
+		parts = append(parts, n)
+	}
+	return parts, nil
+}
@@ -0,0 +1,217 @@
+package types
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// Manifest describes the complete content of one version set. It is read by
+// apply -f from YAML or JSON. Entities are identified by name, nodes by serial
+// number and hardware configs by node, device and ip_cidr.
+type Manifest struct {
+	VersionSet      ManifestVersionSet       `json:"version_set" yaml:"version_set"`
+	EndpointConfigs []ManifestEndpointConfig `json:"endpoint_configs,omitempty" yaml:"endpoint_configs,omitempty"`
+	Groups          []ManifestGroup          `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Nodes           []ManifestNode           `json:"nodes,omitempty" yaml:"nodes,omitempty"`
+	HardwareConfigs []ManifestHardwareConfig `json:"hardware_configs,omitempty" yaml:"hardware_configs,omitempty"`
+	Proxies         []ManifestProxy          `json:"proxies,omitempty" yaml:"proxies,omitempty"`
+}
+
+// ManifestVersionSet selects the version set of a manifest, by ID if given and
+// otherwise by name. A version set which does not exist yet is created.
+type ManifestVersionSet struct {
+	ID          string `json:"id,omitempty" yaml:"id,omitempty"`
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	CreatedBy   string `json:"created_by,omitempty" yaml:"created_by,omitempty"`
+}
+
+type ManifestEndpointConfig struct {
+	Name                 string `json:"name" yaml:"name"`
+	MutualAuth           bool   `json:"mutual_auth" yaml:"mutual_auth"`
+	NoEncryption         bool   `json:"no_encryption" yaml:"no_encryption"`
+	ASLKeyExchangeMethod string `json:"asl_key_exchange_method,omitempty" yaml:"asl_key_exchange_method,omitempty"`
+	Cipher               string `json:"cipher,omitempty" yaml:"cipher,omitempty"`
+}
+
+type ManifestGroup struct {
+	Name           string `json:"name" yaml:"name"`
+	LogLevel       int    `json:"log_level" yaml:"log_level"`
+	EndpointConfig string `json:"endpoint_config,omitempty" yaml:"endpoint_config,omitempty"`
+	LegacyConfig   string `json:"legacy_config,omitempty" yaml:"legacy_config,omitempty"`
+}
+
+type ManifestNode struct {
+	SerialNumber string `json:"serial_number" yaml:"serial_number"`
+	NetworkIndex int    `json:"network_index" yaml:"network_index"`
+	Locality     string `json:"locality,omitempty" yaml:"locality,omitempty"`
+}
+
+type ManifestHardwareConfig struct {
+	Node   string `json:"node" yaml:"node"`
+	Device string `json:"device" yaml:"device"`
+	IPCIDR string `json:"ip_cidr" yaml:"ip_cidr"`
+}
+
+type ManifestProxy struct {
+	Name               string    `json:"name" yaml:"name"`
+	Node               string    `json:"node" yaml:"node"`
+	Group              string    `json:"group" yaml:"group"`
+	State              *bool     `json:"state,omitempty" yaml:"state,omitempty"`
+	ProxyType          ProxyType `json:"proxy_type" yaml:"proxy_type"`
+	ServerEndpointAddr string    `json:"server_endpoint_addr" yaml:"server_endpoint_addr"`
+	ClientEndpointAddr string    `json:"client_endpoint_addr" yaml:"client_endpoint_addr"`
+}
+
+// Key identifies a hardware config within its version set
+func (h ManifestHardwareConfig) Key() string {
+	return h.Node + "/" + h.Device + "/" + h.IPCIDR
+}
+
+// Normalize fills the defaults the database would apply, so that a manifest
+// compares equal to the version set it was applied to.
+func (m *Manifest) Normalize() {
+	for i := range m.EndpointConfigs {
+		if m.EndpointConfigs[i].ASLKeyExchangeMethod == "" {
+			m.EndpointConfigs[i].ASLKeyExchangeMethod = "ASL_KEX_DEFAULT"
+		}
+	}
+	for i := range m.HardwareConfigs {
+		m.HardwareConfigs[i].IPCIDR = NormalizeCIDR(m.HardwareConfigs[i].IPCIDR)
+	}
+	for i := range m.Proxies {
+		if m.Proxies[i].State == nil {
+			enabled := true
+			m.Proxies[i].State = &enabled
+		}
+	}
+}
+
+// NormalizeCIDR writes an address or prefix the way Postgres prints an inet,
+// a single address gets the full prefix length
+func NormalizeCIDR(value string) string {
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return prefix.String()
+	}
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()).String()
+	}
+	return value
+}
+
+// CheckReferences reports missing names, duplicates and references to
+// entities which are not part of the manifest.
+func (m *Manifest) CheckReferences() error {
+	var problems []string
+	fail := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if m.VersionSet.Name == "" && m.VersionSet.ID == "" {
+		fail("version_set needs a name or an id")
+	}
+
+	endpoints := make(map[string]bool)
+	for _, e := range m.EndpointConfigs {
+		if e.Name == "" {
+			fail("endpoint config without name")
+		} else if endpoints[e.Name] {
+			fail("endpoint config %q is defined twice", e.Name)
+		}
+		endpoints[e.Name] = true
+	}
+
+	groups := make(map[string]bool)
+	for _, g := range m.Groups {
+		if g.Name == "" {
+			fail("group without name")
+		} else if groups[g.Name] {
+			fail("group %q is defined twice", g.Name)
+		}
+		groups[g.Name] = true
+		if g.EndpointConfig != "" && !endpoints[g.EndpointConfig] {
+			fail("group %q references unknown endpoint config %q", g.Name, g.EndpointConfig)
+		}
+		if g.LegacyConfig != "" && !endpoints[g.LegacyConfig] {
+			fail("group %q references unknown legacy config %q", g.Name, g.LegacyConfig)
+		}
+	}
+
+	nodes := make(map[string]bool)
+	for _, n := range m.Nodes {
+		if n.SerialNumber == "" {
+			fail("node without serial_number")
+		} else if nodes[n.SerialNumber] {
+			fail("node %q is defined twice", n.SerialNumber)
+		}
+		nodes[n.SerialNumber] = true
+	}
+
+	hwConfigs := make(map[string]bool)
+	for _, h := range m.HardwareConfigs {
+		if h.Device == "" || h.IPCIDR == "" {
+			fail("hardware config of node %q needs device and ip_cidr", h.Node)
+		}
+		if !nodes[h.Node] {
+			fail("hardware config %s references unknown node %q", h.Key(), h.Node)
+		}
+		if hwConfigs[h.Key()] {
+			fail("hardware config %s is defined twice", h.Key())
+		}
+		hwConfigs[h.Key()] = true
+	}
+
+	proxies := make(map[string]bool)
+	for _, p := range m.Proxies {
+		if p.Name == "" {
+			fail("proxy without name")
+		} else if proxies[p.Name] {
+			fail("proxy %q is defined twice", p.Name)
+		}
+		proxies[p.Name] = true
+		if !nodes[p.Node] {
+			fail("proxy %q references unknown node %q", p.Name, p.Node)
+		}
+		if !groups[p.Group] {
+			fail("proxy %q references unknown group %q", p.Name, p.Group)
+		}
+		if _, ok := ProxyTypeMap[p.ProxyType]; !ok {
+			fail("proxy %q has unknown proxy_type %q", p.Name, p.ProxyType)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid manifest: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// PlanAction is what applying a manifest does to an entity.
+type PlanAction string
+
+const (
+	PlanCreate PlanAction = "create"
+	PlanUpdate PlanAction = "update"
+	PlanDelete PlanAction = "delete"
+)
+
+// PlanStep is a single change of a manifest plan. Fields lists the changed
+// fields of an update.
+type PlanStep struct {
+	Action PlanAction `json:"action"`
+	// Kind is version_set, endpoint_config, group, node, hardware_config or proxy
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// ManifestPlan is the outcome of applying a manifest. VersionSetID is
+// uuid.Nil for a dry run which would create the version set.
+type ManifestPlan struct {
+	VersionSetID uuid.UUID  `json:"version_set_id"`
+	Steps        []PlanStep `json:"steps"`
+	Applied      bool       `json:"applied"`
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"encoding/json"
+	"slices"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
@@ -16,6 +18,20 @@ const (
 	VERSION_STATE_DISABLED           VersionState = "disabled"
 )
 
+// versionStateTransitions lists the states a version set may move to. Only
+// drafts are editable, pending_deployment freezes a version set until it is
+// activated and a disabled version set is final.
+var versionStateTransitions = map[VersionState][]VersionState{
+	VERSION_STATE_DRAFT:              {VERSION_STATE_PENDING_DEPLOYMENT, VERSION_STATE_DISABLED},
+	VERSION_STATE_PENDING_DEPLOYMENT: {VERSION_STATE_DRAFT, VERSION_STATE_ACTIVE, VERSION_STATE_DISABLED},
+	VERSION_STATE_ACTIVE:             {VERSION_STATE_DISABLED},
+}
+
+// CanTransition reports whether a version set in state s may move to state to
+func (s VersionState) CanTransition(to VersionState) bool {
+	return slices.Contains(versionStateTransitions[s], to)
+}
+
 type VersionTransitionStatus string
 
 const (
@@ -23,6 +39,7 @@ const (
 	VersionTransitionActive   VersionTransitionStatus = "active"
 	VersionTransitionFailed   VersionTransitionStatus = "failed"
 	VersionTransitionRollback VersionTransitionStatus = "rollback"
+	VersionTransitionDisabled VersionTransitionStatus = "disabled"
 )
 
 // TransactionType represents the PostgreSQL ENUM transaction_type.
@@ -146,12 +163,109 @@ type EndpointConfig struct {
 
 // Transaction represents the transactions table.
 type Transaction struct {
-	ID                  int             `json:"id"`
-	Type                TransactionType `json:"type"`
-	VersionTransitionID *int            `json:"version_transition_id,omitempty"`
-	CreatedAt           time.Time       `json:"created_at"`
-	CompletedAt         *time.Time      `json:"completed_at,omitempty"`
-	Description         *string         `json:"description,omitempty"`
+	ID                  int               `json:"id"`
+	Type                TransactionType   `json:"type"`
+	VersionTransitionID *int              `json:"version_transition_id,omitempty"`
+	CreatedAt           time.Time         `json:"created_at"`
+	CompletedAt         *time.Time        `json:"completed_at,omitempty"`
+	Description         *string           `json:"description,omitempty"`
+	Metadata            []byte            `json:"metadata,omitempty"`
+	State               *TransactionState `json:"state,omitempty"`
+}
+
+// HistoryFilter narrows the listing of transactions and version transitions.
+// Unset fields do not filter.
+type HistoryFilter struct {
+	// NodeSerial keeps entries whose transaction logged the node
+	NodeSerial   *string
+	VersionSetID *uuid.UUID
+	// State is the transaction state or the version transition status
+	State *string
+	Since *time.Time
+	Until *time.Time
+	Limit int
+}
+
+// ChangeType tells how an entity differs between two version sets.
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// ConfigChange is a single difference of a node configuration. Field, Old and
+// New are set for changed entities only.
+type ConfigChange struct {
+	// Kind is node, proxy, group, endpoint_config or hardware_config
+	Kind   string     `json:"kind"`
+	Name   string     `json:"name"`
+	Change ChangeType `json:"change"`
+	Field  string     `json:"field,omitempty"`
+	Old    string     `json:"old,omitempty"`
+	New    string     `json:"new,omitempty"`
+}
+
+// NodeDiff lists what changes on a node between two version sets.
+type NodeDiff struct {
+	SerialNumber string         `json:"serial_number"`
+	Changes      []ConfigChange `json:"changes"`
+}
+
+// RolloutStatus is the state of a staged rollout.
+type RolloutStatus string
+
+const (
+	RolloutRunning   RolloutStatus = "running"
+	RolloutHalted    RolloutStatus = "halted"
+	RolloutCompleted RolloutStatus = "completed"
+	RolloutAborted   RolloutStatus = "aborted"
+)
+
+// RolloutPolicy splits a fleet update into waves.
+type RolloutPolicy struct {
+	CanaryNodes      int           `json:"canary_nodes"`
+	WavePercent      int           `json:"wave_percent"`
+	WavePause        time.Duration `json:"wave_pause"`
+	FailureThreshold int           `json:"failure_threshold"`
+}
+
+// Rollout is the plan and progress of a staged rollout. It is stored in the
+// metadata of its transaction, the per node progress is kept in transaction_log.
+type Rollout struct {
+	VersionSetID          uuid.UUID     `json:"version_set_id"`
+	GroupName             *string       `json:"group_name,omitempty"`
+	Policy                RolloutPolicy `json:"policy"`
+	Status                RolloutStatus `json:"status"`
+	Waves                 [][]string    `json:"waves"`
+	Failed                []string      `json:"failed,omitempty"`
+	Queued                []string      `json:"queued,omitempty"`
+	VersionTransitionID   *int          `json:"version_transition_id,omitempty"`
+	FromVersionTransition *int          `json:"from_version_transition,omitempty"`
+}
+
+// RolloutLogMetadata is stored with every transaction_log row of a rollout.
+type RolloutLogMetadata struct {
+	Wave   int    `json:"wave"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// NodeLogMetadata is stored with the transaction_log row of a node which
+// failed outside of a rollout, Reason tells the operator why.
+type NodeLogMetadata struct {
+	Reason string `json:"reason"`
+}
+
+// LogReason returns the reason stored in the metadata of a transaction_log
+// row, both NodeLogMetadata and RolloutLogMetadata carry one. It returns ""
+// for rows without a reason.
+func LogReason(metadata []byte) string {
+	var m NodeLogMetadata
+	if len(metadata) == 0 || json.Unmarshal(metadata, &m) != nil {
+		return ""
+	}
+	return m.Reason
 }
 
 // TransactionLog represents the transaction_log table.
@@ -190,3 +304,11 @@ type EnrollCallRequest struct {
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 }
+
+// SchemaMigration represents a row in the schema_migrations table, joined with the
+// migrations known to this binary. AppliedAt is nil for pending migrations.
+type SchemaMigration struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
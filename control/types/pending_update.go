@@ -0,0 +1,63 @@
+package types
+
+import (
+	"time"
+
+	grpc_controlplane "github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto/control_plane"
+	"github.com/gofrs/uuid/v5"
+)
+
+// DefaultQueueExpiry is used if offline_queue.expiry is not configured
+const DefaultQueueExpiry = 24 * time.Hour
+
+// PendingUpdateStatus is the status of an update queued for an offline node
+type PendingUpdateStatus string
+
+const (
+	// PendingUpdateQueued updates wait for the next hello of their node
+	PendingUpdateQueued PendingUpdateStatus = "queued"
+	// PendingUpdateDelivering updates are being pushed to their node
+	PendingUpdateDelivering PendingUpdateStatus = "delivering"
+	PendingUpdateDelivered  PendingUpdateStatus = "delivered"
+	PendingUpdateFailed     PendingUpdateStatus = "failed"
+	// PendingUpdateExpired updates were not delivered before ExpiresAt
+	PendingUpdateExpired PendingUpdateStatus = "expired"
+	// PendingUpdateSuperseded updates were replaced by a newer update of their
+	// node, their version set was disabled or the activation which queued them
+	// failed
+	PendingUpdateSuperseded PendingUpdateStatus = "superseded"
+)
+
+// PendingUpdate is an update of a node which was offline when it was
+// activated. It owns the transaction TransactionID, which stays open until the
+// update is delivered, expires or is superseded. A node has at most one
+// queued update.
+type PendingUpdate struct {
+	ID            int       `json:"id"`
+	SerialNumber  string    `json:"serial_number"`
+	VersionSetID  uuid.UUID `json:"version_set_id"`
+	TransactionID int       `json:"transaction_id"`
+	// SourceTransactionID is the fleet update or rollout which queued the
+	// update, nil for the activation of a single node
+	SourceTransactionID *int                              `json:"source_transaction_id,omitempty"`
+	Item                *grpc_controlplane.NodeUpdateItem `json:"item"`
+	Status              PendingUpdateStatus               `json:"status"`
+	Message             string                            `json:"message"`
+	CreatedAt           time.Time                         `json:"created_at"`
+	ExpiresAt           time.Time                         `json:"expires_at"`
+	ClaimedAt           *time.Time                        `json:"claimed_at,omitempty"`
+	FinishedAt          *time.Time                        `json:"finished_at,omitempty"`
+}
+
+// QueuedActivation is the metadata of a fleet update none of whose nodes was
+// online, every update of it was queued. Its transaction and version
+// transition stay open until the queued updates were delivered, failed,
+// expired or superseded.
+type QueuedActivation struct {
+	VersionSetID uuid.UUID `json:"version_set_id"`
+	Queued       int       `json:"queued"`
+	// TransitionID is the version transition of a version update, nil for a
+	// group update
+	TransitionID          *int `json:"transition_id,omitempty"`
+	FromVersionTransition *int `json:"from_version_transition,omitempty"`
+}
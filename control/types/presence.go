@@ -0,0 +1,34 @@
+package types
+
+import "time"
+
+// DefaultOnlineWindow is used if presence.online_window is not configured
+const DefaultOnlineWindow = 2 * time.Minute
+
+// PresenceEvent is a connect or disconnect of an MQTT client reported by the
+// embedded broker. Nodes connect with their serial number as client id.
+type PresenceEvent struct {
+	ClientID  string
+	Connected bool
+	// ConnectedAt identifies the connection, a disconnect only ends the
+	// connection it belongs to and not a newer one which took over the session
+	ConnectedAt time.Time
+	Time        time.Time
+	RemoteAddr  string
+	// Reason is set for disconnects
+	Reason string
+}
+
+// NodeStatus is the liveness of a node as seen by the controller.
+type NodeStatus struct {
+	SerialNumber string `json:"serial_number"`
+	// Online is true if the node is connected to the broker or sent a hello
+	// within the online window
+	Online           bool       `json:"online"`
+	Connected        bool       `json:"connected"`
+	ConnectedAt      *time.Time `json:"connected_at,omitempty"`
+	DisconnectedAt   *time.Time `json:"disconnected_at,omitempty"`
+	RemoteAddr       string     `json:"remote_addr,omitempty"`
+	DisconnectReason string     `json:"disconnect_reason,omitempty"`
+	LastSeen         *time.Time `json:"last_seen,omitempty"`
+}
@@ -0,0 +1,28 @@
+package types
+
+import "time"
+
+const (
+	DefaultReconcileInterval    = time.Minute
+	DefaultReconcileNodesPerRun = 5
+	DefaultReconcileBackoff     = time.Minute
+	DefaultReconcileMaxBackoff  = 30 * time.Minute
+)
+
+// ReconcileReason tells why the reconciler updates a node
+type ReconcileReason string
+
+const (
+	// ReconcileOutdated nodes did not apply the active version set, they were
+	// offline or failed during its activation
+	ReconcileOutdated ReconcileReason = "outdated"
+	// ReconcileDrifted nodes applied the active version set but report a
+	// different config now
+	ReconcileDrifted ReconcileReason = "drifted"
+)
+
+// ReconcileCandidate is an online node which does not run the active version set
+type ReconcileCandidate struct {
+	SerialNumber string
+	Reason       ReconcileReason
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// ReviewAction is an entry of the review history of a version set.
+type ReviewAction string
+
+const (
+	// ReviewSubmitted freezes a draft for review, see VERSION_STATE_PENDING_DEPLOYMENT
+	ReviewSubmitted ReviewAction = "submitted"
+	ReviewApproved  ReviewAction = "approved"
+	// ReviewRejected returns the version set to draft
+	ReviewRejected  ReviewAction = "rejected"
+	ReviewCommented ReviewAction = "commented"
+)
+
+// AuthorizationKey is the gRPC metadata key of the token a cli user
+// authenticates with, its value is "Bearer <token>"
+const AuthorizationKey = "authorization"
+
+// Review records who did what to a version set during its review. Reviews
+// are never changed or deleted, they are the audit trail of the four-eyes
+// approval.
+type Review struct {
+	ID           int          `json:"id"`
+	VersionSetID uuid.UUID    `json:"version_set_id"`
+	Action       ReviewAction `json:"action"`
+	User         string       `json:"user"`
+	Comment      string       `json:"comment,omitempty"`
+	// Diff is what the submitted version set changes compared to the active
+	// one, it is only set on submissions
+	Diff      []*NodeDiff `json:"diff,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// ReviewFilter narrows the review history for audits. Unset fields match
+// every review.
+type ReviewFilter struct {
+	VersionSetID *uuid.UUID
+	User         string
+	Since        *time.Time
+	Until        *time.Time
+	Limit        int
+}
+
+// CurrentApproval walks the reviews of a version set in the order they were
+// made and returns the latest submission and its approval. A rejection
+// voids the submission, the version set has to be submitted again.
+func CurrentApproval(reviews []*Review) (submission *Review, approval *Review) {
+	for _, r := range reviews {
+		switch r.Action {
+		case ReviewSubmitted:
+			submission, approval = r, nil
+		case ReviewRejected:
+			submission, approval = nil, nil
+		case ReviewApproved:
+			if submission != nil {
+				approval = r
+			}
+		}
+	}
+	return submission, approval
+}
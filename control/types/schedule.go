@@ -0,0 +1,164 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// IgnoreWindowKey is the gRPC metadata key which allows ActivateFleet and
+// ActivateNode outside the maintenance windows of the affected localities.
+const IgnoreWindowKey = "x-ignore-maintenance-window"
+
+// MaintenanceWindow is a recurring period in which the nodes of a locality may
+// be updated. A locality without windows can be updated at any time.
+type MaintenanceWindow struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Locality string `json:"locality"`
+	// Days the window opens on, every day if empty
+	Days []time.Weekday `json:"days"`
+	// StartTime is HH:MM in Timezone
+	StartTime string        `json:"start_time"`
+	Duration  time.Duration `json:"duration"`
+	// Timezone is an IANA name like Europe/Berlin
+	Timezone  string    `json:"timezone"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// maxWindowDuration keeps windows from overlapping themselves
+const maxWindowDuration = 24 * time.Hour
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWeekdays reads a comma separated list of weekdays like "mon,wed,fri"
+func ParseWeekdays(value string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if len(part) > 3 {
+			part = part[:3]
+		}
+		day, ok := weekdayNames[part]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", part)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// FormatWeekdays is the inverse of ParseWeekdays
+func FormatWeekdays(days []time.Weekday) string {
+	names := make([]string, 0, len(days))
+	for _, day := range days {
+		names = append(names, strings.ToLower(day.String()[:3]))
+	}
+	return strings.Join(names, ",")
+}
+
+// Validate checks start time, duration and time zone of the window
+func (w *MaintenanceWindow) Validate() error {
+	if w.Name == "" || w.Locality == "" {
+		return fmt.Errorf("maintenance window needs a name and a locality")
+	}
+	if _, err := time.Parse("15:04", w.StartTime); err != nil {
+		return fmt.Errorf("invalid start time %q, expected HH:MM", w.StartTime)
+	}
+	if w.Duration < time.Minute || w.Duration > maxWindowDuration {
+		return fmt.Errorf("duration must be between 1m and %s", maxWindowDuration)
+	}
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", w.Timezone, err)
+	}
+	return nil
+}
+
+// opening returns the start of the window on the day of t, in the time zone
+// of the window, and whether the window opens on that day at all
+func (w *MaintenanceWindow) opening(t time.Time) (time.Time, bool) {
+	location, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	start, err := time.Parse("15:04", w.StartTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t = t.In(location)
+	open := time.Date(t.Year(), t.Month(), t.Day(), start.Hour(), start.Minute(), 0, 0, location)
+	if len(w.Days) == 0 {
+		return open, true
+	}
+	for _, day := range w.Days {
+		if day == open.Weekday() {
+			return open, true
+		}
+	}
+	return open, false
+}
+
+// Contains reports whether the window is open at t. Windows may reach into the
+// next day, so the opening of the previous day is checked as well.
+func (w *MaintenanceWindow) Contains(t time.Time) bool {
+	for _, day := range []time.Time{t, t.AddDate(0, 0, -1)} {
+		if open, ok := w.opening(day); ok && !t.Before(open) && t.Before(open.Add(w.Duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the next time after t the window opens, or the zero time
+// if it never opens
+func (w *MaintenanceWindow) NextOpen(t time.Time) time.Time {
+	for i := 0; i <= 7; i++ {
+		if open, ok := w.opening(t.AddDate(0, 0, i)); ok && open.After(t) {
+			return open
+		}
+	}
+	return time.Time{}
+}
+
+// ScheduleStatus is the state of a scheduled activation.
+type ScheduleStatus string
+
+const (
+	ScheduleScheduled ScheduleStatus = "scheduled"
+	// ScheduleRunning is set while the scheduler starts the activation
+	ScheduleRunning ScheduleStatus = "running"
+	// ScheduleStarted activations were handed to the control plane, the
+	// outcome of the update is the state of their transaction
+	ScheduleStarted   ScheduleStatus = "started"
+	ScheduleFailed    ScheduleStatus = "failed"
+	ScheduleCancelled ScheduleStatus = "cancelled"
+)
+
+// ScheduledActivation is an ActivateFleet fired by the scheduler of the
+// controller at RunAt.
+type ScheduledActivation struct {
+	ID           int            `json:"id"`
+	VersionSetID uuid.UUID      `json:"version_set_id"`
+	GroupName    *string        `json:"group_name,omitempty"`
+	RunAt        time.Time      `json:"run_at"`
+	Status       ScheduleStatus `json:"status"`
+	Force        bool           `json:"force"`
+	IgnoreWindow bool           `json:"ignore_window"`
+	// TransactionID is set once the activation was started
+	TransactionID *int `json:"transaction_id,omitempty"`
+	// TransactionState is the state of the transaction, if it completed
+	TransactionState *TransactionState `json:"transaction_state,omitempty"`
+	Message          string            `json:"message,omitempty"`
+	CreatedBy        string            `json:"created_by"`
+	CreatedAt        time.Time         `json:"created_at"`
+	FinishedAt       *time.Time        `json:"finished_at,omitempty"`
+}
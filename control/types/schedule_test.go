@@ -0,0 +1,87 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return location
+}
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	// Friday 22:00 for four hours, reaching into Saturday
+	friday := &MaintenanceWindow{Days: []time.Weekday{time.Friday}, StartTime: "22:00", Duration: 4 * time.Hour, Timezone: "UTC"}
+	daily := &MaintenanceWindow{StartTime: "22:00", Duration: 4 * time.Hour, Timezone: "UTC"}
+	// the duration is elapsed time: the night the clocks are put forward it is
+	// open from 01:00 CET to 05:00 CEST, when they are put back from 01:00 CEST
+	// to 03:00 CET
+	night := &MaintenanceWindow{StartTime: "01:00", Duration: 3 * time.Hour, Timezone: "Europe/Berlin"}
+
+	tests := []struct {
+		name   string
+		window *MaintenanceWindow
+		at     time.Time
+		want   bool
+	}{
+		{name: "before opening", window: friday, at: time.Date(2026, 10, 23, 21, 59, 0, 0, time.UTC), want: false},
+		{name: "at opening", window: friday, at: time.Date(2026, 10, 23, 22, 0, 0, 0, time.UTC), want: true},
+		{name: "after midnight", window: friday, at: time.Date(2026, 10, 24, 1, 59, 0, 0, time.UTC), want: true},
+		{name: "at closing", window: friday, at: time.Date(2026, 10, 24, 2, 0, 0, 0, time.UTC), want: false},
+		{name: "other day", window: friday, at: time.Date(2026, 10, 24, 22, 30, 0, 0, time.UTC), want: false},
+		{name: "other day after midnight", window: friday, at: time.Date(2026, 10, 23, 1, 0, 0, 0, time.UTC), want: false},
+		{name: "empty days", window: daily, at: time.Date(2026, 10, 24, 22, 30, 0, 0, time.UTC), want: true},
+		{name: "empty days after midnight", window: daily, at: time.Date(2026, 10, 25, 1, 0, 0, 0, time.UTC), want: true},
+		{name: "empty days closed", window: daily, at: time.Date(2026, 10, 24, 12, 0, 0, 0, time.UTC), want: false},
+		{name: "other time zone of t", window: friday, at: time.Date(2026, 10, 24, 0, 30, 0, 0, berlin), want: true},
+		{name: "spring forward open", window: night, at: time.Date(2026, 3, 29, 4, 30, 0, 0, berlin), want: true},
+		{name: "spring forward closed", window: night, at: time.Date(2026, 3, 29, 5, 0, 0, 0, berlin), want: false},
+		{name: "fall back open", window: night, at: time.Date(2026, 10, 25, 1, 59, 0, 0, time.UTC), want: true},
+		{name: "fall back closed", window: night, at: time.Date(2026, 10, 25, 3, 0, 0, 0, berlin), want: false},
+		{name: "invalid time zone", window: &MaintenanceWindow{StartTime: "22:00", Duration: time.Hour, Timezone: "Mars/Olympus"}, at: time.Date(2026, 10, 23, 22, 30, 0, 0, time.UTC), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Contains(tt.at); got != tt.want {
+				t.Errorf("Contains(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindowNextOpen(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	friday := &MaintenanceWindow{Days: []time.Weekday{time.Friday}, StartTime: "22:00", Duration: 4 * time.Hour, Timezone: "UTC"}
+	daily := &MaintenanceWindow{StartTime: "22:00", Duration: 4 * time.Hour, Timezone: "Europe/Berlin"}
+
+	tests := []struct {
+		name   string
+		window *MaintenanceWindow
+		after  time.Time
+		want   time.Time
+	}{
+		{name: "later the same day", window: friday, after: time.Date(2026, 10, 23, 12, 0, 0, 0, time.UTC), want: time.Date(2026, 10, 23, 22, 0, 0, 0, time.UTC)},
+		{name: "at opening", window: friday, after: time.Date(2026, 10, 23, 22, 0, 0, 0, time.UTC), want: time.Date(2026, 10, 30, 22, 0, 0, 0, time.UTC)},
+		{name: "while open", window: friday, after: time.Date(2026, 10, 24, 1, 0, 0, 0, time.UTC), want: time.Date(2026, 10, 30, 22, 0, 0, 0, time.UTC)},
+		{name: "empty days", window: daily, after: time.Date(2026, 10, 20, 23, 0, 0, 0, berlin), want: time.Date(2026, 10, 21, 22, 0, 0, 0, berlin)},
+		{name: "across spring forward", window: daily, after: time.Date(2026, 3, 28, 23, 0, 0, 0, berlin), want: time.Date(2026, 3, 29, 20, 0, 0, 0, time.UTC)},
+		{name: "across fall back", window: daily, after: time.Date(2026, 10, 24, 23, 0, 0, 0, berlin), want: time.Date(2026, 10, 25, 21, 0, 0, 0, time.UTC)},
+		{name: "invalid time zone", window: &MaintenanceWindow{StartTime: "22:00", Duration: time.Hour, Timezone: "Mars/Olympus"}, after: time.Date(2026, 10, 23, 12, 0, 0, 0, time.UTC)},
+		{name: "invalid start time", window: &MaintenanceWindow{StartTime: "25:00", Duration: time.Hour, Timezone: "UTC"}, after: time.Date(2026, 10, 23, 12, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.NextOpen(tt.after); !got.Equal(tt.want) {
+				t.Errorf("NextOpen(%s) = %s, want %s", tt.after, got, tt.want)
+			}
+		})
+	}
+}
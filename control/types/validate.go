@@ -0,0 +1,279 @@
+package types
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+)
+
+// ForceActivationKey is the gRPC metadata key which skips the validation of a
+// version set before ActivateFleet and ActivateNode, their requests are shared
+// with the gateways and have no field for it.
+const ForceActivationKey = "x-force-activation"
+
+type Severity string
+
+const (
+	// SeverityError blocks the activation of a version set
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a violation of a validation rule by one object of a version set.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	// Kind is endpoint_config, group, node, hardware_config or proxy
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// ValidationRule checks a version set for a problem which would otherwise
+// only show up as UPDATE_ERROR of a gateway.
+type ValidationRule struct {
+	ID          string
+	Severity    Severity
+	Description string
+	check       func(m *Manifest, report func(kind, name, format string, args ...any))
+}
+
+// ValidationRules are applied by Validate in this order
+var ValidationRules = []ValidationRule{
+	{
+		ID:          "group-endpoint-config",
+		Severity:    SeverityError,
+		Description: "every group needs an endpoint config",
+		check:       checkGroupEndpointConfig,
+	},
+	{
+		ID:          "tlstls-legacy-config",
+		Severity:    SeverityError,
+		Description: "the group of a tlstls proxy needs a legacy config",
+		check:       checkTLSTLSLegacyConfig,
+	},
+	{
+		ID:          "proxy-address",
+		Severity:    SeverityError,
+		Description: "server_endpoint_addr and client_endpoint_addr must be host:port",
+		check:       checkProxyAddress,
+	},
+	{
+		ID:          "proxy-port-conflict",
+		Severity:    SeverityError,
+		Description: "enabled proxies of a node must not listen on the same port",
+		check:       checkProxyPortConflict,
+	},
+	{
+		ID:          "hardware-config-cidr",
+		Severity:    SeverityError,
+		Description: "ip_cidr must be an address or prefix",
+		check:       checkHardwareConfigCIDR,
+	},
+	{
+		ID:          "hardware-config-overlap",
+		Severity:    SeverityError,
+		Description: "the ip_cidr of a device must not overlap",
+		check:       checkHardwareConfigOverlap,
+	},
+	{
+		ID:          "proxy-type",
+		Severity:    SeverityWarning,
+		Description: "proxies should have a proxy type",
+		check:       checkProxyType,
+	},
+	{
+		ID:          "unused-group",
+		Severity:    SeverityWarning,
+		Description: "groups should be used by a proxy",
+		check:       checkUnusedGroup,
+	},
+	{
+		ID:          "node-without-proxy",
+		Severity:    SeverityWarning,
+		Description: "nodes should run a proxy",
+		check:       checkNodeWithoutProxy,
+	},
+}
+
+// Validate applies all validation rules to a normalized manifest
+func (m *Manifest) Validate() []Finding {
+	var findings []Finding
+	for _, rule := range ValidationRules {
+		rule.check(m, func(kind, name, format string, args ...any) {
+			findings = append(findings, Finding{
+				Rule:     rule.ID,
+				Severity: rule.Severity,
+				Kind:     kind,
+				Name:     name,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		})
+	}
+	return findings
+}
+
+// HasErrors reports whether a finding blocks activation
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func checkGroupEndpointConfig(m *Manifest, report func(kind, name, format string, args ...any)) {
+	for _, g := range m.Groups {
+		if g.EndpointConfig == "" {
+			report("group", g.Name, "group has no endpoint config")
+		}
+	}
+}
+
+func checkTLSTLSLegacyConfig(m *Manifest, report func(kind, name, format string, args ...any)) {
+	legacy := make(map[string]string, len(m.Groups))
+	for _, g := range m.Groups {
+		legacy[g.Name] = g.LegacyConfig
+	}
+	for _, p := range m.Proxies {
+		if p.ProxyType == PROXY_TYPE_TLSTLS && legacy[p.Group] == "" {
+			report("proxy", p.Name, "tlstls proxy uses group %q which has no legacy config", p.Group)
+		}
+	}
+}
+
+func checkProxyAddress(m *Manifest, report func(kind, name, format string, args ...any)) {
+	for _, p := range m.Proxies {
+		if _, _, err := splitEndpointAddr(p.ServerEndpointAddr); err != nil {
+			report("proxy", p.Name, "invalid server_endpoint_addr %q: %v", p.ServerEndpointAddr, err)
+		}
+		if _, _, err := splitEndpointAddr(p.ClientEndpointAddr); err != nil {
+			report("proxy", p.Name, "invalid client_endpoint_addr %q: %v", p.ClientEndpointAddr, err)
+		}
+	}
+}
+
+func checkProxyPortConflict(m *Manifest, report func(kind, name, format string, args ...any)) {
+	type listener struct {
+		proxy string
+		host  string
+	}
+	// node -> port -> proxies listening on it
+	listeners := make(map[string]map[int][]listener)
+	for _, p := range m.Proxies {
+		if p.State != nil && !*p.State {
+			continue
+		}
+		host, port, err := splitEndpointAddr(listenAddr(p))
+		if err != nil {
+			// reported by proxy-address
+			continue
+		}
+		if listeners[p.Node] == nil {
+			listeners[p.Node] = make(map[int][]listener)
+		}
+		for _, other := range listeners[p.Node][port] {
+			if other.host == host || isWildcardHost(other.host) || isWildcardHost(host) {
+				report("proxy", p.Name, "listens on port %d of node %s like proxy %q", port, p.Node, other.proxy)
+				break
+			}
+		}
+		listeners[p.Node][port] = append(listeners[p.Node][port], listener{proxy: p.Name, host: host})
+	}
+}
+
+func checkHardwareConfigCIDR(m *Manifest, report func(kind, name, format string, args ...any)) {
+	for _, h := range m.HardwareConfigs {
+		if _, err := netip.ParsePrefix(NormalizeCIDR(h.IPCIDR)); err != nil {
+			report("hardware_config", h.Key(), "invalid ip_cidr %q", h.IPCIDR)
+		}
+	}
+}
+
+func checkHardwareConfigOverlap(m *Manifest, report func(kind, name, format string, args ...any)) {
+	type prefix struct {
+		key    string
+		prefix netip.Prefix
+	}
+	// node/device -> prefixes seen so far
+	devices := make(map[string][]prefix)
+	for _, h := range m.HardwareConfigs {
+		p, err := netip.ParsePrefix(NormalizeCIDR(h.IPCIDR))
+		if err != nil {
+			// reported by hardware-config-cidr
+			continue
+		}
+		device := h.Node + "/" + h.Device
+		for _, other := range devices[device] {
+			if other.prefix.Overlaps(p) {
+				report("hardware_config", h.Key(), "ip_cidr overlaps %s", other.key)
+			}
+		}
+		devices[device] = append(devices[device], prefix{key: h.Key(), prefix: p})
+	}
+}
+
+func checkProxyType(m *Manifest, report func(kind, name, format string, args ...any)) {
+	for _, p := range m.Proxies {
+		if p.ProxyType == PROXY_TYPE_NOT_SPECIFIED || p.ProxyType == "" {
+			report("proxy", p.Name, "proxy type is not specified")
+		}
+	}
+}
+
+func checkUnusedGroup(m *Manifest, report func(kind, name, format string, args ...any)) {
+	used := make(map[string]bool)
+	for _, p := range m.Proxies {
+		used[p.Group] = true
+	}
+	for _, g := range m.Groups {
+		if !used[g.Name] {
+			report("group", g.Name, "group is not used by any proxy")
+		}
+	}
+}
+
+func checkNodeWithoutProxy(m *Manifest, report func(kind, name, format string, args ...any)) {
+	used := make(map[string]bool)
+	for _, p := range m.Proxies {
+		used[p.Node] = true
+	}
+	for _, n := range m.Nodes {
+		if !used[n.SerialNumber] {
+			report("node", n.SerialNumber, "node has no proxy")
+		}
+	}
+}
+
+// listenAddr is the address a proxy accepts connections on: reverse and
+// tlstls proxies listen on their server endpoint, forward proxies on their
+// client endpoint
+func listenAddr(p ManifestProxy) string {
+	if p.ProxyType == PROXY_TYPE_FORWARD {
+		return p.ClientEndpointAddr
+	}
+	return p.ServerEndpointAddr
+}
+
+// splitEndpointAddr parses host:port, the host may be empty
+func splitEndpointAddr(addr string) (string, int, error) {
+	host, portString, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port %q", portString)
+	}
+	return host, port, nil
+}
+
+func isWildcardHost(host string) bool {
+	if host == "" {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.IsUnspecified()
+}
@@ -0,0 +1,204 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+// validManifest returns a manifest without findings which the test cases
+// break one rule at a time
+func validManifest() *Manifest {
+	return &Manifest{
+		VersionSet: ManifestVersionSet{Name: "plant"},
+		EndpointConfigs: []ManifestEndpointConfig{
+			{Name: "ep"},
+			{Name: "legacy"},
+		},
+		Groups: []ManifestGroup{
+			{Name: "plant", EndpointConfig: "ep", LegacyConfig: "legacy"},
+		},
+		Nodes: []ManifestNode{
+			{SerialNumber: "gw-1", NetworkIndex: 1},
+		},
+		HardwareConfigs: []ManifestHardwareConfig{
+			{Node: "gw-1", Device: "eth0", IPCIDR: "10.0.0.1/24"},
+		},
+		Proxies: []ManifestProxy{
+			{Name: "web", Node: "gw-1", Group: "plant", ProxyType: PROXY_TYPE_REVERSE, ServerEndpointAddr: "0.0.0.0:443", ClientEndpointAddr: "10.0.0.2:80"},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name   string
+		change func(m *Manifest)
+		rules  []string
+	}{
+		{
+			name:   "valid",
+			change: func(m *Manifest) {},
+		},
+		{
+			name: "port conflict on wildcard host",
+			change: func(m *Manifest) {
+				m.Proxies = append(m.Proxies, ManifestProxy{Name: "api", Node: "gw-1", Group: "plant", ProxyType: PROXY_TYPE_TLSTLS, ServerEndpointAddr: "10.0.0.1:443", ClientEndpointAddr: "10.0.0.3:443"})
+			},
+			rules: []string{"proxy-port-conflict"},
+		},
+		{
+			name: "port conflict of a forward proxy on its client endpoint",
+			change: func(m *Manifest) {
+				m.Proxies = append(m.Proxies, ManifestProxy{Name: "out", Node: "gw-1", Group: "plant", ProxyType: PROXY_TYPE_FORWARD, ServerEndpointAddr: "10.0.0.3:8443", ClientEndpointAddr: ":443"})
+			},
+			rules: []string{"proxy-port-conflict"},
+		},
+		{
+			name: "same port on different hosts",
+			change: func(m *Manifest) {
+				m.Proxies[0].ServerEndpointAddr = "10.0.0.1:443"
+				m.Proxies = append(m.Proxies, ManifestProxy{Name: "api", Node: "gw-1", Group: "plant", ProxyType: PROXY_TYPE_REVERSE, ServerEndpointAddr: "10.0.0.4:443", ClientEndpointAddr: "10.0.0.3:443"})
+			},
+		},
+		{
+			name: "same port on different nodes",
+			change: func(m *Manifest) {
+				m.Nodes = append(m.Nodes, ManifestNode{SerialNumber: "gw-2", NetworkIndex: 2})
+				m.Proxies = append(m.Proxies, ManifestProxy{Name: "api", Node: "gw-2", Group: "plant", ProxyType: PROXY_TYPE_REVERSE, ServerEndpointAddr: "0.0.0.0:443", ClientEndpointAddr: "10.0.0.3:443"})
+			},
+		},
+		{
+			name: "same port of a disabled proxy",
+			change: func(m *Manifest) {
+				m.Proxies = append(m.Proxies, ManifestProxy{Name: "api", Node: "gw-1", Group: "plant", State: &disabled, ProxyType: PROXY_TYPE_REVERSE, ServerEndpointAddr: "0.0.0.0:443", ClientEndpointAddr: "10.0.0.3:443"})
+			},
+		},
+		{
+			name: "cidr overlap on a device",
+			change: func(m *Manifest) {
+				m.HardwareConfigs = append(m.HardwareConfigs, ManifestHardwareConfig{Node: "gw-1", Device: "eth0", IPCIDR: "10.0.0.128/25"})
+			},
+			rules: []string{"hardware-config-overlap"},
+		},
+		{
+			name: "cidr overlap with a single address",
+			change: func(m *Manifest) {
+				m.HardwareConfigs = append(m.HardwareConfigs, ManifestHardwareConfig{Node: "gw-1", Device: "eth0", IPCIDR: "10.0.0.7"})
+			},
+			rules: []string{"hardware-config-overlap"},
+		},
+		{
+			name: "same cidr on another device",
+			change: func(m *Manifest) {
+				m.HardwareConfigs = append(m.HardwareConfigs, ManifestHardwareConfig{Node: "gw-1", Device: "eth1", IPCIDR: "10.0.0.1/24"})
+			},
+		},
+		{
+			name: "invalid cidr",
+			change: func(m *Manifest) {
+				m.HardwareConfigs[0].IPCIDR = "10.0.0.300/24"
+			},
+			rules: []string{"hardware-config-cidr"},
+		},
+		{
+			name: "group without endpoint config",
+			change: func(m *Manifest) {
+				m.Groups[0].EndpointConfig = ""
+			},
+			rules: []string{"group-endpoint-config"},
+		},
+		{
+			name: "tlstls without legacy config",
+			change: func(m *Manifest) {
+				m.Groups[0].LegacyConfig = ""
+				m.Proxies[0].ProxyType = PROXY_TYPE_TLSTLS
+			},
+			rules: []string{"tlstls-legacy-config"},
+		},
+		{
+			name: "reverse proxy without legacy config",
+			change: func(m *Manifest) {
+				m.Groups[0].LegacyConfig = ""
+			},
+		},
+		{
+			name: "server address without port",
+			change: func(m *Manifest) {
+				m.Proxies[0].ServerEndpointAddr = "0.0.0.0"
+			},
+			rules: []string{"proxy-address"},
+		},
+		{
+			name: "client address with port out of range",
+			change: func(m *Manifest) {
+				m.Proxies[0].ClientEndpointAddr = "10.0.0.2:65536"
+			},
+			rules: []string{"proxy-address"},
+		},
+		{
+			name: "client address with port 0",
+			change: func(m *Manifest) {
+				m.Proxies[0].ClientEndpointAddr = "10.0.0.2:0"
+			},
+			rules: []string{"proxy-address"},
+		},
+		{
+			name: "proxy type not specified",
+			change: func(m *Manifest) {
+				m.Proxies[0].ProxyType = PROXY_TYPE_NOT_SPECIFIED
+			},
+			rules: []string{"proxy-type"},
+		},
+		{
+			name: "unused group and node without proxy",
+			change: func(m *Manifest) {
+				m.Proxies = nil
+			},
+			rules: []string{"unused-group", "node-without-proxy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := validManifest()
+			tt.change(m)
+
+			var rules []string
+			for _, f := range m.Validate() {
+				rules = append(rules, f.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("rules = %v, want %v", rules, tt.rules)
+			}
+		})
+	}
+}
+
+func TestValidateSeverity(t *testing.T) {
+	severity := make(map[string]Severity, len(ValidationRules))
+	for _, rule := range ValidationRules {
+		severity[rule.ID] = rule.Severity
+	}
+
+	m := validManifest()
+	m.Groups[0].EndpointConfig = ""
+	m.Proxies[0].ProxyType = PROXY_TYPE_NOT_SPECIFIED
+	m.Nodes = append(m.Nodes, ManifestNode{SerialNumber: "gw-2", NetworkIndex: 2})
+
+	findings := m.Validate()
+	if len(findings) != 3 {
+		t.Fatalf("findings = %v, want 3", findings)
+	}
+	for _, f := range findings {
+		if f.Severity != severity[f.Rule] {
+			t.Errorf("severity of %s = %s, want %s", f.Rule, f.Severity, severity[f.Rule])
+		}
+	}
+	if !HasErrors(findings) {
+		t.Errorf("HasErrors = false, want true")
+	}
+	if HasErrors(findings[1:]) {
+		t.Errorf("HasErrors of warnings = true, want false")
+	}
+}
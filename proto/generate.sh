@@ -0,0 +1,16 @@
+#!/bin/bash
+# Generate Go code for all proto files
+
+protoc --experimental_allow_proto3_optional \
+    --go_out=./scale --go_opt=paths=source_relative \
+    --go-grpc_out=./scale --go-grpc_opt=paths=source_relative \
+    -I=. \
+    scale.proto \
+
+# wire.proto imports control_plane.proto of kritis3m_proto
+KRITIS3M_PROTO=${KRITIS3M_PROTO:-$(go list -m -f '{{.Dir}}' github.com/Laboratory-for-Safe-and-Secure-Systems/kritis3m_proto)/proto}
+
+protoc --experimental_allow_proto3_optional \
+    --go_out=./wire --go_opt=paths=source_relative \
+    -I=. -I="$KRITIS3M_PROTO" \
+    wire.proto \